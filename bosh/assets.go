@@ -0,0 +1,11 @@
+package bosh
+
+import "github.com/EngineerBetter/concourse-up/util"
+
+// AssetsSHA256 is a compile-time variable set with -ldflags
+var AssetsSHA256 = "COMPILE_TIME_VARIABLE_bosh_assetsSHA256"
+
+// VerifyAssets returns an error if the embedded manifest templates are not the ones concourse-up was built with
+func VerifyAssets() error {
+	return util.VerifyAssets(AssetNames(), Asset, AssetsSHA256)
+}
@@ -1,9 +1,10 @@
 package bosh
 
 import (
-	"fmt"
 	"io"
 	"net"
+	"net/url"
+	"time"
 
 	"github.com/EngineerBetter/concourse-up/config"
 	"github.com/EngineerBetter/concourse-up/director"
@@ -22,20 +23,31 @@ const CredsFilename = "director-creds.yml"
 
 // Client is a concrete implementation of the IClient interface
 type Client struct {
-	config   *config.Config
-	metadata *terraform.Metadata
-	director director.IClient
-	db       Opener
-	stdout   io.Writer
-	stderr   io.Writer
+	config        *config.Config
+	metadata      *terraform.Metadata
+	director      director.IClient
+	db            Opener
+	stdout        io.Writer
+	stderr        io.Writer
+	releaseBundle *releaseBundle
 }
 
 // IClient is a client for performing bosh-init commands
 type IClient interface {
 	Deploy([]byte, []byte, bool) ([]byte, []byte, error)
+	DeployConcourse([]byte) ([]byte, error)
 	Delete([]byte) ([]byte, error)
 	Cleanup() error
 	Instances() ([]Instance, error)
+	EnsureDatabase(string) error
+	RunErrand(string) error
+	Errands() ([]string, error)
+	Restart(string) error
+	Recreate(string) error
+	RunningVersions() (map[string]string, error)
+	WaitForDeployTask(time.Duration) (Task, error)
+	UseReleaseBundle(string) error
+	GenerateManifest([]byte, bool) ([]byte, error)
 }
 
 // ClientFactory creates a new IClient
@@ -53,15 +65,15 @@ func NewClient(config *config.Config, metadata *terraform.Metadata, director dir
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
 		Auth:            []ssh.AuthMethod{ssh.PublicKeys(key)},
 	}
-	db, err := newProxyOpener(addr, conf, &pq.Driver{},
-		fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=require",
-			config.RDSUsername,
-			config.RDSPassword,
-			metadata.BoshDBAddress.Value,
-			metadata.BoshDBPort.Value,
-			config.RDSDefaultDatabaseName,
-		),
-	)
+	// The credentials are escaped, as an external database's can contain any character
+	dbURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.RDSUsername, config.RDSPassword),
+		Host:     net.JoinHostPort(metadata.BoshDBAddress.Value, metadata.BoshDBPort.Value),
+		Path:     "/" + config.RDSDefaultDatabaseName,
+		RawQuery: "sslmode=require",
+	}
+	db, err := newProxyOpener(addr, conf, &pq.Driver{}, dbURL.String())
 	if err != nil {
 		return nil, err
 	}
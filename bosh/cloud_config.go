@@ -12,18 +12,73 @@ type awsCloudConfigParams struct {
 	ATCSecurityGroupID string
 	PublicSubnetID     string
 	PrivateSubnetID    string
+	IsolatedWorkers    bool
+	// WorkersSubnetID and WorkersSecurityGroupID are only set for isolated workers
+	WorkersSubnetID        string
+	WorkersSecurityGroupID string
+	// WebInstanceProfile is only set when using pre-existing instance profiles
+	WebInstanceProfile string
+	// Dedicated is true if VMs run with dedicated tenancy, which rules out spot instances
+	Dedicated bool
+	// Spot is true if workers run on spot instances. SpotMaxPrice replaces the default bids when set
+	Spot         bool
+	SpotMaxPrice string
+	// WorkerDiskSize is the size of the workers' ephemeral disks in MB
+	WorkerDiskSize int
+	// Network is the layout of the VPC the subnets are in
+	Network *config.Network
+	// DNSServers are the DNS servers the VMs use
+	DNSServers []string
+	// ATCExtraSecurityGroupID holds the web node's extra rules from --tf-vars-file. It's missing
+	// until Terraform is applied by this version
+	ATCExtraSecurityGroupID string
 }
 
 func generateCloudConfig(conf *config.Config, metadata *terraform.Metadata) ([]byte, error) {
+	network, err := conf.Network()
+	if err != nil {
+		return nil, err
+	}
+
 	templateParams := awsCloudConfigParams{
-		AvailabilityZone:   conf.AvailabilityZone,
-		VMsSecurityGroupID: metadata.VMsSecurityGroupID.Value,
-		ATCSecurityGroupID: metadata.ATCSecurityGroupID.Value,
-		PublicSubnetID:     metadata.PublicSubnetID.Value,
-		PrivateSubnetID:    metadata.PrivateSubnetID.Value,
+		AvailabilityZone:       conf.AvailabilityZone,
+		VMsSecurityGroupID:     metadata.VMsSecurityGroupID.Value,
+		ATCSecurityGroupID:     metadata.ATCSecurityGroupID.Value,
+		PublicSubnetID:         metadata.PublicSubnetID.Value,
+		PrivateSubnetID:        metadata.PrivateSubnetID.Value,
+		IsolatedWorkers:        conf.IsolatedWorkers,
+		WorkersSubnetID:        metadata.WorkersSubnetID.Value,
+		WorkersSecurityGroupID: metadata.WorkersSecurityGroupID.Value,
+		Dedicated:              conf.InstanceTenancy == "dedicated",
+		Spot:                   conf.InstanceTenancy != "dedicated" && !conf.WorkerSpotDisabled,
+		SpotMaxPrice:           conf.WorkerSpotMaxPrice,
+		WorkerDiskSize:         conf.WorkerDiskSize() * 1000,
+		Network:                network,
+		DNSServers:             dnsServers(conf, network),
+	}
+
+	templateParams.ATCExtraSecurityGroupID = metadata.ATCExtraSecurityGroupID.Value
+	if conf.WebInstanceProfile != "" {
+		templateParams.WebInstanceProfile = instanceProfileName(conf.WebInstanceProfile)
 	}
 
 	return util.RenderTemplate(awsCloudConfigtemplate, templateParams)
 }
 
+// InstanceType is a helper function to swap instance types that can't be run with the deployment's tenancy
+func (params awsCloudConfigParams) InstanceType(instanceType string) string {
+	if params.Dedicated {
+		return config.InstanceType(instanceType, "dedicated")
+	}
+	return instanceType
+}
+
+// SpotBidPrice is a helper function to replace a worker size's default spot bid with the user's maximum price
+func (params awsCloudConfigParams) SpotBidPrice(defaultPrice string) string {
+	if params.SpotMaxPrice != "" {
+		return params.SpotMaxPrice
+	}
+	return defaultPrice
+}
+
 var awsCloudConfigtemplate = string(MustAsset("assets/cloud-config.yml"))
@@ -0,0 +1,206 @@
+package bosh
+
+import (
+	"strings"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var _ = Describe("generateCloudConfig", func() {
+	var conf *config.Config
+	var metadata *terraform.Metadata
+
+	BeforeEach(func() {
+		conf = &config.Config{
+			AvailabilityZone: "eu-west-1a",
+		}
+		metadata = &terraform.Metadata{
+			ATCSecurityGroupID:     terraform.MetadataStringValue{Value: "sg-888"},
+			PrivateSubnetID:        terraform.MetadataStringValue{Value: "sn-private-123"},
+			PublicSubnetID:         terraform.MetadataStringValue{Value: "sn-public-123"},
+			VMsSecurityGroupID:     terraform.MetadataStringValue{Value: "sg-456"},
+			WorkersSecurityGroupID: terraform.MetadataStringValue{Value: "sg-789"},
+			WorkersSubnetID:        terraform.MetadataStringValue{Value: "sn-workers-123"},
+		}
+	})
+
+	It("Does not include the workers tier by default", func() {
+		cloudConfig, err := generateCloudConfig(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(string(cloudConfig)).ToNot(ContainSubstring("name: workers"))
+		Expect(string(cloudConfig)).ToNot(ContainSubstring("sn-workers-123"))
+	})
+
+	It("Gives every network the VPC's DNS server by default, or the custom DNS servers", func() {
+		var cloudConfig struct {
+			Networks []struct {
+				Subnets []struct {
+					DNS []string `yaml:"dns"`
+				} `yaml:"subnets"`
+			} `yaml:"networks"`
+		}
+		conf.IsolatedWorkers = true
+
+		cloudConfigBytes, err := generateCloudConfig(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yaml.Unmarshal(cloudConfigBytes, &cloudConfig)).To(Succeed())
+		// The last network is the web node's VIP, which has no subnets
+		Expect(cloudConfig.Networks).To(HaveLen(4))
+		for _, network := range cloudConfig.Networks[:3] {
+			Expect(network.Subnets[0].DNS).To(Equal([]string{"10.0.0.2"}))
+		}
+
+		conf.DNSServers = []string{"10.1.0.2", "10.1.0.3"}
+		cloudConfigBytes, err = generateCloudConfig(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yaml.Unmarshal(cloudConfigBytes, &cloudConfig)).To(Succeed())
+		for _, network := range cloudConfig.Networks[:3] {
+			Expect(network.Subnets[0].DNS).To(Equal([]string{"10.1.0.2", "10.1.0.3"}))
+		}
+	})
+
+	It("Adds the group of extra web rules to the web node once Terraform has created it", func() {
+		var cloudConfig struct {
+			VMExtensions []struct {
+				Name            string `yaml:"name"`
+				CloudProperties struct {
+					SecurityGroups []string `yaml:"security_groups"`
+				} `yaml:"cloud_properties"`
+			} `yaml:"vm_extensions"`
+		}
+
+		cloudConfigBytes, err := generateCloudConfig(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yaml.Unmarshal(cloudConfigBytes, &cloudConfig)).To(Succeed())
+		Expect(cloudConfig.VMExtensions[0].Name).To(Equal("atc"))
+		Expect(cloudConfig.VMExtensions[0].CloudProperties.SecurityGroups).To(Equal([]string{"sg-456", "sg-888"}))
+
+		metadata.ATCExtraSecurityGroupID = terraform.MetadataStringValue{Value: "sg-999"}
+		cloudConfigBytes, err = generateCloudConfig(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yaml.Unmarshal(cloudConfigBytes, &cloudConfig)).To(Succeed())
+		Expect(cloudConfig.VMExtensions[0].CloudProperties.SecurityGroups).To(Equal([]string{"sg-456", "sg-888", "sg-999"}))
+	})
+
+	// vmType returns the part of the cloud config that defines the named vm type
+	vmType := func(cloudConfig []byte, name string) string {
+		parts := strings.SplitAfter(string(cloudConfig), "- name: "+name+"\n")
+		Expect(parts).To(HaveLen(2), "vm type %s not found", name)
+		return strings.Split(parts[1], "- name: ")[0]
+	}
+
+	It("Runs large workers on spot instances and the web node on demand", func() {
+		cloudConfig, err := generateCloudConfig(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(vmType(cloudConfig, "concourse-xlarge")).To(ContainSubstring("spot_bid_price: 0.27 "))
+		Expect(vmType(cloudConfig, "concourse-medium")).ToNot(ContainSubstring("spot_bid_price"))
+		for _, size := range config.WebSizes {
+			Expect(vmType(cloudConfig, "concourse-web-"+size)).ToNot(ContainSubstring("spot"))
+		}
+	})
+
+	It("Gives workers the disk size they've always had by default", func() {
+		cloudConfig, err := generateCloudConfig(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, size := range config.WorkerSizes {
+			Expect(vmType(cloudConfig, "concourse-"+size)).To(ContainSubstring("size: 200000\n"))
+		}
+		Expect(vmType(cloudConfig, "concourse-web-small")).To(ContainSubstring("size: 20_000\n"))
+	})
+
+	Context("When a worker disk size is given", func() {
+		It("Sizes every worker's ephemeral disk", func() {
+			conf.ConcourseWorkerDiskSize = 500
+
+			cloudConfig, err := generateCloudConfig(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vmType(cloudConfig, "concourse-medium")).To(ContainSubstring("size: 500000\n"))
+			Expect(vmType(cloudConfig, "concourse-16xlarge")).To(ContainSubstring("size: 500000\n"))
+			Expect(vmType(cloudConfig, "compilation")).To(ContainSubstring("size: 5_000\n"))
+		})
+	})
+
+	Context("When spot workers are disabled", func() {
+		It("Runs the workers on demand", func() {
+			conf.WorkerSpotDisabled = true
+
+			cloudConfig, err := generateCloudConfig(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, size := range config.WorkerSizes {
+				Expect(vmType(cloudConfig, "concourse-"+size)).ToNot(ContainSubstring("spot"))
+			}
+		})
+	})
+
+	Context("When a maximum spot price is given", func() {
+		It("Bids it for every spot worker", func() {
+			conf.WorkerSpotMaxPrice = "0.2"
+
+			cloudConfig, err := generateCloudConfig(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vmType(cloudConfig, "concourse-large")).To(ContainSubstring("spot_bid_price: 0.2 "))
+			Expect(vmType(cloudConfig, "concourse-16xlarge")).To(ContainSubstring("spot_bid_price: 0.2 "))
+			Expect(vmType(cloudConfig, "compilation")).To(ContainSubstring("spot_bid_price: 0.13 "))
+		})
+	})
+
+	Context("When using dedicated tenancy", func() {
+		It("Replaces burstable instances and doesn't bid for spot instances", func() {
+			conf.InstanceTenancy = "dedicated"
+
+			cloudConfig, err := generateCloudConfig(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(cloudConfig)).ToNot(ContainSubstring("t2."))
+			Expect(string(cloudConfig)).ToNot(ContainSubstring("spot_bid_price"))
+		})
+	})
+
+	Context("When using pre-existing instance profiles", func() {
+		It("Gives the web node its instance profile", func() {
+			conf.WebInstanceProfile = "arn:aws:iam::123:instance-profile/ci/web"
+
+			cloudConfig, err := generateCloudConfig(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(cloudConfig)).To(ContainSubstring("iam_instance_profile: web\n"))
+		})
+	})
+
+	Context("When the VPC has its own range", func() {
+		It("Lays out the networks within it", func() {
+			conf.VPCCIDR = "172.16.32.0/20"
+
+			cloudConfig, err := generateCloudConfig(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(cloudConfig)).To(ContainSubstring("range: 172.16.32.0/24\n    gateway: 172.16.32.1\n    dns:\n    - 172.16.32.2"))
+			Expect(string(cloudConfig)).To(ContainSubstring("static:\n    - 172.16.32.6\n    - 172.16.32.7"))
+			Expect(string(cloudConfig)).To(ContainSubstring("range: 172.16.33.0/24"))
+			Expect(string(cloudConfig)).ToNot(ContainSubstring("10.0."))
+		})
+	})
+
+	Context("When workers are isolated", func() {
+		It("Includes the workers network and vm extension", func() {
+			conf.IsolatedWorkers = true
+
+			cloudConfig, err := generateCloudConfig(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(cloudConfig)).To(ContainSubstring("range: 10.0.2.0/24"))
+			Expect(string(cloudConfig)).To(ContainSubstring("subnet: sn-workers-123"))
+			Expect(string(cloudConfig)).To(ContainSubstring("- sg-789"))
+		})
+	})
+})
@@ -3,9 +3,10 @@ package bosh
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"strconv"
 
 	"github.com/EngineerBetter/concourse-up/config"
-	"github.com/EngineerBetter/concourse-up/db"
 	"github.com/EngineerBetter/concourse-up/terraform"
 	"github.com/EngineerBetter/concourse-up/util"
 )
@@ -23,6 +24,9 @@ var ConcourseStemcellVersion = "COMPILE_TIME_VARIABLE_bosh_concourseStemcellVers
 // ConcourseStemcellSHA1 is a compile-time variable set with -ldflags
 var ConcourseStemcellSHA1 = "COMPILE_TIME_VARIABLE_bosh_concourseStemcellSHA1"
 
+// ConcourseStemcellOS is the operating system of the Concourse stemcell, which the releases are compiled for
+const ConcourseStemcellOS = "ubuntu-trusty"
+
 // ConcourseReleaseURL is a compile-time variable set with -ldflags
 var ConcourseReleaseURL = "COMPILE_TIME_VARIABLE_bosh_concourseReleaseURL"
 
@@ -86,26 +90,118 @@ var UAAReleaseVersion = "COMPILE_TIME_VARIABLE_bosh_UAAReleaseVersion"
 // UAAReleaseSHA1 is a compile-time variable set with -ldflags
 var UAAReleaseSHA1 = "COMPILE_TIME_VARIABLE_bosh_UAAReleaseSHA1"
 
+// HAProxyReleaseURL is a compile-time variable set with -ldflags
+var HAProxyReleaseURL = "COMPILE_TIME_VARIABLE_bosh_HAProxyReleaseURL"
+
+// HAProxyReleaseVersion is a compile-time variable set with -ldflags
+var HAProxyReleaseVersion = "COMPILE_TIME_VARIABLE_bosh_HAProxyReleaseVersion"
+
+// HAProxyReleaseSHA1 is a compile-time variable set with -ldflags
+var HAProxyReleaseSHA1 = "COMPILE_TIME_VARIABLE_bosh_HAProxyReleaseSHA1"
+
+// OSConfReleaseURL is a compile-time variable set with -ldflags
+var OSConfReleaseURL = "COMPILE_TIME_VARIABLE_bosh_OSConfReleaseURL"
+
+// OSConfReleaseVersion is a compile-time variable set with -ldflags
+var OSConfReleaseVersion = "COMPILE_TIME_VARIABLE_bosh_OSConfReleaseVersion"
+
+// OSConfReleaseSHA1 is a compile-time variable set with -ldflags
+var OSConfReleaseSHA1 = "COMPILE_TIME_VARIABLE_bosh_OSConfReleaseSHA1"
+
+// SyslogReleaseURL is a compile-time variable set with -ldflags
+var SyslogReleaseURL = "COMPILE_TIME_VARIABLE_bosh_SyslogReleaseURL"
+
+// SyslogReleaseVersion is a compile-time variable set with -ldflags
+var SyslogReleaseVersion = "COMPILE_TIME_VARIABLE_bosh_SyslogReleaseVersion"
+
+// SyslogReleaseSHA1 is a compile-time variable set with -ldflags
+var SyslogReleaseSHA1 = "COMPILE_TIME_VARIABLE_bosh_SyslogReleaseSHA1"
+
+// PrometheusReleaseURL is a compile-time variable set with -ldflags
+var PrometheusReleaseURL = "COMPILE_TIME_VARIABLE_bosh_PrometheusReleaseURL"
+
+// PrometheusReleaseVersion is a compile-time variable set with -ldflags
+var PrometheusReleaseVersion = "COMPILE_TIME_VARIABLE_bosh_PrometheusReleaseVersion"
+
+// PrometheusReleaseSHA1 is a compile-time variable set with -ldflags
+var PrometheusReleaseSHA1 = "COMPILE_TIME_VARIABLE_bosh_PrometheusReleaseSHA1"
+
 func (client *Client) uploadConcourseStemcell() error {
+	stemcell := concourseStemcellURL(StemcellVersion(client.config))
+	if client.releaseBundle != nil {
+		var err error
+		if stemcell, err = client.releaseBundle.path("concourse-stemcell", StemcellVersion(client.config)); err != nil {
+			return err
+		}
+	}
+
 	return client.director.RunAuthenticatedCommand(
 		client.stdout,
 		client.stderr,
 		false,
 		"upload-stemcell",
-		ConcourseStemcellURL,
+		stemcell,
 	)
 }
 
+// concourseRelease is a release the Concourse deployment uses, and where it's downloaded from
+type concourseRelease struct {
+	name    string
+	url     string
+	version string
+}
+
+// concourseReleases returns the releases the Concourse deployment uses, by release name
+func (client *Client) concourseReleases() []concourseRelease {
+	releases := []concourseRelease{
+		{"concourse", ConcourseReleaseURL, ConcourseReleaseVersion},
+		{"garden-runc", GardenReleaseURL, GardenReleaseVersion},
+		{"grafana", GrafanaReleaseURL, GrafanaReleaseVersion},
+		{"riemann", RiemannReleaseURL, RiemannReleaseVersion},
+		{"influxdb", InfluxDBReleaseURL, InfluxDBReleaseVersion},
+		{"uaa", UAAReleaseURL, UAAReleaseVersion},
+		{"credhub", CredhubReleaseURL, CredhubReleaseVersion},
+	}
+	// HAProxy is only needed to route Grafana's path on the Concourse domain
+	if client.config.GrafanaPath != "" {
+		releases = append(releases, concourseRelease{"haproxy", HAProxyReleaseURL, HAProxyReleaseVersion})
+	}
+	// os-conf is only needed to add the registry CA certificates to the workers
+	if client.config.WorkerRegistryCACerts != "" {
+		releases = append(releases, concourseRelease{"os-conf", OSConfReleaseURL, OSConfReleaseVersion})
+	}
+	// syslog is only needed to forward logs to an external collector
+	if client.config.SyslogAddress != "" {
+		releases = append(releases, concourseRelease{"syslog", SyslogReleaseURL, SyslogReleaseVersion})
+	}
+	// prometheus is only needed to send metrics to an external Prometheus
+	if client.config.PrometheusRemoteWriteURL != "" {
+		releases = append(releases, concourseRelease{"prometheus", PrometheusReleaseURL, PrometheusReleaseVersion})
+	}
+	return releases
+}
+
 func (client *Client) uploadConcourseReleases() error {
-	for _, release := range []string{ConcourseReleaseURL, GardenReleaseURL, GrafanaReleaseURL, RiemannReleaseURL, InfluxDBReleaseURL, UAAReleaseURL, CredhubReleaseURL} {
+	for _, release := range client.concourseReleases() {
+		location := release.url
+		if client.releaseBundle != nil {
+			var err error
+			if location, err = client.releaseBundle.path(release.name, release.version); err != nil {
+				return err
+			}
+		}
+		if location == "" {
+			return fmt.Errorf("this build of concourse-up wasn't compiled with the %s release, which this deployment needs", release.name)
+		}
+
 		err := client.director.RunAuthenticatedCommand(
 			client.stdout,
 			client.stderr,
 			false,
 			"upload-release",
 			"--stemcell",
-			"ubuntu-trusty/"+ConcourseStemcellVersion,
-			release,
+			ConcourseStemcellOS+"/"+ConcourseStemcellVersion,
+			location,
 		)
 		if err != nil {
 			return err
@@ -148,27 +244,71 @@ func (client *Client) deployConcourse(creds []byte, detach bool) (newCreds []byt
 	return
 }
 
+// defaultWatchTime is how long BOSH waits for an instance to become healthy when the
+// deployment doesn't set its own watch times, as a range of milliseconds
+const defaultWatchTime = "1000-300000"
+
 func generateConcourseManifest(config *config.Config, metadata *terraform.Metadata) ([]byte, error) {
+	network, err := config.Network()
+	if err != nil {
+		return nil, err
+	}
+
+	atcAddress, err := config.ATCAddress(metadata.ATCPublicIP.Value)
+	if err != nil {
+		return nil, err
+	}
+
 	templateParams := awsConcourseManifestParams{
 		AllowSelfSignedCerts:    "true",
-		ATCPublicIP:             metadata.ATCPublicIP.Value,
+		ATCAddress:              atcAddress,
+		DBMaxIdleConnections:    config.ATCDBMaxIdleConnections,
+		DBMaxOpenConnections:    config.ATCDBMaxOpenConnections,
+		BrandingCSS:             config.BrandingCSS,
+		BrandingWordmark:        config.BrandingWordmark,
 		ConcourseReleaseSHA1:    ConcourseReleaseSHA1,
+		ContainerNetworkMTU:     config.ContainerNetworkMTU,
+		ContainerNetworkPool:    config.ContainerNetworkPool,
 		ConcourseReleaseVersion: ConcourseReleaseVersion,
-		DBCACert:                db.RDSRootCert,
+		ConcoursePort:           config.ConcoursePort(),
+		CredhubPort:             config.CredhubPort(),
+		DBCACert:                config.DBCACert(),
+		DBConnectTimeout:        config.ATCDBConnectTimeout,
 		DBHost:                  metadata.BoshDBAddress.Value,
 		DBName:                  config.ConcourseDBName,
 		DBPassword:              config.RDSPassword,
 		DBPort:                  metadata.BoshDBPort.Value,
 		DBUsername:              config.RDSUsername,
+		EnableGlobalResources:   config.EnableGlobalResources,
 		EncryptionKey:           config.EncryptionKey,
+		Experiments:             config.ATCExperiments,
+		GCHijackGracePeriod:     config.ATCGCHijackGracePeriod,
+		InterceptIdleTimeout:    config.ATCInterceptIdleTimeout,
+		ResourceCheckInterval:   config.ATCResourceCheckingInterval,
+		ResourceCheckTimeout:    config.ATCResourceCheckTimeout,
+		DefaultGetTimeout:       config.ATCDefaultGetTimeout,
+		DefaultPutTimeout:       config.ATCDefaultPutTimeout,
+		ContainerPlacement:      config.ATCContainerPlacementStrategy,
+		LogLevel:                config.ConcourseLogLevel,
+		P2PVolumeStreaming:      config.ATCP2PVolumeStreaming,
+		MaxActiveTasks:          config.MaxActiveTasksPerWorker,
+		MaxContainers:           config.MaxActiveContainersPerWorker,
+		IsolatedWorkers:         config.IsolatedWorkers,
 		GardenReleaseSHA1:       GardenReleaseSHA1,
 		GardenReleaseVersion:    GardenReleaseVersion,
 		GrafanaPassword:         config.GrafanaPassword,
-		GrafanaPort:             "3000",
+		GrafanaPath:             config.GrafanaPath,
+		GrafanaPort:             strconv.Itoa(config.GrafanaPort()),
 		GrafanaReleaseSHA1:      GrafanaReleaseSHA1,
 		GrafanaReleaseVersion:   GrafanaReleaseVersion,
-		GrafanaURL:              fmt.Sprintf("https://%s:3000", config.Domain),
+		GrafanaURL:              config.MetricsURL(),
 		GrafanaUsername:         config.GrafanaUsername,
+		HAProxyReleaseSHA1:      HAProxyReleaseSHA1,
+		HAProxyReleaseVersion:   HAProxyReleaseVersion,
+		OSConfReleaseSHA1:       OSConfReleaseSHA1,
+		OSConfReleaseVersion:    OSConfReleaseVersion,
+		SyslogReleaseSHA1:       SyslogReleaseSHA1,
+		SyslogReleaseVersion:    SyslogReleaseVersion,
 		InfluxDBPassword:        config.InfluxDBPassword,
 		InfluxDBReleaseSHA1:     InfluxDBReleaseSHA1,
 		InfluxDBReleaseVersion:  InfluxDBReleaseVersion,
@@ -178,51 +318,131 @@ func generateConcourseManifest(config *config.Config, metadata *terraform.Metada
 		UAAReleaseSHA1:          UAAReleaseSHA1,
 		UAAReleaseVersion:       UAAReleaseVersion,
 		Password:                config.ConcoursePassword,
+		Private:                 config.Private,
 		Project:                 config.Project,
+		SecretCacheTTL:          config.SecretCacheTTL,
 		RiemannReleaseSHA1:      RiemannReleaseSHA1,
 		RiemannReleaseVersion:   RiemannReleaseVersion,
 		StemcellSHA1:            ConcourseStemcellSHA1,
-		StemcellURL:             ConcourseStemcellURL,
-		StemcellVersion:         ConcourseStemcellVersion,
+		StemcellURL:             concourseStemcellURL(StemcellVersion(config)),
+		StemcellVersion:         StemcellVersion(config),
 		TLSCert:                 config.ConcourseCert,
 		TLSKey:                  config.ConcourseKey,
+		TLSCipherSuites:         config.ATCTLSCipherSuites,
+		TLSMinVersion:           config.ATCTLSMinVersion,
 		TokenPrivateKey:         config.TokenPrivateKey,
 		TokenPublicKey:          config.TokenPublicKey,
 		TSAFingerprint:          config.TSAFingerprint,
+		TSAPort:                 config.TSAPort,
 		TSAPrivateKey:           config.TSAPrivateKey,
 		TSAPublicKey:            config.TSAPublicKey,
-		URL:                     fmt.Sprintf("https://%s", config.Domain),
+		URL:                     config.ConcourseURL(),
 		Username:                config.ConcourseUsername,
 		WorkerCount:             config.ConcourseWorkerCount,
 		WorkerSize:              config.ConcourseWorkerSize,
+		WebIP:                   network.WebIP(),
 		WebSize:                 config.ConcourseWebSize,
+		WorkerEphemeral:         config.WorkerEphemeral,
 		WorkerFingerprint:       config.WorkerFingerprint,
 		WorkerPrivateKey:        config.WorkerPrivateKey,
 		WorkerPublicKey:         config.WorkerPublicKey,
+		WorkerRegistryCACerts:   config.WorkerRegistryCACerts,
+		WorkerTags:              config.WorkerTags,
+		Tags:                    config.Tags,
+		UpdateSerial:            config.BoshUpdateSerial,
+		CanaryWatchTime:         config.BoshCanaryWatchTime,
+		UpdateWatchTime:         config.BoshUpdateWatchTime,
+	}
+	if templateParams.CanaryWatchTime == "" {
+		templateParams.CanaryWatchTime = defaultWatchTime
+	}
+	if templateParams.UpdateWatchTime == "" {
+		templateParams.UpdateWatchTime = defaultWatchTime
+	}
+	if config.WorkerAMIID != "" {
+		templateParams.WorkerStemcellName = workerStemcellName(config.WorkerAMIID)
+		templateParams.WorkerStemcellVersion = StemcellVersion(config)
+	}
+	if config.SyslogAddress != "" {
+		host, port, err := net.SplitHostPort(config.SyslogAddress)
+		if err != nil {
+			return nil, err
+		}
+		templateParams.SyslogHost = host
+		templateParams.SyslogPort = port
+		templateParams.SyslogTransport = config.SyslogTransport
+		templateParams.SyslogTLS = config.SyslogTLS
+		templateParams.SyslogCACert = config.SyslogCACert
+	}
+	if config.PrometheusRemoteWriteURL != "" {
+		templateParams.PrometheusURL = config.PrometheusRemoteWriteURL
+		templateParams.PrometheusUsername = config.PrometheusUsername
+		templateParams.PrometheusPassword = config.PrometheusPassword
+		templateParams.PrometheusReleaseSHA1 = PrometheusReleaseSHA1
+		templateParams.PrometheusReleaseVersion = PrometheusReleaseVersion
+	}
+	if config.GrafanaPath != "" {
+		templateParams.GrafanaURL = config.MetricsURL() + "/"
 	}
 	return util.RenderTemplate(awsConcourseManifestTemplate, templateParams)
 }
 
 type awsConcourseManifestParams struct {
-	ATCPublicIP             string
+	// ATCAddress is the IP address the web node is reached at, which is within the VPC for a private deployment
+	ATCAddress              string
 	AllowSelfSignedCerts    string
+	BrandingCSS             string
+	BrandingWordmark        string
 	ConcourseReleaseSHA1    string
+	ContainerNetworkMTU     int
+	ContainerNetworkPool    string
 	ConcourseReleaseVersion string
+	ConcoursePort           int
+	CredhubPort             int
 	DBCACert                string
+	DBConnectTimeout        string
 	DBHost                  string
+	DBMaxIdleConnections    int
+	DBMaxOpenConnections    int
 	DBName                  string
 	DBPassword              string
 	DBPort                  string
 	DBUsername              string
+	EnableGlobalResources   bool
 	EncryptionKey           string
+	Experiments             []string
+	GCHijackGracePeriod     string
+	InterceptIdleTimeout    string
+	ResourceCheckInterval   string
+	ResourceCheckTimeout    string
+	DefaultGetTimeout       string
+	DefaultPutTimeout       string
+	ContainerPlacement      string
+	LogLevel                string
+	P2PVolumeStreaming      bool
+	MaxActiveTasks          int
+	MaxContainers           int
+	IsolatedWorkers         bool
 	GardenReleaseSHA1       string
 	GardenReleaseVersion    string
 	GrafanaPassword         string
+	GrafanaPath             string
 	GrafanaPort             string
 	GrafanaReleaseSHA1      string
 	GrafanaReleaseVersion   string
 	GrafanaURL              string
 	GrafanaUsername         string
+	HAProxyReleaseSHA1      string
+	HAProxyReleaseVersion   string
+	OSConfReleaseSHA1       string
+	OSConfReleaseVersion    string
+	SyslogReleaseSHA1       string
+	SyslogReleaseVersion    string
+	SyslogHost              string
+	SyslogPort              string
+	SyslogTransport         string
+	SyslogTLS               bool
+	SyslogCACert            string
 	InfluxDBPassword        string
 	InfluxDBReleaseSHA1     string
 	InfluxDBReleaseVersion  string
@@ -232,27 +452,49 @@ type awsConcourseManifestParams struct {
 	UAAReleaseSHA1          string
 	UAAReleaseVersion       string
 	Password                string
+	Private                 bool
 	Project                 string
+	SecretCacheTTL          string
 	RiemannReleaseSHA1      string
 	RiemannReleaseVersion   string
 	StemcellSHA1            string
 	StemcellURL             string
 	StemcellVersion         string
+	WorkerStemcellName      string
+	WorkerStemcellVersion   string
+	Tags                    map[string]string
 	TLSCert                 string
 	TLSKey                  string
+	TLSCipherSuites         []string
+	TLSMinVersion           string
 	TokenPrivateKey         string
 	TokenPublicKey          string
 	TSAFingerprint          string
+	TSAPort                 int
 	TSAPrivateKey           string
 	TSAPublicKey            string
 	URL                     string
 	Username                string
+	WebIP                   string
 	WebSize                 string
 	WorkerCount             int
 	WorkerSize              string
+	WorkerEphemeral         bool
 	WorkerFingerprint       string
 	WorkerPrivateKey        string
 	WorkerPublicKey         string
+	WorkerRegistryCACerts   string
+	WorkerTags              []string
+	UpdateSerial            bool
+	CanaryWatchTime         string
+	UpdateWatchTime         string
+
+	// PrometheusURL is the remote write endpoint the ATC's metrics are sent to. Empty doesn't deploy Prometheus
+	PrometheusURL            string
+	PrometheusUsername       string
+	PrometheusPassword       string
+	PrometheusReleaseSHA1    string
+	PrometheusReleaseVersion string
 }
 
 // Indent is a helper function to indent the field a given number of spaces
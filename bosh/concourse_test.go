@@ -0,0 +1,551 @@
+package bosh
+
+import (
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var _ = Describe("generateConcourseManifest", func() {
+	var conf *config.Config
+	var metadata *terraform.Metadata
+
+	type manifest struct {
+		Stemcells []struct {
+			Alias   string `yaml:"alias"`
+			Name    string `yaml:"name"`
+			Version string `yaml:"version"`
+		} `yaml:"stemcells"`
+		InstanceGroups []struct {
+			Name     string `yaml:"name"`
+			Stemcell string `yaml:"stemcell"`
+			Networks []struct {
+				Name      string   `yaml:"name"`
+				StaticIPs []string `yaml:"static_ips"`
+			} `yaml:"networks"`
+			Jobs []struct {
+				Name       string                 `yaml:"name"`
+				Properties map[string]interface{} `yaml:"properties"`
+			} `yaml:"jobs"`
+		} `yaml:"instance_groups"`
+	}
+
+	jobProperties := func(manifestBytes []byte, name string) map[string]interface{} {
+		var m manifest
+		Expect(yaml.Unmarshal(manifestBytes, &m)).To(Succeed())
+		for _, group := range m.InstanceGroups {
+			for _, job := range group.Jobs {
+				if job.Name == name {
+					return job.Properties
+				}
+			}
+		}
+		Fail("no " + name + " job in manifest")
+		return nil
+	}
+
+	groundcrewProperties := func(manifestBytes []byte) map[string]interface{} {
+		return jobProperties(manifestBytes, "groundcrew")
+	}
+
+	BeforeEach(func() {
+		conf = &config.Config{
+			ConcourseWorkerCount: 1,
+			ConcourseWorkerSize:  "xlarge",
+			ConcourseWebSize:     "small",
+		}
+		metadata = &terraform.Metadata{
+			ATCPublicIP: terraform.MetadataStringValue{Value: "77.77.77.77"},
+		}
+	})
+
+	webNetworks := func(manifestBytes []byte) map[string][]string {
+		var m manifest
+		Expect(yaml.Unmarshal(manifestBytes, &m)).To(Succeed())
+		networks := map[string][]string{}
+		for _, group := range m.InstanceGroups {
+			if group.Name == "web" {
+				for _, network := range group.Networks {
+					networks[network.Name] = network.StaticIPs
+				}
+			}
+		}
+		return networks
+	}
+
+	It("Gives the web node its elastic IP", func() {
+		manifestBytes, err := generateConcourseManifest(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(webNetworks(manifestBytes)).To(HaveKeyWithValue("vip", []string{"77.77.77.77"}))
+		Expect(jobProperties(manifestBytes, "uaa")["uaa"]).To(HaveKeyWithValue("url", "https://77.77.77.77:8443"))
+	})
+
+	It("Quotes database credentials that YAML would otherwise misread", func() {
+		conf.RDSUsername = "admin"
+		conf.RDSPassword = `*p@ss: w/o#rd"`
+
+		manifestBytes, err := generateConcourseManifest(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		postgresql := jobProperties(manifestBytes, "atc")["postgresql"].(map[interface{}]interface{})
+		Expect(postgresql["role"]).To(HaveKeyWithValue("password", `*p@ss: w/o#rd"`))
+		Expect(jobProperties(manifestBytes, "credhub")["credhub"].(map[interface{}]interface{})["data_storage"]).To(HaveKeyWithValue("password", `*p@ss: w/o#rd"`))
+	})
+
+	Context("When ports are chosen", func() {
+		It("Serves Concourse, CredHub and Grafana on them", func() {
+			conf.Domain = "ci.example.com"
+			conf.ConcourseExternalPort = 4443
+			conf.CredhubExternalPort = 9844
+			conf.GrafanaExternalPort = 4000
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("tls_bind_port", 4443))
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("external_url", "https://ci.example.com:4443"))
+			Expect(jobProperties(manifestBytes, "atc")["credhub"]).To(HaveKeyWithValue("url", "https://127.0.0.1:9844"))
+			Expect(jobProperties(manifestBytes, "credhub")["credhub"]).To(HaveKeyWithValue("port", 9844))
+			Expect(jobProperties(manifestBytes, "grafana")["grafana"]).To(HaveKeyWithValue("listen_port", 4000))
+		})
+	})
+
+	Context("When the deployment is private", func() {
+		It("Reaches the web node at its internal IP", func() {
+			conf.Private = true
+			metadata.ATCPublicIP.Value = ""
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(webNetworks(manifestBytes)).To(Equal(map[string][]string{"public": {"10.0.0.7"}}))
+			Expect(jobProperties(manifestBytes, "uaa")["uaa"]).To(HaveKeyWithValue("url", "https://10.0.0.7:8443"))
+		})
+	})
+
+	It("Does not tag workers by default", func() {
+		manifestBytes, err := generateConcourseManifest(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(groundcrewProperties(manifestBytes)).ToNot(HaveKey("tags"))
+	})
+
+	Context("When worker tags are configured", func() {
+		It("Tags every worker", func() {
+			conf.WorkerTags = []string{"iaas:aws", "env:prod"}
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(groundcrewProperties(manifestBytes)["tags"]).To(Equal([]interface{}{"iaas:aws", "env:prod"}))
+		})
+	})
+
+	Describe("the deployment's AWS tags", func() {
+		type tagsManifest struct {
+			Tags map[string]string `yaml:"tags"`
+		}
+
+		tags := func() map[string]string {
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			var m tagsManifest
+			Expect(yaml.Unmarshal(manifestBytes, &m)).To(Succeed())
+			return m.Tags
+		}
+
+		It("Only has concourse-up's tags by default", func() {
+			Expect(tags()).To(HaveLen(2))
+			Expect(tags()).To(HaveKeyWithValue("concourse-up-component", "concourse"))
+		})
+
+		It("Adds the configured tags to every VM", func() {
+			conf.Tags = map[string]string{"cost-centre": "platform", "team": "ci ops"}
+
+			Expect(tags()).To(HaveKeyWithValue("cost-centre", "platform"))
+			Expect(tags()).To(HaveKeyWithValue("team", "ci ops"))
+			Expect(tags()).To(HaveKeyWithValue("concourse-up-component", "concourse"))
+		})
+	})
+
+	Describe("the update block", func() {
+		type updateManifest struct {
+			Update map[string]interface{} `yaml:"update"`
+		}
+
+		update := func() map[string]interface{} {
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			var m updateManifest
+			Expect(yaml.Unmarshal(manifestBytes, &m)).To(Succeed())
+			return m.Update
+		}
+
+		It("Uses the default settings", func() {
+			Expect(update()).To(HaveKeyWithValue("serial", false))
+			Expect(update()).To(HaveKeyWithValue("canary_watch_time", "1000-300000"))
+			Expect(update()).To(HaveKeyWithValue("update_watch_time", "1000-300000"))
+		})
+
+		It("Uses the deployment's settings when they're configured", func() {
+			conf.BoshUpdateSerial = true
+			conf.BoshCanaryWatchTime = "5000-600000"
+			conf.BoshUpdateWatchTime = "120000"
+
+			Expect(update()).To(HaveKeyWithValue("serial", true))
+			Expect(update()).To(HaveKeyWithValue("canary_watch_time", "5000-600000"))
+			Expect(update()).To(HaveKeyWithValue("update_watch_time", 120000))
+		})
+	})
+
+	It("Does not make workers ephemeral by default", func() {
+		manifestBytes, err := generateConcourseManifest(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(groundcrewProperties(manifestBytes)).ToNot(HaveKey("ephemeral"))
+	})
+
+	Context("When workers are ephemeral", func() {
+		It("Marks every worker as ephemeral", func() {
+			conf.WorkerEphemeral = true
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(groundcrewProperties(manifestBytes)).To(HaveKeyWithValue("ephemeral", true))
+		})
+	})
+
+	It("Leaves the ATC's TLS settings alone by default", func() {
+		manifestBytes, err := generateConcourseManifest(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("tls_min_version"))
+		Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("tls_cipher_suites"))
+	})
+
+	Context("When the ATC's TLS settings are configured", func() {
+		It("Restricts the ATC's TLS version and cipher suites", func() {
+			conf.ATCTLSMinVersion = "1.2"
+			conf.ATCTLSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("tls_min_version", "1.2"))
+			Expect(jobProperties(manifestBytes, "atc")["tls_cipher_suites"]).To(Equal([]interface{}{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}))
+		})
+	})
+
+	Context("When Concourse experiments are enabled", func() {
+		It("Enables them on the ATC", func() {
+			conf.ATCExperiments = []string{"enable_across_step", "enable_redact_secrets"}
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("enable_across_step", true))
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("enable_redact_secrets", true))
+		})
+	})
+
+	It("Leaves the hijack settings alone by default", func() {
+		manifestBytes, err := generateConcourseManifest(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("gc"))
+		Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("intercept_idle_timeout"))
+	})
+
+	Context("When a database connect timeout is configured", func() {
+		It("Sets it on the ATC", func() {
+			conf.ATCDBConnectTimeout = "10m0s"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("postgresql", HaveKeyWithValue("connect_timeout", "10m0s")))
+		})
+	})
+
+	Context("When hijack settings are configured", func() {
+		It("Sets them on the ATC", func() {
+			conf.ATCGCHijackGracePeriod = "10m0s"
+			conf.ATCInterceptIdleTimeout = "30m0s"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("gc", HaveKeyWithValue("hijack_grace_period", "10m0s")))
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("intercept_idle_timeout", "30m0s"))
+		})
+	})
+
+	Context("When resource check settings are configured", func() {
+		It("Sets them on the ATC", func() {
+			conf.ATCResourceCheckingInterval = "5m0s"
+			conf.ATCResourceCheckTimeout = "15m0s"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("resource_checking_interval", "5m0s"))
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("global_resource_check_timeout", "15m0s"))
+		})
+	})
+
+	Context("When default step timeouts are configured", func() {
+		It("Sets them on the ATC", func() {
+			conf.ATCDefaultGetTimeout = "30m0s"
+			conf.ATCDefaultPutTimeout = "1h0m0s"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("default_get_timeout", "30m0s"))
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("default_put_timeout", "1h0m0s"))
+		})
+	})
+
+	Context("When a worker AMI is configured", func() {
+		It("Boots only the workers from its light stemcell", func() {
+			conf.WorkerAMIID = "ami-0123456789abcdef0"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			var m manifest
+			Expect(yaml.Unmarshal(manifestBytes, &m)).To(Succeed())
+			Expect(m.Stemcells).To(HaveLen(2))
+			Expect(m.Stemcells[1].Alias).To(Equal("worker"))
+			Expect(m.Stemcells[1].Name).To(Equal("concourse-up-worker-ami-0123456789abcdef0"))
+			Expect(m.Stemcells[1].Version).To(Equal(ConcourseStemcellVersion))
+			for _, group := range m.InstanceGroups {
+				if group.Name == "worker" {
+					Expect(group.Stemcell).To(Equal("worker"))
+				} else {
+					Expect(group.Stemcell).To(Equal("trusty"))
+				}
+			}
+		})
+
+		It("Gives the light stemcell the pinned Concourse stemcell's version", func() {
+			conf.WorkerAMIID = "ami-0123456789abcdef0"
+			conf.StemcellVersion = "3468.17"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			var m manifest
+			Expect(yaml.Unmarshal(manifestBytes, &m)).To(Succeed())
+			Expect(m.Stemcells[1].Version).To(Equal("3468.17"))
+		})
+	})
+
+	Context("When volume streaming settings are configured", func() {
+		It("Sets them on the ATC", func() {
+			conf.ATCContainerPlacementStrategy = "fewest-build-containers"
+			conf.ATCP2PVolumeStreaming = true
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("container_placement_strategy", "fewest-build-containers"))
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("enable_p2p_volume_streaming", true))
+		})
+	})
+
+	Context("When worker limits are configured", func() {
+		It("Sets the task limit on the ATC and the container limit on Garden", func() {
+			conf.ATCContainerPlacementStrategy = "limit-active-tasks"
+			conf.MaxActiveTasksPerWorker = 5
+			conf.MaxActiveContainersPerWorker = 100
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("max_active_tasks_per_worker", 5))
+			Expect(jobProperties(manifestBytes, "garden")["garden"]).To(HaveKeyWithValue("max_containers", 100))
+		})
+
+		It("Leaves them unlimited by default", func() {
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("max_active_tasks_per_worker"))
+			Expect(jobProperties(manifestBytes, "garden")["garden"]).ToNot(HaveKey("max_containers"))
+		})
+	})
+
+	Context("When an ATC log level is configured", func() {
+		It("Sets it on the ATC only", func() {
+			conf.ConcourseLogLevel = "debug"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("log_level", "debug"))
+			Expect(jobProperties(manifestBytes, "tsa")).ToNot(HaveKey("log_level"))
+		})
+
+		It("Leaves the Concourse default in place when it isn't", func() {
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("log_level"))
+		})
+	})
+
+	It("Doesn't add registry CA certificates to the workers by default", func() {
+		manifestBytes, err := generateConcourseManifest(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(string(manifestBytes)).ToNot(ContainSubstring("os-conf"))
+	})
+
+	Context("When registry CA certificates are configured", func() {
+		It("Adds them to the certificates given to resource containers, rather than the VM's", func() {
+			conf.WorkerRegistryCACerts = "-----BEGIN CERTIFICATE-----\nregistry\n-----END CERTIFICATE-----"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(groundcrewProperties(manifestBytes)).To(HaveKeyWithValue("certs_dir", "/var/vcap/data/resource-certs"))
+			Expect(jobProperties(manifestBytes, "pre-start-script")).To(HaveKeyWithValue("script", ContainSubstring("<<'EOF'\n-----BEGIN CERTIFICATE-----\nregistry\n-----END CERTIFICATE-----\nEOF")))
+		})
+	})
+
+	Describe("log forwarding", func() {
+		type addonsManifest struct {
+			Addons []struct {
+				Name string `yaml:"name"`
+				Jobs []struct {
+					Name       string `yaml:"name"`
+					Properties struct {
+						Syslog map[string]interface{} `yaml:"syslog"`
+					} `yaml:"properties"`
+				} `yaml:"jobs"`
+			} `yaml:"addons"`
+		}
+
+		addons := func() addonsManifest {
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			var m addonsManifest
+			Expect(yaml.Unmarshal(manifestBytes, &m)).To(Succeed())
+			return m
+		}
+
+		It("Doesn't forward logs by default", func() {
+			Expect(addons().Addons).To(BeEmpty())
+		})
+
+		It("Forwards logs to the syslog collector when one is configured", func() {
+			conf.SyslogAddress = "logs.example.com:514"
+			conf.SyslogTransport = "udp"
+
+			m := addons()
+			Expect(m.Addons).To(HaveLen(1))
+			syslog := m.Addons[0].Jobs[0].Properties.Syslog
+			Expect(syslog).To(HaveKeyWithValue("address", "logs.example.com"))
+			Expect(syslog).To(HaveKeyWithValue("port", 514))
+			Expect(syslog).To(HaveKeyWithValue("transport", "udp"))
+			Expect(syslog).ToNot(HaveKey("tls_enabled"))
+		})
+
+		It("Forwards logs over TLS when asked to", func() {
+			conf.SyslogAddress = "logs.example.com:6514"
+			conf.SyslogTransport = "tcp"
+			conf.SyslogTLS = true
+			conf.SyslogCACert = "-----BEGIN CERTIFICATE-----\ncollector\n-----END CERTIFICATE-----"
+
+			syslog := addons().Addons[0].Jobs[0].Properties.Syslog
+			Expect(syslog).To(HaveKeyWithValue("tls_enabled", true))
+			Expect(syslog).To(HaveKeyWithValue("permitted_peer", "logs.example.com"))
+			Expect(syslog).To(HaveKeyWithValue("ca_cert", "-----BEGIN CERTIFICATE-----\ncollector\n-----END CERTIFICATE-----"))
+		})
+	})
+
+	Describe("Prometheus remote write", func() {
+		It("Sends the ATC's metrics to Riemann by default", func() {
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKey("riemann"))
+			Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("prometheus"))
+			Expect(string(manifestBytes)).ToNot(ContainSubstring("prometheus2"))
+		})
+
+		It("Sends the ATC's metrics to Prometheus when a remote write URL is configured", func() {
+			conf.PrometheusRemoteWriteURL = "https://prometheus.example.com/api/v1/write"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			atc := jobProperties(manifestBytes, "atc")
+			Expect(atc).ToNot(HaveKey("riemann"))
+			Expect(atc).To(HaveKeyWithValue("prometheus", HaveKeyWithValue("bind_port", 9391)))
+
+			prometheus := jobProperties(manifestBytes, "prometheus2")["prometheus"].(map[interface{}]interface{})
+			remoteWrite := prometheus["remote_write"].([]interface{})
+			Expect(remoteWrite).To(HaveLen(1))
+			Expect(remoteWrite[0]).To(HaveKeyWithValue("url", "https://prometheus.example.com/api/v1/write"))
+			Expect(remoteWrite[0]).ToNot(HaveKey("basic_auth"))
+		})
+
+		It("Authenticates to Prometheus when credentials are configured", func() {
+			conf.PrometheusRemoteWriteURL = "https://prometheus.example.com/api/v1/write"
+			conf.PrometheusUsername = "concourse"
+			conf.PrometheusPassword = "s3cret: \"quoted\""
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			prometheus := jobProperties(manifestBytes, "prometheus2")["prometheus"].(map[interface{}]interface{})
+			remoteWrite := prometheus["remote_write"].([]interface{})
+			Expect(remoteWrite[0]).To(HaveKeyWithValue("basic_auth", map[interface{}]interface{}{
+				"username": "concourse",
+				"password": "s3cret: \"quoted\"",
+			}))
+		})
+	})
+
+	It("Serves Grafana on its own port by default", func() {
+		manifestBytes, err := generateConcourseManifest(conf, metadata)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("tls_bind_port", 443))
+		Expect(jobProperties(manifestBytes, "grafana")["grafana"]).To(HaveKey("ssl"))
+		Expect(string(manifestBytes)).ToNot(ContainSubstring("haproxy"))
+	})
+
+	Context("When Grafana is served under a path", func() {
+		It("Routes the path to Grafana and everything else to the ATC through HAProxy", func() {
+			conf.Domain = "ci.example.com"
+			conf.GrafanaPath = "/grafana"
+			conf.ATCTLSMinVersion = "1.2"
+
+			manifestBytes, err := generateConcourseManifest(conf, metadata)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobProperties(manifestBytes, "atc")).To(HaveKeyWithValue("bind_port", 8080))
+			Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("tls_bind_port"))
+			Expect(jobProperties(manifestBytes, "atc")).ToNot(HaveKey("tls_min_version"))
+			Expect(jobProperties(manifestBytes, "grafana")["grafana"]).To(HaveKeyWithValue("root_url", "https://ci.example.com/grafana/"))
+			Expect(jobProperties(manifestBytes, "grafana")["grafana"]).ToNot(HaveKey("ssl"))
+
+			haproxy := jobProperties(manifestBytes, "haproxy")["ha_proxy"]
+			Expect(haproxy).To(HaveKeyWithValue("backend_port", 8080))
+			Expect(haproxy).To(HaveKeyWithValue("disable_tls_10", true))
+			Expect(haproxy).To(HaveKeyWithValue("disable_tls_11", true))
+			Expect(haproxy).To(HaveKeyWithValue("routed_backend_servers", HaveKeyWithValue("/grafana", HaveKeyWithValue("port", 3000))))
+			Expect(haproxy).To(HaveKeyWithValue("frontend_config", ContainSubstring("http-request set-path %[path,regsub(^/grafana/?,/)]")))
+		})
+	})
+})
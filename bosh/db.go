@@ -122,15 +122,32 @@ func (client *Client) createDefaultDatabases() error {
 	defer db.Close()
 	dbNames := []string{client.config.ConcourseDBName, "uaa", "credhub"}
 	for _, dbName := range dbNames {
-		_, err := db.Exec("CREATE DATABASE " + dbName)
-		if err != nil && !strings.Contains(err.Error(),
-			fmt.Sprintf(`pq: database "%s" already exists`, dbName)) {
+		if err := createDatabase(db, dbName); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// EnsureDatabase creates the named database on the director's RDS instance if it doesn't already exist
+func (client *Client) EnsureDatabase(dbName string) error {
+	db, err := client.db.Open(client.config.RDSDefaultDatabaseName)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return createDatabase(db, dbName)
+}
+
+func createDatabase(db *sql.DB, dbName string) error {
+	_, err := db.Exec("CREATE DATABASE " + dbName)
+	if err != nil && !strings.Contains(err.Error(),
+		fmt.Sprintf(`pq: database "%s" already exists`, dbName)) {
+		return err
+	}
+	return nil
+}
+
 func (client *Client) stopCredhubAuditSpam() error {
 	db, err := client.db.Open("credhub")
 	if err != nil {
@@ -30,7 +30,7 @@ func (client *Client) Delete(stateFileBytes []byte) ([]byte, error) {
 	if err != nil {
 		return stateFileBytes, err
 	}
-	directorManifestBytes, err := generateBoshInitManifest(client.config, client.metadata, pemFilename)
+	directorManifestBytes, err := generateBoshInitManifest(client.config, client.metadata, pemFilename, client.releaseBundle)
 	if err != nil {
 		return stateFileBytes, err
 	}
@@ -24,6 +24,10 @@ func (client *Client) Deploy(state, creds []byte, detach bool) (newState, newCre
 		return state, creds, err
 	}
 
+	if err = client.uploadWorkerStemcell(); err != nil {
+		return state, creds, err
+	}
+
 	if err = client.uploadConcourseReleases(); err != nil {
 		return state, creds, err
 	}
@@ -44,6 +48,12 @@ func (client *Client) Deploy(state, creds []byte, detach bool) (newState, newCre
 	return state, creds, err
 }
 
+// DeployConcourse redeploys the Concourse deployment on the existing director, without
+// converging the director itself. Returns new contents of the creds file
+func (client *Client) DeployConcourse(creds []byte) (newCreds []byte, err error) {
+	return client.deployConcourse(creds, false)
+}
+
 func touch(name string) error {
 	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDONLY, 0666)
 	if err != nil {
@@ -67,7 +77,7 @@ func (client *Client) createEnv(state, creds []byte) (newState, newCreds []byte,
 		return state, creds, err
 	}
 
-	directorManifestBytes, err := generateBoshInitManifest(client.config, client.metadata, pemFilename)
+	directorManifestBytes, err := generateBoshInitManifest(client.config, client.metadata, pemFilename, client.releaseBundle)
 	if err != nil {
 		return state, creds, err
 	}
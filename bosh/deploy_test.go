@@ -210,6 +210,17 @@ X9M1dN0p4Xj/+GYmJTCPbrYm3Jb9BoaE49tJOc789M+VI7lPZ4s=
 		Expect(actions).To(ContainElement("Running authenticated bosh command: upload-release --stemcell ubuntu-trusty/COMPILE_TIME_VARIABLE_bosh_concourseStemcellVersion COMPILE_TIME_VARIABLE_bosh_riemannReleaseURL (detach: false)"))
 	})
 
+	It("Fails before uploading if this build wasn't compiled with a release the deployment needs", func() {
+		url := SyslogReleaseURL
+		SyslogReleaseURL = ""
+		defer func() { SyslogReleaseURL = url }()
+		client.(*Client).config.SyslogAddress = "logs.example.com:514"
+
+		_, _, err := client.Deploy(nil, nil, false)
+		Expect(err).To(MatchError("this build of concourse-up wasn't compiled with the syslog release, which this deployment needs"))
+		Expect(actions).ToNot(ContainElement(ContainSubstring("--deployment concourse deploy")))
+	})
+
 	It("Saves the concourse manifest", func() {
 		_, _, err := client.Deploy(nil, nil, false)
 		Expect(err).ToNot(HaveOccurred())
@@ -223,4 +234,40 @@ X9M1dN0p4Xj/+GYmJTCPbrYm3Jb9BoaE49tJOc789M+VI7lPZ4s=
 		Expect(actions).To(ContainElement(expectedCommand))
 	})
 
+	Context("When using a release bundle", func() {
+		var bundleDir string
+
+		BeforeEach(func() {
+			bundleDir = filepath.Join(tempDir, releaseBundleDir)
+			writeReleaseBundle(filepath.Join(tempDir, "bundle.tgz"), map[string]string{
+				"bosh":               DirectorReleaseVersion,
+				"bosh-aws-cpi":       DirectorCPIReleaseVersion,
+				"bosh-stemcell":      DirectorStemcellVersion,
+				"concourse-stemcell": ConcourseStemcellVersion,
+				"concourse":          ConcourseReleaseVersion,
+				"garden-runc":        GardenReleaseVersion,
+				"grafana":            GrafanaReleaseVersion,
+				"riemann":            RiemannReleaseVersion,
+				"influxdb":           InfluxDBReleaseVersion,
+				"uaa":                UAAReleaseVersion,
+				"credhub":            CredhubReleaseVersion,
+			}, nil)
+		})
+
+		It("Uploads the stemcell and releases from the bundle", func() {
+			Expect(client.UseReleaseBundle(filepath.Join(tempDir, "bundle.tgz"))).To(Succeed())
+
+			_, _, err := client.Deploy(nil, nil, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actions).To(ContainElement(fmt.Sprintf("Running authenticated bosh command: upload-stemcell %s/releases/concourse-stemcell.tgz (detach: false)", bundleDir)))
+			Expect(actions).To(ContainElement(fmt.Sprintf("Running authenticated bosh command: upload-release --stemcell ubuntu-trusty/COMPILE_TIME_VARIABLE_bosh_concourseStemcellVersion %s/releases/concourse.tgz (detach: false)", bundleDir)))
+		})
+
+		It("Fails before deploying if the bundle is missing a release the deployment needs", func() {
+			client.(*Client).config.SyslogAddress = "logs.example.com:514"
+
+			err := client.UseReleaseBundle(filepath.Join(tempDir, "bundle.tgz"))
+			Expect(err).To(MatchError("release bundle has no syslog. This version of concourse-up needs syslog COMPILE_TIME_VARIABLE_bosh_SyslogReleaseVersion"))
+		})
+	})
 })
@@ -1,10 +1,11 @@
 package bosh
 
 import (
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/EngineerBetter/concourse-up/config"
-	"github.com/EngineerBetter/concourse-up/db"
 
 	"github.com/EngineerBetter/concourse-up/terraform"
 	"github.com/EngineerBetter/concourse-up/util"
@@ -37,13 +38,19 @@ var DirectorStemcellURL = "COMPILE_TIME_VARIABLE_bosh_directorStemcellURL"
 // DirectorStemcellVersion is a compile-time varaible set with -ldflags
 var DirectorStemcellVersion = "COMPILE_TIME_VARIABLE_bosh_directorStemcellVersion"
 
-// GenerateBoshInitManifest generates a manifest for the bosh director on AWS
-func generateBoshInitManifest(conf *config.Config, metadata *terraform.Metadata, privateKeyPath string) ([]byte, error) {
+// GenerateBoshInitManifest generates a manifest for the bosh director on AWS. With a release bundle,
+// the director is created from the bundle's files rather than downloading them
+func generateBoshInitManifest(conf *config.Config, metadata *terraform.Metadata, privateKeyPath string, bundle *releaseBundle) ([]byte, error) {
 	dbPort, err := strconv.Atoi(metadata.BoshDBPort.Value)
 	if err != nil {
 		return nil, err
 	}
 
+	network, err := conf.Network()
+	if err != nil {
+		return nil, err
+	}
+
 	templateParams := awsDirectorManifestParams{
 		AWSRegion:                 conf.Region,
 		AdminUserName:             conf.DirectorUsername,
@@ -53,9 +60,9 @@ func generateBoshInitManifest(conf *config.Config, metadata *terraform.Metadata,
 		BoshAWSAccessKeyID:        metadata.BoshUserAccessKeyID.Value,
 		BoshAWSSecretAccessKey:    metadata.BoshSecretAccessKey.Value,
 		BoshSecurityGroupID:       metadata.DirectorSecurityGroupID.Value,
-		DBCACert:                  db.RDSRootCert,
+		DBCACert:                  conf.DBCACert(),
 		DBHost:                    metadata.BoshDBAddress.Value,
-		DBName:                    conf.RDSDefaultDatabaseName,
+		DBName:                    directorDBName(conf),
 		DBPassword:                conf.RDSPassword,
 		DBPort:                    dbPort,
 		DBUsername:                conf.RDSUsername,
@@ -69,13 +76,19 @@ func generateBoshInitManifest(conf *config.Config, metadata *terraform.Metadata,
 		DirectorReleaseURL:        DirectorReleaseURL,
 		DirectorReleaseVersion:    DirectorReleaseVersion,
 		DirectorSubnetID:          metadata.PublicSubnetID.Value,
+		Network:                   network,
 		HMUserPassword:            conf.DirectorHMUserPassword,
+		InstanceType:              config.InstanceType(conf.DirectorInstanceType(), conf.InstanceTenancy),
 		KeyPairName:               metadata.DirectorKeyPair.Value,
 		MbusPassword:              conf.DirectorMbusPassword,
 		NATSPassword:              conf.DirectorNATSPassword,
+		DNSServers:                dnsServers(conf, network),
+		NTPServers:                ntpServers(conf, bundle),
 		PrivateKeyPath:            privateKeyPath,
+		Proxy:                     directorProxy(conf, network),
 		PublicIP:                  metadata.DirectorPublicIP.Value,
 		RegistryPassword:          conf.DirectorRegistryPassword,
+		ResurrectorDisabled:       conf.ResurrectorDisabled,
 		S3AWSAccessKeyID:          metadata.BlobstoreUserAccessKeyID.Value,
 		S3AWSSecretAccessKey:      metadata.BlobstoreSecretAccessKey.Value,
 		StemcellSHA1:              DirectorStemcellSHA1,
@@ -84,9 +97,67 @@ func generateBoshInitManifest(conf *config.Config, metadata *terraform.Metadata,
 		VMsSecurityGroupID:        metadata.VMsSecurityGroupID.Value,
 	}
 
+	// With pre-existing instance profiles there are no IAM users, so the director is
+	// created with the operator's credentials and uses its instance profile from then on
+	if conf.DirectorInstanceProfile != "" {
+		templateParams.BoshAWSAccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		templateParams.BoshAWSSecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		templateParams.BoshAWSSessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		templateParams.DirectorInstanceProfile = instanceProfileName(conf.DirectorInstanceProfile)
+		templateParams.WorkerInstanceProfile = instanceProfileName(conf.WorkerInstanceProfile)
+	}
+
+	if bundle != nil {
+		if templateParams.DirectorReleaseURL, err = bundle.url("bosh", DirectorReleaseVersion); err != nil {
+			return nil, err
+		}
+		if templateParams.DirectorCPIReleaseURL, err = bundle.url("bosh-aws-cpi", DirectorCPIReleaseVersion); err != nil {
+			return nil, err
+		}
+		if templateParams.StemcellURL, err = bundle.url("bosh-stemcell", DirectorStemcellVersion); err != nil {
+			return nil, err
+		}
+	}
+
 	return util.RenderTemplate(awsDirectorManifestTemplate, templateParams)
 }
 
+// dnsServers returns the DNS servers the director and VMs use, which default to the VPC's
+func dnsServers(conf *config.Config, network *config.Network) []string {
+	if len(conf.DNSServers) > 0 {
+		return conf.DNSServers
+	}
+	return []string{network.DNS()}
+}
+
+// ntpServers returns the NTP servers the director and VMs keep time with. Without internet access they
+// default to the Amazon Time Sync Service instead of the NTP pool
+func ntpServers(conf *config.Config, bundle *releaseBundle) []string {
+	if len(conf.NTPServers) > 0 {
+		return conf.NTPServers
+	}
+	if bundle != nil {
+		return []string{amazonTimeSyncAddress}
+	}
+	return []string{"0.pool.ntp.org", "1.pool.ntp.org"}
+}
+
+// directorProxy returns the proxy the director connects through. Its own address, the VPC and the
+// instance metadata service are always connected to directly, so it can still reach its VMs and AWS
+func directorProxy(conf *config.Config, network *config.Network) util.Proxy {
+	proxy := conf.Proxy()
+	if !proxy.IsSet() {
+		return proxy
+	}
+
+	noProxy := []string{"127.0.0.1", "localhost", network.DirectorIP(), network.VPCCIDR(), "169.254.169.254"}
+	if proxy.NoProxy != "" {
+		noProxy = append(noProxy, proxy.NoProxy)
+	}
+	proxy.NoProxy = strings.Join(noProxy, ",")
+	return proxy
+}
+
 type awsDirectorManifestParams struct {
 	AWSRegion                 string
 	AdminUserName             string
@@ -95,6 +166,7 @@ type awsDirectorManifestParams struct {
 	BlobstoreBucket           string
 	BoshAWSAccessKeyID        string
 	BoshAWSSecretAccessKey    string
+	BoshAWSSessionToken       string
 	BoshSecurityGroupID       string
 	DBCACert                  string
 	DBHost                    string
@@ -102,29 +174,51 @@ type awsDirectorManifestParams struct {
 	DBPassword                string
 	DBPort                    int
 	DBUsername                string
+	DNSServers                []string
 	DirectorCACert            string
 	DirectorCPIReleaseSHA1    string
 	DirectorCPIReleaseURL     string
 	DirectorCPIReleaseVersion string
 	DirectorCert              string
+	DirectorInstanceProfile   string
 	DirectorKey               string
 	DirectorReleaseSHA1       string
 	DirectorReleaseURL        string
 	DirectorReleaseVersion    string
 	DirectorSubnetID          string
 	HMUserPassword            string
+	InstanceType              string
 	KeyPairName               string
+	Network                   *config.Network
 	MbusPassword              string
 	NATSPassword              string
+	NTPServers                []string
 	PrivateKeyPath            string
+	Proxy                     util.Proxy
 	PublicIP                  string
 	RegistryPassword          string
+	ResurrectorDisabled       bool
 	S3AWSAccessKeyID          string
 	S3AWSSecretAccessKey      string
 	StemcellSHA1              string
 	StemcellURL               string
 	StemcellVersion           string
 	VMsSecurityGroupID        string
+	WorkerInstanceProfile     string
+}
+
+// directorDBName returns the database the director keeps its state in. Standbys
+// share a replicated RDS instance with their primary, so they use their own database
+func directorDBName(conf *config.Config) string {
+	if conf.DirectorDBName != "" {
+		return conf.DirectorDBName
+	}
+	return conf.RDSDefaultDatabaseName
+}
+
+// instanceProfileName returns the name BOSH refers to an instance profile by, given its ARN
+func instanceProfileName(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
 }
 
 // Indent is a helper function to indent the field a given number of spaces
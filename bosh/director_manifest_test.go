@@ -0,0 +1,186 @@
+package bosh
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var _ = Describe("generateBoshInitManifest", func() {
+	var conf *config.Config
+	var metadata *terraform.Metadata
+
+	type manifest struct {
+		Networks []struct {
+			Subnets []struct {
+				DNS []string `yaml:"dns"`
+			} `yaml:"subnets"`
+		} `yaml:"networks"`
+		Releases []struct {
+			Name string `yaml:"name"`
+			URL  string `yaml:"url"`
+		} `yaml:"releases"`
+		ResourcePools []struct {
+			CloudProperties map[string]interface{} `yaml:"cloud_properties"`
+			Stemcell        struct {
+				URL string `yaml:"url"`
+			} `yaml:"stemcell"`
+		} `yaml:"resource_pools"`
+		Jobs []struct {
+			Properties struct {
+				AWS       map[string]interface{} `yaml:"aws"`
+				Blobstore map[string]interface{} `yaml:"blobstore"`
+				Env       map[string]interface{} `yaml:"env"`
+				HM        map[string]interface{} `yaml:"hm"`
+				NTP       []string               `yaml:"ntp"`
+			} `yaml:"properties"`
+		} `yaml:"jobs"`
+		CloudProvider struct {
+			Properties struct {
+				AWS map[string]interface{} `yaml:"aws"`
+			} `yaml:"properties"`
+		} `yaml:"cloud_provider"`
+	}
+
+	parse := func(manifestBytes []byte) manifest {
+		var m manifest
+		Expect(yaml.Unmarshal(manifestBytes, &m)).To(Succeed())
+		return m
+	}
+
+	BeforeEach(func() {
+		conf = &config.Config{
+			Region: "eu-west-1",
+		}
+		metadata = &terraform.Metadata{
+			BoshDBPort:               terraform.MetadataStringValue{Value: "5432"},
+			BoshUserAccessKeyID:      terraform.MetadataStringValue{Value: "bosh-key-id"},
+			BoshSecretAccessKey:      terraform.MetadataStringValue{Value: "bosh-secret"},
+			BlobstoreUserAccessKeyID: terraform.MetadataStringValue{Value: "blobstore-key-id"},
+			BlobstoreSecretAccessKey: terraform.MetadataStringValue{Value: "blobstore-secret"},
+		}
+	})
+
+	It("Uses the IAM users' keys by default", func() {
+		manifestBytes, err := generateBoshInitManifest(conf, metadata, "/tmp/key", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		m := parse(manifestBytes)
+		Expect(m.Jobs[0].Properties.AWS).To(HaveKeyWithValue("access_key_id", "bosh-key-id"))
+		Expect(m.Jobs[0].Properties.Blobstore).To(HaveKeyWithValue("access_key_id", "blobstore-key-id"))
+		Expect(m.CloudProvider.Properties.AWS).To(HaveKeyWithValue("access_key_id", "bosh-key-id"))
+		Expect(m.ResourcePools[0].CloudProperties).ToNot(HaveKey("iam_instance_profile"))
+		Expect(m.Jobs[0].Properties.Env).To(BeEmpty())
+		Expect(m.Releases[0].URL).To(Equal(DirectorReleaseURL))
+		Expect(m.Jobs[0].Properties.NTP).To(Equal([]string{"0.pool.ntp.org", "1.pool.ntp.org"}))
+		Expect(m.Networks[0].Subnets[0].DNS).To(Equal([]string{"10.0.0.2"}))
+	})
+
+	It("Uses the custom DNS and NTP servers", func() {
+		conf.DNSServers = []string{"10.1.0.2", "10.1.0.3"}
+		conf.NTPServers = []string{"ntp.internal"}
+
+		manifestBytes, err := generateBoshInitManifest(conf, metadata, "/tmp/key", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		m := parse(manifestBytes)
+		Expect(m.Networks[0].Subnets[0].DNS).To(Equal([]string{"10.1.0.2", "10.1.0.3"}))
+		Expect(m.Jobs[0].Properties.NTP).To(Equal([]string{"ntp.internal"}))
+	})
+
+	It("Enables the resurrector unless it's disabled", func() {
+		manifestBytes, err := generateBoshInitManifest(conf, metadata, "/tmp/key", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parse(manifestBytes).Jobs[0].Properties.HM).To(HaveKeyWithValue("resurrector_enabled", true))
+
+		conf.ResurrectorDisabled = true
+		manifestBytes, err = generateBoshInitManifest(conf, metadata, "/tmp/key", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parse(manifestBytes).Jobs[0].Properties.HM).To(HaveKeyWithValue("resurrector_enabled", false))
+	})
+
+	It("Sizes the director", func() {
+		manifestBytes, err := generateBoshInitManifest(conf, metadata, "/tmp/key", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parse(manifestBytes).ResourcePools[0].CloudProperties).To(HaveKeyWithValue("instance_type", "t2.small"))
+
+		conf.DirectorSize = "large"
+		manifestBytes, err = generateBoshInitManifest(conf, metadata, "/tmp/key", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parse(manifestBytes).ResourcePools[0].CloudProperties).To(HaveKeyWithValue("instance_type", "t2.large"))
+	})
+
+	Context("When a proxy is configured", func() {
+		BeforeEach(func() {
+			conf.HTTPProxy = "http://proxy.example.com:3128"
+			conf.HTTPSProxy = "http://proxy.example.com:3128"
+			conf.NoProxy = ".internal.example.com"
+		})
+
+		It("Configures the director's proxy, connecting to the VPC and AWS metadata directly", func() {
+			manifestBytes, err := generateBoshInitManifest(conf, metadata, "/tmp/key", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			m := parse(manifestBytes)
+			Expect(m.Jobs[0].Properties.Env).To(HaveKeyWithValue("http_proxy", "http://proxy.example.com:3128"))
+			Expect(m.Jobs[0].Properties.Env).To(HaveKeyWithValue("https_proxy", "http://proxy.example.com:3128"))
+			Expect(m.Jobs[0].Properties.Env).To(HaveKeyWithValue("no_proxy", "127.0.0.1,localhost,10.0.0.6,10.0.0.0/16,169.254.169.254,.internal.example.com"))
+		})
+	})
+
+	Context("When using pre-existing instance profiles", func() {
+		BeforeEach(func() {
+			conf.DirectorInstanceProfile = "arn:aws:iam::123:instance-profile/director"
+			conf.WorkerInstanceProfile = "arn:aws:iam::123:instance-profile/worker"
+			os.Setenv("AWS_ACCESS_KEY_ID", "operator-key-id")
+			os.Setenv("AWS_SECRET_ACCESS_KEY", "operator-secret")
+		})
+
+		It("Gives the director its instance profile and creates it with the operator's credentials", func() {
+			manifestBytes, err := generateBoshInitManifest(conf, metadata, "/tmp/key", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			m := parse(manifestBytes)
+			Expect(m.ResourcePools[0].CloudProperties).To(HaveKeyWithValue("iam_instance_profile", "director"))
+			Expect(m.Jobs[0].Properties.AWS).To(HaveKeyWithValue("credentials_source", "env_or_profile"))
+			Expect(m.Jobs[0].Properties.AWS).To(HaveKeyWithValue("default_iam_instance_profile", "worker"))
+			Expect(m.Jobs[0].Properties.AWS).ToNot(HaveKey("access_key_id"))
+			Expect(m.Jobs[0].Properties.Blobstore).To(HaveKeyWithValue("credentials_source", "env_or_profile"))
+			Expect(m.CloudProvider.Properties.AWS).To(HaveKeyWithValue("access_key_id", "operator-key-id"))
+			Expect(m.CloudProvider.Properties.AWS).To(HaveKeyWithValue("secret_access_key", "operator-secret"))
+		})
+	})
+
+	Context("When using a release bundle", func() {
+		It("Creates the director from the bundle's files and keeps time with the Amazon Time Sync Service", func() {
+			dir, err := ioutil.TempDir("", "release-bundle")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			bundle := &releaseBundle{dir: dir, components: map[string]bundledComponent{}}
+			for name, version := range map[string]string{
+				"bosh":          DirectorReleaseVersion,
+				"bosh-aws-cpi":  DirectorCPIReleaseVersion,
+				"bosh-stemcell": DirectorStemcellVersion,
+			} {
+				Expect(ioutil.WriteFile(filepath.Join(dir, name+".tgz"), []byte(name), 0600)).To(Succeed())
+				bundle.components[name] = bundledComponent{Version: version, File: name + ".tgz"}
+			}
+
+			manifestBytes, err := generateBoshInitManifest(conf, metadata, "/tmp/key", bundle)
+			Expect(err).ToNot(HaveOccurred())
+
+			m := parse(manifestBytes)
+			Expect(m.Releases[0].URL).To(Equal("file://" + filepath.Join(dir, "bosh.tgz")))
+			Expect(m.Releases[1].URL).To(Equal("file://" + filepath.Join(dir, "bosh-aws-cpi.tgz")))
+			Expect(m.ResourcePools[0].Stemcell.URL).To(Equal("file://" + filepath.Join(dir, "bosh-stemcell.tgz")))
+			Expect(m.Jobs[0].Properties.NTP).To(Equal([]string{"169.254.169.123"}))
+		})
+	})
+})
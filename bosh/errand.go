@@ -0,0 +1,80 @@
+package bosh
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// RunErrand runs the named errand in the Concourse deployment, returning an error if the errand fails
+func (client *Client) RunErrand(name string) error {
+	return client.director.RunAuthenticatedCommand(
+		client.stdout,
+		client.stderr,
+		false,
+		"--deployment",
+		concourseDeploymentName,
+		"run-errand",
+		name,
+	)
+}
+
+// Restart restarts the jobs on the named instance group of the Concourse deployment, without recreating its VMs
+func (client *Client) Restart(instanceGroup string) error {
+	return client.director.RunAuthenticatedCommand(
+		client.stdout,
+		client.stderr,
+		false,
+		"--deployment",
+		concourseDeploymentName,
+		"restart",
+		instanceGroup,
+	)
+}
+
+// Recreate replaces the VMs of the named instance group of the Concourse deployment with fresh ones
+func (client *Client) Recreate(instanceGroup string) error {
+	return client.director.RunAuthenticatedCommand(
+		client.stdout,
+		client.stderr,
+		false,
+		"--deployment",
+		concourseDeploymentName,
+		"recreate",
+		instanceGroup,
+	)
+}
+
+// Errands returns the names of the errands in the Concourse deployment, including those colocated by runtime configs
+func (client *Client) Errands() ([]string, error) {
+	output := new(bytes.Buffer)
+	if err := client.director.RunAuthenticatedCommand(
+		output,
+		client.stderr,
+		false,
+		"--deployment",
+		concourseDeploymentName,
+		"errands",
+		"--json",
+	); err != nil {
+		return nil, err
+	}
+
+	errands := struct {
+		Tables []struct {
+			Rows []struct {
+				Name string `json:"name"`
+			} `json:"Rows"`
+		} `json:"Tables"`
+	}{}
+	if err := json.NewDecoder(output).Decode(&errands); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, table := range errands.Tables {
+		for _, row := range table.Rows {
+			names = append(names, row.Name)
+		}
+	}
+	return names, nil
+}
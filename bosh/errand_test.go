@@ -0,0 +1,27 @@
+package bosh
+
+import (
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errands", func() {
+	It("Lists the errands in the Concourse deployment", func() {
+		client := &Client{
+			director: &FakeDirectorClient{
+				FakeRunAuthenticatedCommand: func(stdout, stderr io.Writer, detach bool, args ...string) error {
+					Expect(strings.Join(args, " ")).To(Equal("--deployment concourse errands --json"))
+					_, err := io.WriteString(stdout, `{"Tables": [{"Rows": [{"name": "smoke-tests"}, {"name": "acceptance-tests"}]}]}`)
+					return err
+				},
+			},
+		}
+
+		errands, err := client.Errands()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(errands).To(Equal([]string{"smoke-tests", "acceptance-tests"}))
+	})
+})
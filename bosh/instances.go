@@ -3,6 +3,7 @@ package bosh
 import (
 	"bytes"
 	"encoding/json"
+	"strconv"
 )
 
 // Instance represents a vm deployed by BOSH
@@ -10,6 +11,7 @@ type Instance struct {
 	Name  string
 	IP    string
 	State string
+	Index int
 }
 
 // Instances returns the list of Concourse VMs
@@ -23,6 +25,7 @@ func (client *Client) Instances() ([]Instance, error) {
 		"--deployment",
 		concourseDeploymentName,
 		"instances",
+		"--details",
 		"--json",
 	); err != nil {
 		// if there is an error, copy the stdout to the main stdout to help debugging
@@ -35,6 +38,7 @@ func (client *Client) Instances() ([]Instance, error) {
 				Instance     string `json:"instance"`
 				IPs          string `json:"ips"`
 				ProcessState string `json:"process_state"`
+				Index        string `json:"index"`
 			} `json:"Rows"`
 		} `json:"Tables"`
 	}{}
@@ -47,10 +51,14 @@ func (client *Client) Instances() ([]Instance, error) {
 
 	for _, table := range jsonOutput.Tables {
 		for _, row := range table.Rows {
+			// Index is only used to work out which instances BOSH will remove
+			// when scaling down, so a missing value is not an error
+			index, _ := strconv.Atoi(row.Index)
 			instances = append(instances, Instance{
 				Name:  row.Instance,
 				IP:    row.IPs,
 				State: row.ProcessState,
+				Index: index,
 			})
 		}
 	}
@@ -0,0 +1,150 @@
+package bosh
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"gopkg.in/yaml.v2"
+)
+
+// redacted replaces the secrets in a generated manifest unless they're asked for
+const redacted = "<redacted>"
+
+// variablePattern matches a variable BOSH interpolates from the vars store, eg ((uaa-tls.private_key))
+var variablePattern = regexp.MustCompile(`\(\(([^()]+)\)\)`)
+
+// GenerateManifest returns the Concourse manifest a deploy would give BOSH, without deploying it. The
+// secrets the config puts in it are redacted and its variables are left for BOSH to interpolate, unless
+// showSecrets is true, when the variables are interpolated from the vars store in creds instead
+func (client *Client) GenerateManifest(creds []byte, showSecrets bool) ([]byte, error) {
+	manifestBytes, err := generateConcourseManifest(client.config, client.metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest yaml.MapSlice
+	if err = yaml.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, err
+	}
+
+	var rendered interface{}
+	if showSecrets {
+		vars := map[interface{}]interface{}{}
+		if err = yaml.Unmarshal(creds, &vars); err != nil {
+			return nil, fmt.Errorf("could not parse the director's credentials: %s", err)
+		}
+		rendered, err = mapStrings(manifest, func(s string) (interface{}, error) {
+			return interpolate(s, vars)
+		})
+	} else {
+		secrets := manifestSecrets(client.config)
+		rendered, err = mapStrings(manifest, func(s string) (interface{}, error) {
+			return redact(s, secrets), nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(rendered)
+}
+
+// manifestSecrets are the secrets from the config that the Concourse manifest contains
+func manifestSecrets(config *config.Config) []string {
+	return []string{
+		config.ConcoursePassword,
+		config.ConcourseKey,
+		config.EncryptionKey,
+		config.GrafanaPassword,
+		config.InfluxDBPassword,
+		config.PrometheusPassword,
+		config.RDSPassword,
+		config.TokenPrivateKey,
+		config.TSAPrivateKey,
+		config.WorkerPrivateKey,
+	}
+}
+
+// redact replaces a value containing any of the secrets
+func redact(value string, secrets []string) interface{} {
+	for _, secret := range secrets {
+		secret = strings.TrimSpace(secret)
+		if secret != "" && strings.Contains(value, secret) {
+			return redacted
+		}
+	}
+	return value
+}
+
+// interpolate replaces the variables in a value with theirs from the vars store, as BOSH does. A value
+// that is just one variable becomes the variable's value, which may be a map, eg for a certificate.
+// Variables that aren't in the vars store yet are left for BOSH to generate
+func interpolate(value string, vars map[interface{}]interface{}) (interface{}, error) {
+	if match := variablePattern.FindStringSubmatch(value); match != nil && match[0] == value {
+		if v, ok := lookupVariable(match[1], vars); ok {
+			return v, nil
+		}
+		return value, nil
+	}
+
+	var err error
+	interpolated := variablePattern.ReplaceAllStringFunc(value, func(variable string) string {
+		v, ok := lookupVariable(strings.Trim(variable, "()"), vars)
+		if !ok {
+			return variable
+		}
+		switch v.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			err = fmt.Errorf("variable %s is not a string, so it cannot be interpolated into `%s`", variable, value)
+			return variable
+		default:
+			return fmt.Sprint(v)
+		}
+	})
+	return interpolated, err
+}
+
+// lookupVariable finds a variable in the vars store, where eg uaa-tls.private_key is the private_key of uaa-tls
+func lookupVariable(name string, vars map[interface{}]interface{}) (interface{}, bool) {
+	var value interface{} = vars
+	for _, key := range strings.Split(name, ".") {
+		m, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// mapStrings returns a parsed manifest with each string in it replaced by f's result
+func mapStrings(value interface{}, f func(string) (interface{}, error)) (interface{}, error) {
+	switch value := value.(type) {
+	case yaml.MapSlice:
+		for i := range value {
+			mapped, err := mapStrings(value[i].Value, f)
+			if err != nil {
+				return nil, err
+			}
+			value[i].Value = mapped
+		}
+		return value, nil
+	case []interface{}:
+		for i := range value {
+			mapped, err := mapStrings(value[i], f)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = mapped
+		}
+		return value, nil
+	case string:
+		return f(value)
+	default:
+		return value, nil
+	}
+}
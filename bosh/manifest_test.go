@@ -0,0 +1,60 @@
+package bosh
+
+import (
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GenerateManifest", func() {
+	var client *Client
+
+	creds := []byte("credhub_cli_password: credhub-secret\nuaa-tls:\n  certificate: uaa-cert\n  private_key: uaa-key\n")
+
+	BeforeEach(func() {
+		client = &Client{
+			config: &config.Config{
+				ConcourseWorkerCount: 1,
+				ConcourseWorkerSize:  "xlarge",
+				ConcourseWebSize:     "small",
+				ConcoursePassword:    "concourse-secret",
+				RDSPassword:          "rds-secret",
+				EncryptionKey:        "encryption-secret",
+			},
+			metadata: &terraform.Metadata{
+				ATCPublicIP: terraform.MetadataStringValue{Value: "77.77.77.77"},
+			},
+		}
+	})
+
+	It("Redacts the config's secrets and leaves the variables to BOSH", func() {
+		manifest, err := client.GenerateManifest(creds, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(string(manifest)).To(ContainSubstring("basic_auth_password: <redacted>"))
+		Expect(string(manifest)).To(ContainSubstring("encryption_key: <redacted>"))
+		Expect(string(manifest)).To(ContainSubstring("((credhub_cli_password))"))
+		Expect(string(manifest)).ToNot(ContainSubstring("concourse-secret"))
+		Expect(string(manifest)).ToNot(ContainSubstring("rds-secret"))
+		Expect(string(manifest)).ToNot(ContainSubstring("credhub-secret"))
+	})
+
+	It("Shows the secrets and interpolates the variables from the vars store when asked", func() {
+		manifest, err := client.GenerateManifest(creds, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(string(manifest)).To(ContainSubstring("basic_auth_password: concourse-secret"))
+		Expect(string(manifest)).To(ContainSubstring("secret: credhub-secret"))
+		Expect(string(manifest)).To(ContainSubstring("sslPrivateKey: uaa-key"))
+		Expect(string(manifest)).ToNot(ContainSubstring("((credhub_cli_password))"))
+		Expect(string(manifest)).ToNot(ContainSubstring("<redacted>"))
+	})
+
+	It("Leaves variables that aren't in the vars store for BOSH to generate", func() {
+		manifest, err := client.GenerateManifest([]byte("{}"), true)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(string(manifest)).To(ContainSubstring("((uaa-tls.private_key))"))
+	})
+})
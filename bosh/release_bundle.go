@@ -0,0 +1,182 @@
+package bosh
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// releaseBundleManifestFilename is the file at the root of a release bundle that lists its contents
+const releaseBundleManifestFilename = "bundle.yml"
+
+// releaseBundleDir is where a release bundle is unpacked in the director's working directory
+const releaseBundleDir = "release-bundle"
+
+// amazonTimeSyncAddress is the Amazon Time Sync Service, which every VPC can reach without internet access
+const amazonTimeSyncAddress = "169.254.169.123"
+
+// releaseBundle holds the stemcells and releases for a deploy without internet access. Its manifest
+// lists each one by the component name ComponentVersions uses, or by release name for the optional releases
+type releaseBundle struct {
+	dir        string
+	components map[string]bundledComponent
+}
+
+type bundledComponent struct {
+	Version string `yaml:"version"`
+	File    string `yaml:"file"`
+}
+
+// bundleComponent is a stemcell or release the deploy needs, by the name it has in a bundle's manifest
+type bundleComponent struct {
+	name    string
+	version string
+}
+
+// UseReleaseBundle unpacks the release bundle at path, and uses its stemcells and releases for the
+// deploy instead of downloading them. Every one the deploy needs is checked for before anything is uploaded
+func (client *Client) UseReleaseBundle(path string) error {
+	bundle, err := openReleaseBundle(path, client.director.PathInWorkingDir(releaseBundleDir))
+	if err != nil {
+		return err
+	}
+
+	for _, component := range client.bundleComponents() {
+		if _, err = bundle.path(component.name, component.version); err != nil {
+			return err
+		}
+	}
+
+	client.releaseBundle = bundle
+	return nil
+}
+
+// bundleComponents returns the stemcells and releases a bundle needs for this deployment
+func (client *Client) bundleComponents() []bundleComponent {
+	components := []bundleComponent{
+		{"bosh", DirectorReleaseVersion},
+		{"bosh-aws-cpi", DirectorCPIReleaseVersion},
+		{"bosh-stemcell", DirectorStemcellVersion},
+		{"concourse-stemcell", StemcellVersion(client.config)},
+	}
+	for _, release := range client.concourseReleases() {
+		components = append(components, bundleComponent{release.name, release.version})
+	}
+	return components
+}
+
+// openReleaseBundle unpacks the gzipped tarball at path into dir and reads its manifest
+func openReleaseBundle(path, dir string) (*releaseBundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err = untar(f, dir); err != nil {
+		return nil, fmt.Errorf("could not unpack release bundle %s: %s", path, err)
+	}
+
+	manifest, err := ioutil.ReadFile(filepath.Join(dir, releaseBundleManifestFilename))
+	if err != nil {
+		return nil, fmt.Errorf("release bundle %s has no %s: %s", path, releaseBundleManifestFilename, err)
+	}
+
+	bundle := &releaseBundle{dir: dir}
+	if err = yaml.Unmarshal(manifest, &bundle.components); err != nil {
+		return nil, fmt.Errorf("could not parse %s in release bundle %s: %s", releaseBundleManifestFilename, path, err)
+	}
+
+	return bundle, nil
+}
+
+// untar unpacks a gzipped tarball into dir, refusing any entry that would be written outside it
+func untar(r io.Reader, dir string) error {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := pathInDir(dir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err = os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tarReader)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s is not a regular file or directory", header.Name)
+		}
+	}
+}
+
+// pathInDir returns where a relative path lies within dir, failing if it's outside it
+func pathInDir(dir, name string) (string, error) {
+	target := filepath.Join(dir, name)
+	if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%s is outside the release bundle", name)
+	}
+	return target, nil
+}
+
+// path returns where the bundle's file for a component was unpacked, failing unless it's the given version
+func (bundle *releaseBundle) path(name, version string) (string, error) {
+	component, ok := bundle.components[name]
+	if !ok {
+		return "", fmt.Errorf("release bundle has no %s. This version of concourse-up needs %s %s", name, name, version)
+	}
+	if component.Version != version {
+		return "", fmt.Errorf("release bundle has %s %s, but this version of concourse-up needs %s %s", name, component.Version, name, version)
+	}
+
+	path, err := pathInDir(bundle.dir, component.File)
+	if err != nil {
+		return "", err
+	}
+	if _, err = os.Stat(path); err != nil {
+		return "", fmt.Errorf("release bundle lists %s for %s, but does not contain it", component.File, name)
+	}
+	return path, nil
+}
+
+// url returns the file URL create-env can fetch a component from
+func (bundle *releaseBundle) url(name, version string) (string, error) {
+	path, err := bundle.path(name, version)
+	if err != nil {
+		return "", err
+	}
+	return "file://" + path, nil
+}
@@ -0,0 +1,98 @@
+package bosh
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// writeReleaseBundle writes a release bundle of the given components, each with a file named after
+// it, plus any extra files
+func writeReleaseBundle(path string, components map[string]string, extra map[string]string) {
+	manifest := map[string]bundledComponent{}
+	files := map[string]string{}
+	for name, version := range components {
+		manifest[name] = bundledComponent{Version: version, File: "releases/" + name + ".tgz"}
+		files["releases/"+name+".tgz"] = name
+	}
+	manifestBytes, err := yaml.Marshal(manifest)
+	Expect(err).ToNot(HaveOccurred())
+	files[releaseBundleManifestFilename] = string(manifestBytes)
+	for name, contents := range extra {
+		files[name] = contents
+	}
+
+	f, err := os.Create(path)
+	Expect(err).ToNot(HaveOccurred())
+	defer f.Close()
+	gzipWriter := gzip.NewWriter(f)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, contents := range files {
+		Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))})).To(Succeed())
+		_, err = tarWriter.Write([]byte(contents))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(tarWriter.Close()).To(Succeed())
+	Expect(gzipWriter.Close()).To(Succeed())
+}
+
+var _ = Describe("openReleaseBundle", func() {
+	var tempDir string
+	var bundlePath string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "release_bundle_test")
+		Expect(err).ToNot(HaveOccurred())
+		bundlePath = filepath.Join(tempDir, "bundle.tgz")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("Finds each component's file at the version it needs", func() {
+		writeReleaseBundle(bundlePath, map[string]string{"concourse": "3.14.1"}, nil)
+
+		bundle, err := openReleaseBundle(bundlePath, filepath.Join(tempDir, "unpacked"))
+		Expect(err).ToNot(HaveOccurred())
+
+		path, err := bundle.path("concourse", "3.14.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(tempDir, "unpacked", "releases", "concourse.tgz")))
+		Expect(ioutil.ReadFile(path)).To(Equal([]byte("concourse")))
+	})
+
+	It("Fails if a component is missing or at the wrong version", func() {
+		writeReleaseBundle(bundlePath, map[string]string{"concourse": "3.14.0"}, nil)
+
+		bundle, err := openReleaseBundle(bundlePath, filepath.Join(tempDir, "unpacked"))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = bundle.path("concourse", "3.14.1")
+		Expect(err).To(MatchError("release bundle has concourse 3.14.0, but this version of concourse-up needs concourse 3.14.1"))
+		_, err = bundle.path("uaa", "57")
+		Expect(err).To(MatchError("release bundle has no uaa. This version of concourse-up needs uaa 57"))
+	})
+
+	It("Refuses files outside the bundle", func() {
+		writeReleaseBundle(bundlePath, nil, map[string]string{"../escaped": "contents"})
+
+		_, err := openReleaseBundle(bundlePath, filepath.Join(tempDir, "unpacked"))
+		Expect(err).To(MatchError(ContainSubstring("../escaped is outside the release bundle")))
+		Expect(filepath.Join(tempDir, "escaped")).ToNot(BeAnExistingFile())
+	})
+
+	It("Fails if the bundle is not a gzipped tarball", func() {
+		Expect(ioutil.WriteFile(bundlePath, []byte("not a tarball"), 0600)).To(Succeed())
+
+		_, err := openReleaseBundle(bundlePath, filepath.Join(tempDir, "unpacked"))
+		Expect(err).To(MatchError(ContainSubstring("could not unpack release bundle")))
+	})
+})
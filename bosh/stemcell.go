@@ -0,0 +1,148 @@
+package bosh
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"fmt"
+	"strings"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"gopkg.in/yaml.v2"
+)
+
+const workerStemcellFilename = "worker-stemcell.tgz"
+
+// StemcellVersion returns the version of the Concourse stemcell the deployment is pinned to,
+// or the version this build of concourse-up deploys if it isn't pinned
+func StemcellVersion(conf *config.Config) string {
+	if conf.StemcellVersion != "" {
+		return conf.StemcellVersion
+	}
+	return ConcourseStemcellVersion
+}
+
+// CheckStemcellVersion fails if the Concourse stemcell can't be pinned to version. The releases are
+// compiled against ConcourseStemcellVersion, and BOSH only reuses compiled packages on stemcells of
+// the same major version, so a pinned stemcell must be on the same line
+func CheckStemcellVersion(version string) error {
+	line := stemcellLine(ConcourseStemcellVersion)
+	if stemcellLine(version) != line {
+		return fmt.Errorf("stemcell %s is not on the %s line this version of concourse-up's releases are compiled for. Pin a %s.x stemcell instead", version, line, line)
+	}
+	if !strings.Contains(ConcourseStemcellURL, ConcourseStemcellVersion) {
+		return fmt.Errorf("could not work out where to download stemcell %s from", version)
+	}
+	return nil
+}
+
+func stemcellLine(version string) string {
+	return strings.SplitN(version, ".", 2)[0]
+}
+
+// concourseStemcellURL returns the URL of the Concourse stemcell of the given version, which is
+// published alongside the version this build of concourse-up deploys
+func concourseStemcellURL(version string) string {
+	return strings.Replace(ConcourseStemcellURL, ConcourseStemcellVersion, version, 1)
+}
+
+const workerStemcellPrefix = "concourse-up-worker-"
+
+// workerStemcellName returns the name of the light stemcell built from a custom worker AMI
+func workerStemcellName(amiID string) string {
+	return workerStemcellPrefix + amiID
+}
+
+type lightStemcellManifest struct {
+	Name            string                       `yaml:"name"`
+	Version         string                       `yaml:"version"`
+	BoshProtocol    int                          `yaml:"bosh_protocol"`
+	APIVersion      int                          `yaml:"api_version"`
+	SHA1            string                       `yaml:"sha1"`
+	OperatingSystem string                       `yaml:"operating_system"`
+	StemcellFormats []string                     `yaml:"stemcell_formats"`
+	CloudProperties map[string]map[string]string `yaml:"cloud_properties"`
+}
+
+// lightStemcell returns a BOSH light stemcell which refers to an existing AMI in the region.
+// BOSH can't use an AMI directly, but the AWS CPI boots VMs from the AMI a light stemcell names
+func lightStemcell(name, version, operatingSystem, region, amiID string) ([]byte, error) {
+	// A light stemcell has no image of its own, so the image is empty
+	image := []byte{}
+
+	manifest, err := yaml.Marshal(lightStemcellManifest{
+		Name:            name,
+		Version:         version,
+		BoshProtocol:    1,
+		APIVersion:      2,
+		SHA1:            fmt.Sprintf("%x", sha1.Sum(image)),
+		OperatingSystem: operatingSystem,
+		StemcellFormats: []string{"aws-light"},
+		CloudProperties: map[string]map[string]string{
+			"ami": {region: amiID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var stemcell bytes.Buffer
+	gzipWriter := gzip.NewWriter(&stemcell)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, file := range []struct {
+		name     string
+		contents []byte
+	}{
+		{"stemcell.MF", manifest},
+		{"image", image},
+	} {
+		err = tarWriter.WriteHeader(&tar.Header{
+			Name: file.name,
+			Mode: 0644,
+			Size: int64(len(file.contents)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = tarWriter.Write(file.contents); err != nil {
+			return nil, err
+		}
+	}
+	if err = tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return stemcell.Bytes(), nil
+}
+
+// uploadWorkerStemcell uploads a light stemcell for the custom worker AMI, if there is one
+func (client *Client) uploadWorkerStemcell() error {
+	if client.config.WorkerAMIID == "" {
+		return nil
+	}
+
+	// The AMI is built from the Concourse stemcell, so has its version. The releases are compiled
+	// for that version, which BOSH checks against the version of the stemcell the workers use
+	stemcell, err := lightStemcell(workerStemcellName(client.config.WorkerAMIID), StemcellVersion(client.config), ConcourseStemcellOS, client.config.Region, client.config.WorkerAMIID)
+	if err != nil {
+		return err
+	}
+
+	stemcellPath, err := client.director.SaveFileToWorkingDir(workerStemcellFilename, stemcell)
+	if err != nil {
+		return err
+	}
+
+	return client.director.RunAuthenticatedCommand(
+		client.stdout,
+		client.stderr,
+		false,
+		"upload-stemcell",
+		stemcellPath,
+	)
+}
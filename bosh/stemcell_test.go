@@ -0,0 +1,73 @@
+package bosh
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var _ = Describe("lightStemcell", func() {
+	It("Refers to the AMI in the region", func() {
+		stemcell, err := lightStemcell("concourse-up-worker-ami-123", "3468.22", "ubuntu-trusty", "eu-west-1", "ami-123")
+		Expect(err).ToNot(HaveOccurred())
+
+		gzipReader, err := gzip.NewReader(bytes.NewReader(stemcell))
+		Expect(err).ToNot(HaveOccurred())
+		tarReader := tar.NewReader(gzipReader)
+
+		files := map[string][]byte{}
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			files[header.Name], err = ioutil.ReadAll(tarReader)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(files).To(HaveKey("image"))
+
+		var manifest lightStemcellManifest
+		Expect(yaml.Unmarshal(files["stemcell.MF"], &manifest)).To(Succeed())
+		Expect(manifest.Name).To(Equal("concourse-up-worker-ami-123"))
+		Expect(manifest.Version).To(Equal("3468.22"))
+		Expect(manifest.OperatingSystem).To(Equal("ubuntu-trusty"))
+		Expect(manifest.StemcellFormats).To(ConsistOf("aws-light"))
+		Expect(manifest.CloudProperties["ami"]).To(Equal(map[string]string{"eu-west-1": "ami-123"}))
+	})
+})
+
+var _ = Describe("Pinned stemcells", func() {
+	var originalVersion, originalURL string
+
+	BeforeEach(func() {
+		originalVersion, originalURL = ConcourseStemcellVersion, ConcourseStemcellURL
+		ConcourseStemcellVersion = "3468.22"
+		ConcourseStemcellURL = "https://s3.amazonaws.com/bosh-aws-light-stemcells/light-bosh-stemcell-3468.22-aws-xen-hvm-ubuntu-trusty-go_agent.tgz"
+	})
+
+	AfterEach(func() {
+		ConcourseStemcellVersion, ConcourseStemcellURL = originalVersion, originalURL
+	})
+
+	It("Uses the version this build deploys unless one is pinned", func() {
+		Expect(StemcellVersion(&config.Config{})).To(Equal("3468.22"))
+		Expect(StemcellVersion(&config.Config{StemcellVersion: "3468.17"})).To(Equal("3468.17"))
+	})
+
+	It("Downloads pinned stemcells from alongside the one this build deploys", func() {
+		Expect(concourseStemcellURL("3468.17")).To(Equal("https://s3.amazonaws.com/bosh-aws-light-stemcells/light-bosh-stemcell-3468.17-aws-xen-hvm-ubuntu-trusty-go_agent.tgz"))
+	})
+
+	It("Only allows stemcells on the line the releases are compiled for", func() {
+		Expect(CheckStemcellVersion("3468.17")).To(Succeed())
+		Expect(CheckStemcellVersion("3541.10")).To(MatchError("stemcell 3541.10 is not on the 3468 line this version of concourse-up's releases are compiled for. Pin a 3468.x stemcell instead"))
+	})
+})
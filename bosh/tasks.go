@@ -0,0 +1,76 @@
+package bosh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// taskPollInterval is how often WaitForDeployTask asks the director about the deploy
+var taskPollInterval = 30 * time.Second
+
+// finishedTaskStates are the states a BOSH task doesn't leave
+var finishedTaskStates = map[string]bool{
+	"done":      true,
+	"error":     true,
+	"cancelled": true,
+	"timeout":   true,
+}
+
+// Task is a task run by the director
+type Task struct {
+	ID          string `json:"id"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+}
+
+// WaitForDeployTask polls the director until the Concourse deployment's latest task has
+// finished, returning it in its final state, eg done or error. It errors if the task is
+// still running after timeout
+func (client *Client) WaitForDeployTask(timeout time.Duration) (Task, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		task, err := client.latestDeployTask()
+		if err != nil {
+			return Task{}, err
+		}
+		if finishedTaskStates[task.State] {
+			return task, nil
+		}
+		if time.Now().Add(taskPollInterval).After(deadline) {
+			return task, fmt.Errorf("BOSH task %s was still %s after %s", task.ID, task.State, timeout)
+		}
+		time.Sleep(taskPollInterval)
+	}
+}
+
+func (client *Client) latestDeployTask() (Task, error) {
+	output := new(bytes.Buffer)
+	if err := client.director.RunAuthenticatedCommand(
+		output,
+		client.stderr,
+		false,
+		"--deployment",
+		concourseDeploymentName,
+		"tasks",
+		"--recent=1",
+		"--json",
+	); err != nil {
+		return Task{}, err
+	}
+
+	tasks := struct {
+		Tables []struct {
+			Rows []Task `json:"Rows"`
+		} `json:"Tables"`
+	}{}
+	if err := json.NewDecoder(output).Decode(&tasks); err != nil {
+		return Task{}, err
+	}
+	if len(tasks.Tables) == 0 || len(tasks.Tables[0].Rows) == 0 {
+		return Task{}, fmt.Errorf("the director has no tasks for the %s deployment", concourseDeploymentName)
+	}
+
+	return tasks.Tables[0].Rows[0], nil
+}
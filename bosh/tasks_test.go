@@ -0,0 +1,63 @@
+package bosh
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WaitForDeployTask", func() {
+	var client *Client
+	var states []string
+	var commands []string
+
+	BeforeEach(func() {
+		taskPollInterval = time.Millisecond
+		commands = []string{}
+		client = &Client{
+			director: &FakeDirectorClient{
+				FakeRunAuthenticatedCommand: func(stdout, stderr io.Writer, detach bool, args ...string) error {
+					commands = append(commands, strings.Join(args, " "))
+					state := states[0]
+					if len(states) > 1 {
+						states = states[1:]
+					}
+					_, err := io.WriteString(stdout, `{"Tables": [{"Rows": [{"id": "42", "state": "`+state+`", "description": "create deployment"}]}]}`)
+					return err
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		taskPollInterval = 30 * time.Second
+	})
+
+	It("Polls the latest task of the Concourse deployment until it finishes", func() {
+		states = []string{"queued", "processing", "done"}
+
+		task, err := client.WaitForDeployTask(time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(task).To(Equal(Task{ID: "42", State: "done", Description: "create deployment"}))
+		Expect(commands).To(HaveLen(3))
+		Expect(commands[0]).To(Equal("--deployment concourse tasks --recent=1 --json"))
+	})
+
+	It("Returns tasks that fail", func() {
+		states = []string{"processing", "error"}
+
+		task, err := client.WaitForDeployTask(time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(task.State).To(Equal("error"))
+	})
+
+	It("Errors if the task is still running at the timeout", func() {
+		states = []string{"processing"}
+
+		_, err := client.WaitForDeployTask(0)
+		Expect(err).To(MatchError("BOSH task 42 was still processing after 0s"))
+	})
+})
@@ -0,0 +1,105 @@
+package bosh
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// ComponentVersions returns the versions of the releases and stemcells this
+// build of concourse-up deploys, keyed by component name
+func ComponentVersions() map[string]string {
+	return map[string]string{
+		"bosh":               DirectorReleaseVersion,
+		"bosh-aws-cpi":       DirectorCPIReleaseVersion,
+		"bosh-stemcell":      DirectorStemcellVersion,
+		"concourse":          ConcourseReleaseVersion,
+		"concourse-stemcell": ConcourseStemcellVersion,
+		"credhub":            CredhubReleaseVersion,
+		"garden-runc":        GardenReleaseVersion,
+		"grafana":            GrafanaReleaseVersion,
+		"influxdb":           InfluxDBReleaseVersion,
+		"riemann":            RiemannReleaseVersion,
+		"uaa":                UAAReleaseVersion,
+	}
+}
+
+// RunningVersions asks the director for its own version and the versions of the releases and
+// stemcell in the Concourse deployment, keyed by the same component names as ComponentVersions.
+// Components the director can't report, such as its CPI and stemcell, are left out
+func (client *Client) RunningVersions() (map[string]string, error) {
+	output := new(bytes.Buffer)
+	if err := client.director.RunAuthenticatedCommand(output, client.stderr, false, "env", "--json"); err != nil {
+		return nil, err
+	}
+
+	env := struct {
+		Tables []struct {
+			Rows []struct {
+				Version string `json:"version"`
+			} `json:"Rows"`
+		} `json:"Tables"`
+	}{}
+	if err := json.NewDecoder(output).Decode(&env); err != nil {
+		return nil, err
+	}
+
+	versions := map[string]string{}
+	for _, table := range env.Tables {
+		for _, row := range table.Rows {
+			// The director reports its version with its commit, eg 264.7.0 (00000000)
+			if fields := strings.Fields(row.Version); len(fields) > 0 {
+				versions["bosh"] = fields[0]
+			}
+		}
+	}
+
+	output.Reset()
+	if err := client.director.RunAuthenticatedCommand(
+		output,
+		client.stderr,
+		false,
+		"--deployment",
+		concourseDeploymentName,
+		"deployment",
+		"--json",
+	); err != nil {
+		return nil, err
+	}
+
+	deployment := struct {
+		Tables []struct {
+			Rows []struct {
+				Releases  string `json:"release_s"`
+				Stemcells string `json:"stemcell_s"`
+			} `json:"Rows"`
+		} `json:"Tables"`
+	}{}
+	if err := json.NewDecoder(output).Decode(&deployment); err != nil {
+		return nil, err
+	}
+
+	components := ComponentVersions()
+	for _, table := range deployment.Tables {
+		for _, row := range table.Rows {
+			// Releases and stemcells are listed one per line as name/version
+			for _, release := range strings.Fields(row.Releases) {
+				parts := strings.SplitN(release, "/", 2)
+				if _, ok := components[parts[0]]; ok && len(parts) == 2 {
+					versions[parts[0]] = parts[1]
+				}
+			}
+			for _, stemcell := range strings.Fields(row.Stemcells) {
+				// A custom worker stemcell has the Concourse stemcell's version, but isn't the one it reports
+				if strings.HasPrefix(stemcell, workerStemcellPrefix) {
+					continue
+				}
+				if parts := strings.SplitN(stemcell, "/", 2); len(parts) == 2 {
+					versions["concourse-stemcell"] = parts[1]
+				}
+			}
+		}
+	}
+
+	return versions, nil
+}
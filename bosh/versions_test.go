@@ -0,0 +1,42 @@
+package bosh
+
+import (
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RunningVersions", func() {
+	It("Reads the versions from the director and the Concourse deployment", func() {
+		client := &Client{
+			director: &FakeDirectorClient{
+				FakeRunAuthenticatedCommand: func(stdout, stderr io.Writer, detach bool, args ...string) error {
+					var output string
+					switch strings.Join(args, " ") {
+					case "env --json":
+						output = `{"Tables": [{"Rows": [{"name": "concourse-up-happymeal", "version": "264.7.0 (00000000)"}]}]}`
+					case "--deployment concourse deployment --json":
+						output = `{"Tables": [{"Rows": [{
+							"name": "concourse",
+							"release_s": "concourse/3.14.1\ngarden-runc/1.13.1\nhaproxy/8.7.0",
+							"stemcell_s": "bosh-aws-xen-hvm-ubuntu-trusty-go_agent/3468.22\nconcourse-up-worker-ami-123/3468.17"
+						}]}]}`
+					}
+					_, err := io.WriteString(stdout, output)
+					return err
+				},
+			},
+		}
+
+		versions, err := client.RunningVersions()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions).To(Equal(map[string]string{
+			"bosh":               "264.7.0",
+			"concourse":          "3.14.1",
+			"garden-runc":        "1.13.1",
+			"concourse-stemcell": "3468.22",
+		}))
+	})
+})
@@ -30,6 +30,20 @@ influxdb_release_sha1=$(jq -r .influxdb_release_sha1 compilation-vars.json)
 credhub_release_url=$(jq -r .credhub_release_url compilation-vars.json)
 credhub_release_version=$(jq -r .credhub_release_version compilation-vars.json)
 credhub_release_sha1=$(jq -r .credhub_release_sha1 compilation-vars.json)
+# The optional releases are left empty until CI next compiles them into compilation-vars.json, and
+# deployments that need one will refuse to upload it
+haproxy_release_url=$(jq -r '.haproxy_release_url // empty' compilation-vars.json)
+haproxy_release_version=$(jq -r '.haproxy_release_version // empty' compilation-vars.json)
+haproxy_release_sha1=$(jq -r '.haproxy_release_sha1 // empty' compilation-vars.json)
+os_conf_release_url=$(jq -r '.os_conf_release_url // empty' compilation-vars.json)
+os_conf_release_version=$(jq -r '.os_conf_release_version // empty' compilation-vars.json)
+os_conf_release_sha1=$(jq -r '.os_conf_release_sha1 // empty' compilation-vars.json)
+syslog_release_url=$(jq -r '.syslog_release_url // empty' compilation-vars.json)
+syslog_release_version=$(jq -r '.syslog_release_version // empty' compilation-vars.json)
+syslog_release_sha1=$(jq -r '.syslog_release_sha1 // empty' compilation-vars.json)
+prometheus_release_url=$(jq -r '.prometheus_release_url // empty' compilation-vars.json)
+prometheus_release_version=$(jq -r '.prometheus_release_version // empty' compilation-vars.json)
+prometheus_release_sha1=$(jq -r '.prometheus_release_sha1 // empty' compilation-vars.json)
 uaa_release_url=$(jq -r .uaa_release_url compilation-vars.json)
 uaa_release_version=$(jq -r .uaa_release_version compilation-vars.json)
 uaa_release_sha1=$(jq -r .uaa_release_sha1 compilation-vars.json)
@@ -48,6 +62,11 @@ terraform_windows_binary_url=$(jq -r .terraform_windows_binary_url compilation-v
 
 go generate github.com/EngineerBetter/concourse-up/bosh
 go generate github.com/EngineerBetter/concourse-up/terraform
+
+# Recorded so that self-check can spot a corrupted binary
+bosh_assets_sha256=$(cd bosh/assets && LC_ALL=C ls | xargs cat | shasum -a 256 | cut -d ' ' -f 1)
+terraform_assets_sha256=$(cd terraform/assets && LC_ALL=C ls | xargs cat | shasum -a 256 | cut -d ' ' -f 1)
+
 go build -ldflags "
   -X github.com/EngineerBetter/concourse-up/bosh.ConcourseStemcellURL=$concourse_stemcell_url
   -X github.com/EngineerBetter/concourse-up/bosh.ConcourseStemcellVersion=$concourse_stemcell_version
@@ -67,6 +86,18 @@ go build -ldflags "
   -X github.com/EngineerBetter/concourse-up/bosh.CredhubReleaseURL=$credhub_release_url
   -X github.com/EngineerBetter/concourse-up/bosh.CredhubReleaseVersion=$credhub_release_version
   -X github.com/EngineerBetter/concourse-up/bosh.CredhubReleaseSHA1=$credhub_release_sha1
+  -X github.com/EngineerBetter/concourse-up/bosh.HAProxyReleaseURL=$haproxy_release_url
+  -X github.com/EngineerBetter/concourse-up/bosh.HAProxyReleaseVersion=$haproxy_release_version
+  -X github.com/EngineerBetter/concourse-up/bosh.HAProxyReleaseSHA1=$haproxy_release_sha1
+  -X github.com/EngineerBetter/concourse-up/bosh.OSConfReleaseURL=$os_conf_release_url
+  -X github.com/EngineerBetter/concourse-up/bosh.OSConfReleaseVersion=$os_conf_release_version
+  -X github.com/EngineerBetter/concourse-up/bosh.OSConfReleaseSHA1=$os_conf_release_sha1
+  -X github.com/EngineerBetter/concourse-up/bosh.SyslogReleaseURL=$syslog_release_url
+  -X github.com/EngineerBetter/concourse-up/bosh.SyslogReleaseVersion=$syslog_release_version
+  -X github.com/EngineerBetter/concourse-up/bosh.SyslogReleaseSHA1=$syslog_release_sha1
+  -X github.com/EngineerBetter/concourse-up/bosh.PrometheusReleaseURL=$prometheus_release_url
+  -X github.com/EngineerBetter/concourse-up/bosh.PrometheusReleaseVersion=$prometheus_release_version
+  -X github.com/EngineerBetter/concourse-up/bosh.PrometheusReleaseSHA1=$prometheus_release_sha1
   -X github.com/EngineerBetter/concourse-up/bosh.UAAReleaseURL=$uaa_release_url
   -X github.com/EngineerBetter/concourse-up/bosh.UAAReleaseVersion=$uaa_release_version
   -X github.com/EngineerBetter/concourse-up/bosh.UAAReleaseSHA1=$uaa_release_sha1
@@ -92,6 +123,8 @@ go build -ldflags "
   -X github.com/EngineerBetter/concourse-up/terraform.DarwinBinaryURL=$terraform_darwin_binary_url
   -X github.com/EngineerBetter/concourse-up/terraform.LinuxBinaryURL=$terraform_linux_binary_url
   -X github.com/EngineerBetter/concourse-up/terraform.WindowsBinaryURL=$terraform_windows_binary_url
+  -X github.com/EngineerBetter/concourse-up/bosh.AssetsSHA256=$bosh_assets_sha256
+  -X github.com/EngineerBetter/concourse-up/terraform.AssetsSHA256=$terraform_assets_sha256
   -X main.ConcourseUpVersion=$version
 " -o concourse-up
 
@@ -0,0 +1,150 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// header identifies a concourse-up bundle and the version of its format
+const header = "concourse-up-bundle-v1\n"
+
+const (
+	saltSize = 32
+	keySize  = 32
+)
+
+// ErrWrongPassphrase is returned when a bundle can't be decrypted with the given passphrase
+var ErrWrongPassphrase = errors.New("could not decrypt the bundle. Check the passphrase is the one it was exported with")
+
+// Write writes the files to w as a gzipped tarball, encrypted with a key derived from passphrase
+func Write(w io.Writer, passphrase string, files map[string][]byte) error {
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for name, contents := range files {
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0600,
+			Size: int64(len(contents)),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = tarWriter.Write(contents); err != nil {
+			return err
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, archive.Bytes(), []byte(header))
+
+	for _, part := range [][]byte{[]byte(header), salt, nonce, ciphertext} {
+		if _, err = w.Write(part); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Read decrypts a bundle written by Write and returns the files in it
+func Read(r io.Reader, passphrase string) (map[string][]byte, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(contents, []byte(header)) {
+		return nil, errors.New("not a concourse-up bundle")
+	}
+	contents = contents[len(header):]
+
+	if len(contents) < saltSize {
+		return nil, errors.New("bundle is truncated")
+	}
+	salt, contents := contents[:saltSize], contents[saltSize:]
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contents) < gcm.NonceSize() {
+		return nil, errors.New("bundle is truncated")
+	}
+	nonce, ciphertext := contents[:gcm.NonceSize()], contents[gcm.NonceSize():]
+
+	archive, err := gcm.Open(nil, nonce, ciphertext, []byte(header))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	files := map[string][]byte{}
+	for {
+		fileHeader, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fileContents, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, err
+		}
+		files[fileHeader.Name] = fileContents
+	}
+
+	return files, nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
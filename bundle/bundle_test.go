@@ -0,0 +1,40 @@
+package bundle_test
+
+import (
+	"bytes"
+
+	. "github.com/EngineerBetter/concourse-up/bundle"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundle", func() {
+	files := map[string][]byte{
+		"config.json":         []byte(`{"project":"test"}`),
+		"director-state.json": []byte(`{}`),
+	}
+
+	It("Reads back the files it was written with", func() {
+		var buf bytes.Buffer
+		Expect(Write(&buf, "correct horse battery staple", files)).To(Succeed())
+
+		Expect(buf.String()).ToNot(ContainSubstring(`"project":"test"`))
+
+		read, err := Read(&buf, "correct horse battery staple")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read).To(Equal(files))
+	})
+
+	It("Fails with the wrong passphrase", func() {
+		var buf bytes.Buffer
+		Expect(Write(&buf, "correct horse battery staple", files)).To(Succeed())
+
+		_, err := Read(&buf, "incorrect horse")
+		Expect(err).To(Equal(ErrWrongPassphrase))
+	})
+
+	It("Rejects files that aren't bundles", func() {
+		_, err := Read(bytes.NewBufferString("hello"), "correct horse battery staple")
+		Expect(err).To(MatchError("not a concourse-up bundle"))
+	})
+})
@@ -0,0 +1,177 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/xenolf/lego/acme"
+	r53provider "github.com/xenolf/lego/providers/dns/route53"
+)
+
+type user struct {
+	k crypto.PrivateKey
+	r *acme.RegistrationResource
+	sync.Once
+}
+
+func (u *user) GetEmail() string {
+	return "nobody@example.com"
+}
+
+func (u *user) GetRegistration() *acme.RegistrationResource {
+	return u.r
+}
+
+func (u *user) GetPrivateKey() crypto.PrivateKey {
+	u.Do(func() {
+		var err error
+		u.k, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+	})
+	return u.k
+}
+
+type timeoutProvider struct {
+	acme.ChallengeProvider
+	timeout, interval time.Duration
+}
+
+func (t timeoutProvider) Timeout() (timeout, interval time.Duration) {
+	return t.timeout, t.interval
+}
+
+func acmeURL() string {
+	if u := os.Getenv("CONCOURSE_UP_ACME_URL"); u != "" {
+		return u
+	}
+	return "https://acme-v01.api.letsencrypt.org/directory"
+}
+
+// ACMEClient obtains trusted certificates from an ACME certificate authority, such as
+// Let's Encrypt, by answering DNS-01 challenges with TXT records in Route53
+type ACMEClient struct {
+	url          string
+	hostedZoneID string
+}
+
+// NewACMEClient returns an ACMEClient for the certificate authority at url that answers
+// challenges in the given hosted zone. If hostedZoneID is empty the zone is looked up from each domain
+func NewACMEClient(url, hostedZoneID string) *ACMEClient {
+	return &ACMEClient{
+		url:          url,
+		hostedZoneID: hostedZoneID,
+	}
+}
+
+// ObtainFromACME obtains a certificate for the domains from Let's Encrypt, or the
+// certificate authority in CONCOURSE_UP_ACME_URL, using the given hosted zone
+func ObtainFromACME(hostedZoneID string, domains ...string) (*Certs, error) {
+	return NewACMEClient(acmeURL(), hostedZoneID).Obtain(domains...)
+}
+
+// Obtain registers with the certificate authority and obtains a certificate for the domains
+func (client *ACMEClient) Obtain(domains ...string) (*Certs, error) {
+	u := &user{}
+	c, err := acme.NewClient(client.url, u, acme.RSA2048)
+	if err != nil {
+		return nil, err
+	}
+	c.ExcludeChallenges([]acme.Challenge{acme.HTTP01, acme.TLSSNI01})
+
+	provider, err := client.challengeProvider()
+	if err != nil {
+		return nil, err
+	}
+	c.SetChallengeProvider(acme.DNS01, timeoutProvider{
+		provider,
+		10 * time.Minute,
+		1 * time.Second,
+	})
+
+	u.r, err = c.Register()
+	if err != nil {
+		return nil, err
+	}
+	c.AgreeToTOS()
+	resp, errs := c.ObtainCertificate(domains, true, nil, false)
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("%v", errs)
+	}
+	return &Certs{
+		CACert: resp.IssuerCertificate,
+		Key:    resp.PrivateKey,
+		Cert:   resp.Certificate,
+	}, nil
+}
+
+func (client *ACMEClient) challengeProvider() (acme.ChallengeProvider, error) {
+	if client.hostedZoneID == "" {
+		return r53provider.NewDNSProvider()
+	}
+
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return nil, err
+	}
+	return &hostedZoneProvider{
+		client:       route53.New(sess),
+		hostedZoneID: client.hostedZoneID,
+	}, nil
+}
+
+// hostedZoneProvider answers DNS-01 challenges in a hosted zone that is already known,
+// rather than searching the account for the zone that best matches each domain
+type hostedZoneProvider struct {
+	client       *route53.Route53
+	hostedZoneID string
+}
+
+// Present creates the TXT record for the challenge and waits for Route53 to publish it
+func (p *hostedZoneProvider) Present(domain, token, keyAuth string) error {
+	fqdn, value, ttl := acme.DNS01Record(domain, keyAuth)
+	return p.changeRecord(route53.ChangeActionUpsert, fqdn, value, ttl)
+}
+
+// CleanUp removes the TXT record for the challenge
+func (p *hostedZoneProvider) CleanUp(domain, token, keyAuth string) error {
+	fqdn, value, ttl := acme.DNS01Record(domain, keyAuth)
+	return p.changeRecord(route53.ChangeActionDelete, fqdn, value, ttl)
+}
+
+func (p *hostedZoneProvider) changeRecord(action, fqdn, value string, ttl int) error {
+	resp, err := p.client.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(p.hostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(action),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name: aws.String(fqdn),
+						Type: aws.String(route53.RRTypeTxt),
+						TTL:  aws.Int64(int64(ttl)),
+						ResourceRecords: []*route53.ResourceRecord{
+							{Value: aws.String(fmt.Sprintf("%q", value))},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.client.WaitUntilResourceRecordSetsChanged(&route53.GetChangeInput{
+		Id: resp.ChangeInfo.Id,
+	})
+}
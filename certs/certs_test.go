@@ -16,15 +16,22 @@ var _ = Describe("Certs", func() {
 		Expect(string(certs.Key)).To(ContainSubstring("BEGIN RSA PRIVATE KEY"))
 		Expect(string(certs.Cert)).To(ContainSubstring("BEGIN CERTIFICATE"))
 	})
-	It("Generates a cert for a domain", func() {
-		certs, err := Generate("concourse-up-mole", "concourse-up-test-"+util.GeneratePasswordWithLength(10)+".engineerbetter.com")
+	It("Generates a self-signed cert for a domain", func() {
+		certs, err := Generate("concourse-up-mole", "ci.example.com")
 		Expect(err).ToNot(HaveOccurred())
 		Expect(string(certs.CACert)).To(ContainSubstring("BEGIN CERTIFICATE"))
 		Expect(string(certs.Key)).To(ContainSubstring("BEGIN RSA PRIVATE KEY"))
 		Expect(string(certs.Cert)).To(ContainSubstring("BEGIN CERTIFICATE"))
 	})
-	It("Can't generate a cert for google.com", func() {
-		_, err := Generate("concourse-up-mole", "google.com")
+	It("Obtains a cert for a domain from ACME", func() {
+		certs, err := ObtainFromACME("", "concourse-up-test-"+util.GeneratePasswordWithLength(10)+".engineerbetter.com")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(certs.CACert)).To(ContainSubstring("BEGIN CERTIFICATE"))
+		Expect(string(certs.Key)).To(ContainSubstring("BEGIN RSA PRIVATE KEY"))
+		Expect(string(certs.Cert)).To(ContainSubstring("BEGIN CERTIFICATE"))
+	})
+	It("Can't obtain a cert for google.com from ACME", func() {
+		_, err := ObtainFromACME("", "google.com")
 		Expect(err).To(HaveOccurred())
 	})
 })
@@ -42,6 +42,18 @@ pushd compilation-vars
   credhub_release_url=$(jq -r .credhub_release_url compilation-vars.json)
   credhub_release_version=$(jq -r .credhub_release_version compilation-vars.json)
   credhub_release_sha1=$(jq -r .credhub_release_sha1 compilation-vars.json)
+  haproxy_release_url=$(jq -r .haproxy_release_url compilation-vars.json)
+  haproxy_release_version=$(jq -r .haproxy_release_version compilation-vars.json)
+  haproxy_release_sha1=$(jq -r .haproxy_release_sha1 compilation-vars.json)
+  os_conf_release_url=$(jq -r .os_conf_release_url compilation-vars.json)
+  os_conf_release_version=$(jq -r .os_conf_release_version compilation-vars.json)
+  os_conf_release_sha1=$(jq -r .os_conf_release_sha1 compilation-vars.json)
+  syslog_release_url=$(jq -r .syslog_release_url compilation-vars.json)
+  syslog_release_version=$(jq -r .syslog_release_version compilation-vars.json)
+  syslog_release_sha1=$(jq -r .syslog_release_sha1 compilation-vars.json)
+  prometheus_release_url=$(jq -r .prometheus_release_url compilation-vars.json)
+  prometheus_release_version=$(jq -r .prometheus_release_version compilation-vars.json)
+  prometheus_release_sha1=$(jq -r .prometheus_release_sha1 compilation-vars.json)
   garden_release_url=$(jq -r .garden_release_url compilation-vars.json)
   garden_release_version=$(jq -r .garden_release_version compilation-vars.json)
   garden_release_sha1=$(jq -r .garden_release_sha1 compilation-vars.json)
@@ -63,6 +75,11 @@ cd "$GOPATH/src/github.com/EngineerBetter/concourse-up"
 GOOS=linux go get -u github.com/mattn/go-bindata/...
 go generate github.com/EngineerBetter/concourse-up/bosh
 go generate github.com/EngineerBetter/concourse-up/terraform
+
+# Recorded so that self-check can spot a corrupted binary
+bosh_assets_sha256=$(cd bosh/assets && LC_ALL=C ls | xargs cat | sha256sum | cut -d ' ' -f 1)
+terraform_assets_sha256=$(cd terraform/assets && LC_ALL=C ls | xargs cat | sha256sum | cut -d ' ' -f 1)
+
 go build -ldflags "
   -X main.ConcourseUpVersion=$version
   -X github.com/EngineerBetter/concourse-up/bosh.ConcourseStemcellURL=$concourse_stemcell_url
@@ -86,6 +103,18 @@ go build -ldflags "
   -X github.com/EngineerBetter/concourse-up/bosh.CredhubReleaseURL=$credhub_release_url
   -X github.com/EngineerBetter/concourse-up/bosh.CredhubReleaseVersion=$credhub_release_version
   -X github.com/EngineerBetter/concourse-up/bosh.CredhubReleaseSHA1=$credhub_release_sha1
+  -X github.com/EngineerBetter/concourse-up/bosh.HAProxyReleaseURL=$haproxy_release_url
+  -X github.com/EngineerBetter/concourse-up/bosh.HAProxyReleaseVersion=$haproxy_release_version
+  -X github.com/EngineerBetter/concourse-up/bosh.HAProxyReleaseSHA1=$haproxy_release_sha1
+  -X github.com/EngineerBetter/concourse-up/bosh.OSConfReleaseURL=$os_conf_release_url
+  -X github.com/EngineerBetter/concourse-up/bosh.OSConfReleaseVersion=$os_conf_release_version
+  -X github.com/EngineerBetter/concourse-up/bosh.OSConfReleaseSHA1=$os_conf_release_sha1
+  -X github.com/EngineerBetter/concourse-up/bosh.SyslogReleaseURL=$syslog_release_url
+  -X github.com/EngineerBetter/concourse-up/bosh.SyslogReleaseVersion=$syslog_release_version
+  -X github.com/EngineerBetter/concourse-up/bosh.SyslogReleaseSHA1=$syslog_release_sha1
+  -X github.com/EngineerBetter/concourse-up/bosh.PrometheusReleaseURL=$prometheus_release_url
+  -X github.com/EngineerBetter/concourse-up/bosh.PrometheusReleaseVersion=$prometheus_release_version
+  -X github.com/EngineerBetter/concourse-up/bosh.PrometheusReleaseSHA1=$prometheus_release_sha1
   -X github.com/EngineerBetter/concourse-up/bosh.GardenReleaseURL=$garden_release_url
   -X github.com/EngineerBetter/concourse-up/bosh.GardenReleaseVersion=$garden_release_version
   -X github.com/EngineerBetter/concourse-up/bosh.GardenReleaseSHA1=$garden_release_sha1
@@ -108,4 +137,6 @@ go build -ldflags "
   -X github.com/EngineerBetter/concourse-up/terraform.DarwinBinaryURL=$terraform_darwin_binary_url
   -X github.com/EngineerBetter/concourse-up/terraform.LinuxBinaryURL=$terraform_linux_binary_url
   -X github.com/EngineerBetter/concourse-up/terraform.WindowsBinaryURL=$terraform_windows_binary_url
+  -X github.com/EngineerBetter/concourse-up/bosh.AssetsSHA256=$bosh_assets_sha256
+  -X github.com/EngineerBetter/concourse-up/terraform.AssetsSHA256=$terraform_assets_sha256
 " -o "$build_dir/$OUTPUT_FILE"
@@ -42,6 +42,23 @@ credhub_release_version=$(cat credhub-release/version)
 credhub_release_url=$(cat credhub-release/url)
 credhub_release_sha1=$(cat credhub-release/sha1)
 
+haproxy_release_version=$(cat haproxy-release/version)
+
+# os-conf has no packages to compile, so it's used straight from bosh.io
+os_conf_release_version=$(cat os-conf-release/version)
+os_conf_release_url=$(cat os-conf-release/url)
+os_conf_release_sha1=$(cat os-conf-release/sha1)
+
+# syslog only configures the stemcell's rsyslog, so it's also used straight from bosh.io
+syslog_release_version=$(cat syslog-release/version)
+syslog_release_url=$(cat syslog-release/url)
+syslog_release_sha1=$(cat syslog-release/sha1)
+
+# prometheus is only deployed to send metrics to an external Prometheus, so it's compiled by the director when needed
+prometheus_release_version=$(cat prometheus-release/version)
+prometheus_release_url=$(cat prometheus-release/url)
+prometheus_release_sha1=$(cat prometheus-release/sha1)
+
 director_bosh_release_version=$(cat director-bosh-release/version)
 concourse_release_version=$(basename concourse-bosh-release/concourse-*.tgz .tgz | sed 's/^concourse-//')
 garden_release_version=$(basename concourse-bosh-release/garden-runc-*.tgz .tgz | sed 's/^garden-runc-//')
@@ -56,6 +73,7 @@ $bosh upload-release "grafana-release/release.tgz"
 $bosh upload-release "influxdb-release/release.tgz"
 $bosh upload-release "uaa-release/release.tgz"
 $bosh upload-release "credhub-release/release.tgz"
+$bosh upload-release "haproxy-release/release.tgz"
 
 echo "---
 name: cup-compilation-workspace
@@ -79,6 +97,8 @@ releases:
   version: \"$uaa_release_version\"
 - name: credhub
   version: \"$credhub_release_version\"
+- name: haproxy
+  version: \"$haproxy_release_version\"
 
 stemcells:
 - alias: trusty
@@ -131,6 +151,10 @@ $bosh \
   --deployment cup-compilation-workspace \
   export-release "credhub/$credhub_release_version" "ubuntu-trusty/$concourse_stemcell_version"
 
+$bosh \
+  --deployment cup-compilation-workspace \
+  export-release "haproxy/$haproxy_release_version" "ubuntu-trusty/$concourse_stemcell_version"
+
 compiled_concourse_release=$(echo concourse-"$concourse_release_version"-ubuntu-trusty-"$concourse_stemcell_version"-*.tgz)
 compiled_garden_release=$(echo garden-runc-"$garden_release_version"-ubuntu-trusty-"$concourse_stemcell_version"-*.tgz)
 compiled_director_bosh_release=$(echo bosh-"$director_bosh_release_version"-ubuntu-trusty-"$concourse_stemcell_version"-*.tgz)
@@ -139,6 +163,7 @@ compiled_grafana_release=$(echo grafana-"$grafana_release_version"-ubuntu-trusty
 compiled_influxdb_release=$(echo influxdb-"$influxdb_release_version"-ubuntu-trusty-"$concourse_stemcell_version"-*.tgz)
 compiled_uaa_release=$(echo uaa-"$uaa_release_version"-ubuntu-trusty-"$concourse_stemcell_version"-*.tgz)
 compiled_credhub_release=$(echo credhub-"$credhub_release_version"-ubuntu-trusty-"$concourse_stemcell_version"-*.tgz)
+compiled_haproxy_release=$(echo haproxy-"$haproxy_release_version"-ubuntu-trusty-"$concourse_stemcell_version"-*.tgz)
 
 aws s3 cp --acl public-read "$compiled_concourse_release" "s3://$PUBLIC_ARTIFACTS_BUCKET/$compiled_concourse_release"
 aws s3 cp --acl public-read "$compiled_garden_release" "s3://$PUBLIC_ARTIFACTS_BUCKET/$compiled_garden_release"
@@ -148,6 +173,7 @@ aws s3 cp --acl public-read "$compiled_grafana_release" "s3://$PUBLIC_ARTIFACTS_
 aws s3 cp --acl public-read "$compiled_influxdb_release" "s3://$PUBLIC_ARTIFACTS_BUCKET/$compiled_influxdb_release"
 aws s3 cp --acl public-read "$compiled_uaa_release" "s3://$PUBLIC_ARTIFACTS_BUCKET/$compiled_uaa_release"
 aws s3 cp --acl public-read "$compiled_credhub_release" "s3://$PUBLIC_ARTIFACTS_BUCKET/$compiled_credhub_release"
+aws s3 cp --acl public-read "$compiled_haproxy_release" "s3://$PUBLIC_ARTIFACTS_BUCKET/$compiled_haproxy_release"
 
 aws s3 cp --acl public-read "concourse-bosh-release/fly_darwin_amd64" "s3://$PUBLIC_ARTIFACTS_BUCKET/fly_darwin_amd64-$concourse_release_version"
 aws s3 cp --acl public-read "concourse-bosh-release/fly_linux_amd64" "s3://$PUBLIC_ARTIFACTS_BUCKET/fly_linux_amd64-$concourse_release_version"
@@ -169,6 +195,8 @@ uaa_release_sha1=$(sha1sum "$compiled_uaa_release" | awk '{ print $1 }')
 uaa_release_url="https://s3-$AWS_DEFAULT_REGION.amazonaws.com/$PUBLIC_ARTIFACTS_BUCKET/$compiled_uaa_release"
 credhub_release_sha1=$(sha1sum "$compiled_credhub_release" | awk '{ print $1 }')
 credhub_release_url="https://s3-$AWS_DEFAULT_REGION.amazonaws.com/$PUBLIC_ARTIFACTS_BUCKET/$compiled_credhub_release"
+haproxy_release_sha1=$(sha1sum "$compiled_haproxy_release" | awk '{ print $1 }')
+haproxy_release_url="https://s3-$AWS_DEFAULT_REGION.amazonaws.com/$PUBLIC_ARTIFACTS_BUCKET/$compiled_haproxy_release"
 
 fly_darwin_binary_url="https://s3-$AWS_DEFAULT_REGION.amazonaws.com/$PUBLIC_ARTIFACTS_BUCKET/fly_darwin_amd64-$concourse_release_version"
 fly_linux_binary_url="https://s3-$AWS_DEFAULT_REGION.amazonaws.com/$PUBLIC_ARTIFACTS_BUCKET/fly_linux_amd64-$concourse_release_version"
@@ -232,6 +260,18 @@ echo "{
   \"credhub_release_url\": \"$credhub_release_url\",
   \"credhub_release_sha1\": \"$credhub_release_sha1\",
   \"credhub_release_version\": \"$credhub_release_version\",
+  \"haproxy_release_url\": \"$haproxy_release_url\",
+  \"haproxy_release_sha1\": \"$haproxy_release_sha1\",
+  \"haproxy_release_version\": \"$haproxy_release_version\",
+  \"os_conf_release_url\": \"$os_conf_release_url\",
+  \"os_conf_release_sha1\": \"$os_conf_release_sha1\",
+  \"os_conf_release_version\": \"$os_conf_release_version\",
+  \"syslog_release_url\": \"$syslog_release_url\",
+  \"syslog_release_sha1\": \"$syslog_release_sha1\",
+  \"syslog_release_version\": \"$syslog_release_version\",
+  \"prometheus_release_url\": \"$prometheus_release_url\",
+  \"prometheus_release_sha1\": \"$prometheus_release_sha1\",
+  \"prometheus_release_version\": \"$prometheus_release_version\",
   \"fly_darwin_binary_url\": \"$fly_darwin_binary_url\",
   \"fly_linux_binary_url\": \"$fly_linux_binary_url\",
   \"fly_windows_binary_url\": \"$fly_windows_binary_url\",
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var adoptDNSArgs config.AdoptDNSArgs
+
+var adoptDNSFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &adoptDNSArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "domain",
+		Usage:       "Domain to move the deployment to. It must be in a Route53 hosted zone in the same account",
+		EnvVar:      "DOMAIN",
+		Destination: &adoptDNSArgs.Domain,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &adoptDNSArgs.IAAS,
+	},
+}
+
+var adoptDNS = cli.Command{
+	Name:      "adopt-dns",
+	Usage:     "Moves a deployment reached by its IP address onto a domain, redeploying only the web node",
+	ArgsUsage: "<name>",
+	Flags:     adoptDNSFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up adopt-dns --domain <domain> <name>`")
+		}
+
+		if err := adoptDNSArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, adoptDNSArgs.IAAS, adoptDNSArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		adoptDNSArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(adoptDNSArgs.IAAS, adoptDNSArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			&config.DeployArgs{
+				IAAS:      adoptDNSArgs.IAAS,
+				AWSRegion: adoptDNSArgs.AWSRegion,
+				Domain:    adoptDNSArgs.Domain,
+			},
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.AdoptDNS()
+	},
+}
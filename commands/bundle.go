@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var bundleArgs config.BundleArgs
+
+var bundlePassphraseFile string
+
+var bundleFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region. Defaults to the region of the existing deployment when exporting, or eu-west-1 when importing",
+		EnvVar:      "AWS_REGION",
+		Destination: &bundleArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "file",
+		Usage:       "Path of the bundle file",
+		EnvVar:      "BUNDLE_FILE",
+		Destination: &bundleArgs.File,
+	},
+	cli.StringFlag{
+		Name:        "passphrase",
+		Usage:       "(optional) Passphrase the bundle is encrypted with. Prefer the env var or --passphrase-file to keep it out of your shell history",
+		EnvVar:      "BUNDLE_PASSPHRASE",
+		Destination: &bundleArgs.Passphrase,
+	},
+	cli.StringFlag{
+		Name:        "passphrase-file",
+		Usage:       "(optional) Path to a file containing the passphrase the bundle is encrypted with",
+		EnvVar:      "BUNDLE_PASSPHRASE_FILE",
+		Destination: &bundlePassphraseFile,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &bundleArgs.IAAS,
+	},
+}
+
+var exportBundle = cli.Command{
+	Name:      "export-bundle",
+	Usage:     "Exports a deployment's config and state to an encrypted bundle",
+	ArgsUsage: "<name>",
+	Flags:     bundleFlags,
+	Action: func(c *cli.Context) error {
+		client, err := buildBundleClient(c, "export-bundle")
+		if err != nil {
+			return err
+		}
+
+		// Refuse to overwrite an existing file, which may be the only copy of another bundle
+		file, err := os.OpenFile(bundleArgs.File, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+
+		if err = client.ExportBundle(file, bundleArgs.Passphrase); err != nil {
+			file.Close()
+			os.Remove(bundleArgs.File)
+			return err
+		}
+		if err = file.Close(); err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(os.Stdout, "Exported %s to %s\n", c.Args().Get(0), bundleArgs.File)
+		return err
+	},
+}
+
+var importBundle = cli.Command{
+	Name:      "import-bundle",
+	Usage:     "Imports a deployment's config and state from a bundle into a new config bucket",
+	ArgsUsage: "<name>",
+	Flags:     bundleFlags,
+	Action: func(c *cli.Context) error {
+		client, err := buildBundleClient(c, "import-bundle")
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(bundleArgs.File)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err = client.ImportBundle(file, bundleArgs.Passphrase); err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(os.Stdout, "Imported %s from %s\n", c.Args().Get(0), bundleArgs.File)
+		return err
+	},
+}
+
+func buildBundleClient(c *cli.Context, command string) (concourse.IClient, error) {
+	name := c.Args().Get(0)
+	if name == "" {
+		return nil, fmt.Errorf("Usage is `concourse-up %s <name>`", command)
+	}
+
+	if err := readSecretFile(&bundleArgs.Passphrase, "passphrase", bundlePassphraseFile); err != nil {
+		return nil, err
+	}
+	if err := bundleArgs.Validate(); err != nil {
+		return nil, err
+	}
+
+	// An imported deployment doesn't exist yet, so there's no region to look up
+	if command == "import-bundle" {
+		if !c.IsSet("region") {
+			bundleArgs.AWSRegion = defaultRegion
+		}
+	} else {
+		region, err := deploymentRegion(c, bundleArgs.IAAS, bundleArgs.AWSRegion, name)
+		if err != nil {
+			return nil, err
+		}
+		bundleArgs.AWSRegion = region
+	}
+
+	awsClient, err := iaas.New(bundleArgs.IAAS, bundleArgs.AWSRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	return concourse.NewClient(
+		awsClient,
+		terraform.NewClient,
+		bosh.NewClient,
+		fly.New,
+		certs.Generate,
+		certs.ObtainFromACME,
+		config.New(awsClient, name, configBucketName),
+		nil,
+		os.Stdout,
+		os.Stderr,
+	), nil
+}
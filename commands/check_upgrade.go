@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var checkUpgradeArgs config.CheckUpgradeArgs
+
+var checkUpgradeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &checkUpgradeArgs.AWSRegion,
+	},
+	cli.BoolFlag{
+		Name:        "json",
+		Usage:       "(optional) Output as json",
+		EnvVar:      "JSON",
+		Destination: &checkUpgradeArgs.JSON,
+	},
+	cli.BoolFlag{
+		Name:        "running",
+		Usage:       "(optional) Ask the BOSH director and Concourse which versions they are running instead of using those recorded at the last deploy, and exit non-zero if a deploy would upgrade any of them",
+		EnvVar:      "CHECK_RUNNING_VERSIONS",
+		Destination: &checkUpgradeArgs.Running,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &checkUpgradeArgs.IAAS,
+	},
+}
+
+var checkUpgrade = cli.Command{
+	Name:      "check-upgrade",
+	Usage:     "Reports whether newer versions are available than those deployed",
+	ArgsUsage: "<name>",
+	Flags:     checkUpgradeFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up check-upgrade <name>`")
+		}
+
+		region, err := deploymentRegion(c, checkUpgradeArgs.IAAS, checkUpgradeArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		checkUpgradeArgs.AWSRegion = region
+
+		awsClient, err := iaas.New(checkUpgradeArgs.IAAS, checkUpgradeArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			awsClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(awsClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		if checkUpgradeArgs.Running {
+			return checkRunningVersions(client, name)
+		}
+
+		report, err := client.CheckUpgrade()
+		if err != nil {
+			return err
+		}
+
+		if checkUpgradeArgs.JSON {
+			return json.NewEncoder(os.Stdout).Encode(report)
+		}
+
+		return writeUpgradeReport(report)
+	},
+}
+
+func checkRunningVersions(client concourse.IClient, name string) error {
+	report, err := client.CheckRunningVersions()
+	if err != nil {
+		return err
+	}
+
+	if checkUpgradeArgs.JSON {
+		err = json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		err = writeUpgradeReport(report)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Exiting non-zero lets upgrades be scheduled from a script, rather than happening during a self-update
+	if report.UpgradeAvailable() {
+		return fmt.Errorf("deploying %s with concourse-up %s would upgrade it", name, fly.ConcourseUpVersion)
+	}
+	return nil
+}
+
+func writeUpgradeReport(report *concourse.UpgradeReport) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tDEPLOYED\tAVAILABLE\tUPGRADE")
+	for _, component := range report.Components {
+		deployed := component.Deployed
+		if deployed == "" {
+			deployed = "unknown"
+		}
+		upgrade := ""
+		if component.UpgradeAvailable {
+			upgrade = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", component.Component, deployed, component.Available, upgrade)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, release := range report.NewerReleases {
+		critical := ""
+		if release.Critical {
+			critical = " (includes security fixes)"
+		}
+		fmt.Printf("\nconcourse-up %s is available%s: %s", release.Version, critical, release.URL)
+	}
+	if len(report.NewerReleases) > 0 {
+		fmt.Println()
+	}
+
+	for _, component := range report.Components {
+		if component.Deployed == "" {
+			fmt.Println("\nThe deployed versions of some components are unknown because they were last deployed by an older concourse-up. Redeploy to record them.")
+			break
+		}
+	}
+
+	if !report.UpgradeAvailable() {
+		fmt.Println("\nThe deployment is up to date")
+	}
+
+	return nil
+}
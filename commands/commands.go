@@ -1,6 +1,13 @@
 package commands
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/util"
+
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -9,10 +16,30 @@ var Commands = []cli.Command{
 	deploy,
 	destroy,
 	info,
+	pruneWorkers,
+	promote,
+	events,
+	exportBundle,
+	importBundle,
+	exportCreds,
+	checkUpgrade,
+	status,
+	adoptDNS,
+	runErrand,
+	drain,
+	resume,
+	renewCerts,
+	restore,
+	rollback,
+	scaleWorkers,
+	setDNSWeight,
+	selfCheck,
 }
 
 var nonInteractive bool
 
+var configBucketName string
+
 // GlobalFlags are the global CLIflags
 var GlobalFlags = []cli.Flag{
 	cli.BoolFlag{
@@ -21,6 +48,93 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "Non interactive",
 		Destination: &nonInteractive,
 	},
+	cli.StringFlag{
+		Name:        "work-dir",
+		EnvVar:      "CONCOURSE_UP_WORK_DIR",
+		Usage:       "(optional) Directory to write working files and downloaded binaries to, instead of the system temp directory",
+		Destination: &util.WorkDir,
+	},
+	cli.StringFlag{
+		Name:        "config-bucket-name",
+		EnvVar:      "CONFIG_BUCKET_NAME",
+		Usage:       "(optional) Name of the S3 bucket to store the deployment's config in, if the default name is taken",
+		Destination: &configBucketName,
+	},
+	cli.StringFlag{
+		Name:        "http-proxy",
+		EnvVar:      "CONCOURSE_UP_HTTP_PROXY",
+		Usage:       "(optional) Proxy to make HTTP connections through, including the BOSH director's. Kept for later deploys of the deployment",
+		Destination: &util.GlobalProxy.HTTPProxy,
+	},
+	cli.StringFlag{
+		Name:        "https-proxy",
+		EnvVar:      "CONCOURSE_UP_HTTPS_PROXY",
+		Usage:       "(optional) Proxy to make HTTPS connections through, including to AWS and the BOSH director's. Kept for later deploys of the deployment",
+		Destination: &util.GlobalProxy.HTTPSProxy,
+	},
+	cli.StringFlag{
+		Name:        "no-proxy",
+		EnvVar:      "CONCOURSE_UP_NO_PROXY",
+		Usage:       "(optional) Comma separated hosts, domains and CIDR ranges to connect to directly, bypassing the proxy, eg s3.amazonaws.com",
+		Destination: &util.GlobalProxy.NoProxy,
+	},
+	cli.StringFlag{
+		Name:        "s3-endpoint",
+		EnvVar:      "S3_ENDPOINT",
+		Usage:       "(optional) URL of an S3-compatible object store, eg MinIO, to keep the config bucket in instead of S3. Pass it to every command",
+		Destination: &iaas.GlobalS3Endpoint.URL,
+	},
+	cli.BoolFlag{
+		Name:        "s3-force-path-style",
+		EnvVar:      "S3_FORCE_PATH_STYLE",
+		Usage:       "(optional) Address the config bucket as a path on --s3-endpoint rather than as its subdomain",
+		Destination: &iaas.GlobalS3Endpoint.ForcePathStyle,
+	},
+	cli.StringFlag{
+		Name:        "s3-access-key-id",
+		EnvVar:      "S3_ACCESS_KEY_ID",
+		Usage:       "(optional) Access key ID for --s3-endpoint, if it doesn't take the AWS credentials",
+		Destination: &iaas.GlobalS3Endpoint.AccessKeyID,
+	},
+	cli.StringFlag{
+		Name:        "s3-secret-access-key",
+		EnvVar:      "S3_SECRET_ACCESS_KEY",
+		Usage:       "(optional) Secret access key for --s3-endpoint. Prefer the env var to keep it out of your shell history",
+		Destination: &iaas.GlobalS3Endpoint.SecretAccessKey,
+	},
+}
+
+// ApplyGlobalFlags applies the global flags that affect the whole process before any command runs.
+// The proxy is put in the environment so that every connection uses it, from the first to S3
+func ApplyGlobalFlags(c *cli.Context) error {
+	if err := validateS3Endpoint(iaas.GlobalS3Endpoint); err != nil {
+		return err
+	}
+	return util.GlobalProxy.Setenv()
+}
+
+func validateS3Endpoint(endpoint iaas.S3Endpoint) error {
+	if !endpoint.IsSet() {
+		if endpoint.ForcePathStyle || endpoint.AccessKeyID != "" || endpoint.SecretAccessKey != "" {
+			return errors.New("--s3-force-path-style, --s3-access-key-id and --s3-secret-access-key can only be used with --s3-endpoint")
+		}
+		return nil
+	}
+
+	if u, err := url.Parse(endpoint.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("--s3-endpoint must be an http or https URL, eg https://minio.example.com:9000, not `%s`", endpoint.URL)
+	}
+
+	if (endpoint.AccessKeyID == "") != (endpoint.SecretAccessKey == "") {
+		return errors.New("--s3-access-key-id and --s3-secret-access-key must be given together")
+	}
+
+	return nil
+}
+
+// proxyIsSet returns true if any of the global proxy flags have been given
+func proxyIsSet(c *cli.Context) bool {
+	return c.GlobalIsSet("http-proxy") || c.GlobalIsSet("https-proxy") || c.GlobalIsSet("no-proxy")
 }
 
 // NonInteractiveModeEnabled returns true if --non-interactive true has been passed in
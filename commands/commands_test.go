@@ -2,7 +2,9 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -143,33 +145,588 @@ var _ = Describe("commands", func() {
 			})
 		})
 
+		Context("When an unknown profile is provided", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--profile", "huge")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown profile: `huge`. Valid profiles are: \\[large medium production small\\]"))
+			})
+		})
+
 		Context("When an invalid worker size is provided", func() {
 			It("Should show a meaningful error", func() {
-				command := exec.Command(cliPath, "deploy", "abc", "--worker-size", "small")
+				command := exec.Command(cliPath, "deploy", "abc", "--worker-size", "small")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown worker size"))
+			})
+		})
+
+		Context("When an invalid web size is provided", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--web-size", "tiny")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown web node size"))
+			})
+		})
+
+		Context("When an invalid db size is provided", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--db-size", "huge")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown DB size"))
+			})
+		})
+
+		Context("When the Postgres version isn't a version number", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--db-engine-version", "latest")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--db-engine-version must be a Postgres version, eg 9.6.6"))
+			})
+		})
+
+		Context("When the maintenance window is malformed", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--maintenance-window", "sun:03:00")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("maintenance window `sun:03:00` must be in the form ddd:hh:mm-ddd:hh:mm"))
+			})
+		})
+
+		Context("When the spot price isn't a price", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--spot-max-price", "$1")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--spot-max-price must be a price per hour in US dollars, eg 0.25, not `\\$1`"))
+			})
+		})
+
+		Context("When the worker disk size is too small", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--worker-disk-size", "10")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--worker-disk-size must be between 50 and 16000 GB"))
+			})
+		})
+
+		Context("When --show-secrets is given without --generate-manifest-only", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--show-secrets")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--manifest-file and --show-secrets can only be used with --generate-manifest-only"))
+			})
+		})
+
+		Context("When a worker limit is negative", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--max-active-containers-per-worker", "-1")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--max-active-containers-per-worker cannot be negative"))
+			})
+		})
+
+		Context("When an allowed CIDR range is invalid", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--allow-cidr", "203.0.113.7")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--allow-cidr must be an IPv4 CIDR range, eg 203.0.113.0/24, not `203.0.113.7`"))
+			})
+		})
+
+		Context("When the backup region is the deployment's region", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--region", "eu-west-1", "--backup-region", "eu-west-1")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--backup-region must be a different region to --region"))
+			})
+		})
+
+		Context("When the config bucket is replicated from an S3-compatible object store", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "--s3-endpoint", "https://minio.example.com:9000", "deploy", "abc", "--backup-region", "us-east-1")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--backup-region cannot be used with --s3-endpoint"))
+			})
+		})
+
+		Context("When the S3 endpoint isn't a URL", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "--s3-endpoint", "minio.example.com", "deploy", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--s3-endpoint must be an http or https URL"))
+			})
+		})
+
+		Context("When the KMS key is invalid", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--kms-key", "my-key")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("`my-key` is not a KMS key ID, alias or ARN, eg alias/concourse-up"))
+			})
+
+			It("Should reject a key in another region", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--region", "eu-west-1", "--kms-key", "arn:aws:kms:us-east-1:123456789012:alias/concourse-up")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--kms-key must be a key in eu-west-1"))
+			})
+		})
+
+		Context("When the ATC log level is unknown", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--atc-log-level", "verbose")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown ATC log level: `verbose`. Valid levels are: \\[debug info error\\]"))
+			})
+		})
+
+		Context("When the director size is unknown", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--director-size", "huge")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown director size: `huge`"))
+			})
+		})
+
+		Context("When the external port clashes with another service", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--external-port", "8844")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--external-port and --credhub-port cannot both be 8844"))
+			})
+		})
+
+		Context("When the deployment is private without a domain", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--private")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--private requires a --domain in a private hosted zone"))
+			})
+		})
+
+		Context("When the stemcell version is invalid", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--stemcell-version", "latest")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--stemcell-version must be a stemcell version, eg 3468.22, not `latest`"))
+			})
+		})
+
+		Context("When the CredHub seed file can't be read", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--credhub-seed-file", "does-not-exist.yml")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("could not read the CredHub seed file"))
+			})
+		})
+
+		Context("When an external database is given without credentials", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--external-db-url", "postgres://db.example.com/bosh")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--external-db-url requires --external-db-username, --external-db-password and --external-db-ca-cert to also be provided"))
+			})
+		})
+
+		Context("When more idle than open db connections are requested", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--db-max-open-connections", "10", "--db-max-idle-connections", "20")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--db-max-idle-connections cannot be greater than --db-max-open-connections"))
+			})
+		})
+
+		Context("When the secret cache TTL is out of range", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--concourse-secret-cache", "--concourse-secret-cache-ttl", "2h")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--concourse-secret-cache-ttl must be between 10s and 1h0m0s"))
+			})
+		})
+
+		Context("When a password is given both directly and as a file", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--concourse-password", "s3cret", "--concourse-password-file", "/tmp/password")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--concourse-password and --concourse-password-file cannot both be provided"))
+			})
+		})
+
+		Context("When the TLS key file does not exist", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--tls-key-file", "/does/not/exist")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("could not read --tls-key-file: open /does/not/exist: no such file or directory"))
+			})
+		})
+
+		Context("When the Terraform variables file sets a variable the template doesn't declare", func() {
+			It("Should show a meaningful error", func() {
+				file, err := ioutil.TempFile("", "tf-vars")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.Remove(file.Name())
+				_, err = file.WriteString(`{"ami": "ami-123"}`)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(file.Close()).To(Succeed())
+
+				command := exec.Command(cliPath, "deploy", "abc", "--tf-vars-file", file.Name())
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--tf-vars-file sets `ami`, which isn't a Terraform variable. It can set atc_extra_ingress_rules, instance_tenancy, rds_allocated_storage, rds_backup_retention_period"))
+			})
+		})
+
+		Context("When the container network pool overlaps the VPC", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--worker-container-network-pool", "10.0.128.0/22")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--worker-container-network-pool cannot overlap the VPC's 10.0.0.0/16 range"))
+			})
+		})
+
+		Context("When the VPC CIDR is too small", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--vpc-cidr", "10.0.0.0/26")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("VPC CIDR `10.0.0.0/26` must be between a /16 and a /24"))
+			})
+		})
+
+		Context("When only public subnets are given", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--public-subnet-id", "subnet-0a1b2c3d")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--public-subnet-id and --private-subnet-id must be given together"))
+			})
+		})
+
+		Context("When a subnet ID isn't one", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--public-subnet-id", "subnet-0a1b2c3d", "--private-subnet-id", "10.0.1.0/24")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("`10.0.1.0/24` is not a subnet ID, eg subnet-0a1b2c3d"))
+			})
+		})
+
+		Context("When the container network MTU is out of range", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--worker-container-network-mtu", "9500")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--worker-container-network-mtu must be between 1280 and 9001"))
+			})
+		})
+
+		Context("When an unknown tenancy is given", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--tenancy", "host")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown tenancy: `host`"))
+			})
+		})
+
+		Context("When the registry CA certificate isn't a certificate", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--worker-registry-ca-cert", "not a certificate")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--worker-registry-ca-cert must be one or more PEM encoded certificates"))
+			})
+		})
+
+		Context("When the Grafana path is one Concourse serves", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--grafana-path", "/api")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--grafana-path cannot be `/api` as Concourse already serves that path"))
+			})
+		})
+
+		Context("When an insecure TLS cipher suite is given", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--concourse-tls-cipher-suite", "TLS_RSA_WITH_RC4_128_SHA")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown or insecure TLS cipher suite: `TLS_RSA_WITH_RC4_128_SHA`"))
+			})
+		})
+
+		Context("When only some instance profiles are given", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--director-instance-profile", "arn:aws:iam::123:instance-profile/director")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--director-instance-profile, --web-instance-profile and --worker-instance-profile must all be provided together"))
+			})
+		})
+
+		Context("When a worker tag contains whitespace", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--worker-tag", "env prod")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("worker tag `env prod` cannot contain whitespace or commas"))
+			})
+		})
+
+		Context("When the description is more than one line", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--description", "line one\nline two")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--description must be a single line. Use --notes for anything longer"))
+			})
+		})
+
+		Context("When syslog TLS is used with UDP", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--syslog-address", "logs.example.com:514", "--syslog-transport", "udp", "--syslog-tls")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--syslog-tls can only be used with the tcp transport"))
+			})
+		})
+
+		Context("When the Prometheus URL isn't an http URL", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--prometheus-url", "prometheus.example.com:9090")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--prometheus-url must be an http or https URL"))
+			})
+		})
+
+		Context("When a Prometheus username is given without a password", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--prometheus-url", "https://prometheus.example.com/api/v1/write", "--prometheus-username", "concourse")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--prometheus-username and --prometheus-password must be provided together"))
+			})
+		})
+
+		Context("When the syslog address has no port", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--syslog-address", "logs.example.com")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--syslog-address must be a host and port, eg logs.example.com:514, not `logs.example.com`"))
+			})
+		})
+
+		Context("When a BOSH watch time is malformed", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--bosh-canary-watch-time", "5m")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--bosh-canary-watch-time must be a number of milliseconds or a range such as 1000-300000, not `5m`"))
+			})
+		})
+
+		Context("When waiting for more workers than are deployed", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--workers", "2", "--wait-for-workers", "3")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--wait-for-workers cannot be more than the 2 workers being deployed"))
+			})
+		})
+
+		Context("When the pipeline retries are negative", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--pipeline-retries", "-1")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--pipeline-retries cannot be negative"))
+			})
+		})
+
+		Context("When the TSA port clashes with the web node", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--tsa-port", "443")
 				session, err := Start(command, GinkgoWriter, GinkgoWriter)
 				Expect(err).ToNot(HaveOccurred())
 				Eventually(session).Should(Exit(1))
-				Eventually(session.Err).Should(Say("unknown worker size"))
+				Eventually(session.Err).Should(Say("--tsa-port cannot be 443 as it is already in use on the web node"))
 			})
 		})
 
-		Context("When an invalid web size is provided", func() {
+		Context("When the standby is in the same region as its primary", func() {
 			It("Should show a meaningful error", func() {
-				command := exec.Command(cliPath, "deploy", "abc", "--web-size", "tiny")
+				command := exec.Command(cliPath, "deploy", "abc", "--region", "eu-west-1", "--standby-of", "eu-west-1")
 				session, err := Start(command, GinkgoWriter, GinkgoWriter)
 				Expect(err).ToNot(HaveOccurred())
 				Eventually(session).Should(Exit(1))
-				Eventually(session.Err).Should(Say("unknown web node size"))
+				Eventually(session.Err).Should(Say("--standby-of must be a different region to --region"))
 			})
 		})
 
-		Context("When an invalid db size is provided", func() {
+		Context("When a tag isn't a key=value pair", func() {
 			It("Should show a meaningful error", func() {
-				command := exec.Command(cliPath, "deploy", "abc", "--db-size", "huge")
+				command := exec.Command(cliPath, "deploy", "abc", "--tag", "platform")
 				session, err := Start(command, GinkgoWriter, GinkgoWriter)
 				Expect(err).ToNot(HaveOccurred())
 				Eventually(session).Should(Exit(1))
-				Eventually(session.Err).Should(Say("unknown DB size"))
+				Eventually(session.Err).Should(Say("tag `platform` must be in the form key=value"))
+			})
+		})
+
+		Context("When --dry-run is combined with --self-update", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--dry-run", "--self-update")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--dry-run cannot be used with --self-update or --standby-of"))
+			})
+		})
+
+		Context("When --detach-timeout is used without --self-update", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--detach-timeout", "30")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--detach-timeout can only be used with --self-update"))
+			})
+		})
+
+		Context("When --recreate-workers is combined with --self-update", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--recreate-workers", "--self-update")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--recreate-workers cannot be used with --self-update, which doesn't wait for the deploy to finish"))
+			})
+		})
+
+		Context("When --force-redeploy-director is combined with --dry-run", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--force-redeploy-director", "--dry-run")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--force-redeploy-director cannot be used with --dry-run, --self-update, --standby-of or --generate-manifest-only"))
+			})
+		})
+
+		Context("When a DNS server is not an IP address", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--dns-server", "dns.internal")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("DNS server `dns.internal` must be an IP address"))
+			})
+		})
+
+		Context("When --release-bundle is combined with --self-update", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--release-bundle", "bundle.tgz", "--self-update")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--release-bundle cannot be used with --self-update, which runs in the pipeline without the bundle"))
+			})
+		})
+
+		Context("When --no-pipeline is combined with --self-update", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--no-pipeline", "--self-update")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--no-pipeline cannot be used with --self-update"))
+			})
+		})
+
+		Context("When the branding wordmark is not an SVG", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "deploy", "abc", "--branding-wordmark", "not an image")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--branding-wordmark must be the contents of an SVG image"))
 			})
 		})
 	})
@@ -195,4 +752,286 @@ var _ = Describe("commands", func() {
 			})
 		})
 	})
+
+	Describe("prune-workers", func() {
+		Context("When no name is passed in", func() {
+			It("should display correct usage", func() {
+				command := exec.Command(cliPath, "prune-workers")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("Usage is `concourse-up prune-workers <name>`"))
+			})
+		})
+
+		Context("When an invalid worker state is provided", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "prune-workers", "abc", "--state", "stalled,running")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown worker state: `running`"))
+			})
+		})
+	})
+
+	Describe("events", func() {
+		Context("When no name is passed in", func() {
+			It("should display correct usage", func() {
+				command := exec.Command(cliPath, "events")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("Usage is `concourse-up events <name>`"))
+			})
+		})
+	})
+
+	Describe("info", func() {
+		Context("When --versions is passed with --env", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "info", "--versions", "--env", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("--versions cannot be used with --env"))
+			})
+		})
+	})
+
+	Describe("check-upgrade", func() {
+		Context("When no name is passed in", func() {
+			It("should display correct usage", func() {
+				command := exec.Command(cliPath, "check-upgrade")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("Usage is `concourse-up check-upgrade <name>`"))
+			})
+		})
+	})
+
+	Describe("adopt-dns", func() {
+		Context("When no name is passed in", func() {
+			It("should display correct usage", func() {
+				command := exec.Command(cliPath, "adopt-dns", "--domain", "ci.example.com")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("Usage is `concourse-up adopt-dns --domain <domain> <name>`"))
+			})
+		})
+
+		Context("When the domain is an IP address", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "adopt-dns", "--domain", "1.2.3.4", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("--domain must be a domain name, not the IP address 1.2.3.4"))
+			})
+		})
+	})
+
+	Describe("run-errand", func() {
+		Context("When no errand is passed in", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "run-errand", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("--errand is required"))
+			})
+		})
+	})
+
+	Describe("restore", func() {
+		Context("When no snapshot is passed in", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "restore", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("--snapshot is required"))
+			})
+		})
+	})
+
+	Describe("rollback", func() {
+		Context("When no name is passed in", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "rollback")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("Usage is `concourse-up rollback <name>`"))
+			})
+		})
+	})
+
+	Describe("scale-workers", func() {
+		Context("When neither a count nor a size is passed in", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "scale-workers", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("--count or --size is required"))
+			})
+		})
+
+		Context("When the count is zero", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "scale-workers", "--count", "0", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("minimum of workers is 1"))
+			})
+		})
+	})
+
+	Describe("drain", func() {
+		Context("When no name is passed in", func() {
+			It("should display correct usage", func() {
+				command := exec.Command(cliPath, "drain")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("Usage is `concourse-up drain <name>`"))
+			})
+		})
+
+		Context("When the timeout isn't positive", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "drain", "--timeout", "0", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("--timeout must be a positive number of minutes"))
+			})
+		})
+	})
+
+	Describe("export-creds", func() {
+		Context("When no name is given", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "export-creds")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("Usage is `concourse-up export-creds <name>`"))
+			})
+		})
+
+		Context("When an unknown format is given", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "export-creds", "--format", "toml", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("unknown format: `toml`. Valid formats are: \\[yaml json\\]"))
+			})
+		})
+
+		Context("When writing to a file without a passphrase", func() {
+			It("Should refuse to write the credentials unencrypted", func() {
+				command := exec.Command(cliPath, "export-creds", "--file", "creds.yml.asc", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("the passphrase must be at least 12 characters to write credentials to --file"))
+			})
+		})
+	})
+
+	Describe("set-dns-weight", func() {
+		Context("When no weight is passed in", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "set-dns-weight", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("--weight is required"))
+			})
+		})
+
+		Context("When the weight is out of range", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "set-dns-weight", "--weight", "256", "abc")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("--weight must be between 0 and 255"))
+			})
+		})
+	})
+
+	Describe("resume", func() {
+		Context("When no name is passed in", func() {
+			It("should display correct usage", func() {
+				command := exec.Command(cliPath, "resume")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("Usage is `concourse-up resume <name>`"))
+			})
+		})
+	})
+
+	Describe("export-bundle", func() {
+		Context("When no name is passed in", func() {
+			It("should display correct usage", func() {
+				command := exec.Command(cliPath, "export-bundle")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("Usage is `concourse-up export-bundle <name>`"))
+			})
+		})
+
+		Context("When the passphrase is too short", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "export-bundle", "abc", "--file", "abc.bundle", "--passphrase", "short")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("the bundle passphrase must be at least 12 characters"))
+			})
+		})
+	})
+
+	Describe("import-bundle", func() {
+		Context("When no file is passed in", func() {
+			It("Should show a meaningful error", func() {
+				command := exec.Command(cliPath, "import-bundle", "abc", "--passphrase", "correct horse battery staple")
+				session, err := Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("--file is required"))
+			})
+		})
+	})
+
+	Describe("self-check", func() {
+		passing := selfCheckStep{"templates", func() (string, error) { return "checksum matches", nil }}
+		failing := selfCheckStep{"fly CLI", func() (string, error) { return "", errors.New("exec format error") }}
+
+		It("Reports each check", func() {
+			out := NewBuffer()
+			err := runSelfCheck(out, []selfCheckStep{passing})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Say(`templates\s+OK\s+checksum matches`))
+		})
+
+		Context("When a check fails", func() {
+			It("Runs the rest and returns an error", func() {
+				out := NewBuffer()
+				err := runSelfCheck(out, []selfCheckStep{failing, passing})
+				Expect(err).To(MatchError("1 of 2 checks failed. Download concourse-up again before deploying with it"))
+				Expect(out).To(Say(`fly CLI\s+FAILED\s+exec format error`))
+				Expect(out).To(Say(`templates\s+OK`))
+			})
+		})
+	})
 })
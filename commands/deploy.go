@@ -2,22 +2,39 @@ package commands
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/EngineerBetter/concourse-up/bosh"
 	"github.com/EngineerBetter/concourse-up/certs"
 	"github.com/EngineerBetter/concourse-up/concourse"
 	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/credhub"
 	"github.com/EngineerBetter/concourse-up/fly"
 	"github.com/EngineerBetter/concourse-up/iaas"
 	"github.com/EngineerBetter/concourse-up/terraform"
+	"github.com/EngineerBetter/concourse-up/util"
 
 	"gopkg.in/urfave/cli.v1"
 )
 
 var deployArgs config.DeployArgs
 
-var deployFlags = []cli.Flag{
+var (
+	tlsKeyFile                string
+	concoursePasswordFile     string
+	metricsPasswordFile       string
+	notesFile                 string
+	externalDBPasswordFile    string
+	tfVarsFile                string
+	standbyOfConfigBucketName string
+)
+
+var deployFlags = append([]cli.Flag{
 	cli.StringFlag{
 		Name:        "region",
 		Value:       "eu-west-1",
@@ -31,6 +48,12 @@ var deployFlags = []cli.Flag{
 		EnvVar:      "DOMAIN",
 		Destination: &deployArgs.Domain,
 	},
+	cli.IntFlag{
+		Name:        "dns-weight",
+		Usage:       "(optional) Make the domain's Route53 record a weighted record with this weight, from 0 to 255, so it can share the domain with another deployment",
+		EnvVar:      "DNS_WEIGHT",
+		Destination: &deployArgs.DNSWeight,
+	},
 	cli.StringFlag{
 		Name:        "tls-cert",
 		Usage:       "(optional) TLS cert to use with Concourse endpoint",
@@ -43,6 +66,54 @@ var deployFlags = []cli.Flag{
 		EnvVar:      "TLS_KEY",
 		Destination: &deployArgs.TLSKey,
 	},
+	cli.BoolTFlag{
+		Name:        "acme",
+		Usage:       "(optional) Get a certificate for --domain from Let's Encrypt when --tls-cert isn't given. Set to false to use a self-signed certificate",
+		EnvVar:      "ACME",
+		Destination: &deployArgs.ACMEEnabled,
+	},
+	cli.StringFlag{
+		Name:        "tls-key-file",
+		Usage:       "(optional) Path to a file containing the TLS private key, as an alternative to --tls-key",
+		EnvVar:      "TLS_KEY_FILE",
+		Destination: &tlsKeyFile,
+	},
+	cli.StringFlag{
+		Name:        "concourse-password",
+		Usage:       "(optional) Password for the Concourse admin user. Prefer the env var or --concourse-password-file to keep it out of your shell history",
+		EnvVar:      "CONCOURSE_PASSWORD",
+		Destination: &deployArgs.ConcoursePassword,
+	},
+	cli.StringFlag{
+		Name:        "concourse-password-file",
+		Usage:       "(optional) Path to a file containing the password for the Concourse admin user",
+		EnvVar:      "CONCOURSE_PASSWORD_FILE",
+		Destination: &concoursePasswordFile,
+	},
+	cli.StringFlag{
+		Name:        "metrics-username",
+		Usage:       "(optional) Username for Grafana, rather than the Concourse admin's. Pass an empty username to share the Concourse admin's again",
+		EnvVar:      "METRICS_USERNAME",
+		Destination: &deployArgs.MetricsUsername,
+	},
+	cli.StringFlag{
+		Name:        "metrics-password",
+		Usage:       "(optional) Password for Grafana, rather than the Concourse admin's. Prefer the env var or --metrics-password-file to keep it out of your shell history",
+		EnvVar:      "METRICS_PASSWORD",
+		Destination: &deployArgs.MetricsPassword,
+	},
+	cli.StringFlag{
+		Name:        "metrics-password-file",
+		Usage:       "(optional) Path to a file containing the password for Grafana",
+		EnvVar:      "METRICS_PASSWORD_FILE",
+		Destination: &metricsPasswordFile,
+	},
+	cli.StringFlag{
+		Name:        "profile",
+		Usage:       "(optional) Preset sizes for the workers, web node and database. Can be small, medium, large or production. The size flags override it",
+		EnvVar:      "PROFILE",
+		Destination: &deployArgs.Profile,
+	},
 	cli.IntFlag{
 		Name:        "workers",
 		Usage:       "(optional) Number of Concourse worker instances to deploy",
@@ -57,6 +128,228 @@ var deployFlags = []cli.Flag{
 		Value:       "xlarge",
 		Destination: &deployArgs.WorkerSize,
 	},
+	cli.IntFlag{
+		Name:        "worker-drain-timeout",
+		Usage:       "(optional) Minutes to wait for running builds to finish on workers removed when scaling down. Set to 0 to remove workers immediately",
+		EnvVar:      "WORKER_DRAIN_TIMEOUT",
+		Value:       60,
+		Destination: &deployArgs.WorkerDrainTimeout,
+	},
+	cli.IntFlag{
+		Name:        "drain-timeout",
+		Usage:       "(optional) Minutes a self-update waits for running builds to finish before updating the deployment. Set to 0, the default, to update immediately",
+		EnvVar:      "DRAIN_TIMEOUT",
+		Destination: &deployArgs.DrainTimeout,
+	},
+	cli.IntFlag{
+		Name:        "detach-timeout",
+		Usage:       "(optional) Minutes a self-update waits for the deployment to finish, failing unless it succeeds. By default the update runs in the background",
+		EnvVar:      "DETACH_TIMEOUT",
+		Destination: &deployArgs.DetachTimeout,
+	},
+	cli.BoolFlag{
+		Name:        "recreate-workers",
+		Usage:       "(optional) Replace the workers' VMs with fresh ones once the deploy has finished, eg to recover a worker in a bad state. The director and web node are left alone",
+		EnvVar:      "RECREATE_WORKERS",
+		Destination: &deployArgs.RecreateWorkers,
+	},
+	cli.BoolFlag{
+		Name:        "force-redeploy-director",
+		Usage:       "(optional) Delete the BOSH director's VM and bootstrap a new one from scratch, with new certificates, to recover a broken director. Disruptive: see the README first",
+		EnvVar:      "FORCE_REDEPLOY_DIRECTOR",
+		Destination: &deployArgs.ForceDirectorRebuild,
+	},
+	cli.StringFlag{
+		Name:        "release-bundle",
+		Usage:       "(optional) Path of a tarball of the stemcells and releases to upload from, for directors and VMs without internet access. See the README for its layout",
+		EnvVar:      "RELEASE_BUNDLE",
+		Destination: &deployArgs.ReleaseBundle,
+	},
+	cli.IntFlag{
+		Name:        "wait-for-workers",
+		Usage:       "(optional) Fail the deploy unless at least this many workers are running within --wait-for-workers-timeout",
+		EnvVar:      "WAIT_FOR_WORKERS",
+		Destination: &deployArgs.WaitForWorkers,
+	},
+	cli.IntFlag{
+		Name:        "wait-for-workers-timeout",
+		Usage:       "(optional) Minutes to wait for the workers given by --wait-for-workers to be running",
+		EnvVar:      "WAIT_FOR_WORKERS_TIMEOUT",
+		Value:       10,
+		Destination: &deployArgs.WaitForWorkersTimeout,
+	},
+	cli.StringSliceFlag{
+		Name:   "post-deploy-errand",
+		Usage:  "(optional) BOSH errand to run after the deploy, which fails if the errand does. Can be repeated to run several in order. Pass an empty errand to stop running them",
+		EnvVar: "POST_DEPLOY_ERRANDS",
+	},
+	cli.StringSliceFlag{
+		Name:   "pipeline",
+		Usage:  "(optional) Pipeline to set after the deploy, as team/name=pipeline.yml or team/name=pipeline.yml:vars.yml. Teams other than main are created. Can be repeated. Pass an empty pipeline to stop setting them",
+		EnvVar: "PIPELINES",
+	},
+	cli.StringFlag{
+		Name:        "credhub-seed-file",
+		Usage:       "(optional) Path to a YAML file of secrets to set in CredHub after the deploy, keyed by team, so that pipelines can use them straight away. Existing secrets of the same name are overwritten",
+		EnvVar:      "CREDHUB_SEED_FILE",
+		Destination: &deployArgs.CredhubSeedFile,
+	},
+	cli.BoolFlag{
+		Name:        "isolate-workers",
+		Usage:       "(optional) Deploy workers into their own subnet and security group, with access to only the parts of the web node and director they need",
+		EnvVar:      "ISOLATE_WORKERS",
+		Destination: &deployArgs.IsolatedWorkers,
+	},
+	cli.BoolFlag{
+		Name:        "enable-ipv6",
+		Usage:       "(optional) Give the VPC and its subnets IPv6 addresses, with outbound-only IPv6 internet access for the VMs. Pass --enable-ipv6=false to stop",
+		EnvVar:      "ENABLE_IPV6",
+		Destination: &deployArgs.EnableIPv6,
+	},
+	cli.BoolFlag{
+		Name:        "public-ipv6",
+		Usage:       "(optional) Make the web node reachable over IPv6 too, with an AAAA record for --domain. Requires --enable-ipv6. Pass --public-ipv6=false to stop",
+		EnvVar:      "PUBLIC_IPV6",
+		Destination: &deployArgs.PublicIPv6,
+	},
+	cli.BoolFlag{
+		Name:        "private",
+		Usage:       "(optional) Only make Concourse reachable from within the VPC, with no public IP. Requires a --domain in a private hosted zone. Can only be chosen on the first deploy",
+		EnvVar:      "PRIVATE",
+		Destination: &deployArgs.Private,
+	},
+	cli.StringFlag{
+		Name:        "vpc-cidr",
+		Usage:       "(optional) IPv4 address range of the VPC, from a /16 to a /24, if the default of " + config.DefaultVPCCIDR + " clashes with a network you peer with. Can only be chosen on the first deploy",
+		EnvVar:      "VPC_CIDR",
+		Destination: &deployArgs.VPCCIDR,
+	},
+	cli.StringSliceFlag{
+		Name:   "public-subnet-id",
+		Usage:  "(optional) Existing subnet, with a route to an internet gateway, to put the director and web node in instead of creating a VPC. The one in the region's first availability zone, eg eu-west-1a, is used. Can be repeated. Requires --private-subnet-id. Can only be chosen on the first deploy",
+		EnvVar: "PUBLIC_SUBNET_IDS",
+	},
+	cli.StringSliceFlag{
+		Name:   "private-subnet-id",
+		Usage:  "(optional) Existing subnet, with outbound internet access, to put the workers in. The one in the region's first availability zone is used, and the RDS instance spans them all, so they must cover at least two availability zones. Can be repeated. Requires --public-subnet-id",
+		EnvVar: "PRIVATE_SUBNET_IDS",
+	},
+	cli.StringSliceFlag{
+		Name:   "peer-vpc",
+		Usage:  "(optional) VPC in the same account and region to peer with, as vpc-id=cidr or vpc-id=cidr:route-table, where route-table is private (the default, for workers), public or all. Can be repeated. Pass an empty VPC to remove existing peering connections",
+		EnvVar: "PEER_VPCS",
+	},
+	cli.StringSliceFlag{
+		Name:   "allow-cidr",
+		Usage:  "(optional) IPv4 CIDR range, such as an office or VPN, to allow to reach the BOSH director as well as this machine. Can be repeated. Pass an empty range to remove existing ranges",
+		EnvVar: "ALLOW_CIDRS",
+	},
+	cli.StringSliceFlag{
+		Name:   "worker-tag",
+		Usage:  "(optional) Concourse tag to give every worker, for placing builds. Can be repeated. Pass an empty tag to remove existing tags",
+		EnvVar: "WORKER_TAGS",
+	},
+	cli.StringSliceFlag{
+		Name:   "dns-server",
+		Usage:  "(optional) IP address of a DNS server for the director and VMs to use instead of the VPC's. Can be repeated. Pass an empty address to go back to the VPC's",
+		EnvVar: "DNS_SERVERS",
+	},
+	cli.StringSliceFlag{
+		Name:   "ntp-server",
+		Usage:  "(optional) NTP server for the director and VMs to keep time with instead of pool.ntp.org. Can be repeated. Pass an empty server to go back to pool.ntp.org",
+		EnvVar: "NTP_SERVERS",
+	},
+	cli.StringSliceFlag{
+		Name:   "tag",
+		Usage:  "(optional) AWS tag, as key=value, to give the VMs, RDS instance and S3 buckets. Can be repeated. Pass an empty tag to remove existing tags",
+		EnvVar: "TAGS",
+	},
+	cli.StringFlag{
+		Name:        "tf-vars-file",
+		Usage:       "(optional) Path to a JSON file of Terraform variables to set, eg rds_allocated_storage. concourse-up's own variables can't be overridden. Pass an empty path to go back to the defaults",
+		EnvVar:      "TF_VARS_FILE",
+		Destination: &tfVarsFile,
+	},
+	cli.StringFlag{
+		Name:        "worker-ami-id",
+		Usage:       "(optional) Customer-managed AMI to boot workers from, which must be built from a BOSH " + bosh.ConcourseStemcellOS + " stemcell. Pass an empty ID to go back to the stock stemcell",
+		EnvVar:      "WORKER_AMI_ID",
+		Destination: &deployArgs.WorkerAMIID,
+	},
+	cli.StringFlag{
+		Name:        "stemcell-version",
+		Usage:       "(optional) Pin the Concourse VMs to this version of the stemcell, eg 3468.22, instead of the version this release of concourse-up deploys. Must be on the same major version. Pass an empty version to unpin it",
+		EnvVar:      "STEMCELL_VERSION",
+		Destination: &deployArgs.StemcellVersion,
+	},
+	cli.IntFlag{
+		Name:        "worker-disk-size",
+		Usage:       fmt.Sprintf("(optional) Size in GB of each worker's ephemeral disk, from %d to %d. Defaults to %d. Changing it recreates the workers", config.MinWorkerDiskSize, config.MaxWorkerDiskSize, config.DefaultWorkerDiskSize),
+		EnvVar:      "WORKER_DISK_SIZE",
+		Destination: &deployArgs.WorkerDiskSize,
+	},
+	cli.BoolFlag{
+		Name:        "concourse-worker-ephemeral",
+		Usage:       "(optional) Mark workers as ephemeral, so Concourse removes them as soon as they go away instead of leaving them stalled. Pass --concourse-worker-ephemeral=false to stop",
+		EnvVar:      "CONCOURSE_WORKER_EPHEMERAL",
+		Destination: &deployArgs.WorkerEphemeral,
+	},
+	cli.BoolTFlag{
+		Name:        "spot-workers",
+		Usage:       "(optional) Run large and bigger workers on spot instances, which AWS can reclaim at any time. Passing it also marks workers as ephemeral. Set to false to run workers on demand",
+		EnvVar:      "SPOT_WORKERS",
+		Destination: &deployArgs.WorkerSpot,
+	},
+	cli.StringFlag{
+		Name:        "spot-max-price",
+		Usage:       "(optional) Most to pay per hour, in US dollars, for each spot worker, eg 0.25. Pass an empty price to go back to the defaults for each size",
+		EnvVar:      "SPOT_MAX_PRICE",
+		Destination: &deployArgs.WorkerSpotMaxPrice,
+	},
+	cli.IntFlag{
+		Name:        "tsa-port",
+		Usage:       "(optional) Port the web node listens on for workers to register with the TSA",
+		EnvVar:      "TSA_PORT",
+		Value:       config.DefaultTSAPort,
+		Destination: &deployArgs.TSAPort,
+	},
+	cli.IntFlag{
+		Name:        "external-port",
+		Usage:       fmt.Sprintf("(optional) Port the web node serves Concourse on. Defaults to %d", config.DefaultConcoursePort),
+		EnvVar:      "EXTERNAL_PORT",
+		Destination: &deployArgs.ExternalPort,
+	},
+	cli.IntFlag{
+		Name:        "credhub-port",
+		Usage:       fmt.Sprintf("(optional) Port the web node serves CredHub on. Defaults to %d", config.DefaultCredhubPort),
+		EnvVar:      "CREDHUB_PORT",
+		Destination: &deployArgs.CredhubPort,
+	},
+	cli.IntFlag{
+		Name:        "grafana-port",
+		Usage:       fmt.Sprintf("(optional) Port the web node serves Grafana on when --grafana-path isn't given. Defaults to %d", config.DefaultGrafanaPort),
+		EnvVar:      "GRAFANA_PORT",
+		Destination: &deployArgs.GrafanaPort,
+	},
+	cli.StringFlag{
+		Name:        "worker-container-network-pool",
+		Usage:       "(optional) CIDR range to allocate worker container IPs from, if the default of 10.254.0.0/22 clashes with your network",
+		EnvVar:      "WORKER_CONTAINER_NETWORK_POOL",
+		Destination: &deployArgs.ContainerNetworkPool,
+	},
+	cli.IntFlag{
+		Name:        "worker-container-network-mtu",
+		Usage:       "(optional) MTU of the worker container network, eg to match a VPN connection",
+		EnvVar:      "WORKER_CONTAINER_NETWORK_MTU",
+		Destination: &deployArgs.ContainerNetworkMTU,
+	},
+	cli.StringFlag{
+		Name:        "tenancy",
+		Usage:       "(optional) EC2 tenancy of the deployment's instances. Can be default or dedicated. Can only be chosen on the first deploy",
+		EnvVar:      "TENANCY",
+		Value:       "default",
+		Destination: &deployArgs.Tenancy,
+	},
 	cli.StringFlag{
 		Name:        "web-size",
 		Usage:       "(optional) Size of Concourse web node. Can be small, medium, large, xlarge, 2xlarge",
@@ -64,6 +357,12 @@ var deployFlags = []cli.Flag{
 		Value:       "small",
 		Destination: &deployArgs.WebSize,
 	},
+	cli.StringFlag{
+		Name:        "director-size",
+		Usage:       "(optional) Size of the BOSH director. Can be small, medium, large, xlarge. Changing it recreates only the director's VM (default: small)",
+		EnvVar:      "DIRECTOR_SIZE",
+		Destination: &deployArgs.DirectorSize,
+	},
 	cli.StringFlag{
 		Name:        "iaas",
 		Usage:       "(optional) IAAS, can be AWS or GCP",
@@ -72,6 +371,49 @@ var deployFlags = []cli.Flag{
 		Hidden:      true,
 		Destination: &deployArgs.IAAS,
 	},
+	cli.IntFlag{
+		Name:        "pipeline-retries",
+		Usage:       "(optional) Number of times to retry setting the default pipeline while Concourse finishes starting on a fresh deploy",
+		EnvVar:      "PIPELINE_RETRIES",
+		Value:       5,
+		Destination: &deployArgs.PipelineRetries,
+	},
+	cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "(optional) Print the infrastructure changes Terraform would make, without making them or deploying BOSH",
+		EnvVar:      "DRY_RUN",
+		Destination: &deployArgs.DryRun,
+	},
+	cli.BoolFlag{
+		Name:        "generate-manifest-only",
+		Usage:       "(optional) Print the Concourse manifest BOSH would be given, with secrets redacted, without changing anything",
+		EnvVar:      "GENERATE_MANIFEST_ONLY",
+		Destination: &deployArgs.GenerateManifestOnly,
+	},
+	cli.StringFlag{
+		Name:        "manifest-file",
+		Usage:       "(optional) Path of a file to write the manifest from --generate-manifest-only to, rather than printing it",
+		EnvVar:      "MANIFEST_FILE",
+		Destination: &deployArgs.ManifestFile,
+	},
+	cli.BoolFlag{
+		Name:        "show-secrets",
+		Usage:       "(optional) Include the secrets in the manifest from --generate-manifest-only, rather than redacting them",
+		EnvVar:      "SHOW_SECRETS",
+		Destination: &deployArgs.ShowSecrets,
+	},
+	cli.BoolFlag{
+		Name:        "json",
+		Usage:       "(optional) Write the login details as a JSON object on the last line of output, instead of the success message",
+		EnvVar:      "JSON",
+		Destination: &deployArgs.JSONOutput,
+	},
+	cli.BoolFlag{
+		Name:        "no-pipeline",
+		Usage:       "(optional) Don't set the concourse-up-self-update pipeline, for when pipelines are managed elsewhere. The deployment won't update itself, and an existing pipeline is destroyed. Pass --no-pipeline=false to set it again",
+		EnvVar:      "NO_PIPELINE",
+		Destination: &deployArgs.NoDefaultPipeline,
+	},
 	cli.BoolFlag{
 		Name:        "self-update",
 		Usage:       "(optional) Causes Concourse-up to exit as soon as the BOSH deployment starts. May only be used when upgrading an existing deployment",
@@ -86,6 +428,96 @@ var deployFlags = []cli.Flag{
 		Value:       "small",
 		Destination: &deployArgs.DBSize,
 	},
+	cli.BoolTFlag{
+		Name:        "db-apply-immediately",
+		Usage:       "(optional) Apply changes to the RDS instance immediately. Set to false to defer them to the next maintenance window",
+		EnvVar:      "DB_APPLY_IMMEDIATELY",
+		Destination: &deployArgs.DBApplyImmediately,
+	},
+	cli.BoolFlag{
+		Name:        "db-backup",
+		Usage:       "(optional) Snapshot the RDS instance before deploying Concourse, so the database can be restored if the deploy fails",
+		EnvVar:      "DB_BACKUP",
+		Destination: &deployArgs.BackupDB,
+	},
+	cli.StringFlag{
+		Name:        "db-engine-version",
+		Usage:       "(optional) Postgres version of the RDS instance, eg 10.4. Existing databases can be upgraded but not downgraded",
+		EnvVar:      "DB_ENGINE_VERSION",
+		Destination: &deployArgs.DBEngineVersion,
+	},
+	cli.StringFlag{
+		Name:        "maintenance-window",
+		Usage:       "(optional) Weekly window, in UTC, that RDS applies updates in, eg sun:03:00-sun:04:00. Daily backups are taken in the half hour before it",
+		EnvVar:      "MAINTENANCE_WINDOW",
+		Destination: &deployArgs.MaintenanceWindow,
+	},
+	cli.BoolFlag{
+		Name:        "enforce-maintenance-window",
+		Usage:       "(optional) Refuse to deploy outside the maintenance window. Pass --enforce-maintenance-window=false to stop enforcing it",
+		EnvVar:      "ENFORCE_MAINTENANCE_WINDOW",
+		Destination: &deployArgs.EnforceMaintenanceWindow,
+	},
+	cli.StringFlag{
+		Name:        "db-availability-zone",
+		Usage:       "(optional) Availability zone to create the RDS primary in, eg eu-west-1a. Must be the a or b zone of the region. Can only be chosen on the first deploy",
+		EnvVar:      "DB_AVAILABILITY_ZONE",
+		Destination: &deployArgs.DBAvailabilityZone,
+	},
+	cli.IntFlag{
+		Name:        "db-max-open-connections",
+		Usage:       "(optional) Maximum number of open connections from the ATC to the database",
+		EnvVar:      "DB_MAX_OPEN_CONNECTIONS",
+		Destination: &deployArgs.DBMaxOpenConnections,
+	},
+	cli.IntFlag{
+		Name:        "db-max-idle-connections",
+		Usage:       "(optional) Maximum number of idle connections from the ATC to the database",
+		EnvVar:      "DB_MAX_IDLE_CONNECTIONS",
+		Destination: &deployArgs.DBMaxIdleConnections,
+	},
+	cli.DurationFlag{
+		Name:        "db-connect-timeout",
+		Usage:       "(optional) How long the ATC keeps trying to connect to the database before it exits and monit restarts it, eg 10m to ride out an RDS failover. Pass 0 to go back to the Concourse default of 5m",
+		EnvVar:      "DB_CONNECT_TIMEOUT",
+		Destination: &deployArgs.DBConnectTimeout,
+	},
+	cli.BoolTFlag{
+		Name:        "resurrector",
+		Usage:       "(optional) Have the BOSH director recreate VMs whose agent stops responding, eg a web VM that is stuck. Set to false to disable",
+		EnvVar:      "RESURRECTOR",
+		Destination: &deployArgs.Resurrector,
+	},
+	cli.StringFlag{
+		Name:        "external-db-url",
+		Usage:       "(optional) URL of an existing Postgres server to use instead of creating an RDS instance, eg postgres://db.example.com:5432/bosh. The director keeps its state in the database in the URL, which must already exist. Can only be chosen on the first deploy",
+		EnvVar:      "EXTERNAL_DB_URL",
+		Destination: &deployArgs.ExternalDBURL,
+	},
+	cli.StringFlag{
+		Name:        "external-db-username",
+		Usage:       "(optional) User to connect to the --external-db-url server as, which must be able to create databases",
+		EnvVar:      "EXTERNAL_DB_USERNAME",
+		Destination: &deployArgs.ExternalDBUsername,
+	},
+	cli.StringFlag{
+		Name:        "external-db-password",
+		Usage:       "(optional) Password of the --external-db-username user. Prefer the env var or --external-db-password-file to keep it out of your shell history",
+		EnvVar:      "EXTERNAL_DB_PASSWORD",
+		Destination: &deployArgs.ExternalDBPassword,
+	},
+	cli.StringFlag{
+		Name:        "external-db-password-file",
+		Usage:       "(optional) Path to a file containing the password of the --external-db-username user",
+		EnvVar:      "EXTERNAL_DB_PASSWORD_FILE",
+		Destination: &externalDBPasswordFile,
+	},
+	cli.StringFlag{
+		Name:        "external-db-ca-cert",
+		Usage:       "(optional) PEM encoded CA certificate to verify the --external-db-url server's certificate with. Connections to it always use TLS",
+		EnvVar:      "EXTERNAL_DB_CA_CERT",
+		Destination: &deployArgs.ExternalDBCACert,
+	},
 	cli.StringFlag{
 		Name:        "allow-ips",
 		Usage:       "(optional) Comma seperated list of IP addresses or CIDR ranges to allow access too",
@@ -93,6 +525,278 @@ var deployFlags = []cli.Flag{
 		Value:       "0.0.0.0/0",
 		Destination: &deployArgs.AllowIPs,
 	},
+	cli.BoolTFlag{
+		Name:        "instance-termination-protection",
+		Usage:       "(optional) Protect the director and web VMs from termination outside of concourse-up. Set to false to disable",
+		EnvVar:      "INSTANCE_TERMINATION_PROTECTION",
+		Destination: &deployArgs.TerminationProtection,
+	},
+	cli.BoolFlag{
+		Name:        "concourse-secret-cache",
+		Usage:       "(optional) Cache secrets looked up from Credhub to reduce the load on it",
+		EnvVar:      "CONCOURSE_SECRET_CACHE",
+		Destination: &deployArgs.SecretCache,
+	},
+	cli.DurationFlag{
+		Name:        "concourse-secret-cache-ttl",
+		Usage:       "(optional) How long to cache secrets for when --concourse-secret-cache is set",
+		EnvVar:      "CONCOURSE_SECRET_CACHE_TTL",
+		Value:       time.Minute,
+		Destination: &deployArgs.SecretCacheTTL,
+	},
+	cli.StringFlag{
+		Name:        "concourse-tls-min-version",
+		Usage:       "(optional) Minimum TLS version the Concourse web node accepts. Can be 1.0, 1.1, 1.2 or 1.3. Defaults to 1.2 for new deployments. Requires Concourse 7.0.0 or later",
+		EnvVar:      "CONCOURSE_TLS_MIN_VERSION",
+		Destination: &deployArgs.TLSMinVersion,
+	},
+	cli.StringSliceFlag{
+		Name:   "concourse-tls-cipher-suite",
+		Usage:  "(optional) TLS cipher suite the Concourse web node accepts for TLS 1.2 and below, eg TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384. Can be repeated. Requires Concourse 7.0.0 or later. Pass an empty suite to remove existing ones",
+		EnvVar: "CONCOURSE_TLS_CIPHER_SUITES",
+	},
+	cli.DurationFlag{
+		Name:        "concourse-gc-hijack-grace-period",
+		Usage:       "(optional) How long to keep a hijacked container after its fly hijack session ends, eg 10m. Requires Concourse 4.0.0 or later. Pass 0 to go back to the Concourse default",
+		EnvVar:      "CONCOURSE_GC_HIJACK_GRACE_PERIOD",
+		Destination: &deployArgs.HijackGracePeriod,
+	},
+	cli.DurationFlag{
+		Name:        "concourse-intercept-idle-timeout",
+		Usage:       "(optional) How long a fly hijack session can be idle before it is ended, eg 30m. Requires Concourse 5.0.0 or later. Pass 0 to go back to the Concourse default of never ending them",
+		EnvVar:      "CONCOURSE_INTERCEPT_IDLE_TIMEOUT",
+		Destination: &deployArgs.InterceptIdleTimeout,
+	},
+	cli.DurationFlag{
+		Name:        "concourse-resource-checking-interval",
+		Usage:       "(optional) How often to check resources whose pipelines don't set a check_every, eg 5m. Pass 0 to go back to the Concourse default of 1m",
+		EnvVar:      "CONCOURSE_RESOURCE_CHECKING_INTERVAL",
+		Destination: &deployArgs.ResourceCheckingInterval,
+	},
+	cli.DurationFlag{
+		Name:        "concourse-resource-check-timeout",
+		Usage:       "(optional) How long a resource check can run before it is stopped, when its pipeline doesn't set a check_timeout, eg 15m. Requires Concourse 4.0.0 or later. Pass 0 to go back to the Concourse default of 1h",
+		EnvVar:      "CONCOURSE_RESOURCE_CHECK_TIMEOUT",
+		Destination: &deployArgs.ResourceCheckTimeout,
+	},
+	cli.DurationFlag{
+		Name:        "concourse-default-get-timeout",
+		Usage:       "(optional) How long a get step can run before it is stopped, when its pipeline doesn't set a timeout, eg 30m. Pass 0 to go back to no timeout",
+		EnvVar:      "CONCOURSE_DEFAULT_GET_TIMEOUT",
+		Destination: &deployArgs.DefaultGetTimeout,
+	},
+	cli.DurationFlag{
+		Name:        "concourse-default-put-timeout",
+		Usage:       "(optional) How long a put step can run before it is stopped, when its pipeline doesn't set a timeout, eg 1h. Pass 0 to go back to no timeout",
+		EnvVar:      "CONCOURSE_DEFAULT_PUT_TIMEOUT",
+		Destination: &deployArgs.DefaultPutTimeout,
+	},
+	cli.StringFlag{
+		Name:        "concourse-container-placement-strategy",
+		Usage:       "(optional) How the ATC chooses a worker for each container: volume-locality, random, fewest-build-containers or limit-active-tasks. Pass an empty strategy to go back to the Concourse default of volume-locality",
+		EnvVar:      "CONCOURSE_CONTAINER_PLACEMENT_STRATEGY",
+		Destination: &deployArgs.ContainerPlacementStrategy,
+	},
+	cli.IntFlag{
+		Name:        "max-active-tasks-per-worker",
+		Usage:       "(optional) Most tasks the ATC places on each worker, with the limit-active-tasks container placement strategy. It is set on the ATC, so changing it updates the web VM rather than the workers. Pass 0 to go back to unlimited",
+		EnvVar:      "MAX_ACTIVE_TASKS_PER_WORKER",
+		Destination: &deployArgs.MaxActiveTasksPerWorker,
+	},
+	cli.IntFlag{
+		Name:        "max-active-containers-per-worker",
+		Usage:       "(optional) Most containers each worker runs. Pass 0 to go back to the Garden default",
+		EnvVar:      "MAX_ACTIVE_CONTAINERS_PER_WORKER",
+		Destination: &deployArgs.MaxActiveContainersPerWorker,
+	},
+	cli.StringFlag{
+		Name:        "atc-log-level",
+		Usage:       "(optional) Minimum level the ATC logs at: debug, info or error. Pass an empty level to go back to the Concourse default of info",
+		EnvVar:      "ATC_LOG_LEVEL",
+		Destination: &deployArgs.ATCLogLevel,
+	},
+	cli.BoolFlag{
+		Name:        "concourse-enable-p2p-volume-streaming",
+		Usage:       "(optional) Stream volumes directly between workers instead of through the ATC. Requires Concourse 7.0.0 or later. Pass --concourse-enable-p2p-volume-streaming=false to stop",
+		EnvVar:      "CONCOURSE_ENABLE_P2P_VOLUME_STREAMING",
+		Destination: &deployArgs.P2PVolumeStreaming,
+	},
+	cli.BoolFlag{
+		Name:        "concourse-enable-global-resources",
+		Usage:       "(optional) Share resource checks and versions between pipelines that use the same resource config",
+		EnvVar:      "CONCOURSE_ENABLE_GLOBAL_RESOURCES",
+		Destination: &deployArgs.EnableGlobalResources,
+	},
+	cli.BoolFlag{
+		Name:        "confirm",
+		Usage:       "(optional) Confirm changes that affect the pipelines running on an existing deployment",
+		EnvVar:      "CONFIRM",
+		Destination: &deployArgs.Confirm,
+	},
+	cli.StringFlag{
+		Name:        "branding-wordmark",
+		Usage:       "(optional) SVG image to replace the Concourse wordmark in the web UI",
+		EnvVar:      "BRANDING_WORDMARK",
+		Destination: &deployArgs.BrandingWordmark,
+	},
+	cli.StringFlag{
+		Name:        "branding-css",
+		Usage:       "(optional) Custom CSS to apply to the Concourse web UI",
+		EnvVar:      "BRANDING_CSS",
+		Destination: &deployArgs.BrandingCSS,
+	},
+	cli.StringFlag{
+		Name:        "director-instance-profile",
+		Usage:       "(optional) ARN of an existing instance profile for the BOSH director, instead of having concourse-up create IAM users. Requires --web-instance-profile and --worker-instance-profile",
+		EnvVar:      "DIRECTOR_INSTANCE_PROFILE",
+		Destination: &deployArgs.DirectorInstanceProfile,
+	},
+	cli.StringFlag{
+		Name:        "web-instance-profile",
+		Usage:       "(optional) ARN of an existing instance profile for the Concourse web node",
+		EnvVar:      "WEB_INSTANCE_PROFILE",
+		Destination: &deployArgs.WebInstanceProfile,
+	},
+	cli.StringFlag{
+		Name:        "worker-instance-profile",
+		Usage:       "(optional) ARN of an existing instance profile for the Concourse workers",
+		EnvVar:      "WORKER_INSTANCE_PROFILE",
+		Destination: &deployArgs.WorkerInstanceProfile,
+	},
+	cli.StringFlag{
+		Name:        "worker-registry-ca-cert",
+		Usage:       "(optional) PEM encoded CA certificates for the workers' resource containers to trust when pulling images from private registries. Pass an empty value to remove them",
+		EnvVar:      "WORKER_REGISTRY_CA_CERT",
+		Destination: &deployArgs.WorkerRegistryCACerts,
+	},
+	cli.StringFlag{
+		Name:        "grafana-path",
+		Usage:       "(optional) Serve Grafana under this path on the Concourse domain, eg /grafana, instead of on port 3000. Pass an empty path to move it back to port 3000",
+		EnvVar:      "GRAFANA_PATH",
+		Destination: &deployArgs.GrafanaPath,
+	},
+	cli.StringFlag{
+		Name:        "syslog-address",
+		Usage:       "(optional) Host and port of an external syslog collector to forward the logs of every VM but the director to, eg logs.example.com:514. Pass an empty address to stop forwarding logs",
+		EnvVar:      "SYSLOG_ADDRESS",
+		Destination: &deployArgs.SyslogAddress,
+	},
+	cli.StringFlag{
+		Name:        "syslog-transport",
+		Usage:       "(optional) Protocol to forward logs with when --syslog-address is given. Can be tcp, udp or relp",
+		EnvVar:      "SYSLOG_TRANSPORT",
+		Value:       "tcp",
+		Destination: &deployArgs.SyslogTransport,
+	},
+	cli.BoolFlag{
+		Name:        "syslog-tls",
+		Usage:       "(optional) Forward logs over TLS, checking the collector's certificate is for the host in --syslog-address",
+		EnvVar:      "SYSLOG_TLS",
+		Destination: &deployArgs.SyslogTLS,
+	},
+	cli.StringFlag{
+		Name:        "syslog-ca-cert",
+		Usage:       "(optional) PEM encoded CA certificate to verify the syslog collector's certificate with, if it isn't signed by a well known CA",
+		EnvVar:      "SYSLOG_CA_CERT",
+		Destination: &deployArgs.SyslogCACert,
+	},
+	cli.StringFlag{
+		Name:        "prometheus-url",
+		Usage:       "(optional) Remote write endpoint of an external Prometheus to send Concourse's metrics to instead of Grafana, eg https://prometheus.example.com/api/v1/write. Pass an empty URL to stop sending them",
+		EnvVar:      "PROMETHEUS_URL",
+		Destination: &deployArgs.PrometheusURL,
+	},
+	cli.StringFlag{
+		Name:        "prometheus-username",
+		Usage:       "(optional) Username to authenticate to --prometheus-url with using basic auth",
+		EnvVar:      "PROMETHEUS_USERNAME",
+		Destination: &deployArgs.PrometheusUsername,
+	},
+	cli.StringFlag{
+		Name:        "prometheus-password",
+		Usage:       "(optional) Password to authenticate to --prometheus-url with using basic auth",
+		EnvVar:      "PROMETHEUS_PASSWORD",
+		Destination: &deployArgs.PrometheusPassword,
+	},
+	cli.BoolFlag{
+		Name:        "bosh-update-serial",
+		Usage:       "(optional) Make BOSH wait for other deployments on the director to finish updating before updating Concourse. Pass --bosh-update-serial=false to stop",
+		EnvVar:      "BOSH_UPDATE_SERIAL",
+		Destination: &deployArgs.BoshUpdateSerial,
+	},
+	cli.StringFlag{
+		Name:        "bosh-canary-watch-time",
+		Usage:       "(optional) Milliseconds, or a range of milliseconds, BOSH waits for canary instances to become healthy. Defaults to 1000-300000. Pass an empty value to go back to the default",
+		EnvVar:      "BOSH_CANARY_WATCH_TIME",
+		Destination: &deployArgs.BoshCanaryWatchTime,
+	},
+	cli.StringFlag{
+		Name:        "bosh-update-watch-time",
+		Usage:       "(optional) Milliseconds, or a range of milliseconds, BOSH waits for the remaining instances to become healthy. Defaults to 1000-300000. Pass an empty value to go back to the default",
+		EnvVar:      "BOSH_UPDATE_WATCH_TIME",
+		Destination: &deployArgs.BoshUpdateWatchTime,
+	},
+	cli.StringFlag{
+		Name:        "description",
+		Usage:       "(optional) One line summary of what the deployment is for, shown by info. Pass an empty description to remove it",
+		EnvVar:      "DESCRIPTION",
+		Destination: &deployArgs.Description,
+	},
+	cli.StringFlag{
+		Name:        "notes",
+		Usage:       "(optional) Freeform notes about the deployment, such as its owner, shown by info. Pass empty notes to remove them",
+		EnvVar:      "NOTES",
+		Destination: &deployArgs.Notes,
+	},
+	cli.StringFlag{
+		Name:        "operator",
+		Usage:       "(optional) Who is running the deploy, recorded as the last deployer and shown by info and status. Defaults to your AWS identity",
+		EnvVar:      "OPERATOR",
+		Destination: &deployArgs.Operator,
+	},
+	cli.StringFlag{
+		Name:        "notes-file",
+		Usage:       "(optional) Path to a file containing the notes about the deployment, as an alternative to --notes",
+		EnvVar:      "NOTES_FILE",
+		Destination: &notesFile,
+	},
+	cli.StringFlag{
+		Name:        "standby-of",
+		Usage:       "(optional) Region of an existing deployment of the same name to deploy a disaster recovery standby for",
+		EnvVar:      "STANDBY_OF",
+		Destination: &deployArgs.StandbyOf,
+	},
+	cli.StringFlag{
+		Name:        "standby-of-config-bucket-name",
+		Usage:       "(optional) Name of the config bucket of the deployment given to --standby-of, if it was deployed with --config-bucket-name",
+		EnvVar:      "STANDBY_OF_CONFIG_BUCKET_NAME",
+		Destination: &standbyOfConfigBucketName,
+	},
+	cli.StringFlag{
+		Name:        "backup-region",
+		Usage:       "(optional) Region to replicate the config bucket to, which is read from if the deployment's region can't be reached",
+		EnvVar:      "BACKUP_REGION",
+		Destination: &deployArgs.BackupRegion,
+	},
+	cli.StringFlag{
+		Name:        "kms-key",
+		Usage:       "(optional) ID, alias or ARN of a KMS key to encrypt the config bucket's objects with, rather than S3's own keys",
+		EnvVar:      "KMS_KEY_ID",
+		Destination: &deployArgs.KMSKeyID,
+	},
+}, experimentFlags()...)
+
+// experimentFlags returns a flag to enable each of the ATC experiments
+func experimentFlags() []cli.Flag {
+	var flags []cli.Flag
+	for _, experiment := range config.Experiments {
+		flags = append(flags, cli.BoolFlag{
+			Name:   experiment.Flag,
+			Usage:  fmt.Sprintf("%s. Requires Concourse %s or later", experiment.Usage, experiment.MinConcourseVersion),
+			EnvVar: strings.ToUpper(strings.Replace(experiment.Flag, "-", "_", -1)),
+		})
+	}
+	return flags
 }
 
 var deploy = cli.Command{
@@ -102,33 +806,269 @@ var deploy = cli.Command{
 	ArgsUsage: "<name>",
 	Flags:     deployFlags,
 	Action: func(c *cli.Context) error {
-		name := c.Args().Get(0)
-		if name == "" {
-			return errors.New("Usage is `concourse-up deploy <name>`")
+		return runDeploy(c, "deploy")
+	},
+}
+
+func runDeploy(c *cli.Context, command string) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return fmt.Errorf("Usage is `concourse-up %s <name>`", command)
+	}
+
+	deployArgs.DBSizeIsSet = c.IsSet("db-size")
+	deployArgs.DBApplyImmediatelyIsSet = c.IsSet("db-apply-immediately")
+	deployArgs.ProfileIsSet = c.IsSet("profile")
+	if deployArgs.ProfileIsSet {
+		if err := deployArgs.ApplyProfile(c.IsSet); err != nil {
+			return err
 		}
+	}
+	deployArgs.DBAvailabilityZoneIsSet = c.IsSet("db-availability-zone")
+	deployArgs.DBEngineVersionIsSet = c.IsSet("db-engine-version")
+	deployArgs.MaintenanceWindowIsSet = c.IsSet("maintenance-window")
+	deployArgs.EnforceMaintenanceWindowIsSet = c.IsSet("enforce-maintenance-window")
+	deployArgs.TSAPortIsSet = c.IsSet("tsa-port")
+	deployArgs.ExternalPortIsSet = c.IsSet("external-port")
+	deployArgs.CredhubPortIsSet = c.IsSet("credhub-port")
+	deployArgs.GrafanaPortIsSet = c.IsSet("grafana-port")
+	deployArgs.DNSWeightIsSet = c.IsSet("dns-weight")
+	deployArgs.ACMEEnabledIsSet = c.IsSet("acme")
+	deployArgs.TenancyIsSet = c.IsSet("tenancy")
+	deployArgs.GrafanaPathIsSet = c.IsSet("grafana-path")
+	deployArgs.WorkerRegistryCACertsIsSet = c.IsSet("worker-registry-ca-cert")
+	deployArgs.WorkerTagsIsSet = c.IsSet("worker-tag")
+	deployArgs.WorkerEphemeralIsSet = c.IsSet("concourse-worker-ephemeral")
+	deployArgs.WorkerSpotIsSet = c.IsSet("spot-workers")
+	deployArgs.WorkerSpotMaxPriceIsSet = c.IsSet("spot-max-price")
+	deployArgs.WorkerAMIIDIsSet = c.IsSet("worker-ami-id")
+	deployArgs.WorkerDiskSizeIsSet = c.IsSet("worker-disk-size")
+	deployArgs.MaxActiveTasksPerWorkerIsSet = c.IsSet("max-active-tasks-per-worker")
+	deployArgs.MaxActiveContainersPerWorkerIsSet = c.IsSet("max-active-containers-per-worker")
+	deployArgs.StemcellVersionIsSet = c.IsSet("stemcell-version")
+	deployArgs.DirectorSizeIsSet = c.IsSet("director-size")
+	deployArgs.BackupRegionIsSet = c.IsSet("backup-region")
+	deployArgs.KMSKeyIDIsSet = c.IsSet("kms-key")
+	deployArgs.IsolatedWorkersIsSet = c.IsSet("isolate-workers")
+	deployArgs.ContainerNetworkPoolIsSet = c.IsSet("worker-container-network-pool")
+	deployArgs.ContainerNetworkMTUIsSet = c.IsSet("worker-container-network-mtu")
+	deployArgs.TerminationProtectionIsSet = c.IsSet("instance-termination-protection")
+	deployArgs.SecretCacheIsSet = c.IsSet("concourse-secret-cache")
+	deployArgs.DBMaxOpenConnectionsIsSet = c.IsSet("db-max-open-connections")
+	deployArgs.DBMaxIdleConnectionsIsSet = c.IsSet("db-max-idle-connections")
+	deployArgs.DBConnectTimeoutIsSet = c.IsSet("db-connect-timeout")
+	deployArgs.ResurrectorIsSet = c.IsSet("resurrector")
+	deployArgs.SecretCacheTTLIsSet = c.IsSet("concourse-secret-cache-ttl")
+	deployArgs.EnableIPv6IsSet = c.IsSet("enable-ipv6")
+	deployArgs.PublicIPv6IsSet = c.IsSet("public-ipv6")
+	deployArgs.PrivateIsSet = c.IsSet("private")
+	deployArgs.DrainTimeoutIsSet = c.IsSet("drain-timeout")
+	deployArgs.WaitForDetach = c.IsSet("detach-timeout")
+	deployArgs.VPCCIDRIsSet = c.IsSet("vpc-cidr")
+	deployArgs.ExistingPublicSubnetIDs = c.StringSlice("public-subnet-id")
+	deployArgs.ExistingPrivateSubnetIDs = c.StringSlice("private-subnet-id")
+	deployArgs.PeeringConnectionsIsSet = c.IsSet("peer-vpc")
+	peers, err := config.ParsePeeringSpecs(c.StringSlice("peer-vpc"))
+	if err != nil {
+		return err
+	}
+	deployArgs.PeeringConnections = peers
+	deployArgs.AdditionalAllowedCIDRsIsSet = c.IsSet("allow-cidr")
+	allowedCIDRs, err := config.ParseAllowedCIDRs(c.StringSlice("allow-cidr"))
+	if err != nil {
+		return err
+	}
+	deployArgs.AdditionalAllowedCIDRs = allowedCIDRs
+	deployArgs.ProxyIsSet = proxyIsSet(c)
+	deployArgs.Proxy = util.GlobalProxy
+	deployArgs.S3Endpoint = iaas.GlobalS3Endpoint.URL
+	for _, tag := range c.StringSlice("worker-tag") {
+		if tag != "" {
+			deployArgs.WorkerTags = append(deployArgs.WorkerTags, tag)
+		}
+	}
+	deployArgs.DNSServersIsSet = c.IsSet("dns-server")
+	for _, server := range c.StringSlice("dns-server") {
+		if server != "" {
+			deployArgs.DNSServers = append(deployArgs.DNSServers, server)
+		}
+	}
+	deployArgs.NTPServersIsSet = c.IsSet("ntp-server")
+	for _, server := range c.StringSlice("ntp-server") {
+		if server != "" {
+			deployArgs.NTPServers = append(deployArgs.NTPServers, server)
+		}
+	}
+	deployArgs.TagsIsSet = c.IsSet("tag")
+	tags, err := config.ParseTags(c.StringSlice("tag"))
+	if err != nil {
+		return err
+	}
+	deployArgs.Tags = tags
+	deployArgs.TerraformVarsIsSet = c.IsSet("tf-vars-file")
+	if tfVarsFile != "" {
+		if deployArgs.TerraformVars, err = terraform.ReadVarsFile(tfVarsFile, os.Stderr); err != nil {
+			return err
+		}
+	}
+	deployArgs.PostDeployErrandsIsSet = c.IsSet("post-deploy-errand")
+	for _, errand := range c.StringSlice("post-deploy-errand") {
+		if errand != "" {
+			deployArgs.PostDeployErrands = append(deployArgs.PostDeployErrands, errand)
+		}
+	}
+	deployArgs.NoDefaultPipelineIsSet = c.IsSet("no-pipeline")
+	deployArgs.ExtraPipelinesIsSet = c.IsSet("pipeline")
+	pipelines, err := config.ParsePipelineSpecs(c.StringSlice("pipeline"))
+	if err != nil {
+		return err
+	}
+	if err = config.ReadPipelineFiles(pipelines); err != nil {
+		return err
+	}
+	deployArgs.ExtraPipelines = pipelines
+	if deployArgs.CredhubSeedFile != "" {
+		if _, err = credhub.ReadSecretsFile(deployArgs.CredhubSeedFile); err != nil {
+			return err
+		}
+	}
+	for _, experiment := range config.Experiments {
+		if c.Bool(experiment.Flag) {
+			deployArgs.Experiments = append(deployArgs.Experiments, experiment.Property)
+		}
+	}
+	deployArgs.TLSMinVersionIsSet = c.IsSet("concourse-tls-min-version")
+	deployArgs.TLSCipherSuitesIsSet = c.IsSet("concourse-tls-cipher-suite")
+	for _, suite := range c.StringSlice("concourse-tls-cipher-suite") {
+		if suite != "" {
+			deployArgs.TLSCipherSuites = append(deployArgs.TLSCipherSuites, suite)
+		}
+	}
+	if err := readSecretFile(&deployArgs.TLSKey, "tls-key", tlsKeyFile); err != nil {
+		return err
+	}
+	if err := readSecretFile(&deployArgs.ConcoursePassword, "concourse-password", concoursePasswordFile); err != nil {
+		return err
+	}
+	deployArgs.MetricsUsernameIsSet = c.IsSet("metrics-username")
+	deployArgs.MetricsPasswordIsSet = c.IsSet("metrics-password") || c.IsSet("metrics-password-file")
+	if err := readSecretFile(&deployArgs.MetricsPassword, "metrics-password", metricsPasswordFile); err != nil {
+		return err
+	}
+	if err := readSecretFile(&deployArgs.ExternalDBPassword, "external-db-password", externalDBPasswordFile); err != nil {
+		return err
+	}
+	deployArgs.HijackGracePeriodIsSet = c.IsSet("concourse-gc-hijack-grace-period")
+	deployArgs.InterceptIdleTimeoutIsSet = c.IsSet("concourse-intercept-idle-timeout")
+	deployArgs.ResourceCheckingIntervalIsSet = c.IsSet("concourse-resource-checking-interval")
+	deployArgs.ResourceCheckTimeoutIsSet = c.IsSet("concourse-resource-check-timeout")
+	deployArgs.DefaultGetTimeoutIsSet = c.IsSet("concourse-default-get-timeout")
+	deployArgs.DefaultPutTimeoutIsSet = c.IsSet("concourse-default-put-timeout")
+	deployArgs.ContainerPlacementStrategyIsSet = c.IsSet("concourse-container-placement-strategy")
+	deployArgs.ATCLogLevelIsSet = c.IsSet("atc-log-level")
+	deployArgs.P2PVolumeStreamingIsSet = c.IsSet("concourse-enable-p2p-volume-streaming")
+	deployArgs.SyslogAddressIsSet = c.IsSet("syslog-address")
+	deployArgs.PrometheusURLIsSet = c.IsSet("prometheus-url")
+	deployArgs.BoshUpdateSerialIsSet = c.IsSet("bosh-update-serial")
+	deployArgs.BoshCanaryWatchTimeIsSet = c.IsSet("bosh-canary-watch-time")
+	deployArgs.BoshUpdateWatchTimeIsSet = c.IsSet("bosh-update-watch-time")
+	deployArgs.DescriptionIsSet = c.IsSet("description")
+	deployArgs.NotesIsSet = c.IsSet("notes") || c.IsSet("notes-file")
+	if err := readSecretFile(&deployArgs.Notes, "notes", notesFile); err != nil {
+		return err
+	}
+	if err := deployArgs.Validate(); err != nil {
+		return err
+	}
+	if standbyOfConfigBucketName != "" && deployArgs.StandbyOf == "" {
+		return errors.New("--standby-of-config-bucket-name requires --standby-of")
+	}
+
+	awsClient, err := iaas.New(deployArgs.IAAS, deployArgs.AWSRegion)
+	if err != nil {
+		return err
+	}
 
-		deployArgs.DBSizeIsSet = c.IsSet("db-size")
-		if err := deployArgs.Validate(); err != nil {
+	configClient := config.New(awsClient, name, configBucketName)
+	if deployArgs.BackupRegion != "" {
+		backupAWSClient, err := iaas.New(deployArgs.IAAS, deployArgs.BackupRegion)
+		if err != nil {
 			return err
 		}
+		configClient = config.NewWithBackup(awsClient, backupAWSClient, name, configBucketName)
+	}
+
+	// A generated manifest may be printed, so that's all that goes to stdout
+	var stdout io.Writer = os.Stdout
+	if deployArgs.GenerateManifestOnly {
+		stdout = os.Stderr
+	}
+
+	client := concourse.NewClient(
+		awsClient,
+		terraform.NewClient,
+		bosh.NewClient,
+		fly.New,
+		certs.Generate,
+		certs.ObtainFromACME,
+		configClient,
+		&deployArgs,
+		stdout,
+		os.Stderr,
+	)
+
+	if deployArgs.GenerateManifestOnly {
+		return writeManifest(client, name)
+	}
 
-		awsClient, err := iaas.New(deployArgs.IAAS, deployArgs.AWSRegion)
+	if deployArgs.StandbyOf != "" {
+		primaryAWSClient, err := iaas.New(deployArgs.IAAS, deployArgs.StandbyOf)
 		if err != nil {
 			return err
 		}
+		client.SetPrimary(primaryAWSClient, config.New(primaryAWSClient, name, standbyOfConfigBucketName))
+	}
 
-		client := concourse.NewClient(
-			awsClient,
-			terraform.NewClient,
-			bosh.NewClient,
-			fly.New,
-			certs.Generate,
-			config.New(awsClient, name),
-			&deployArgs,
-			os.Stdout,
-			os.Stderr,
-		)
+	return client.Deploy()
+}
 
-		return client.Deploy()
-	},
+// writeManifest prints the manifest the deployment would be given, or writes it to --manifest-file
+func writeManifest(client concourse.IClient, name string) error {
+	manifest, err := client.GenerateManifest()
+	if err != nil {
+		return err
+	}
+
+	if deployArgs.ManifestFile == "" {
+		_, err = os.Stdout.Write(manifest)
+		return err
+	}
+
+	// The manifest may contain secrets, so only the user can read it
+	if err = ioutil.WriteFile(deployArgs.ManifestFile, manifest, 0600); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(os.Stderr, "Wrote the manifest of %s to %s\n", name, deployArgs.ManifestFile)
+	return err
+}
+
+// readSecretFile sets value to the contents of the file at path, so that secrets
+// can be passed without appearing in the command line
+func readSecretFile(value *string, flag, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	if *value != "" {
+		return fmt.Errorf("--%s and --%s-file cannot both be provided", flag, flag)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read --%s-file: %s", flag, err)
+	}
+
+	*value = strings.TrimRight(string(contents), "\r\n")
+	return nil
 }
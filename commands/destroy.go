@@ -22,11 +22,16 @@ var destroyArgs config.DestroyArgs
 var destroyFlags = []cli.Flag{
 	cli.StringFlag{
 		Name:        "region",
-		Value:       "eu-west-1",
-		Usage:       "(optional) AWS region",
+		Usage:       existingRegionUsage,
 		EnvVar:      "AWS_REGION",
 		Destination: &destroyArgs.AWSRegion,
 	},
+	cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "(optional) List what would be destroyed, including Terraform's plan, without deleting anything",
+		EnvVar:      "DRY_RUN",
+		Destination: &destroyArgs.DryRun,
+	},
 	cli.StringFlag{
 		Name:        "iaas",
 		Usage:       "(optional) IAAS, can be AWS or GCP",
@@ -49,7 +54,8 @@ var destroy = cli.Command{
 			return errors.New("Usage is `concourse-up destroy <name>`")
 		}
 
-		if !NonInteractiveModeEnabled() {
+		// Dry runs don't delete anything, so don't need confirming
+		if !NonInteractiveModeEnabled() && !destroyArgs.DryRun {
 			confirm, err := util.CheckConfirmation(os.Stdin, os.Stdout, name)
 			if err != nil {
 				return err
@@ -61,6 +67,12 @@ var destroy = cli.Command{
 			}
 		}
 
+		region, err := deploymentRegion(c, destroyArgs.IAAS, destroyArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		destroyArgs.AWSRegion = region
+
 		iaasClient, err := iaas.New(destroyArgs.IAAS, destroyArgs.AWSRegion)
 		if err != nil {
 			return err
@@ -72,12 +84,13 @@ var destroy = cli.Command{
 			bosh.NewClient,
 			fly.New,
 			certs.Generate,
-			config.New(iaasClient, name),
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
 			nil,
 			os.Stdout,
 			os.Stderr,
 		)
 
-		return client.Destroy()
+		return client.Destroy(destroyArgs.DryRun)
 	},
 }
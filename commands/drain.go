@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var drainArgs config.DrainArgs
+
+var drainFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &drainArgs.AWSRegion,
+	},
+	cli.IntFlag{
+		Name:        "timeout",
+		Value:       60,
+		Usage:       "(optional) Minutes to wait for running builds to finish",
+		EnvVar:      "DRAIN_TIMEOUT",
+		Destination: &drainArgs.Timeout,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &drainArgs.IAAS,
+	},
+}
+
+var drain = cli.Command{
+	Name:      "drain",
+	Usage:     "Pauses pipelines and lands workers before maintenance on a Concourse",
+	ArgsUsage: "<name>",
+	Flags:     drainFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up drain <name>`")
+		}
+
+		if err := drainArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, drainArgs.IAAS, drainArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		drainArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(drainArgs.IAAS, drainArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.Drain(time.Duration(drainArgs.Timeout) * time.Minute)
+	},
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var eventsArgs config.EventsArgs
+
+var eventsFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &eventsArgs.AWSRegion,
+	},
+	cli.BoolFlag{
+		Name:        "json",
+		Usage:       "(optional) Output as json",
+		EnvVar:      "JSON",
+		Destination: &eventsArgs.JSON,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &eventsArgs.IAAS,
+	},
+}
+
+var events = cli.Command{
+	Name:      "events",
+	Usage:     "Lists the operations that have been performed on a deployment",
+	ArgsUsage: "<name>",
+	Flags:     eventsFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up events <name>`")
+		}
+
+		region, err := deploymentRegion(c, eventsArgs.IAAS, eventsArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		eventsArgs.AWSRegion = region
+
+		awsClient, err := iaas.New(eventsArgs.IAAS, eventsArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			awsClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(awsClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		events, err := client.FetchEvents()
+		if err != nil {
+			return err
+		}
+
+		if eventsArgs.JSON {
+			return json.NewEncoder(os.Stdout).Encode(events)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tOPERATOR\tCOMMAND\tDURATION\tRESULT\tARGS")
+		for _, event := range events {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", event.Time.Format("2006-01-02 15:04:05 MST"), event.Operator, event.Command, event.Duration, event.Result, event.Args)
+		}
+		return w.Flush()
+	},
+}
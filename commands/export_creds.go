@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var exportCredsArgs config.ExportCredsArgs
+
+var exportCredsPassphraseFile string
+
+var exportCredsFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &exportCredsArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "format",
+		Usage:       "(optional) Format to write the credentials in: yaml or json",
+		EnvVar:      "EXPORT_CREDS_FORMAT",
+		Value:       "yaml",
+		Destination: &exportCredsArgs.Format,
+	},
+	cli.StringFlag{
+		Name:        "file",
+		Usage:       "(optional) Path of a file to write the credentials to, encrypted with --passphrase, rather than printing them",
+		EnvVar:      "EXPORT_CREDS_FILE",
+		Destination: &exportCredsArgs.File,
+	},
+	cli.StringFlag{
+		Name:        "passphrase",
+		Usage:       "(optional) Passphrase --file is encrypted with. Prefer the env var or --passphrase-file to keep it out of your shell history",
+		EnvVar:      "EXPORT_CREDS_PASSPHRASE",
+		Destination: &exportCredsArgs.Passphrase,
+	},
+	cli.StringFlag{
+		Name:        "passphrase-file",
+		Usage:       "(optional) Path to a file containing the passphrase --file is encrypted with",
+		EnvVar:      "EXPORT_CREDS_PASSPHRASE_FILE",
+		Destination: &exportCredsPassphraseFile,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &exportCredsArgs.IAAS,
+	},
+}
+
+var exportCreds = cli.Command{
+	Name:      "export-creds",
+	Usage:     "Writes the credentials of Concourse, Grafana, CredHub and the BOSH director as a single document",
+	ArgsUsage: "<name>",
+	Flags:     exportCredsFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up export-creds <name>`")
+		}
+
+		if err := readSecretFile(&exportCredsArgs.Passphrase, "passphrase", exportCredsPassphraseFile); err != nil {
+			return err
+		}
+		if err := exportCredsArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, exportCredsArgs.IAAS, exportCredsArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		exportCredsArgs.AWSRegion = region
+
+		awsClient, err := iaas.New(exportCredsArgs.IAAS, exportCredsArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		// Progress goes to stderr, so that stdout is only the credentials document
+		client := concourse.NewClient(
+			awsClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(awsClient, name, configBucketName),
+			nil,
+			os.Stderr,
+			os.Stderr,
+		)
+
+		creds, err := client.ExportCreds()
+		if err != nil {
+			return err
+		}
+
+		document, err := creds.Encode(exportCredsArgs.Format)
+		if err != nil {
+			return err
+		}
+
+		if exportCredsArgs.File == "" {
+			_, err = os.Stdout.Write(document)
+			return err
+		}
+
+		// Refuse to overwrite an existing file, which may be the only copy of other credentials
+		file, err := os.OpenFile(exportCredsArgs.File, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+
+		if err = concourse.EncryptCreds(file, exportCredsArgs.Passphrase, document); err != nil {
+			file.Close()
+			os.Remove(exportCredsArgs.File)
+			return err
+		}
+		if err = file.Close(); err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(os.Stderr, "Exported the credentials of %s to %s\n", name, exportCredsArgs.File)
+		return err
+	},
+}
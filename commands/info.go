@@ -23,8 +23,7 @@ var infoArgs config.InfoArgs
 var infoFlags = []cli.Flag{
 	cli.StringFlag{
 		Name:        "region",
-		Value:       "eu-west-1",
-		Usage:       "(optional) AWS region",
+		Usage:       existingRegionUsage,
 		EnvVar:      "AWS_REGION",
 		Destination: &infoArgs.AWSRegion,
 	},
@@ -39,6 +38,11 @@ var infoFlags = []cli.Flag{
 		Usage:       "(optional) Output environment variables",
 		Destination: &infoArgs.Env,
 	},
+	cli.BoolFlag{
+		Name:        "versions",
+		Usage:       "(optional) Only output the Concourse, BOSH director and stemcell versions the deployment is running",
+		Destination: &infoArgs.Versions,
+	},
 	cli.StringFlag{
 		Name:        "iaas",
 		Usage:       "(optional) IAAS, can be AWS or GCP",
@@ -61,6 +65,16 @@ var info = cli.Command{
 			return errors.New("Usage is `concourse-up info <name>`")
 		}
 
+		if infoArgs.Versions && infoArgs.Env {
+			return errors.New("--versions cannot be used with --env")
+		}
+
+		region, err := deploymentRegion(c, infoArgs.IAAS, infoArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		infoArgs.AWSRegion = region
+
 		awsClient, err := iaas.New(infoArgs.IAAS, infoArgs.AWSRegion)
 		if err != nil {
 			return err
@@ -72,7 +86,8 @@ var info = cli.Command{
 			bosh.NewClient,
 			fly.New,
 			certs.Generate,
-			config.New(awsClient, name),
+			certs.ObtainFromACME,
+			config.New(awsClient, name, configBucketName),
 			nil,
 			os.Stdout,
 			os.Stderr,
@@ -84,6 +99,15 @@ var info = cli.Command{
 		}
 
 		switch {
+		case infoArgs.Versions:
+			if info.Versions == nil {
+				return fmt.Errorf("%s is a standby of %s, and runs no Concourse until it is promoted", name, info.Config.StandbyOf)
+			}
+			if infoArgs.JSON {
+				return json.NewEncoder(os.Stdout).Encode(info.Versions)
+			}
+			_, err := fmt.Fprint(os.Stdout, info.Versions)
+			return err
 		case infoArgs.JSON:
 			return json.NewEncoder(os.Stdout).Encode(info)
 		case infoArgs.Env:
@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"gopkg.in/urfave/cli.v1"
+)
+
+var promote = cli.Command{
+	Name:      "promote",
+	Usage:     "Promotes a disaster recovery standby to a full Concourse, taking over from its primary",
+	ArgsUsage: "<name>",
+	Flags:     deployFlags,
+	Action: func(c *cli.Context) error {
+		deployArgs.Promote = true
+		return runDeploy(c, "promote")
+	},
+}
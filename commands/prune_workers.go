@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var pruneWorkersArgs config.PruneWorkersArgs
+
+var pruneWorkersFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &pruneWorkersArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "state",
+		Value:       "stalled",
+		Usage:       "(optional) Comma separated list of the worker states to prune. Can be stalled, landing, landed or retiring",
+		EnvVar:      "STATE",
+		Destination: &pruneWorkersArgs.States,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &pruneWorkersArgs.IAAS,
+	},
+}
+
+var pruneWorkers = cli.Command{
+	Name:      "prune-workers",
+	Usage:     "Removes stale worker registrations from a Concourse",
+	ArgsUsage: "<name>",
+	Flags:     pruneWorkersFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up prune-workers <name>`")
+		}
+
+		if err := pruneWorkersArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, pruneWorkersArgs.IAAS, pruneWorkersArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		pruneWorkersArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(pruneWorkersArgs.IAAS, pruneWorkersArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.PruneWorkers(pruneWorkersArgs.StateList())
+	},
+}
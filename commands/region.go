@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/iaas"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+// defaultRegion is where new deployments go when --region isn't given
+const defaultRegion = "eu-west-1"
+
+// existingRegionUsage is the usage of --region for commands that act on an existing deployment
+const existingRegionUsage = "(optional) AWS region. Defaults to the region of the existing deployment"
+
+// deploymentRegion returns the region given with --region or, when it's omitted,
+// the region of the existing deployment called name
+func deploymentRegion(c *cli.Context, iaasName, region, name string) (string, error) {
+	if c.IsSet("region") {
+		return region, nil
+	}
+
+	// Buckets can be listed from any region
+	iaasClient, err := iaas.New(iaasName, defaultRegion)
+	if err != nil {
+		return "", err
+	}
+
+	return config.FindRegion(iaasClient, name, configBucketName)
+}
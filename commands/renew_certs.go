@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	renewCertsArgs       config.RenewCertsArgs
+	renewCertsTLSKeyFile string
+)
+
+var renewCertsFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &renewCertsArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "tls-cert",
+		Usage:       "(optional) TLS cert to replace a Concourse certificate that was provided with deploy --tls-cert",
+		EnvVar:      "TLS_CERT",
+		Destination: &renewCertsArgs.TLSCert,
+	},
+	cli.StringFlag{
+		Name:        "tls-key",
+		Usage:       "(optional) TLS private key for --tls-cert",
+		EnvVar:      "TLS_KEY",
+		Destination: &renewCertsArgs.TLSKey,
+	},
+	cli.StringFlag{
+		Name:        "tls-key-file",
+		Usage:       "(optional) Path to a file containing the TLS private key, as an alternative to --tls-key",
+		EnvVar:      "TLS_KEY_FILE",
+		Destination: &renewCertsTLSKeyFile,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &renewCertsArgs.IAAS,
+	},
+}
+
+var renewCerts = cli.Command{
+	Name:      "renew-certs",
+	Usage:     "Renews the Concourse and BOSH director certificates that are due to expire",
+	ArgsUsage: "<name>",
+	Flags:     renewCertsFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up renew-certs <name>`")
+		}
+
+		if err := readSecretFile(&renewCertsArgs.TLSKey, "tls-key", renewCertsTLSKeyFile); err != nil {
+			return err
+		}
+		if err := renewCertsArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, renewCertsArgs.IAAS, renewCertsArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		renewCertsArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(renewCertsArgs.IAAS, renewCertsArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.RenewCerts(renewCertsArgs.TLSCert, renewCertsArgs.TLSKey)
+	},
+}
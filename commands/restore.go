@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var restoreArgs config.RestoreArgs
+
+var restoreFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &restoreArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "snapshot",
+		Usage:       "ID of the RDS snapshot to restore the database from, eg one taken by deploy --db-backup",
+		EnvVar:      "SNAPSHOT",
+		Destination: &restoreArgs.SnapshotID,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &restoreArgs.IAAS,
+	},
+}
+
+var restore = cli.Command{
+	Name:      "restore",
+	Usage:     "Recreates a deployment's database from an RDS snapshot and redeploys Concourse on it",
+	ArgsUsage: "<name>",
+	Flags:     restoreFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up restore --snapshot <snapshot-id> <name>`")
+		}
+
+		if err := restoreArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, restoreArgs.IAAS, restoreArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		restoreArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(restoreArgs.IAAS, restoreArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			&config.DeployArgs{
+				IAAS:      restoreArgs.IAAS,
+				AWSRegion: restoreArgs.AWSRegion,
+			},
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.Restore(restoreArgs.SnapshotID)
+	},
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var resumeArgs config.ResumeArgs
+
+var resumeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &resumeArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &resumeArgs.IAAS,
+	},
+}
+
+var resume = cli.Command{
+	Name:      "resume",
+	Usage:     "Restarts the workers and unpauses the pipelines of a drained Concourse",
+	ArgsUsage: "<name>",
+	Flags:     resumeFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up resume <name>`")
+		}
+
+		region, err := deploymentRegion(c, resumeArgs.IAAS, resumeArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		resumeArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(resumeArgs.IAAS, resumeArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.Resume()
+	},
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var rollbackArgs config.RollbackArgs
+
+var rollbackFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &rollbackArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &rollbackArgs.IAAS,
+	},
+}
+
+var rollback = cli.Command{
+	Name:      "rollback",
+	Usage:     "Restores the config and director state from before the last deploy and redeploys BOSH with them",
+	ArgsUsage: "<name>",
+	Flags:     rollbackFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up rollback <name>`")
+		}
+
+		region, err := deploymentRegion(c, rollbackArgs.IAAS, rollbackArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		rollbackArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(rollbackArgs.IAAS, rollbackArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			&config.DeployArgs{
+				IAAS:      rollbackArgs.IAAS,
+				AWSRegion: rollbackArgs.AWSRegion,
+			},
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.Rollback()
+	},
+}
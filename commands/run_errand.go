@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var runErrandArgs config.RunErrandArgs
+
+var runErrandFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &runErrandArgs.AWSRegion,
+	},
+	cli.StringFlag{
+		Name:        "errand",
+		Usage:       "Name of the BOSH errand to run",
+		EnvVar:      "ERRAND",
+		Destination: &runErrandArgs.Errand,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &runErrandArgs.IAAS,
+	},
+}
+
+var runErrand = cli.Command{
+	Name:      "run-errand",
+	Usage:     "Runs a BOSH errand in a Concourse deployment",
+	ArgsUsage: "<name>",
+	Flags:     runErrandFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up run-errand --errand <errand> <name>`")
+		}
+
+		if err := runErrandArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, runErrandArgs.IAAS, runErrandArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		runErrandArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(runErrandArgs.IAAS, runErrandArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.RunErrand(runErrandArgs.Errand)
+	},
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var scaleWorkersArgs config.ScaleWorkersArgs
+
+var scaleWorkersFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &scaleWorkersArgs.AWSRegion,
+	},
+	cli.IntFlag{
+		Name:        "count",
+		Usage:       "Number of Concourse worker instances to scale to",
+		EnvVar:      "WORKERS",
+		Destination: &scaleWorkersArgs.WorkerCount,
+	},
+	cli.StringFlag{
+		Name:        "size",
+		Usage:       "(optional) Size to give the Concourse workers. Can be medium, large, xlarge, 2xlarge, 4xlarge, 10xlarge or 16xlarge",
+		EnvVar:      "WORKER_SIZE",
+		Destination: &scaleWorkersArgs.WorkerSize,
+	},
+	cli.IntFlag{
+		Name:        "drain-timeout",
+		Usage:       "(optional) Minutes to wait for running builds to finish on workers removed when scaling down. Set to 0 to remove workers immediately",
+		EnvVar:      "WORKER_DRAIN_TIMEOUT",
+		Value:       60,
+		Destination: &scaleWorkersArgs.WorkerDrainTimeout,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &scaleWorkersArgs.IAAS,
+	},
+}
+
+var scaleWorkers = cli.Command{
+	Name:      "scale-workers",
+	Usage:     "Changes the number or size of a Concourse's workers without redeploying the rest of it",
+	ArgsUsage: "<name>",
+	Flags:     scaleWorkersFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up scale-workers --count <count> <name>`")
+		}
+
+		scaleWorkersArgs.WorkerCountIsSet = c.IsSet("count")
+		if err := scaleWorkersArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, scaleWorkersArgs.IAAS, scaleWorkersArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		scaleWorkersArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(scaleWorkersArgs.IAAS, scaleWorkersArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			&config.DeployArgs{
+				IAAS:               scaleWorkersArgs.IAAS,
+				AWSRegion:          scaleWorkersArgs.AWSRegion,
+				WorkerDrainTimeout: scaleWorkersArgs.WorkerDrainTimeout,
+			},
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.ScaleWorkers(scaleWorkersArgs.WorkerCount, scaleWorkersArgs.WorkerSize)
+	},
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/director"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+// selfCheckStep checks one part of the concourse-up binary, returning details to show when it passes
+type selfCheckStep struct {
+	name  string
+	check func() (string, error)
+}
+
+var selfCheckSteps = []selfCheckStep{
+	{"manifest templates", verifiedAssets(bosh.VerifyAssets)},
+	{"terraform templates", verifiedAssets(terraform.VerifyAssets)},
+	{"terraform CLI", terraform.BinaryVersion},
+	{"fly CLI", fly.BinaryVersion},
+	{"bosh CLI", director.BinaryVersion},
+}
+
+var selfCheck = cli.Command{
+	Name:  "self-check",
+	Usage: "Checks this concourse-up binary is intact, and can download and run the CLIs it uses",
+	Action: func(c *cli.Context) error {
+		return runSelfCheck(os.Stdout, selfCheckSteps)
+	},
+}
+
+func verifiedAssets(verify func() error) func() (string, error) {
+	return func() (string, error) {
+		if err := verify(); err != nil {
+			return "", err
+		}
+		return "checksum matches", nil
+	}
+}
+
+func runSelfCheck(stdout io.Writer, steps []selfCheckStep) error {
+	w := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	failed := 0
+	for _, step := range steps {
+		details, err := step.check()
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\tFAILED\t%s\n", step.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\tOK\t%s\n", step.name, details)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed. Download concourse-up again before deploying with it", failed, len(steps))
+	}
+	return nil
+}
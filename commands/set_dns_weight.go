@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var setDNSWeightArgs config.SetDNSWeightArgs
+
+var setDNSWeightFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &setDNSWeightArgs.AWSRegion,
+	},
+	cli.IntFlag{
+		Name:        "weight",
+		Usage:       "Weight of the deployment's Route53 record, from 0 to 255. Route53 sends each deployment its weight's share of the traffic",
+		EnvVar:      "DNS_WEIGHT",
+		Destination: &setDNSWeightArgs.Weight,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &setDNSWeightArgs.IAAS,
+	},
+}
+
+var setDNSWeight = cli.Command{
+	Name:      "set-dns-weight",
+	Usage:     "Changes the weight of a deployment's Route53 record, for a blue/green cutover",
+	ArgsUsage: "<name>",
+	Flags:     setDNSWeightFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up set-dns-weight --weight <weight> <name>`")
+		}
+
+		setDNSWeightArgs.WeightIsSet = c.IsSet("weight")
+		if err := setDNSWeightArgs.Validate(); err != nil {
+			return err
+		}
+
+		region, err := deploymentRegion(c, setDNSWeightArgs.IAAS, setDNSWeightArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		setDNSWeightArgs.AWSRegion = region
+
+		iaasClient, err := iaas.New(setDNSWeightArgs.IAAS, setDNSWeightArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			iaasClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(iaasClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		return client.SetDNSWeight(setDNSWeightArgs.Weight)
+	},
+}
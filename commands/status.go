@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/certs"
+	"github.com/EngineerBetter/concourse-up/concourse"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
+	"github.com/EngineerBetter/concourse-up/terraform"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var statusArgs config.StatusArgs
+
+var statusFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       existingRegionUsage,
+		EnvVar:      "AWS_REGION",
+		Destination: &statusArgs.AWSRegion,
+	},
+	cli.BoolFlag{
+		Name:        "json",
+		Usage:       "(optional) Output as json",
+		EnvVar:      "JSON",
+		Destination: &statusArgs.JSON,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(optional) IAAS, can be AWS or GCP",
+		EnvVar:      "IAAS",
+		Value:       "AWS",
+		Hidden:      true,
+		Destination: &statusArgs.IAAS,
+	},
+}
+
+var status = cli.Command{
+	Name:      "status",
+	Usage:     "Reports whether a Concourse is reachable and its workers are running",
+	ArgsUsage: "<name>",
+	Flags:     statusFlags,
+	Action: func(c *cli.Context) error {
+		name := c.Args().Get(0)
+		if name == "" {
+			return errors.New("Usage is `concourse-up status <name>`")
+		}
+
+		region, err := deploymentRegion(c, statusArgs.IAAS, statusArgs.AWSRegion, name)
+		if err != nil {
+			return err
+		}
+		statusArgs.AWSRegion = region
+
+		awsClient, err := iaas.New(statusArgs.IAAS, statusArgs.AWSRegion)
+		if err != nil {
+			return err
+		}
+
+		client := concourse.NewClient(
+			awsClient,
+			terraform.NewClient,
+			bosh.NewClient,
+			fly.New,
+			certs.Generate,
+			certs.ObtainFromACME,
+			config.New(awsClient, name, configBucketName),
+			nil,
+			os.Stdout,
+			os.Stderr,
+		)
+
+		deploymentStatus, err := client.Status()
+		if err != nil {
+			return err
+		}
+
+		if statusArgs.JSON {
+			err = json.NewEncoder(os.Stdout).Encode(deploymentStatus)
+		} else {
+			err = writeStatus(deploymentStatus)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Exiting non-zero lets scripts and monitoring check the status without parsing it
+		if !deploymentStatus.Healthy() {
+			return fmt.Errorf("%s is not healthy", name)
+		}
+		return nil
+	},
+}
+
+func writeStatus(status *concourse.Status) error {
+	reachable := "no"
+	if status.Reachable {
+		reachable = "yes"
+	}
+
+	workers := "unknown, the director could not be reached"
+	if status.DirectorReachable {
+		workers = fmt.Sprintf("%d of %d running", status.RunningWorkers, status.Workers)
+	}
+
+	directorVersion := status.DirectorVersion
+	if directorVersion == "" {
+		directorVersion = "unknown"
+	}
+
+	lastDeployed := "unknown"
+	if status.LastDeployedAt != nil {
+		lastDeployed = fmt.Sprintf("%s by %s", status.LastDeployedAt.Format("2006-01-02 15:04:05 MST"), status.LastDeployedBy)
+	}
+
+	if status.Description != "" {
+		fmt.Fprintf(os.Stdout, "%s\n\n", status.Description)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Reachable:\t%s (%s)\n", reachable, status.URL)
+	fmt.Fprintf(w, "Workers:\t%s\n", workers)
+	if status.Profile != "" {
+		fmt.Fprintf(w, "Profile:\t%s\n", status.Profile)
+	}
+	fmt.Fprintf(w, "Concourse cert expires in:\t%s\n", expiryDays(status.ConcourseCertExpiresInDays))
+	fmt.Fprintf(w, "Director cert expires in:\t%s\n", expiryDays(status.DirectorCertExpiresInDays))
+	fmt.Fprintf(w, "Director version:\t%s\n", directorVersion)
+	fmt.Fprintf(w, "Last deployed:\t%s\n", lastDeployed)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if status.Notes != "" {
+		_, err := fmt.Fprintf(os.Stdout, "\nNotes:\n\t%s\n", strings.Replace(status.Notes, "\n", "\n\t", -1))
+		return err
+	}
+	return nil
+}
+
+func expiryDays(days *int) string {
+	switch {
+	case days == nil:
+		return "unknown"
+	case *days < 0:
+		return "expired"
+	default:
+		return strconv.Itoa(*days) + " days"
+	}
+}
@@ -0,0 +1,96 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/fly"
+)
+
+// AdoptDNS moves a deployment that is reached by its IP address onto the domain in the deploy args
+func (client *Client) AdoptDNS() error {
+	start := time.Now()
+	err := client.adoptDNS()
+	client.recordEvent("adopt-dns", fmt.Sprintf("--domain %s", client.deployArgs.Domain), start, err)
+	return err
+}
+
+// adoptDNS adds the domain's Route53 record, reissues the Concourse certificate for the
+// domain and redeploys. Only the web node depends on the domain, so it's the only VM BOSH rolls
+func (client *Client) adoptDNS() error {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if config.StandbyOf != "" {
+		return errors.New("cannot adopt a domain for a standby. Promote it first")
+	}
+	if config.HostedZoneID != "" {
+		return fmt.Errorf("the deployment already uses the domain %s. Use deploy --domain to change it", config.Domain)
+	}
+
+	if err = client.setHostedZone(config); err != nil {
+		return err
+	}
+
+	metadata, err := client.applyTerraform(config)
+	if err != nil {
+		return err
+	}
+
+	config, err = client.ensureConcourseCerts(true, config, metadata)
+	if err != nil {
+		return err
+	}
+	config, err = client.ensureBrandingAssets(config)
+	if err != nil {
+		return err
+	}
+	if err = client.configClient.Update(config); err != nil {
+		return err
+	}
+
+	if err = client.deployBosh(config, metadata, false, false); err != nil {
+		return err
+	}
+	if err = client.configClient.Update(config); err != nil {
+		return err
+	}
+
+	// The new record may not have propagated yet, so Concourse is reached by its IP address
+	address, err := config.ATCAddress(metadata.ATCPublicIP.Value)
+	if err != nil {
+		return err
+	}
+	flyClient, err := client.flyClientFactory(fly.Credentials{
+		Target:   config.Deployment,
+		API:      fmt.Sprintf("https://%s:%d", address, config.ConcoursePort()),
+		Username: config.ConcourseUsername,
+		Password: config.ConcoursePassword,
+		Proxy:    deploymentProxy(config),
+	},
+		client.stdout,
+		client.stderr,
+	)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+
+	// The self-update pipeline redeploys with the flags it was set with, so it needs
+	// the domain or it would move the deployment back to its IP address
+	pipelineArgs := *client.deployArgs
+	pipelineArgs.AWSRegion = config.Region
+	pipelineArgs.WorkerCount = config.ConcourseWorkerCount
+	pipelineArgs.WorkerSize = config.ConcourseWorkerSize
+	pipelineArgs.WebSize = config.ConcourseWebSize
+	if !config.DefaultPipelineDisabled {
+		if err = flyClient.SetDefaultPipeline(&pipelineArgs, config, false); err != nil {
+			return err
+		}
+	}
+
+	return writeDeploySuccessMessage(config, metadata, client.stdout)
+}
@@ -0,0 +1,121 @@
+package concourse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/bundle"
+	"github.com/EngineerBetter/concourse-up/config"
+)
+
+// bundleConfigFilename is the name the config is kept under in bundles
+const bundleConfigFilename = "config.json"
+
+// ExportBundle writes the deployment's config and the assets kept alongside it
+// to w as an encrypted bundle
+func (client *Client) ExportBundle(w io.Writer, passphrase string) error {
+	start := time.Now()
+	err := client.exportBundle(w, passphrase)
+	client.recordEvent("export-bundle", "", start, err)
+	return err
+}
+
+func (client *Client) exportBundle(w io.Writer, passphrase string) error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	configBytes, err := json.Marshal(conf)
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{bundleConfigFilename: configBytes}
+	for _, filename := range bundleAssets(conf) {
+		hasAsset, err := client.configClient.HasAsset(filename)
+		if err != nil {
+			return err
+		}
+		if !hasAsset {
+			continue
+		}
+
+		contents, err := client.configClient.LoadAsset(filename)
+		if err != nil {
+			return err
+		}
+		files[filename] = contents
+	}
+
+	return bundle.Write(w, passphrase, files)
+}
+
+// ImportBundle stores the contents of a bundle written by ExportBundle in the
+// config bucket, so that the deployment can be managed from there
+func (client *Client) ImportBundle(r io.Reader, passphrase string) error {
+	files, err := bundle.Read(r, passphrase)
+	if err != nil {
+		return err
+	}
+
+	configBytes, ok := files[bundleConfigFilename]
+	if !ok {
+		return fmt.Errorf("the bundle has no %s", bundleConfigFilename)
+	}
+
+	conf, err := config.Parse(configBytes)
+	if err != nil {
+		return err
+	}
+
+	if conf.Region != client.iaasClient.Region() {
+		return fmt.Errorf("the bundle is for a deployment in %s. Use --region %s to import it", conf.Region, conf.Region)
+	}
+
+	// Everything is checked before anything is written, so that a bad bundle leaves the config bucket as it was
+	assets := map[string][]byte{}
+	for _, filename := range bundleAssets(conf) {
+		if contents, ok := files[filename]; ok {
+			assets[filename] = contents
+		}
+	}
+	for filename := range files {
+		if _, ok := assets[filename]; !ok && filename != bundleConfigFilename {
+			return fmt.Errorf("the bundle has %s, which isn't part of the deployment's config", filename)
+		}
+	}
+	for _, filename := range []string{conf.BrandingWordmarkAsset, conf.BrandingCSSAsset} {
+		if _, ok := assets[filename]; filename != "" && !ok {
+			return fmt.Errorf("the bundle has no %s, which the deployment's config refers to", filename)
+		}
+	}
+
+	start := time.Now()
+	if err = client.configClient.Import(conf, assets); err != nil {
+		return err
+	}
+
+	client.recordEvent("import-bundle", "", start, nil)
+	return nil
+}
+
+// bundleAssets are the files kept in the config bucket alongside the config
+func bundleAssets(conf *config.Config) []string {
+	assets := []string{
+		conf.TFStatePath,
+		bosh.StateFilename,
+		bosh.CredsFilename,
+		EventsFilename,
+	}
+	if conf.BrandingWordmarkAsset != "" {
+		assets = append(assets, conf.BrandingWordmarkAsset)
+	}
+	if conf.BrandingCSSAsset != "" {
+		assets = append(assets, conf.BrandingCSSAsset)
+	}
+	return assets
+}
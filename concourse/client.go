@@ -2,14 +2,17 @@ package concourse
 
 import (
 	"io"
+	"time"
 
 	"github.com/EngineerBetter/concourse-up/bosh"
 	"github.com/EngineerBetter/concourse-up/certs"
 	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/credhub"
 	"github.com/EngineerBetter/concourse-up/director"
 	"github.com/EngineerBetter/concourse-up/fly"
 	"github.com/EngineerBetter/concourse-up/iaas"
 	"github.com/EngineerBetter/concourse-up/terraform"
+	"github.com/EngineerBetter/concourse-up/util"
 )
 
 // Client is a concrete implementation of IClient interface
@@ -18,18 +21,40 @@ type Client struct {
 	terraformClientFactory terraform.ClientFactory
 	boshClientFactory      bosh.ClientFactory
 	flyClientFactory       func(fly.Credentials, io.Writer, io.Writer) (fly.IClient, error)
+	credhubClientFactory   credhub.ClientFactory
 	certGenerator          func(caName string, ip ...string) (*certs.Certs, error)
+	acmeCertGenerator      func(hostedZoneID string, domains ...string) (*certs.Certs, error)
 	configClient           config.IClient
 	deployArgs             *config.DeployArgs
 	stdout                 io.Writer
 	stderr                 io.Writer
+	primaryIAASClient      iaas.IClient
+	primaryConfigClient    config.IClient
 }
 
 // IClient represents a concourse-up client
 type IClient interface {
 	Deploy() error
-	Destroy() error
+	Destroy(dryRun bool) error
 	FetchInfo() (*Info, error)
+	PruneWorkers(states []string) error
+	FetchEvents() ([]Event, error)
+	ExportBundle(w io.Writer, passphrase string) error
+	ImportBundle(r io.Reader, passphrase string) error
+	ExportCreds() (*Creds, error)
+	GenerateManifest() ([]byte, error)
+	CheckUpgrade() (*UpgradeReport, error)
+	CheckRunningVersions() (*UpgradeReport, error)
+	Status() (*Status, error)
+	AdoptDNS() error
+	RunErrand(name string) error
+	Drain(timeout time.Duration) error
+	Resume() error
+	SetDNSWeight(weight int) error
+	RenewCerts(tlsCert, tlsKey string) error
+	ScaleWorkers(count int, size string) error
+	Restore(snapshotID string) error
+	Rollback() error
 }
 
 // NewClient returns a new Client
@@ -39,6 +64,7 @@ func NewClient(
 	boshClientFactory bosh.ClientFactory,
 	flyClientFactory func(fly.Credentials, io.Writer, io.Writer) (fly.IClient, error),
 	certGenerator func(caName string, ip ...string) (*certs.Certs, error),
+	acmeCertGenerator func(hostedZoneID string, domains ...string) (*certs.Certs, error),
 	configClient config.IClient,
 	deployArgs *config.DeployArgs,
 	stdout, stderr io.Writer) *Client {
@@ -47,20 +73,29 @@ func NewClient(
 		terraformClientFactory: terraformClientFactory,
 		boshClientFactory:      boshClientFactory,
 		flyClientFactory:       flyClientFactory,
+		credhubClientFactory:   credhub.New,
 		configClient:           configClient,
 		certGenerator:          certGenerator,
+		acmeCertGenerator:      acmeCertGenerator,
 		deployArgs:             deployArgs,
 		stdout:                 stdout,
 		stderr:                 stderr,
 	}
 }
 
+// SetPrimary sets the clients for the primary deployment that a standby is deployed from
+func (client *Client) SetPrimary(iaasClient iaas.IClient, configClient config.IClient) {
+	client.primaryIAASClient = iaasClient
+	client.primaryConfigClient = configClient
+}
+
 func (client *Client) buildBoshClient(config *config.Config, metadata *terraform.Metadata) (bosh.IClient, error) {
 	director, err := director.NewClient(director.Credentials{
 		Username: config.DirectorUsername,
 		Password: config.DirectorPassword,
 		Host:     metadata.DirectorPublicIP.Value,
 		CACert:   config.DirectorCACert,
+		Proxy:    deploymentProxy(config),
 	})
 	if err != nil {
 		return nil, err
@@ -74,3 +109,12 @@ func (client *Client) buildBoshClient(config *config.Config, metadata *terraform
 		client.stderr,
 	)
 }
+
+// deploymentProxy returns the proxy to connect to the deployment through: the one given to this run
+// of concourse-up, or otherwise the one the deployment was last deployed with
+func deploymentProxy(config *config.Config) util.Proxy {
+	if util.GlobalProxy.IsSet() {
+		return util.GlobalProxy
+	}
+	return config.Proxy()
+}
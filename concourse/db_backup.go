@@ -0,0 +1,35 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+)
+
+// backupDB snapshots the RDS instance, so that the database can be restored if
+// a Concourse upgrade's migrations fail part way through
+func (client *Client) backupDB(config *config.Config, metadata *terraform.Metadata) error {
+	if metadata.RDSARN.Value == "" {
+		return errors.New("cannot back up the database as Terraform did not output the ARN of the RDS instance")
+	}
+
+	snapshotID := dbSnapshotID(config.Deployment, time.Now())
+	if _, err := fmt.Fprintf(client.stdout, "\nSNAPSHOTTING RDS INSTANCE TO %s\n\n", snapshotID); err != nil {
+		return err
+	}
+
+	if err := client.iaasClient.CreateDBSnapshot(metadata.RDSARN.Value, snapshotID); err != nil {
+		return fmt.Errorf("could not snapshot the RDS instance, so BOSH was not deployed: %s", err)
+	}
+
+	_, err := fmt.Fprintf(client.stdout, "Created RDS snapshot %s\n", snapshotID)
+	return err
+}
+
+// dbSnapshotID names a snapshot of the deployment's RDS instance taken at t
+func dbSnapshotID(deployment string, t time.Time) string {
+	return fmt.Sprintf("%s-%s", deployment, t.UTC().Format("20060102-150405"))
+}
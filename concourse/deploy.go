@@ -2,9 +2,12 @@ package concourse
 
 import (
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"text/template"
 	"time"
 
@@ -14,6 +17,8 @@ import (
 
 	"github.com/EngineerBetter/concourse-up/bosh"
 	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/credhub"
+	"github.com/EngineerBetter/concourse-up/db"
 	"github.com/EngineerBetter/concourse-up/fly"
 	"github.com/EngineerBetter/concourse-up/terraform"
 	"github.com/EngineerBetter/concourse-up/util"
@@ -21,71 +26,319 @@ import (
 
 // Deploy deploys a concourse instance
 func (client *Client) Deploy() error {
+	// A dry run changes nothing, so there's nothing to record in the audit trail
+	if client.deployArgs.DryRun {
+		return client.deploy()
+	}
+
+	start := time.Now()
+	err := client.deploy()
+	client.recordEvent(deployCommand(client.deployArgs), deployArgsSummary(client.deployArgs), start, err)
+	return err
+}
+
+func (client *Client) deploy() error {
 	config, err := client.loadConfig()
 	if err != nil {
 		return err
 	}
 
+	if client.deployArgs.StandbyOf != "" {
+		return client.deployStandby(config)
+	}
+	if err = client.checkStandbyPromotion(config); err != nil {
+		return err
+	}
+	if client.deployArgs.SelfUpdate {
+		deferred, deferErr := client.deferSelfUpdate(config)
+		if deferErr != nil || deferred {
+			return deferErr
+		}
+	}
+	// The config is stamped before anything is written, so rollback can tell which versions the deploy replaced
+	if !client.deployArgs.DryRun {
+		client.recordDeployer(config)
+	}
+
 	isDomainUpdated := client.deployArgs.Domain != config.Domain
+	previousDBInstanceClass := config.RDSInstanceClass
+	// A config that has never been deployed has no URL to check a self-update against
+	var deployedURL string
+	if config.Domain != "" {
+		deployedURL = config.ConcourseURL()
+	}
+	previousWorkerCount := config.ConcourseWorkerCount
+	previousPassword := config.ConcoursePassword
+	globalResourcesEnabled := config.EnableGlobalResources
 
 	config, err = client.checkPreTerraformConfigRequirements(config)
 	if err != nil {
 		return err
 	}
+	if err = client.warnIfMetricsLeaveGrafana(); err != nil {
+		return err
+	}
 
 	metadata, err := client.applyTerraform(config)
 	if err != nil {
 		return err
 	}
+	if client.deployArgs.DryRun {
+		_, err = client.stdout.Write([]byte("\nDRY RUN. Terraform's plan is above. Nothing was changed and BOSH was not deployed\n"))
+		return err
+	}
+	if client.deployArgs.BackupDB {
+		if err = client.backupDB(config, metadata); err != nil {
+			return err
+		}
+	}
+	if err = client.warnIfDBChangeDeferred(config, metadata, previousDBInstanceClass); err != nil {
+		return err
+	}
+	if err = client.checkInstanceProfiles(metadata); err != nil {
+		return err
+	}
 	config, err = client.checkPreDeployConfigRequiments(isDomainUpdated, config, metadata)
 	if err != nil {
 		return err
 	}
+	if err = client.warnIfDirectorCertExpiring(config); err != nil {
+		return err
+	}
 
-	flyClient, err := client.flyClientFactory(fly.Credentials{
-		Target:   config.Deployment,
-		API:      fmt.Sprintf("https://%s", config.Domain),
-		Username: config.ConcourseUsername,
-		Password: config.ConcoursePassword,
-	},
-		client.stdout,
-		client.stderr,
-	)
+	// The running Concourse still has the previous admin password until BOSH has deployed a new one
+	preDeployFlyClient, err := client.buildFlyClientWithPassword(config, previousPassword)
 	if err != nil {
 		return err
 	}
-	defer flyClient.Cleanup()
+	defer preDeployFlyClient.Cleanup()
+
+	// Self-updates aren't given the flag, so they keep the setting from the last manual deploy
+	if !client.deployArgs.SelfUpdate {
+		if client.deployArgs.EnableGlobalResources && !globalResourcesEnabled {
+			if err = client.confirmGlobalResources(preDeployFlyClient); err != nil {
+				return err
+			}
+		}
+		config.EnableGlobalResources = client.deployArgs.EnableGlobalResources
+	}
 
 	if client.deployArgs.SelfUpdate {
-		err = client.updateBoshAndPipeline(config, metadata, flyClient)
+		err = client.updateBoshAndPipeline(config, metadata, preDeployFlyClient, deployedURL)
 	} else {
-		err = client.deployBoshAndPipeline(config, metadata, flyClient)
+		err = client.deployBoshAndSecrets(config, metadata, preDeployFlyClient, previousWorkerCount)
 	}
 	if err != nil {
 		return err
 	}
-	return client.configClient.Update(config)
+
+	config.DeployedVersions = deployedVersions(config)
+	if err = client.configClient.Update(config); err != nil {
+		return err
+	}
+
+	flyClient, err := client.buildFlyClient(config)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+
+	// A self-update sets its pipeline before BOSH deploys, and hasn't the files of any others
+	if !client.deployArgs.SelfUpdate {
+		if err = client.setPipelines(config, flyClient); err != nil {
+			return err
+		}
+	}
+
+	if client.deployArgs.WaitForWorkers > 0 {
+		timeout := time.Duration(client.deployArgs.WaitForWorkersTimeout) * time.Minute
+		if err = flyClient.WaitForWorkers(client.deployArgs.WaitForWorkers, timeout); err != nil {
+			return err
+		}
+	}
+
+	// Self-updates detach before BOSH has finished deploying, so there's nothing yet to run errands against
+	if client.deployArgs.SelfUpdate {
+		return nil
+	}
+	if err = client.runErrands(config, metadata, config.PostDeployErrands); err != nil {
+		return err
+	}
+
+	return client.reportDeploySuccess(config, metadata)
 }
 
-func (client *Client) deployBoshAndPipeline(config *config.Config, metadata *terraform.Metadata, flyClient fly.IClient) error {
+func (client *Client) deployBoshAndSecrets(config *config.Config, metadata *terraform.Metadata, flyClient fly.IClient, previousWorkerCount int) error {
+	if err := client.retireSurplusWorkers(previousWorkerCount, config, metadata, flyClient); err != nil {
+		return err
+	}
+
 	// When we are deploying for the first time rather than updating
 	// ensure that the pipeline is set _after_ the concourse is deployed
-	if err := client.deployBosh(config, metadata, false); err != nil {
+	if err := client.deployBosh(config, metadata, false, client.deployArgs.ForceDirectorRebuild); err != nil {
 		return err
 	}
 
-	if err := flyClient.SetDefaultPipeline(client.deployArgs, config, false); err != nil {
-		return err
+	// Secrets are seeded before the pipelines are set, so that their first builds can use them
+	if client.deployArgs.CredhubSeedFile != "" {
+		if err := client.seedCredhub(config); err != nil {
+			return err
+		}
+	}
+
+	return client.dropPipelinesWithoutContents(config)
+}
+
+// setPipelines sets the default pipeline and any extra ones once BOSH has deployed Concourse. Passing
+// --no-pipeline destroys a default pipeline set by earlier deploys, so that it can't update the deployment
+func (client *Client) setPipelines(config *config.Config, flyClient fly.IClient) error {
+	if !config.DefaultPipelineDisabled {
+		if err := client.setDefaultPipeline(config, flyClient); err != nil {
+			return err
+		}
+	} else if client.deployArgs.NoDefaultPipelineIsSet {
+		if err := flyClient.DestroyDefaultPipeline(); err != nil {
+			return err
+		}
+	}
+
+	if len(config.ExtraPipelines) > 0 {
+		if err := flyClient.SetPipelines(config.ExtraPipelines); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropPipelinesWithoutContents stops setting pipelines stored by older versions, which only kept the paths of
+// their files, as those files may not be there for this deploy
+func (client *Client) dropPipelinesWithoutContents(config *config.Config) error {
+	pipelines := config.ExtraPipelines[:0]
+	for _, pipeline := range config.ExtraPipelines {
+		if pipeline.Config != "" {
+			pipelines = append(pipelines, pipeline)
+			continue
+		}
+		if _, err := client.stderr.Write([]byte(fmt.Sprintf("\nWARNING: pipeline %s/%s is no longer set, as its contents weren't stored. Pass --pipeline to set it again\n\n", pipeline.Team, pipeline.Name))); err != nil {
+			return err
+		}
+	}
+	config.ExtraPipelines = pipelines
+	return nil
+}
+
+// reportDeploySuccess is only called once the workers are up and the errands have passed,
+// so a deploy is never reported as successful and then fail
+func (client *Client) reportDeploySuccess(config *config.Config, metadata *terraform.Metadata) error {
+	if client.deployArgs.JSONOutput {
+		return writeDeploySuccessJSON(config, client.stdout)
 	}
 
 	if err := writeDeploySuccessMessage(config, metadata, client.stdout); err != nil {
 		return err
 	}
 
+	if client.deployArgs.RecreateWorkers {
+		if _, err := client.stdout.Write([]byte("\nThe workers were recreated on fresh VMs\n")); err != nil {
+			return err
+		}
+	}
+
+	if client.deployArgs.ForceDirectorRebuild {
+		if _, err := client.stdout.Write([]byte("\nThe BOSH director was rebuilt from scratch. Delete the old director's persistent disk once you no longer need it\n")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pipelineRetryBackoff is the wait before the first retry of setting the default pipeline.
+// It doubles with each retry up to maxPipelineRetryBackoff
+var pipelineRetryBackoff = 5 * time.Second
+
+const maxPipelineRetryBackoff = time.Minute
+
+// setDefaultPipeline retries setting the default pipeline, as a freshly deployed ATC
+// can briefly fail requests while it finishes starting up
+func (client *Client) setDefaultPipeline(config *config.Config, flyClient fly.IClient) error {
+	backoff := pipelineRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := flyClient.SetDefaultPipeline(client.deployArgs, config, false)
+		if err == nil || attempt >= client.deployArgs.PipelineRetries {
+			return err
+		}
+
+		warning := fmt.Sprintf("WARNING: failed to set the default pipeline, retrying in %s: %s\n", backoff, err)
+		if _, err = client.stderr.Write([]byte(warning)); err != nil {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxPipelineRetryBackoff {
+			backoff = maxPipelineRetryBackoff
+		}
+	}
+}
+
+// credhubRetryBackoff is the wait between attempts to seed CredHub, which may still be
+// starting on a fresh deploy. credhubSeedRetries bounds the number of retries
+var credhubRetryBackoff = 10 * time.Second
+
+const credhubSeedRetries = 5
+
+// seedCredhub sets the secrets in deployArgs.CredhubSeedFile in the deployment's CredHub, under each team's path
+func (client *Client) seedCredhub(config *config.Config) error {
+	secrets, err := credhub.ReadSecretsFile(client.deployArgs.CredhubSeedFile)
+	if err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintf(client.stdout, "\nSEEDING %d SECRETS IN CREDHUB\n\n", len(secrets)); err != nil {
+		return err
+	}
+
+	credhubClient, err := client.credhubClientFactory(credhub.Credentials{
+		URL:          config.CredhubURL,
+		Client:       "credhub_cli",
+		ClientSecret: config.CredhubPassword,
+		CACert:       config.CredhubCACert,
+	})
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = credhubClient.SetSecrets(secrets)
+		if err == nil || attempt >= credhubSeedRetries {
+			break
+		}
+
+		warning := fmt.Sprintf("WARNING: failed to seed CredHub, retrying in %s: %s\n", credhubRetryBackoff, err)
+		if _, err = client.stderr.Write([]byte(warning)); err != nil {
+			return err
+		}
+		time.Sleep(credhubRetryBackoff)
+	}
+	if err != nil {
+		return fmt.Errorf("could not seed CredHub after %d attempts: %s", credhubSeedRetries+1, err)
+	}
+
+	for _, secret := range secrets {
+		if _, err = fmt.Fprintf(client.stdout, "Set %s\n", secret.Path()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (client *Client) updateBoshAndPipeline(config *config.Config, metadata *terraform.Metadata, flyClient fly.IClient) error {
+func (client *Client) updateBoshAndPipeline(config *config.Config, metadata *terraform.Metadata, flyClient fly.IClient, deployedURL string) (err error) {
+	if err := checkSelfUpdateTarget(config, flyClient, deployedURL); err != nil {
+		return err
+	}
+
 	// If concourse is already running this is an update rather than a fresh deploy
 	// When updating we need to deploy the BOSH as the final step in order to
 	// Detach from the update, so the update job can exit
@@ -94,57 +347,1291 @@ func (client *Client) updateBoshAndPipeline(config *config.Config, metadata *ter
 		return err
 	}
 
-	if !concourseAlreadyRunning {
-		return fmt.Errorf("In detach mode but it seems that concourse is not currently running")
+	if !concourseAlreadyRunning {
+		return fmt.Errorf("In detach mode but it seems that concourse is not currently running at %s", config.ConcourseURL())
+	}
+
+	// Allow a fly version discrepancy since we might be targetting an older Concourse. A deployment
+	// deployed with --no-pipeline has no pipeline to update
+	if !config.DefaultPipelineDisabled {
+		if err = flyClient.SetDefaultPipeline(client.deployArgs, config, true); err != nil {
+			return err
+		}
+	}
+
+	if err = client.waitForBuilds(config, flyClient); err != nil {
+		return err
+	}
+
+	if err = client.deployBosh(config, metadata, true, false); err != nil {
+		return err
+	}
+
+	if client.deployArgs.WaitForDetach {
+		// The deploy lifted protection from the web VM for BOSH. It's restored once the wait is over, even when
+		// the deploy failed or timed out, so the VM isn't left unprotected
+		if config.TerminationProtection {
+			defer func() {
+				if protectErr := client.setTerminationProtection(metadata, true); err == nil {
+					err = protectErr
+				}
+			}()
+		}
+		if err = client.waitForDetachedDeploy(config, metadata); err != nil {
+			return err
+		}
+		// BOSH is done with the web node now, so the AAAA record can follow it to its new address
+		return client.refreshWebIPv6(config, metadata)
+	}
+
+	_, err = client.stdout.Write([]byte("\nUPGRADE RUNNING IN BACKGROUND\n\n"))
+
+	return err
+}
+
+// checkSelfUpdateTarget stops a self-update whose fly client targets another Concourse than the one
+// the deployment was at before this deploy, eg as the update was given another --domain, as the pipeline
+// it sets and the connection check it makes would then be for another environment. A self-update against
+// the wrong region has already been stopped before Terraform ran
+func checkSelfUpdateTarget(config *config.Config, flyClient fly.IClient, deployedURL string) error {
+	if deployedURL != "" && flyClient.URL() != deployedURL {
+		return fmt.Errorf("refusing to self-update: %s is deployed at %s, but fly is targeting %s", config.Deployment, deployedURL, flyClient.URL())
+	}
+	return nil
+}
+
+// waitForDetachedDeploy follows the BOSH deploy a self-update detached from until it finishes,
+// failing unless it succeeds within the detach timeout
+func (client *Client) waitForDetachedDeploy(config *config.Config, metadata *terraform.Metadata) error {
+	if _, err := fmt.Fprintf(client.stdout, "\nWAITING UP TO %d MINUTES FOR THE UPGRADE TO FINISH\n", client.deployArgs.DetachTimeout); err != nil {
+		return err
+	}
+
+	boshClient, err := client.buildBoshClient(config, metadata)
+	if err != nil {
+		return err
+	}
+	defer boshClient.Cleanup()
+
+	task, err := boshClient.WaitForDeployTask(time.Duration(client.deployArgs.DetachTimeout) * time.Minute)
+	if err != nil {
+		return err
+	}
+	if task.State != "done" {
+		return fmt.Errorf("the upgrade's BOSH task %s finished with state %s. Run `bosh task %s` against the director for details", task.ID, task.State, task.ID)
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nUPGRADE FINISHED\n\n")
+	return err
+}
+
+// recordDeployer stamps the config with the time of the deploy and who ran it, which is the
+// operator given or, like the audit trail, the caller's AWS identity. As with the audit trail,
+// not knowing who is deploying is warned about rather than stopping the deploy
+func (client *Client) recordDeployer(config *config.Config) {
+	operator := client.deployArgs.Operator
+	if operator == "" {
+		identity, err := client.iaasClient.CallerIdentity()
+		if err != nil {
+			fmt.Fprintf(client.stderr, "WARNING: could not find out who is deploying: %s. Pass --operator to name them\n", err)
+			identity = "unknown"
+		}
+		operator = identity
+	}
+
+	config.LastDeployedAt = time.Now().UTC()
+	config.LastDeployedBy = operator
+}
+
+// waitForBuilds gives running builds up to the deployment's drain timeout to finish before a self-update
+// restarts the VMs under them. The update goes ahead if they don't, naming the builds it will interrupt.
+// Workers aren't landed, as the update only restarts the workers it changes and the rest would stay landed
+func (client *Client) waitForBuilds(config *config.Config, flyClient fly.IClient) error {
+	if config.UpdateDrainTimeout == 0 {
+		return nil
+	}
+
+	interrupted, err := flyClient.WaitForBuilds(time.Duration(config.UpdateDrainTimeout) * time.Minute)
+	if err != nil {
+		return err
+	}
+	if len(interrupted) == 0 {
+		return nil
+	}
+
+	if _, err = fmt.Fprintf(client.stderr, "WARNING: %d builds did not finish within %d minutes and may be interrupted by the update:\n", len(interrupted), config.UpdateDrainTimeout); err != nil {
+		return err
+	}
+	for _, build := range interrupted {
+		if _, err = fmt.Fprintf(client.stderr, "  %s\n", build); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirmGlobalResources guards against enabling global resources under running pipelines
+// without the user's agreement, as it changes how checks are run across the whole cluster
+func (client *Client) confirmGlobalResources(flyClient fly.IClient) error {
+	concourseAlreadyRunning, err := flyClient.CanConnect()
+	if err != nil {
+		return err
+	}
+	if !concourseAlreadyRunning {
+		return nil
+	}
+
+	pipelines, err := flyClient.ActivePipelines()
+	if err != nil {
+		return err
+	}
+	if len(pipelines) == 0 {
+		return nil
+	}
+
+	if !client.deployArgs.Confirm {
+		return fmt.Errorf("enabling global resources changes how resource checks and versions are shared by all %d running pipelines. Re-run with --confirm to enable it anyway", len(pipelines))
+	}
+
+	_, err = client.stderr.Write([]byte(fmt.Sprintf("WARNING: enabling global resources for %d running pipelines. Resource versions will be shared between pipelines with the same resource config\n", len(pipelines))))
+	return err
+}
+
+// retireSurplusWorkers drains the workers that BOSH is about to delete when scaling down,
+// so that their running builds are not killed
+func (client *Client) retireSurplusWorkers(previousWorkerCount int, config *config.Config, metadata *terraform.Metadata, flyClient fly.IClient) error {
+	surplus := previousWorkerCount - config.ConcourseWorkerCount
+	if surplus <= 0 || client.deployArgs.WorkerDrainTimeout == 0 {
+		return nil
+	}
+
+	concourseAlreadyRunning, err := flyClient.CanConnect()
+	if err != nil {
+		return err
+	}
+	if !concourseAlreadyRunning {
+		return nil
+	}
+
+	boshClient, err := client.buildBoshClient(config, metadata)
+	if err != nil {
+		return err
+	}
+	defer boshClient.Cleanup()
+
+	instances, err := boshClient.Instances()
+	if err != nil {
+		return err
+	}
+
+	workers := []bosh.Instance{}
+	for _, instance := range instances {
+		if strings.HasPrefix(instance.Name, "worker/") {
+			workers = append(workers, instance)
+		}
+	}
+
+	// BOSH removes the instances with the highest indices when scaling down
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].Index > workers[j].Index
+	})
+	if surplus > len(workers) {
+		surplus = len(workers)
+	}
+
+	names := []string{}
+	for _, worker := range workers[:surplus] {
+		names = append(names, strings.TrimPrefix(worker.Name, "worker/"))
+	}
+
+	_, err = client.stdout.Write([]byte(fmt.Sprintf("\nRETIRING %d WORKERS BEFORE SCALING DOWN\n", len(names))))
+	if err != nil {
+		return err
+	}
+
+	return flyClient.RetireWorkers(names, time.Duration(client.deployArgs.WorkerDrainTimeout)*time.Minute)
+}
+
+func (client *Client) checkPreTerraformConfigRequirements(conf *config.Config) (*config.Config, error) {
+	region := client.deployArgs.AWSRegion
+
+	if conf.Region != "" {
+		if conf.Region != region {
+			return nil, fmt.Errorf("found previous deployment in %s. Refusing to deploy to %s as changing regions for existing deployments is not supported", conf.Region, region)
+		}
+	}
+
+	conf.Region = region
+
+	if err := client.setTerraformLockTable(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setVPCCIDR(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setExistingSubnets(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setPeeringConnections(conf); err != nil {
+		return nil, err
+	}
+
+	// Keep the profile unless one is given, so that self-updates don't reset it
+	if client.deployArgs.ProfileIsSet {
+		conf.Profile = client.deployArgs.Profile
+	}
+	if err := client.setExternalDB(conf); err != nil {
+		return nil, err
+	}
+	// An external database is sized and upgraded outside concourse-up, so the RDS settings only apply without one
+	if conf.ExternalDBURL == "" {
+		if err := client.setRDSInstance(conf); err != nil {
+			return nil, err
+		}
+	}
+	if err := client.setMaintenanceWindow(conf); err != nil {
+		return nil, err
+	}
+	// Keep the workers isolated unless asked otherwise, so that self-updates don't destroy their subnet
+	if client.deployArgs.IsolatedWorkersIsSet {
+		conf.IsolatedWorkers = client.deployArgs.IsolatedWorkers
+	}
+
+	// Keep IPv6 enabled unless asked otherwise, so that self-updates don't remove the VMs' IPv6 addresses
+	if client.deployArgs.EnableIPv6IsSet {
+		conf.EnableIPv6 = client.deployArgs.EnableIPv6
+	}
+
+	// Keep the existing TSA port unless one is given, so that self-updates don't move it
+	if client.deployArgs.TSAPortIsSet {
+		conf.TSAPort = client.deployArgs.TSAPort
+	} else if conf.TSAPort == 0 {
+		conf.TSAPort = config.DefaultTSAPort
+	}
+
+	// Keep the pool's size unless a new one is given, so that self-updates don't reset it
+	if client.deployArgs.DBMaxOpenConnectionsIsSet {
+		conf.ATCDBMaxOpenConnections = client.deployArgs.DBMaxOpenConnections
+	}
+	if client.deployArgs.DBMaxIdleConnectionsIsSet {
+		conf.ATCDBMaxIdleConnections = client.deployArgs.DBMaxIdleConnections
+	}
+	if client.deployArgs.DBConnectTimeoutIsSet {
+		conf.ATCDBConnectTimeout = durationSetting(client.deployArgs.DBConnectTimeout)
+	}
+	if client.deployArgs.ResurrectorIsSet {
+		conf.ResurrectorDisabled = !client.deployArgs.Resurrector
+	}
+	if conf.ATCDBMaxOpenConnections != 0 && conf.ATCDBMaxIdleConnections > conf.ATCDBMaxOpenConnections {
+		return nil, errors.New("--db-max-idle-connections cannot be greater than --db-max-open-connections")
+	}
+	// The stored pool is checked too, as a smaller --db-size may no longer fit it
+	if conf.ExternalDBURL == "" {
+		if err := checkATCConnectionPool(conf.ATCDBMaxOpenConnections, conf.RDSInstanceClass); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := client.setInstanceProfiles(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setBackupRegion(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setKMSKey(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setDirectorSize(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setNameServers(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.checkTenancy(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setPrivate(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setSpotWorkers(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setTLSSettings(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.checkBranding(); err != nil {
+		return nil, err
+	}
+
+	if err := client.setGrafanaPath(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setPorts(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setExperiments(conf); err != nil {
+		return nil, err
+	}
+
+	// Global resources are only enabled once fly can look for running pipelines, but a Concourse
+	// without them should fail before anything is changed
+	if client.deployArgs.EnableGlobalResources && !client.deployArgs.SelfUpdate {
+		if err := requireConcourseVersion("concourse-enable-global-resources", "5.0.0"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := client.setHijackSettings(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setResourceCheckSettings(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setDefaultStepTimeouts(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setWorkerAMI(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setStemcellVersion(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setVolumeStreaming(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setWorkerLimits(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setSecretCache(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setTags(conf); err != nil {
+		return nil, err
+	}
+
+	// Keep the existing variables unless a file is given, so that self-updates keep them
+	if client.deployArgs.TerraformVarsIsSet {
+		conf.TerraformVars = client.deployArgs.TerraformVars
+	}
+
+	// Keep the existing ranges unless new ones are given. Self-updates aren't given the flag, so they keep
+	// allowing the ranges that automation outside the VPC relies on
+	if client.deployArgs.AdditionalAllowedCIDRsIsSet {
+		conf.AdditionalAllowedCIDRs = client.deployArgs.AdditionalAllowedCIDRs
+	}
+
+	// When in self-update mode do not override the user IP, since we already have access to the worker
+	if !client.deployArgs.SelfUpdate {
+		if err := client.setUserIP(conf); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := client.setHostedZone(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setDNSWeight(conf); err != nil {
+		return nil, err
+	}
+
+	if err := client.setPublicIPv6(conf); err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// setPublicIPv6 sets whether the web node is reachable over IPv6, through an AAAA record in the hosted zone
+// alongside the A record. Once enabled it's kept for later deploys that don't pass the flag, including self-updates
+func (client *Client) setPublicIPv6(conf *config.Config) error {
+	if client.deployArgs.PublicIPv6IsSet {
+		conf.PublicIPv6 = client.deployArgs.PublicIPv6
+	}
+	if !conf.PublicIPv6 {
+		conf.ATCPublicIPv6 = ""
+		return nil
+	}
+
+	if !conf.EnableIPv6 {
+		return errors.New("--public-ipv6 needs the VPC to have IPv6 addresses. Deploy with --enable-ipv6 too")
+	}
+	if conf.Private {
+		return errors.New("--public-ipv6 cannot be used with --private, which has no public addresses")
+	}
+	if conf.HostedZoneID == "" {
+		return errors.New("--public-ipv6 needs a --domain in a Route 53 hosted zone, for the AAAA record that follows the web node's IPv6 address")
+	}
+	// --allow-ips only restricts IPv4, so IPv6 mustn't open up a web node it restricts
+	if conf.AllowIPs != `"0.0.0.0/0"` {
+		return errors.New("--public-ipv6 lets in every IPv6 address, so cannot be used with --allow-ips")
+	}
+
+	// A self-update that doesn't wait for BOSH can't follow the web node to a new address. Unless the web VM keeps
+	// its termination protection, which stops BOSH replacing it, the record is withdrawn until a deploy that waits
+	webProtected := conf.TerminationProtection
+	if client.deployArgs.TerminationProtectionIsSet {
+		webProtected = client.deployArgs.TerminationProtection
+	}
+	if client.deployArgs.SelfUpdate && !client.deployArgs.WaitForDetach && !webProtected && conf.ATCPublicIPv6 != "" {
+		if _, err := fmt.Fprintf(client.stderr, "\nWARNING: removing the AAAA record for %s, as BOSH may replace the web node after this self-update exits. Deploy without --self-update, or pass --detach-timeout, to add it back\n\n", conf.Domain); err != nil {
+			return err
+		}
+		conf.ATCPublicIPv6 = ""
+	}
+
+	return nil
+}
+
+// setRDSInstance sets the size, availability and Postgres version of the RDS instance
+func (client *Client) setRDSInstance(conf *config.Config) error {
+	// If the RDS instance size has manually set, override the existing size in the config
+	if client.deployArgs.DBSizeIsSet {
+		conf.RDSInstanceClass = config.DBSizes[client.deployArgs.DBSize]
+	}
+	conf.RDSApplyImmediately = client.deployArgs.DBApplyImmediately
+	// Keep the multi-AZ setting unless a profile is given, so that self-updates don't reset it
+	if client.deployArgs.MultiAZRDSIsSet {
+		conf.MultiAZRDS = client.deployArgs.MultiAZRDS
+	}
+	if err := client.setDBAvailabilityZone(conf); err != nil {
+		return err
+	}
+	return client.setDBEngineVersion(conf)
+}
+
+// setExternalDB points the deployment at an external Postgres server instead of an RDS instance. The
+// director keeps its state in the server, so it can only be chosen on the first deploy. The server's
+// credentials take the place of the generated RDS ones, and it's checked before anything is created
+// so that a server that can't be reached doesn't leave a deployment half made
+func (client *Client) setExternalDB(conf *config.Config) error {
+	args := client.deployArgs
+	if args.ExternalDBURL != "" {
+		if args.ExternalDBURL != conf.ExternalDBURL && conf.DirectorPublicIP != "" {
+			return errors.New("found an existing deployment. Refusing to move its database to --external-db-url as the database can only be chosen on the first deploy")
+		}
+
+		externalDB, err := config.ParseExternalDBURL(args.ExternalDBURL)
+		if err != nil {
+			return err
+		}
+		conf.ExternalDBURL = args.ExternalDBURL
+		conf.ExternalDBCACert = args.ExternalDBCACert
+		conf.RDSDefaultDatabaseName = externalDB.Name
+		conf.RDSUsername = args.ExternalDBUsername
+		conf.RDSPassword = args.ExternalDBPassword
+	}
+
+	externalDB, err := conf.ExternalDB()
+	if err != nil || externalDB == nil {
+		return err
+	}
+
+	rdsFlags := []struct {
+		flag  string
+		isSet bool
+	}{
+		// A profile's size is ignored rather than refused, as its other sizes still apply
+		{"--db-size", args.DBSizeIsSet && !args.ProfileIsSet},
+		{"--db-backup", args.BackupDB},
+		{"--db-engine-version", args.DBEngineVersionIsSet},
+		{"--db-availability-zone", args.DBAvailabilityZoneIsSet},
+		{"--db-apply-immediately", args.DBApplyImmediatelyIsSet},
+	}
+	for _, rdsFlag := range rdsFlags {
+		if rdsFlag.isSet {
+			return fmt.Errorf("%s cannot be used with an external database, as there is no RDS instance", rdsFlag.flag)
+		}
+	}
+
+	if _, err = fmt.Fprintf(client.stdout, "\nCHECKING THE EXTERNAL DATABASE AT %s\n\n", externalDB.Address()); err != nil {
+		return err
+	}
+	if err = pingExternalDB(externalDB.Address(), externalDB.Name, conf.RDSUsername, conf.RDSPassword, conf.ExternalDBCACert); err != nil {
+		return fmt.Errorf("could not connect to the external database at %s, so nothing was deployed: %s", externalDB.Address(), err)
+	}
+	return nil
+}
+
+// pingExternalDB is replaced in tests, which have no database to connect to
+var pingExternalDB = db.Ping
+
+// checkATCConnectionPool ensures the ATC's connections fit within the RDS instance's
+// max_connections alongside the other components which share the database
+func checkATCConnectionPool(maxOpenConnections int, rdsInstanceClass string) error {
+	maxConnections, ok := config.RDSMaxConnections[rdsInstanceClass]
+	if maxOpenConnections == 0 || !ok {
+		return nil
+	}
+
+	available := maxConnections - config.RDSReservedConnections
+	if maxOpenConnections > available {
+		return fmt.Errorf("--db-max-open-connections of %d exceeds the %d connections available to the ATC on a %s RDS instance. Use a larger --db-size or fewer connections", maxOpenConnections, available, rdsInstanceClass)
+	}
+
+	return nil
+}
+
+// setPorts sets the ports the web node serves Concourse, CredHub and Grafana on. The existing
+// ports are kept unless new ones are given, so that self-updates don't move them
+func (client *Client) setPorts(conf *config.Config) error {
+	if client.deployArgs.ExternalPortIsSet {
+		conf.ConcourseExternalPort = client.deployArgs.ExternalPort
+	}
+	if client.deployArgs.CredhubPortIsSet {
+		conf.CredhubExternalPort = client.deployArgs.CredhubPort
+	}
+	if client.deployArgs.GrafanaPortIsSet {
+		conf.GrafanaExternalPort = client.deployArgs.GrafanaPort
+	}
+
+	if conf.GrafanaPath != "" && conf.ConcoursePort() != config.DefaultConcoursePort {
+		return fmt.Errorf("Grafana cannot be served under a path while Concourse is on port %d, as HAProxy serves both on %d. Pass --external-port %d to move Concourse back", conf.ConcoursePort(), config.DefaultConcoursePort, config.DefaultConcoursePort)
+	}
+	return nil
+}
+
+// tlsSettingsMinConcourseVersion is the first Concourse whose ATC has the tls_min_version and tls_cipher_suites properties
+const tlsSettingsMinConcourseVersion = "7.0.0"
+
+// setTLSSettings sets the ATC's minimum TLS version and cipher suites, which existing deployments only
+// have once they're given. The default minimum of new deployments is dropped on an older Concourse, which
+// doesn't have the settings, but the flags fail
+func (client *Client) setTLSSettings(conf *config.Config) error {
+	if client.deployArgs.TLSMinVersionIsSet {
+		conf.ATCTLSMinVersion = client.deployArgs.TLSMinVersion
+	}
+	if client.deployArgs.TLSCipherSuitesIsSet {
+		conf.ATCTLSCipherSuites = client.deployArgs.TLSCipherSuites
+	}
+
+	if compareVersions(bosh.ConcourseReleaseVersion, tlsSettingsMinConcourseVersion) < 0 {
+		if client.deployArgs.TLSMinVersionIsSet && client.deployArgs.TLSMinVersion != "" {
+			return requireConcourseVersion("concourse-tls-min-version", tlsSettingsMinConcourseVersion)
+		}
+		if len(conf.ATCTLSCipherSuites) > 0 {
+			return requireConcourseVersion("concourse-tls-cipher-suite", tlsSettingsMinConcourseVersion)
+		}
+		conf.ATCTLSMinVersion = ""
+	}
+
+	if conf.ATCTLSMinVersion == "1.3" && len(conf.ATCTLSCipherSuites) > 0 {
+		return errors.New("the deployment's TLS cipher suites cannot be used with TLS 1.3. Pass --concourse-tls-cipher-suite \"\" to remove them")
+	}
+	return nil
+}
+
+// setGrafanaPath moves Grafana between port 3000 and a path on the Concourse domain. A path
+// is routed by HAProxy, which can't apply the ATC's cipher suites or a minimum version of TLS 1.3
+func (client *Client) setGrafanaPath(conf *config.Config) error {
+	// Keep the existing path unless one is given, so that self-updates don't move Grafana
+	if client.deployArgs.GrafanaPathIsSet {
+		conf.GrafanaPath = client.deployArgs.GrafanaPath
+	}
+	if conf.GrafanaPath == "" {
+		return nil
+	}
+
+	if len(conf.ATCTLSCipherSuites) > 0 {
+		return errors.New("the deployment's TLS cipher suites cannot be used when Grafana is served under a path. Pass --concourse-tls-cipher-suite \"\" to remove them")
+	}
+	if conf.ATCTLSMinVersion == "1.3" {
+		return errors.New("cannot serve Grafana under a path with a minimum TLS version of 1.3. Pass --concourse-tls-min-version 1.2 to lower it")
+	}
+
+	return nil
+}
+
+// setExperiments enables the ATC experiments given, failing if the Concourse this
+// version of concourse-up deploys doesn't have them yet
+func (client *Client) setExperiments(conf *config.Config) error {
+	// Self-updates aren't given the flags, so they keep the experiments from the last manual deploy
+	if !client.deployArgs.SelfUpdate {
+		conf.ATCExperiments = client.deployArgs.Experiments
+	}
+
+	for _, property := range conf.ATCExperiments {
+		experiment, ok := config.FindExperiment(property)
+		if !ok {
+			return fmt.Errorf("unknown Concourse experiment: `%s`", property)
+		}
+		if err := requireConcourseVersion(experiment.Flag, experiment.MinConcourseVersion); err != nil {
+			return err
+		}
+		if experiment.MaxConcourseVersion != "" {
+			if err := requireConcourseVersionBefore(experiment.Flag, experiment.MaxConcourseVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setHijackSettings sets how the ATC reaps hijacked containers, failing if the Concourse
+// this version of concourse-up deploys doesn't support the settings yet
+func (client *Client) setHijackSettings(conf *config.Config) error {
+	// Keep the existing settings unless new ones are given, so that self-updates don't reset them
+	if client.deployArgs.HijackGracePeriodIsSet {
+		conf.ATCGCHijackGracePeriod = durationSetting(client.deployArgs.HijackGracePeriod)
+	}
+	if client.deployArgs.InterceptIdleTimeoutIsSet {
+		conf.ATCInterceptIdleTimeout = durationSetting(client.deployArgs.InterceptIdleTimeout)
+	}
+
+	if conf.ATCGCHijackGracePeriod != "" {
+		if err := requireConcourseVersion("concourse-gc-hijack-grace-period", "4.0.0"); err != nil {
+			return err
+		}
+	}
+	if conf.ATCInterceptIdleTimeout != "" {
+		if err := requireConcourseVersion("concourse-intercept-idle-timeout", "5.0.0"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setResourceCheckSettings sets the ATC's defaults for checking resources, failing if the
+// Concourse this version of concourse-up deploys doesn't support the settings yet
+func (client *Client) setResourceCheckSettings(conf *config.Config) error {
+	// Keep the existing settings unless new ones are given, so that self-updates don't reset them
+	if client.deployArgs.ResourceCheckingIntervalIsSet {
+		conf.ATCResourceCheckingInterval = durationSetting(client.deployArgs.ResourceCheckingInterval)
+	}
+	if client.deployArgs.ResourceCheckTimeoutIsSet {
+		conf.ATCResourceCheckTimeout = durationSetting(client.deployArgs.ResourceCheckTimeout)
+	}
+
+	if conf.ATCResourceCheckTimeout != "" {
+		return requireConcourseVersion("concourse-resource-check-timeout", "4.0.0")
+	}
+
+	return nil
+}
+
+// setDefaultStepTimeouts sets how long get and put steps can run when their pipelines don't
+// set a timeout, failing if the Concourse this version of concourse-up deploys doesn't support them yet
+func (client *Client) setDefaultStepTimeouts(conf *config.Config) error {
+	// Keep the existing timeouts unless new ones are given, so that self-updates don't reset them
+	if client.deployArgs.DefaultGetTimeoutIsSet {
+		conf.ATCDefaultGetTimeout = durationSetting(client.deployArgs.DefaultGetTimeout)
+	}
+	if client.deployArgs.DefaultPutTimeoutIsSet {
+		conf.ATCDefaultPutTimeout = durationSetting(client.deployArgs.DefaultPutTimeout)
+	}
+
+	if conf.ATCDefaultGetTimeout != "" {
+		if err := requireConcourseVersion("concourse-default-get-timeout", "6.5.0"); err != nil {
+			return err
+		}
+	}
+	if conf.ATCDefaultPutTimeout != "" {
+		return requireConcourseVersion("concourse-default-put-timeout", "6.5.0")
+	}
+
+	return nil
+}
+
+// setVolumeStreaming sets how the ATC places containers on workers and whether workers stream
+// volumes to each other directly, failing if the Concourse this version of concourse-up deploys can't
+func (client *Client) setVolumeStreaming(conf *config.Config) error {
+	// Keep the existing settings unless new ones are given, so that self-updates don't reset them
+	if client.deployArgs.ContainerPlacementStrategyIsSet {
+		conf.ATCContainerPlacementStrategy = client.deployArgs.ContainerPlacementStrategy
+	}
+	if client.deployArgs.P2PVolumeStreamingIsSet {
+		conf.ATCP2PVolumeStreaming = client.deployArgs.P2PVolumeStreaming
+	}
+
+	if conf.ATCContainerPlacementStrategy != "" {
+		if err := requireConcourseVersion("concourse-container-placement-strategy", config.ContainerPlacementStrategies[conf.ATCContainerPlacementStrategy]); err != nil {
+			return err
+		}
+	}
+	if conf.ATCP2PVolumeStreaming {
+		return requireConcourseVersion("concourse-enable-p2p-volume-streaming", "7.0.0")
+	}
+
+	return nil
+}
+
+// setSecretCache sets how long the ATC caches secrets for, failing if the Concourse this version of
+// concourse-up deploys can't cache them yet. An empty TTL leaves caching off
+func (client *Client) setSecretCache(conf *config.Config) error {
+	// Keep the existing TTL unless caching is toggled or a new TTL is given, so that self-updates don't turn it off
+	if client.deployArgs.SecretCacheIsSet {
+		conf.SecretCacheTTL = ""
+		if client.deployArgs.SecretCache {
+			conf.SecretCacheTTL = client.deployArgs.SecretCacheTTL.String()
+		}
+	} else if client.deployArgs.SecretCacheTTLIsSet {
+		if conf.SecretCacheTTL == "" {
+			return errors.New("--concourse-secret-cache-ttl requires --concourse-secret-cache, as secrets aren't being cached")
+		}
+		conf.SecretCacheTTL = client.deployArgs.SecretCacheTTL.String()
+	}
+
+	if conf.SecretCacheTTL != "" {
+		return requireConcourseVersion("concourse-secret-cache", "5.1.0")
+	}
+
+	return nil
+}
+
+// setWorkerLimits sets how many tasks the ATC places on each worker and how many containers each
+// worker runs. The task limit is only applied by the limit-active-tasks container placement strategy
+func (client *Client) setWorkerLimits(conf *config.Config) error {
+	// Keep the existing limits unless new ones are given, so that self-updates don't remove them
+	if client.deployArgs.MaxActiveTasksPerWorkerIsSet {
+		conf.MaxActiveTasksPerWorker = client.deployArgs.MaxActiveTasksPerWorker
+	}
+	if client.deployArgs.MaxActiveContainersPerWorkerIsSet {
+		conf.MaxActiveContainersPerWorker = client.deployArgs.MaxActiveContainersPerWorker
+	}
+
+	if conf.MaxActiveTasksPerWorker > 0 && conf.ATCContainerPlacementStrategy != "limit-active-tasks" {
+		return errors.New("--max-active-tasks-per-worker is only applied by the limit-active-tasks container placement strategy. Deploy with --concourse-container-placement-strategy limit-active-tasks too")
+	}
+
+	return nil
+}
+
+// setTags sets the AWS tags given to the deployment's resources. Terraform and BOSH tag the
+// resources they manage, but the config bucket is created before either runs so it's tagged here
+func (client *Client) setTags(conf *config.Config) error {
+	// Keep the existing tags unless new ones are given, so that self-updates don't remove them
+	if !client.deployArgs.TagsIsSet || client.deployArgs.DryRun {
+		return nil
+	}
+
+	previous := conf.Tags
+	conf.Tags = client.deployArgs.Tags
+	return client.iaasClient.SetBucketTags(conf.ConfigBucket, previous, conf.Tags)
+}
+
+// setWorkerAMI sets the customer-managed AMI workers are booted from, after checking
+// it was built from a stemcell BOSH can boot in place of the stock one
+func (client *Client) setWorkerAMI(conf *config.Config) error {
+	// Keep the existing AMI unless a new one is given, so that self-updates don't go back to the stock stemcell
+	if !client.deployArgs.WorkerAMIIDIsSet || client.deployArgs.WorkerAMIID == conf.WorkerAMIID {
+		return nil
+	}
+
+	if client.deployArgs.WorkerAMIID != "" {
+		if err := client.iaasClient.CheckStemcellImage(client.deployArgs.WorkerAMIID, bosh.ConcourseStemcellOS); err != nil {
+			return err
+		}
+	}
+
+	conf.WorkerAMIID = client.deployArgs.WorkerAMIID
+	return nil
+}
+
+// setBackupRegion chooses the region the config bucket is replicated to. Terraform creates the replica
+// bucket there, and applyTerraform points the config bucket's replication at it
+func (client *Client) setBackupRegion(conf *config.Config) error {
+	// Keep replicating unless a region is given, so that self-updates don't stop it
+	if !client.deployArgs.BackupRegionIsSet || client.deployArgs.BackupRegion == conf.BackupRegion {
+		return nil
+	}
+
+	if conf.BackupRegion != "" && client.deployArgs.BackupRegion != "" {
+		return fmt.Errorf("the config bucket is already replicated to %s. Stop replicating it with --backup-region \"\" before choosing another region", conf.BackupRegion)
+	}
+
+	if client.deployArgs.BackupRegion == "" {
+		// Replication must stop before Terraform deletes the replica bucket
+		if !client.deployArgs.DryRun {
+			if err := client.iaasClient.SetBucketReplication(conf.ConfigBucket, "", "", ""); err != nil {
+				return err
+			}
+		}
+	} else if len(conf.ConfigReplicaBucket()) > maxBucketNameLength {
+		return fmt.Errorf("cannot replicate the config bucket, as the name of its replica %s would be longer than %d characters. Use --config-bucket-name to choose a shorter name", conf.ConfigReplicaBucket(), maxBucketNameLength)
+	}
+
+	conf.BackupRegion = client.deployArgs.BackupRegion
+	return nil
+}
+
+// setKMSKey chooses the KMS key the config bucket's objects are encrypted with. Objects written before the
+// key was chosen, or with another key, are re-encrypted straight away
+func (client *Client) setKMSKey(conf *config.Config) error {
+	// Keep the existing key unless one is given, so that self-updates don't stop using it
+	if !client.deployArgs.KMSKeyIDIsSet || client.deployArgs.KMSKeyID == conf.KMSKeyID {
+		return nil
+	}
+
+	if !client.deployArgs.DryRun {
+		if _, err := fmt.Fprintf(client.stdout, "\nENCRYPTING CONFIG BUCKET %s\n", conf.ConfigBucket); err != nil {
+			return err
+		}
+		if err := client.iaasClient.SetBucketEncryption(conf.ConfigBucket, client.deployArgs.KMSKeyID); err != nil {
+			return err
+		}
+	}
+
+	conf.KMSKeyID = client.deployArgs.KMSKeyID
+	return nil
+}
+
+// maxBucketNameLength is the longest name S3 allows for a bucket
+const maxBucketNameLength = 63
+
+// setDirectorSize resizes the BOSH director. create-env recreates only the director's VM, keeping its
+// persistent disk and the state loaded from the config bucket, so the Concourse VMs are left running
+func (client *Client) setDirectorSize(conf *config.Config) error {
+	// Keep the existing size unless one is given, so that self-updates don't reset it
+	if !client.deployArgs.DirectorSizeIsSet {
+		return nil
+	}
+
+	size := client.deployArgs.DirectorSize
+	if size == config.DefaultDirectorSize {
+		size = ""
+	}
+	if size == conf.DirectorSize {
+		return nil
+	}
+
+	conf.DirectorSize = size
+	if conf.DirectorPublicIP != "" {
+		_, err := fmt.Fprintf(client.stdout, "RECREATING THE BOSH DIRECTOR AS A %s INSTANCE\n", conf.DirectorInstanceType())
+		return err
+	}
+	return nil
+}
+
+// setPrivate makes the deployment reachable only from within its VPC. It can only be chosen on the
+// first deploy, as the web node's address is in its certificates and in every client's config
+func (client *Client) setPrivate(conf *config.Config) error {
+	if !client.deployArgs.PrivateIsSet || client.deployArgs.Private == conf.Private {
+		return nil
+	}
+	if conf.DirectorPublicIP != "" {
+		if conf.Private {
+			return errors.New("found an existing private deployment. Refusing to give it a public IP")
+		}
+		return errors.New("found an existing deployment with a public IP. Refusing to make it private")
+	}
+	conf.Private = client.deployArgs.Private
+	return nil
+}
+
+// setStemcellVersion pins the Concourse VMs to a stemcell version, warning when it's older than
+// the stemcell they run, as BOSH recreates every VM to move them onto a different stemcell
+func (client *Client) setStemcellVersion(conf *config.Config) error {
+	// Keep the existing pin unless a version is given, so that self-updates don't unpin it
+	if client.deployArgs.StemcellVersionIsSet {
+		conf.StemcellVersion = client.deployArgs.StemcellVersion
+	}
+	if conf.StemcellVersion == "" {
+		return nil
+	}
+
+	if err := bosh.CheckStemcellVersion(conf.StemcellVersion); err != nil {
+		return err
+	}
+
+	deployed := conf.DeployedVersions["concourse-stemcell"]
+	if deployed == "" || compareVersions(conf.StemcellVersion, deployed) >= 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(client.stderr, "\nWARNING: the Concourse VMs run stemcell %s. Pinning them to the older stemcell %s is a downgrade, which may recreate every VM\n\n", deployed, conf.StemcellVersion)
+	return err
+}
+
+// requireConcourseVersion fails if the Concourse this version of concourse-up deploys
+// is older than the version that introduced the setting for flag
+func requireConcourseVersion(flag, minConcourseVersion string) error {
+	if compareVersions(bosh.ConcourseReleaseVersion, minConcourseVersion) < 0 {
+		return fmt.Errorf("--%s requires Concourse %s or later, but this version of concourse-up deploys Concourse %s", flag, minConcourseVersion, bosh.ConcourseReleaseVersion)
+	}
+	return nil
+}
+
+// requireConcourseVersionBefore fails if the Concourse this version of concourse-up deploys
+// is as new as the version that removed the setting for flag
+func requireConcourseVersionBefore(flag, maxConcourseVersion string) error {
+	if compareVersions(bosh.ConcourseReleaseVersion, maxConcourseVersion) >= 0 {
+		return fmt.Errorf("--%s was removed in Concourse %s, but this version of concourse-up deploys Concourse %s", flag, maxConcourseVersion, bosh.ConcourseReleaseVersion)
+	}
+	return nil
+}
+
+// durationSetting returns the manifest value for an optional duration, which is empty for zero
+func durationSetting(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// setDBAvailabilityZone sets the availability zone the RDS primary is created in, which can
+// only be chosen on the first deploy as moving it would recreate the database
+func (client *Client) setDBAvailabilityZone(conf *config.Config) error {
+	zone := client.deployArgs.DBAvailabilityZone
+	if !client.deployArgs.DBAvailabilityZoneIsSet || zone == conf.RDSAvailabilityZone {
+		return nil
+	}
+
+	if conf.DirectorPublicIP != "" {
+		return fmt.Errorf("found an existing deployment. Refusing to move its RDS primary to %s, as that would recreate the database", zone)
+	}
+
+	zones := config.RDSAvailabilityZones(conf.Region)
+	if conf.ExistingNetwork != nil {
+		zones = conf.ExistingNetwork.DBAvailabilityZones
+	}
+	for _, subnetZone := range zones {
+		if zone != subnetZone {
+			continue
+		}
+		conf.RDSAvailabilityZone = zone
+
+		if conf.MultiAZRDS {
+			_, err := client.stderr.Write([]byte("WARNING: the RDS instance is multi-AZ, so --db-availability-zone only controls where the primary is first created. It moves to the standby's zone after a failover\n"))
+			return err
+		}
+		return nil
+	}
+
+	return fmt.Errorf("--db-availability-zone must be one of the RDS subnets' availability zones: %s", strings.Join(zones, ", "))
+}
+
+// setTerraformLockTable makes Terraform lock its state, which is kept in the config bucket, with a
+// DynamoDB table so that two people deploying at once can't overwrite each other's changes.
+// Dry runs use the table if there is one, but don't create it
+func (client *Client) setTerraformLockTable(conf *config.Config) error {
+	if client.deployArgs.DryRun {
+		return nil
+	}
+
+	// DynamoDB locks go with S3, so the state in another object store isn't locked
+	if client.deployArgs.S3Endpoint != "" {
+		conf.TFLockTable = ""
+		return nil
+	}
+
+	if conf.TFLockTable == "" {
+		conf.TFLockTable = fmt.Sprintf("%s-terraform-lock", conf.Deployment)
+	}
+	return client.iaasClient.EnsureLockTable(conf.TFLockTable)
+}
+
+// setSpotWorkers sets whether workers run on spot instances and what to bid for them.
+// Spot workers can be reclaimed at any time, so opting into them makes the workers
+// ephemeral unless the user has said otherwise. The web node always runs on demand
+func (client *Client) setSpotWorkers(conf *config.Config) error {
+	if client.deployArgs.WorkerSpotIsSet {
+		conf.WorkerSpotDisabled = !client.deployArgs.WorkerSpot
+		if client.deployArgs.WorkerSpot && !client.deployArgs.WorkerEphemeralIsSet {
+			conf.WorkerEphemeral = true
+		}
+	}
+	if client.deployArgs.WorkerSpotMaxPriceIsSet {
+		conf.WorkerSpotMaxPrice = client.deployArgs.WorkerSpotMaxPrice
+	}
+
+	if conf.InstanceTenancy != "dedicated" {
+		return nil
+	}
+	if client.deployArgs.WorkerSpotIsSet && client.deployArgs.WorkerSpot {
+		return errors.New("--spot-workers cannot be used with dedicated tenancy, as spot instances can't be dedicated")
+	}
+	if client.deployArgs.WorkerSpotMaxPriceIsSet && client.deployArgs.WorkerSpotMaxPrice != "" {
+		return errors.New("--spot-max-price cannot be used with dedicated tenancy, as spot instances can't be dedicated")
+	}
+	return nil
+}
+
+// setDBEngineVersion sets the Postgres version of the RDS instance. Terraform upgrades an existing
+// database in place, but RDS can't downgrade one, so downgrades are refused before Terraform runs
+func (client *Client) setDBEngineVersion(conf *config.Config) error {
+	// Deployments from before the version could be chosen run the default version
+	if conf.RDSEngineVersion == "" {
+		conf.RDSEngineVersion = config.DefaultRDSEngineVersion
+	}
+
+	version := client.deployArgs.DBEngineVersion
+	if !client.deployArgs.DBEngineVersionIsSet || version == conf.RDSEngineVersion {
+		return nil
+	}
+
+	if conf.DirectorPublicIP != "" && compareVersions(version, conf.RDSEngineVersion) < 0 {
+		return fmt.Errorf("found an existing database on Postgres %s. Refusing to downgrade it to %s as RDS can't downgrade databases", conf.RDSEngineVersion, version)
+	}
+
+	conf.RDSEngineVersion = version
+	return nil
+}
+
+// setVPCCIDR sets the VPC's address range, which can only be chosen on the first deploy
+// as changing it would recreate the VPC and everything in it
+func (client *Client) setVPCCIDR(conf *config.Config) error {
+	network, err := conf.Network()
+	if err != nil {
+		return err
+	}
+
+	if client.deployArgs.VPCCIDRIsSet && client.deployArgs.VPCCIDR != "" {
+		requested, err := config.ParseVPCCIDR(client.deployArgs.VPCCIDR)
+		if err != nil {
+			return err
+		}
+		if requested.VPCCIDR() != network.VPCCIDR() {
+			if conf.DirectorPublicIP != "" {
+				return fmt.Errorf("found previous deployment in %s. Refusing to move it to %s as changing the VPC CIDR for existing deployments is not supported", network.VPCCIDR(), requested.VPCCIDR())
+			}
+			network = requested
+		}
+	}
+	conf.VPCCIDR = network.VPCCIDR()
+
+	// The range may have come from the config rather than the flags, which is all the arguments were checked against
+	if client.deployArgs.ContainerNetworkPool != "" {
+		return config.CheckContainerNetworkPool(client.deployArgs.ContainerNetworkPool, network)
+	}
+	return nil
+}
+
+// setExistingSubnets puts the deployment in an existing VPC's subnets instead of a VPC created by Terraform.
+// It can only be chosen on the first deploy, as moving would recreate everything. The subnets are checked
+// before anything is created: they must share a VPC, have a public and a private subnet in the deployment's
+// availability zone and, unless there's an external database, private subnets in two zones for RDS
+func (client *Client) setExistingSubnets(conf *config.Config) error {
+	args := client.deployArgs
+	if len(args.ExistingPublicSubnetIDs) > 0 {
+		existing, err := client.describeExistingNetwork(conf, args.ExistingPublicSubnetIDs, args.ExistingPrivateSubnetIDs)
+		if err != nil {
+			return err
+		}
+		// The RDS instance's subnet group can be changed, but the VMs' subnets can't
+		moved := conf.ExistingNetwork == nil || existing.PublicSubnetID != conf.ExistingNetwork.PublicSubnetID || existing.PrivateSubnetID != conf.ExistingNetwork.PrivateSubnetID
+		if conf.DirectorPublicIP != "" && moved {
+			return errors.New("found an existing deployment. Refusing to move it into --public-subnet-id and --private-subnet-id as its network can only be chosen on the first deploy")
+		}
+
+		vpcCIDR, err := client.iaasClient.VPCCIDR(existing.VPCID)
+		if err != nil {
+			return err
+		}
+		conf.VPCCIDR = vpcCIDR
+		conf.ExistingNetwork = existing
+	}
+	if conf.ExistingNetwork == nil {
+		return nil
+	}
+
+	// Terraform doesn't manage the existing VPC, so can't give it what these need
+	unsupported := []struct {
+		flag  string
+		isSet bool
+	}{
+		{"--vpc-cidr", args.VPCCIDRIsSet && args.VPCCIDR != ""},
+		{"--peer-vpc", args.PeeringConnectionsIsSet && len(args.PeeringConnections) > 0},
+		{"--isolate-workers", args.IsolatedWorkersIsSet && args.IsolatedWorkers},
+		{"--enable-ipv6", args.EnableIPv6IsSet && args.EnableIPv6},
+	}
+	for _, flag := range unsupported {
+		if flag.isSet {
+			return fmt.Errorf("%s cannot be used with existing subnets, as concourse-up doesn't manage their VPC", flag.flag)
+		}
+	}
+
+	// This also checks the director and web node's static IPs fit in the public subnet
+	network, err := conf.Network()
+	if err != nil {
+		return err
+	}
+	if args.ContainerNetworkPool != "" {
+		return config.CheckContainerNetworkPool(args.ContainerNetworkPool, network)
+	}
+	return nil
+}
+
+func (client *Client) describeExistingNetwork(conf *config.Config, publicIDs, privateIDs []string) (*config.ExistingNetwork, error) {
+	publicSubnets, err := client.iaasClient.DescribeSubnets(publicIDs)
+	if err != nil {
+		return nil, err
+	}
+	privateSubnets, err := client.iaasClient.DescribeSubnets(privateIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := &config.ExistingNetwork{VPCID: publicSubnets[0].VPCID}
+	zones := map[string]bool{}
+	for _, subnet := range append(publicSubnets, privateSubnets...) {
+		if subnet.VPCID != existing.VPCID {
+			return nil, fmt.Errorf("subnet %s is in %s, but the other subnets are in %s. The subnets must all be in one VPC", subnet.ID, subnet.VPCID, existing.VPCID)
+		}
+	}
+	for _, subnet := range publicSubnets {
+		if subnet.AvailabilityZone == conf.AvailabilityZone && existing.PublicSubnetID == "" {
+			existing.PublicSubnetID = subnet.ID
+			existing.PublicSubnetCIDR = subnet.CIDR
+		}
+	}
+	for _, subnet := range privateSubnets {
+		if subnet.AvailabilityZone == conf.AvailabilityZone && existing.PrivateSubnetID == "" {
+			existing.PrivateSubnetID = subnet.ID
+			existing.PrivateSubnetCIDR = subnet.CIDR
+		}
+		existing.DBSubnetIDs = append(existing.DBSubnetIDs, subnet.ID)
+		if !zones[subnet.AvailabilityZone] {
+			zones[subnet.AvailabilityZone] = true
+			existing.DBAvailabilityZones = append(existing.DBAvailabilityZones, subnet.AvailabilityZone)
+		}
+	}
+
+	if existing.PublicSubnetID == "" {
+		return nil, fmt.Errorf("none of the --public-subnet-id subnets are in %s, which the director and web node are deployed to", conf.AvailabilityZone)
+	}
+	if existing.PrivateSubnetID == "" {
+		return nil, fmt.Errorf("none of the --private-subnet-id subnets are in %s, which the workers are deployed to", conf.AvailabilityZone)
+	}
+	externalDB := conf.ExternalDBURL != "" || client.deployArgs.ExternalDBURL != ""
+	if !externalDB && len(existing.DBAvailabilityZones) < 2 {
+		return nil, errors.New("the --private-subnet-id subnets must cover at least two availability zones, as RDS requires for its subnet group")
+	}
+	return existing, nil
+}
+
+// setPeeringConnections sets the VPCs to peer with, keeping the existing ones unless new ones are given
+// so that self-updates don't remove them, and checks they can be routed to before Terraform tries
+func (client *Client) setPeeringConnections(conf *config.Config) error {
+	if client.deployArgs.PeeringConnectionsIsSet {
+		conf.PeeringConnections = client.deployArgs.PeeringConnections
+	}
+
+	network, err := conf.Network()
+	if err != nil {
+		return err
+	}
+	return config.CheckPeeringCIDRs(conf.PeeringConnections, network)
+}
+
+// checkTenancy sets the deployment's tenancy, which can only be chosen on the first deploy,
+// and ensures all of its instance types can be run with that tenancy
+func (client *Client) checkTenancy(conf *config.Config) error {
+	tenancy := conf.InstanceTenancy
+	if tenancy == "" {
+		tenancy = "default"
+	}
+
+	requested, isRequested, err := client.requestedTenancy()
+	if err != nil {
+		return err
+	}
+
+	if isRequested && requested != tenancy {
+		if conf.DirectorPublicIP != "" {
+			return fmt.Errorf("found an existing deployment with %s tenancy. Refusing to change it to %s, as that would recreate the VPC and everything in it", tenancy, requested)
+		}
+		conf.InstanceTenancy = requested
 	}
 
-	// Allow a fly version discrepancy since we might be targetting an older Concourse
-	if err = flyClient.SetDefaultPipeline(client.deployArgs, config, true); err != nil {
-		return err
+	if conf.InstanceTenancy != "dedicated" {
+		return nil
 	}
 
-	if err = client.deployBosh(config, metadata, true); err != nil {
-		return err
+	instanceTypes := []struct {
+		usedFor      string
+		instanceType string
+	}{
+		{"the BOSH director", conf.DirectorInstanceType()},
+		{"compiling BOSH packages", config.CompilationInstanceType},
+		{fmt.Sprintf("--web-size %s", client.deployArgs.WebSize), config.WebInstanceTypes[client.deployArgs.WebSize]},
+		{fmt.Sprintf("--worker-size %s", client.deployArgs.WorkerSize), config.WorkerInstanceTypes[client.deployArgs.WorkerSize]},
 	}
 
-	_, err = client.stdout.Write([]byte("\nUPGRADE RUNNING IN BACKGROUND\n\n"))
+	for _, t := range instanceTypes {
+		instanceType := config.InstanceType(t.instanceType, conf.InstanceTenancy)
+		supported, err := client.iaasClient.SupportsDedicatedTenancy(instanceType, conf.AvailabilityZone)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			return fmt.Errorf("%s instances, used for %s, cannot be run with dedicated tenancy in %s", instanceType, t.usedFor, conf.AvailabilityZone)
+		}
+	}
 
-	return err
+	return nil
 }
 
-func (client *Client) checkPreTerraformConfigRequirements(conf *config.Config) (*config.Config, error) {
-	region := client.deployArgs.AWSRegion
-
-	if conf.Region != "" {
-		if conf.Region != region {
-			return nil, fmt.Errorf("found previous deployment in %s. Refusing to deploy to %s as changing regions for existing deployments is not supported", conf.Region, region)
-		}
+// requestedTenancy returns the tenancy given by --tenancy or the instance_tenancy variable of
+// --tf-vars-file, which sets the VPC's tenancy and so has to agree with the VMs'
+func (client *Client) requestedTenancy() (string, bool, error) {
+	fileTenancy, inFile := client.deployArgs.TerraformVars["instance_tenancy"]
+	if !client.deployArgs.TerraformVarsIsSet || !inFile {
+		return client.deployArgs.Tenancy, client.deployArgs.TenancyIsSet, nil
 	}
 
-	conf.Region = region
-
-	// If the RDS instance size has manually set, override the existing size in the config
-	if client.deployArgs.DBSizeIsSet {
-		conf.RDSInstanceClass = config.DBSizes[client.deployArgs.DBSize]
+	tenancy, ok := fileTenancy.(string)
+	if !ok || (tenancy != "default" && tenancy != "dedicated") {
+		return "", false, fmt.Errorf("--tf-vars-file sets instance_tenancy to %v, but it can only be default or dedicated", fileTenancy)
+	}
+	if client.deployArgs.TenancyIsSet && client.deployArgs.Tenancy != tenancy {
+		return "", false, fmt.Errorf("--tf-vars-file sets instance_tenancy to %s, but --tenancy is %s", tenancy, client.deployArgs.Tenancy)
 	}
+	return tenancy, true, nil
+}
 
-	// When in self-update mode do not override the user IP, since we already have access to the worker
-	if !client.deployArgs.SelfUpdate {
-		if err := client.setUserIP(conf); err != nil {
-			return nil, err
-		}
+// warnIfDBChangeDeferred warns when --db-apply-immediately=false leaves a new RDS instance size
+// waiting for the maintenance window
+func (client *Client) warnIfDBChangeDeferred(config *config.Config, metadata *terraform.Metadata, previousInstanceClass string) error {
+	if config.RDSApplyImmediately || config.RDSInstanceClass == previousInstanceClass || config.ExternalDBURL != "" {
+		return nil
 	}
 
-	if err := client.setHostedZone(conf); err != nil {
-		return nil, err
+	_, err := client.stderr.Write([]byte(fmt.Sprintf(
+		"\nWARNING: a change to the RDS instance size will not take effect until the next maintenance window (%s UTC). Deploy without --db-apply-immediately=false to apply it now\n\n", metadata.RDSMaintenanceWindow.Value)))
+	return err
+}
+
+// warnIfMetricsLeaveGrafana warns when --prometheus-url is given, as the ATC has a single emitter
+// and its metrics stop reaching the bundled Grafana while they go to Prometheus
+func (client *Client) warnIfMetricsLeaveGrafana() error {
+	if !client.deployArgs.PrometheusURLIsSet || client.deployArgs.PrometheusURL == "" {
+		return nil
 	}
 
-	return conf, nil
+	_, err := client.stderr.Write([]byte("\nWARNING: Concourse's metrics will be sent to Prometheus instead of the bundled Grafana, which will only show the VMs' metrics. Pass --prometheus-url \"\" to send them to Grafana again\n\n"))
+	return err
 }
 
 func (client *Client) checkPreDeployConfigRequiments(isDomainUpdated bool, config *config.Config, metadata *terraform.Metadata) (*config.Config, error) {
 	if client.deployArgs.Domain == "" {
-		config.Domain = metadata.ATCPublicIP.Value
+		address, err := config.ATCAddress(metadata.ATCPublicIP.Value)
+		if err != nil {
+			return nil, err
+		}
+		config.Domain = address
 	}
 
 	config, err := client.ensureDirectorCerts(config, metadata)
@@ -157,10 +1644,14 @@ func (client *Client) checkPreDeployConfigRequiments(isDomainUpdated bool, confi
 		return nil, err
 	}
 
-	config.ConcourseWorkerCount = client.deployArgs.WorkerCount
-	config.ConcourseWorkerSize = client.deployArgs.WorkerSize
-	config.ConcourseWebSize = client.deployArgs.WebSize
-	config.DirectorPublicIP = metadata.DirectorPublicIP.Value
+	config, err = client.ensureBrandingAssets(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = client.applyDeployArgs(config, metadata); err != nil {
+		return nil, err
+	}
 
 	if err := client.configClient.Update(config); err != nil {
 		return nil, err
@@ -169,21 +1660,136 @@ func (client *Client) checkPreDeployConfigRequiments(isDomainUpdated bool, confi
 	return config, nil
 }
 
+// applyDeployArgs sets the config from the deploy args that only BOSH uses, once the infrastructure is in place
+func (client *Client) applyDeployArgs(config *config.Config, metadata *terraform.Metadata) error {
+	// Grafana shares the Concourse admin's credentials, including a new password, until separate ones are given.
+	// Configs without Grafana credentials share them too
+	sharedMetricsCreds := config.GrafanaPassword == "" ||
+		config.GrafanaUsername == config.ConcourseUsername && config.GrafanaPassword == config.ConcoursePassword
+	if client.deployArgs.ConcoursePassword != "" {
+		config.ConcoursePassword = client.deployArgs.ConcoursePassword
+	}
+	if sharedMetricsCreds {
+		config.GrafanaUsername = config.ConcourseUsername
+		config.GrafanaPassword = config.ConcoursePassword
+	}
+	// Separate credentials are kept unless new ones are given, so that self-updates don't reset them
+	if client.deployArgs.MetricsUsernameIsSet {
+		config.GrafanaUsername = client.deployArgs.MetricsUsername
+		if config.GrafanaUsername == "" {
+			config.GrafanaUsername = config.ConcourseUsername
+		}
+	}
+	if client.deployArgs.MetricsPasswordIsSet {
+		config.GrafanaPassword = client.deployArgs.MetricsPassword
+		if config.GrafanaPassword == "" {
+			config.GrafanaPassword = config.ConcoursePassword
+		}
+	}
+	config.ConcourseWorkerCount = client.deployArgs.WorkerCount
+	config.ConcourseWorkerSize = client.deployArgs.WorkerSize
+	config.ConcourseWebSize = client.deployArgs.WebSize
+	// Keep protection as it is unless asked otherwise, so that self-updates don't turn it back on
+	if client.deployArgs.TerminationProtectionIsSet {
+		config.TerminationProtection = client.deployArgs.TerminationProtection
+	}
+	// Keep the existing tags and registry CA certificates unless new ones are given, so that self-updates don't remove them
+	if client.deployArgs.WorkerTagsIsSet {
+		config.WorkerTags = client.deployArgs.WorkerTags
+	}
+	if client.deployArgs.WorkerEphemeralIsSet {
+		config.WorkerEphemeral = client.deployArgs.WorkerEphemeral
+	}
+	if client.deployArgs.WorkerDiskSizeIsSet {
+		config.ConcourseWorkerDiskSize = client.deployArgs.WorkerDiskSize
+	}
+	if client.deployArgs.DrainTimeoutIsSet {
+		config.UpdateDrainTimeout = client.deployArgs.DrainTimeout
+	}
+	if client.deployArgs.PostDeployErrandsIsSet {
+		config.PostDeployErrands = client.deployArgs.PostDeployErrands
+	}
+	if client.deployArgs.NoDefaultPipelineIsSet {
+		config.DefaultPipelineDisabled = client.deployArgs.NoDefaultPipeline
+	}
+	if client.deployArgs.ExtraPipelinesIsSet {
+		config.ExtraPipelines = client.deployArgs.ExtraPipelines
+	}
+	// The proxy settings are given together, and kept unless new ones are given so that self-updates keep using them
+	if client.deployArgs.ProxyIsSet {
+		config.HTTPProxy = client.deployArgs.Proxy.HTTPProxy
+		config.HTTPSProxy = client.deployArgs.Proxy.HTTPSProxy
+		config.NoProxy = client.deployArgs.Proxy.NoProxy
+	}
+	// The syslog settings are given together, and kept unless a new address is given so that self-updates don't stop forwarding logs
+	if client.deployArgs.SyslogAddressIsSet {
+		config.SyslogAddress = client.deployArgs.SyslogAddress
+		config.SyslogTransport = client.deployArgs.SyslogTransport
+		config.SyslogTLS = client.deployArgs.SyslogTLS
+		config.SyslogCACert = client.deployArgs.SyslogCACert
+	}
+	// Likewise the Prometheus endpoint and its credentials, so that self-updates keep sending metrics
+	if client.deployArgs.PrometheusURLIsSet {
+		config.PrometheusRemoteWriteURL = client.deployArgs.PrometheusURL
+		config.PrometheusUsername = client.deployArgs.PrometheusUsername
+		config.PrometheusPassword = client.deployArgs.PrometheusPassword
+	}
+	// Keep the existing update settings unless new ones are given, so that self-updates don't reset them
+	if client.deployArgs.BoshUpdateSerialIsSet {
+		config.BoshUpdateSerial = client.deployArgs.BoshUpdateSerial
+	}
+	if client.deployArgs.BoshCanaryWatchTimeIsSet {
+		config.BoshCanaryWatchTime = client.deployArgs.BoshCanaryWatchTime
+	}
+	if client.deployArgs.BoshUpdateWatchTimeIsSet {
+		config.BoshUpdateWatchTime = client.deployArgs.BoshUpdateWatchTime
+	}
+	if client.deployArgs.DescriptionIsSet {
+		config.Description = client.deployArgs.Description
+	}
+	if client.deployArgs.NotesIsSet {
+		config.Notes = client.deployArgs.Notes
+	}
+	if client.deployArgs.WorkerRegistryCACertsIsSet {
+		config.WorkerRegistryCACerts = client.deployArgs.WorkerRegistryCACerts
+	}
+	// The log level is an ATC property, so changing only it makes BOSH update just the web instance group
+	if client.deployArgs.ATCLogLevelIsSet {
+		config.ConcourseLogLevel = client.deployArgs.ATCLogLevel
+	}
+	// Keep the container network unless it's given, so that self-updates don't move garden back to the default pool
+	if client.deployArgs.ContainerNetworkPoolIsSet {
+		config.ContainerNetworkPool = client.deployArgs.ContainerNetworkPool
+	}
+	if client.deployArgs.ContainerNetworkMTUIsSet {
+		config.ContainerNetworkMTU = client.deployArgs.ContainerNetworkMTU
+	}
+	config.DirectorPublicIP = metadata.DirectorPublicIP.Value
+
+	return nil
+}
+
 func (client *Client) ensureDirectorCerts(config *config.Config, metadata *terraform.Metadata) (*config.Config, error) {
 	// If we already have director certificates, don't regenerate as changing them will
-	// force a bosh director re-deploy even if there are no other changes
-	if config.DirectorCACert != "" {
+	// force a bosh director re-deploy even if there are no other changes. A director being
+	// rebuilt is re-deployed anyway, so it gets new ones
+	if config.DirectorCACert != "" && !client.forceDirectorRebuild() {
 		return config, nil
 	}
 
+	network, err := config.Network()
+	if err != nil {
+		return nil, err
+	}
+
 	ip := metadata.DirectorPublicIP.Value
-	_, err := client.stdout.Write(
-		[]byte(fmt.Sprintf("\nGENERATING BOSH DIRECTOR CERTIFICATE (%s, 10.0.0.6)\n", ip)))
+	_, err = client.stdout.Write(
+		[]byte(fmt.Sprintf("\nGENERATING BOSH DIRECTOR CERTIFICATE (%s, %s)\n", ip, network.DirectorIP())))
 	if err != nil {
 		return nil, err
 	}
 
-	directorCerts, err := client.certGenerator(config.Deployment, ip, "10.0.0.6")
+	directorCerts, err := client.certGenerator(config.Deployment, ip, network.DirectorIP())
 	if err != nil {
 		return nil, err
 	}
@@ -207,7 +1813,40 @@ func timeTillExpiry(cert string) time.Duration {
 	return time.Until(c.NotAfter)
 }
 
+// certRenewalPeriod is how long before a certificate expires that it is replaced
+const certRenewalPeriod = 28 * 24 * time.Hour
+
+// directorCertWarningPeriod is how long before the director cert expires that we start warning about it
+const directorCertWarningPeriod = 60 * 24 * time.Hour
+
+// warnIfDirectorCertExpiring warns when the director cert has expired or is due to
+// expire soon, as every BOSH operation fails with a TLS error once it has
+func (client *Client) warnIfDirectorCertExpiring(config *config.Config) error {
+	remaining := timeTillExpiry(config.DirectorCert)
+	// timeTillExpiry returns 0 for a missing or unparseable cert
+	if remaining == 0 || remaining > directorCertWarningPeriod {
+		return nil
+	}
+
+	var message string
+	if remaining < 0 {
+		message = "\nWARNING: the BOSH director certificate has expired. BOSH operations will fail with TLS errors until it is replaced\n\n"
+	} else {
+		message = fmt.Sprintf("\nWARNING: the BOSH director certificate expires in %d days. BOSH operations will fail with TLS errors once it has expired\n\n", int(remaining.Hours()/24))
+	}
+
+	_, err := client.stderr.Write([]byte(message))
+	return err
+}
+
 func (client *Client) ensureConcourseCerts(domainUpdated bool, config *config.Config, metadata *terraform.Metadata) (*config.Config, error) {
+	// Let's Encrypt is used by default, so only turning it off is kept in the config
+	acmeToggled := false
+	if client.deployArgs.ACMEEnabledIsSet && config.ACMEDisabled == client.deployArgs.ACMEEnabled {
+		config.ACMEDisabled = !client.deployArgs.ACMEEnabled
+		acmeToggled = true
+	}
+
 	if client.deployArgs.TLSCert != "" {
 		config.ConcourseCert = client.deployArgs.TLSCert
 		config.ConcourseKey = client.deployArgs.TLSKey
@@ -217,13 +1856,50 @@ func (client *Client) ensureConcourseCerts(domainUpdated bool, config *config.Co
 	}
 
 	// Skip concourse re-deploy if certs have already been set,
-	// unless domain has changed
-	if config.ConcourseCert != "" && !domainUpdated && timeTillExpiry(config.ConcourseCert) > 28*24*time.Hour {
+	// unless domain or where they come from has changed
+	if config.ConcourseCert != "" && !domainUpdated && !acmeToggled && timeTillExpiry(config.ConcourseCert) > certRenewalPeriod {
+		return config, nil
+	}
+
+	if err := client.waitForDomain(config, metadata); err != nil {
+		return nil, err
+	}
+
+	return client.generateConcourseCerts(config)
+}
+
+// generateConcourseCerts replaces the Concourse certificate with one from Let's Encrypt or a self-signed one
+func (client *Client) generateConcourseCerts(config *config.Config) (*config.Config, error) {
+	// A domain in one of the account's hosted zones can be proven to Let's Encrypt with a
+	// DNS-01 challenge, and its certificates are trusted, so fly doesn't need --insecure.
+	// Let's Encrypt can't see the records of a private deployment's private hosted zone
+	if usesACME(config) {
+		concourseCerts, err := client.acmeCertGenerator(config.HostedZoneID, config.Domain)
+		if err != nil {
+			return nil, err
+		}
+
+		config.ConcourseCert = string(concourseCerts.Cert)
+		config.ConcourseKey = string(concourseCerts.Key)
+		config.ConcourseCACert = string(concourseCerts.CACert)
+		config.ConcourseUserProvidedCert = true
+
 		return config, nil
 	}
 
-	// If no domain has been provided by the user, the value of config.Domain is set to the ATC's public IP in checkPreDeployConfigRequiments
-	concourseCerts, err := client.certGenerator(config.Deployment, config.Domain)
+	// If no domain has been provided by the user, the value of config.Domain is set to the ATC's address in checkPreDeployConfigRequiments.
+	// Clients within the VPC can also reach a private deployment at the web node's internal IP
+	names := []string{config.Domain}
+	if config.Private {
+		network, err := config.Network()
+		if err != nil {
+			return nil, err
+		}
+		if network.WebIP() != config.Domain {
+			names = append(names, network.WebIP())
+		}
+	}
+	concourseCerts, err := client.certGenerator(config.Deployment, names...)
 	if err != nil {
 		return nil, err
 	}
@@ -231,10 +1907,81 @@ func (client *Client) ensureConcourseCerts(domainUpdated bool, config *config.Co
 	config.ConcourseCert = string(concourseCerts.Cert)
 	config.ConcourseKey = string(concourseCerts.Key)
 	config.ConcourseCACert = string(concourseCerts.CACert)
+	config.ConcourseUserProvidedCert = false
 
 	return config, nil
 }
 
+// usesACME returns true if the Concourse certificate is obtained from Let's Encrypt rather than self-signed
+func usesACME(config *config.Config) bool {
+	return config.HostedZoneID != "" && !config.ACMEDisabled && !config.Private
+}
+
+const (
+	brandingWordmarkFilename = "branding-wordmark.svg"
+	brandingCSSFilename      = "branding.css"
+)
+
+// brandingMinConcourseVersion is the first Concourse whose ATC has the asset_overrides property
+const brandingMinConcourseVersion = "7.0.0"
+
+// checkBranding fails if branding is given for a Concourse whose ATC can't apply it
+func (client *Client) checkBranding() error {
+	if client.deployArgs.BrandingWordmark != "" {
+		return requireConcourseVersion("branding-wordmark", brandingMinConcourseVersion)
+	}
+	if client.deployArgs.BrandingCSS != "" {
+		return requireConcourseVersion("branding-css", brandingMinConcourseVersion)
+	}
+	return nil
+}
+
+// ensureBrandingAssets stores any branding passed in the deploy args in the config
+// bucket and loads the contents of previously stored branding for the manifest
+func (client *Client) ensureBrandingAssets(config *config.Config) (*config.Config, error) {
+	// Stored branding is kept, but left out of the manifest of a Concourse that can't apply it
+	if compareVersions(bosh.ConcourseReleaseVersion, brandingMinConcourseVersion) < 0 {
+		return config, nil
+	}
+
+	if client.deployArgs.BrandingWordmark != "" {
+		if err := client.configClient.StoreAsset(brandingWordmarkFilename, []byte(client.deployArgs.BrandingWordmark)); err != nil {
+			return nil, err
+		}
+		config.BrandingWordmarkAsset = brandingWordmarkFilename
+	}
+
+	if client.deployArgs.BrandingCSS != "" {
+		if err := client.configClient.StoreAsset(brandingCSSFilename, []byte(client.deployArgs.BrandingCSS)); err != nil {
+			return nil, err
+		}
+		config.BrandingCSSAsset = brandingCSSFilename
+	}
+
+	return config, client.loadBrandingAssets(config)
+}
+
+// loadBrandingAssets loads the stored branding into the config, which only records the assets' names
+func (client *Client) loadBrandingAssets(config *config.Config) error {
+	if config.BrandingWordmarkAsset != "" {
+		wordmark, err := client.configClient.LoadAsset(config.BrandingWordmarkAsset)
+		if err != nil {
+			return err
+		}
+		config.BrandingWordmark = string(wordmark)
+	}
+
+	if config.BrandingCSSAsset != "" {
+		css, err := client.configClient.LoadAsset(config.BrandingCSSAsset)
+		if err != nil {
+			return err
+		}
+		config.BrandingCSS = string(css)
+	}
+
+	return nil
+}
+
 func (client *Client) applyTerraform(config *config.Config) (*terraform.Metadata, error) {
 	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), config, client.stdout, client.stderr)
 	if err != nil {
@@ -242,9 +1989,14 @@ func (client *Client) applyTerraform(config *config.Config) (*terraform.Metadata
 	}
 	defer terraformClient.Cleanup()
 
-	if err = terraformClient.Apply(false); err != nil {
+	// A dry run only plans, so there are no outputs to fetch
+	dryRun := client.deployArgs != nil && client.deployArgs.DryRun
+	if err = terraformClient.Apply(dryRun); err != nil {
 		return nil, err
 	}
+	if dryRun {
+		return nil, nil
+	}
 
 	metadata, err := terraformClient.Output()
 	if err != nil {
@@ -255,25 +2007,111 @@ func (client *Client) applyTerraform(config *config.Config) (*terraform.Metadata
 		return nil, err
 	}
 
+	if config.BackupRegion != "" {
+		if err = client.iaasClient.SetBucketReplication(config.ConfigBucket, metadata.ConfigReplicationRoleARN.Value, config.ConfigReplicaBucket(), metadata.ConfigReplicaKMSKeyARN.Value); err != nil {
+			return nil, err
+		}
+	}
+
 	return metadata, nil
 }
 
-func (client *Client) deployBosh(config *config.Config, metadata *terraform.Metadata, detach bool) error {
-	boshClient, err := client.buildBoshClient(config, metadata)
+// refreshWebIPv6 points the AAAA record at the web node's IPv6 address. The address changes whenever BOSH
+// recreates the web node, so it's looked up after each deploy, and Terraform is applied again when it has moved
+func (client *Client) refreshWebIPv6(config *config.Config, metadata *terraform.Metadata) error {
+	if !config.PublicIPv6 {
+		return nil
+	}
+
+	network, err := config.Network()
 	if err != nil {
 		return err
 	}
-	defer boshClient.Cleanup()
 
-	boshStateBytes, err := loadDirectorState(client.configClient)
+	address, err := client.iaasClient.InstanceIPv6Address(metadata.VPCID.Value, network.WebIP())
 	if err != nil {
+		return err
+	}
+	if address == "" {
+		_, err = fmt.Fprintf(client.stderr, "\nWARNING: the web node has no IPv6 address, eg as it was created before --enable-ipv6. %s is only reachable over IPv4 until BOSH next recreates it\n\n", config.Domain)
+		return err
+	}
+	if address == config.ATCPublicIPv6 {
 		return nil
 	}
+
+	if _, err = fmt.Fprintf(client.stdout, "\nPOINTING THE AAAA RECORD FOR %s AT THE WEB NODE'S IPV6 ADDRESS %s\n", config.Domain, address); err != nil {
+		return err
+	}
+	config.ATCPublicIPv6 = address
+	if err = client.configClient.Update(config); err != nil {
+		return err
+	}
+
+	_, err = client.applyTerraform(config)
+	return err
+}
+
+// forceDirectorRebuild returns true if the deploy should throw the director away and bootstrap a new one
+func (client *Client) forceDirectorRebuild() bool {
+	return client.deployArgs != nil && client.deployArgs.ForceDirectorRebuild
+}
+
+func (client *Client) deployBosh(config *config.Config, metadata *terraform.Metadata, detach, recreateDirector bool) error {
+	boshClient, err := client.buildBoshClient(config, metadata)
+	if err != nil {
+		return err
+	}
+	defer boshClient.Cleanup()
+
+	// Without internet access the stemcells and releases are uploaded from a bundle instead of downloaded
+	if client.deployArgs != nil && client.deployArgs.ReleaseBundle != "" {
+		if _, err = fmt.Fprintf(client.stdout, "\nUSING RELEASE BUNDLE %s\n", client.deployArgs.ReleaseBundle); err != nil {
+			return err
+		}
+		if err = boshClient.UseReleaseBundle(client.deployArgs.ReleaseBundle); err != nil {
+			return err
+		}
+	}
+
+	// Without its state, create-env bootstraps a new director rather than updating the old one. The
+	// creds are kept, as the passwords in them are the ones the database on RDS was set up with
+	var boshStateBytes []byte
+	if !recreateDirector {
+		boshStateBytes, err = loadDirectorState(client.configClient)
+		if err != nil {
+			return nil
+		}
+	}
 	boshCredsBytes, err := loadDirectorCreds(client.configClient)
 	if err != nil {
 		return nil
 	}
 
+	// BOSH needs to be able to replace the director and web VMs, so protection is lifted for the duration
+	// of the deploy. A detached deploy may still be replacing the web VM when this returns, so unless the
+	// self-update waits for it, only the director's protection is lifted, as create-env is done with it by then
+	liftWebProtection := !detach || client.deployArgs.WaitForDetach
+	if liftWebProtection {
+		err = client.setTerminationProtection(metadata, false)
+	} else {
+		err = client.setDirectorTerminationProtection(metadata, false)
+	}
+	if err != nil {
+		return err
+	}
+	if !liftWebProtection && config.TerminationProtection {
+		if _, err = fmt.Fprintln(client.stderr, "\nWARNING: the web VM keeps its termination protection, as this self-update doesn't wait to restore it. If BOSH needs to replace the web VM, pass --detach-timeout or deploy without --self-update"); err != nil {
+			return err
+		}
+	}
+
+	if recreateDirector {
+		if err = client.deleteDirector(config, metadata); err != nil {
+			return err
+		}
+	}
+
 	boshStateBytes, boshCredsBytes, err = boshClient.Deploy(boshStateBytes, boshCredsBytes, detach)
 	err1 := client.configClient.StoreAsset(bosh.StateFilename, boshStateBytes)
 	if err == nil {
@@ -287,6 +2125,33 @@ func (client *Client) deployBosh(config *config.Config, metadata *terraform.Meta
 		return err
 	}
 
+	// The workers are recreated once the deploy has converged them, so they come up on the new manifest.
+	// renew-certs redeploys the director without deploy args, so never recreates them
+	if client.deployArgs != nil && client.deployArgs.RecreateWorkers && !detach {
+		if err = boshClient.Recreate("worker"); err != nil {
+			return err
+		}
+	}
+
+	// A self-update that waits for its detached deploy restores the web VM's protection once it has finished
+	if config.TerminationProtection {
+		if !detach {
+			err = client.setTerminationProtection(metadata, true)
+		} else if !liftWebProtection {
+			err = client.setDirectorTerminationProtection(metadata, true)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Likewise the web node may not have been replaced yet, so its IPv6 address is left for the next deploy
+	if !detach {
+		if err = client.refreshWebIPv6(config, metadata); err != nil {
+			return err
+		}
+	}
+
 	type credhubCreds struct {
 		Password string `yaml:"credhub_cli_password"`
 		CACert   struct {
@@ -300,12 +2165,30 @@ func (client *Client) deployBosh(config *config.Config, metadata *terraform.Meta
 	}
 	config.CredhubCACert = cc.CACert.Cert
 	config.CredhubPassword = cc.Password
-	config.CredhubURL = fmt.Sprintf("https://%s:8844/", metadata.ATCPublicIP.Value)
+	address, err := config.ATCAddress(metadata.ATCPublicIP.Value)
+	if err != nil {
+		return err
+	}
+	config.CredhubURL = fmt.Sprintf("https://%s:%d/", address, config.CredhubPort())
 	config.CredhubUsername = "credhub-cli"
 
 	return nil
 }
 
+func (client *Client) setTerminationProtection(metadata *terraform.Metadata, enabled bool) error {
+	publicIPs := []string{metadata.DirectorPublicIP.Value}
+	// A private deployment's web node has no public IP
+	if metadata.ATCPublicIP.Value != "" {
+		publicIPs = append(publicIPs, metadata.ATCPublicIP.Value)
+	}
+	return client.iaasClient.SetTerminationProtection(metadata.VPCID.Value, publicIPs, enabled)
+}
+
+// setDirectorTerminationProtection sets termination protection on just the director's VM
+func (client *Client) setDirectorTerminationProtection(metadata *terraform.Metadata, enabled bool) error {
+	return client.iaasClient.SetTerminationProtection(metadata.VPCID.Value, []string{metadata.DirectorPublicIP.Value}, enabled)
+}
+
 func (client *Client) loadConfig() (*config.Config, error) {
 	cfg, createdNewConfig, err := client.configClient.LoadOrCreate(client.deployArgs)
 	if err != nil {
@@ -333,6 +2216,9 @@ func (client *Client) setUserIP(config *config.Config) error {
 		if err != nil {
 			return err
 		}
+		if client.deployArgs.DryRun {
+			return nil
+		}
 		if err = client.configClient.Update(config); err != nil {
 			return err
 		}
@@ -360,6 +2246,9 @@ func (client *Client) setHostedZone(config *config.Config) error {
 	if err != nil {
 		return err
 	}
+	if client.deployArgs.DryRun {
+		return nil
+	}
 	if err = client.configClient.Update(config); err != nil {
 		return err
 	}
@@ -368,25 +2257,81 @@ func (client *Client) setHostedZone(config *config.Config) error {
 }
 
 const deployMsg = `DEPLOY SUCCESSFUL. Log in with:
-fly --target {{.Project}} login{{if not .ConcourseUserProvidedCert}} --insecure{{end}} --concourse-url https://{{.Domain}} --username {{.ConcourseUsername}} --password {{.ConcoursePassword}}
+fly --target {{.Project}} login{{if not .ConcourseUserProvidedCert}} --insecure{{end}} --concourse-url {{.ConcourseURL}} --username {{.ConcourseUsername}} --password {{.ConcoursePassword}}
 
-Metrics available at https://{{.Domain}}:3000 using the same username and password
+Metrics available at {{.MetricsURL}}
+{{- if and (eq .GrafanaUsername .ConcourseUsername) (eq .GrafanaPassword .ConcoursePassword)}} using the same username and password
+{{- else}} with username {{.GrafanaUsername}} and password {{.GrafanaPassword}}
+{{- end}}
+{{- with .PrometheusRemoteWriteURL}}
+
+Concourse's metrics are sent to Prometheus at {{.}}, so Grafana only shows the VMs' metrics
+{{- end}}
 
 Log into credhub with:
 eval "$(concourse-up info --env --region {{.Region}})"
-`
+{{with .ATCPublicIPv6}}
+Concourse is reachable over IPv6 too, at {{$.ConcourseURL}} ({{.}})
+{{end}}
+{{- if .Private}}
+Concourse is private, so it can only be reached from within its VPC ({{.Network.VPCCIDR}}), at {{.ConcourseURL}} or https://{{.Network.WebIP}}{{if ne .ConcoursePort 443}}:{{.ConcoursePort}}{{end}}
+{{end}}`
 
 func writeDeploySuccessMessage(config *config.Config, metadata *terraform.Metadata, stdout io.Writer) error {
 	t := template.Must(template.New("deploy").Parse(deployMsg))
 	return t.Execute(stdout, config)
 }
 
+// deploySuccess is the deploy success message as JSON, for scripts that log into the deployment
+type deploySuccess struct {
+	Domain          string `json:"domain"`
+	URL             string `json:"url"`
+	Username        string `json:"username"`
+	Password        string `json:"password"`
+	CredhubURL      string `json:"credhub_url"`
+	Region          string `json:"region"`
+	MetricsURL      string `json:"metrics_url"`
+	MetricsUsername string `json:"metrics_username"`
+	MetricsPassword string `json:"metrics_password"`
+}
+
+// writeDeploySuccessJSON writes the deploy success message as a JSON object on a single line. Terraform and BOSH
+// write their progress to stdout before it, so it's always the last line of the output
+func writeDeploySuccessJSON(config *config.Config, stdout io.Writer) error {
+	return json.NewEncoder(stdout).Encode(deploySuccess{
+		Domain:          config.Domain,
+		URL:             config.ConcourseURL(),
+		Username:        config.ConcourseUsername,
+		Password:        config.ConcoursePassword,
+		CredhubURL:      config.CredhubURL,
+		Region:          config.Region,
+		MetricsURL:      config.MetricsURL(),
+		MetricsUsername: config.GrafanaUsername,
+		MetricsPassword: config.GrafanaPassword,
+	})
+}
+
 func writeConfigLoadedSuccessMessage(stdout io.Writer) error {
 	_, err := stdout.Write([]byte("\nUSING PREVIOUS DEPLOYMENT CONFIG\n"))
 
 	return err
 }
 
+// deleteDirector deletes the director's VM, so that a new one can be bootstrapped at the same IP. The
+// VMs it deployed are left running, and the new director finds them in its database on RDS
+func (client *Client) deleteDirector(config *config.Config, metadata *terraform.Metadata) error {
+	network, err := config.Network()
+	if err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintf(client.stderr, "\nWARNING: --force-redeploy-director is deleting the BOSH director at %s and bootstrapping a new one. Concourse keeps running, but can't be managed by BOSH until the new director is up, and the old director's persistent disk is left behind\n\n", network.DirectorIP()); err != nil {
+		return err
+	}
+
+	return client.iaasClient.DeleteVMWithPrivateIP(metadata.VPCID.Value, network.DirectorIP())
+}
+
 func loadDirectorState(configClient config.IClient) ([]byte, error) {
 	hasState, err := configClient.HasAsset(bosh.StateFilename)
 	if err != nil {
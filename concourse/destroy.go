@@ -1,11 +1,29 @@
 package concourse
 
 import (
+	"fmt"
 	"io"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
 )
 
-// Destroy destroys a concourse instance
-func (client *Client) Destroy() error {
+// Destroy destroys a concourse instance. A dry run lists what would be destroyed without deleting anything
+func (client *Client) Destroy(dryRun bool) error {
+	start := time.Now()
+	err := client.destroy(dryRun)
+	// A successful destroy deletes the config bucket and the audit trail with it,
+	// and a dry run doesn't change anything worth recording
+	if err != nil && !dryRun {
+		client.recordEvent("destroy", "", start, err)
+	}
+	return err
+}
+
+func (client *Client) destroy(dryRun bool) error {
 	conf, err := client.configClient.Load()
 	if err != nil {
 		return err
@@ -22,20 +40,90 @@ func (client *Client) Destroy() error {
 		return err
 	}
 
-	if err = client.iaasClient.DeleteVMsInVPC(metadata.VPCID.Value); err != nil {
+	if dryRun {
+		if err = terraformClient.Destroy(true); err != nil {
+			return err
+		}
+		return client.writeDestroyDryRunMessage(conf, metadata)
+	}
+
+	if err = client.setTerminationProtection(metadata, false); err != nil {
 		return err
 	}
 
-	if err := terraformClient.Destroy(); err != nil {
+	if err = client.deleteVMs(conf, metadata); err != nil {
 		return err
 	}
 
+	if err := terraformClient.Destroy(false); err != nil {
+		return err
+	}
+
+	if conf.TFLockTable != "" {
+		if err := client.iaasClient.DeleteLockTable(conf.TFLockTable); err != nil {
+			return err
+		}
+	}
+
 	if err := client.configClient.DeleteAll(conf); err != nil {
 		return err
 	}
 
 	return writeDestroySuccessMessage(client.stdout)
 }
+
+// deleteVMs deletes the VMs BOSH created, which Terraform doesn't know about. A VPC that concourse-up
+// created only has its VMs, but an existing VPC is shared, so only the VMs in its security groups are deleted
+func (client *Client) deleteVMs(conf *config.Config, metadata *terraform.Metadata) error {
+	if conf.ExistingNetwork == nil {
+		return client.iaasClient.DeleteVMsInVPC(metadata.VPCID.Value)
+	}
+	return client.iaasClient.DeleteVMsInSecurityGroups(deploymentSecurityGroups(metadata))
+}
+
+func deploymentSecurityGroups(metadata *terraform.Metadata) []string {
+	groups := []string{metadata.DirectorSecurityGroupID.Value, metadata.VMsSecurityGroupID.Value, metadata.ATCSecurityGroupID.Value}
+	if metadata.WorkersSecurityGroupID.Value != "" {
+		groups = append(groups, metadata.WorkersSecurityGroupID.Value)
+	}
+	return groups
+}
+
+// writeDestroyDryRunMessage lists what destroy deletes besides the resources in Terraform's plan
+func (client *Client) writeDestroyDryRunMessage(conf *config.Config, metadata *terraform.Metadata) error {
+	vms := metadata.VPCID.Value
+	if conf.ExistingNetwork != nil {
+		vms = "the security groups " + strings.Join(deploymentSecurityGroups(metadata), ", ")
+	}
+	message := fmt.Sprintf("\nDRY RUN. Nothing was deleted. As well as the resources in Terraform's plan above, destroy would delete:\n\n"+
+		"  - the BOSH director and every VM in %s, including the web node and workers\n", vms)
+	if metadata.RDSARN.Value != "" {
+		message += fmt.Sprintf("  - the RDS instance %s and every database on it, without taking a final snapshot\n", metadata.RDSARN.Value)
+	}
+	if conf.TFLockTable != "" {
+		message += fmt.Sprintf("  - the DynamoDB table %s, which locks Terraform's state\n", conf.TFLockTable)
+	}
+	message += fmt.Sprintf("  - the config bucket %s and everything in it, including the deployment's config, Terraform's state and the audit trail\n", conf.ConfigBucket)
+
+	if _, err := client.stdout.Write([]byte(message)); err != nil {
+		return err
+	}
+
+	hasDirectorState, err := client.configClient.HasAsset(bosh.StateFilename)
+	if err != nil {
+		return err
+	}
+	if hasDirectorState {
+		_, err = fmt.Fprintf(client.stderr, "\nWARNING: the director's state (%s) and creds (%s) are kept in the config bucket, so will be deleted with it. Use export-bundle to keep a copy\n", bosh.StateFilename, bosh.CredsFilename)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = client.stdout.Write([]byte("\nRe-run without --dry-run to destroy the deployment\n\n"))
+	return err
+}
+
 func writeDestroySuccessMessage(stdout io.Writer) error {
 	_, err := stdout.Write([]byte("\nDESTROY SUCCESSFUL\n\n"))
 
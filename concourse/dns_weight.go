@@ -0,0 +1,78 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+)
+
+// SetDNSWeight changes the weight of the deployment's weighted Route53 record, shifting
+// traffic on the domain towards or away from it without redeploying
+func (client *Client) SetDNSWeight(weight int) error {
+	start := time.Now()
+	err := client.setDNSWeightOnly(weight)
+	client.recordEvent("set-dns-weight", fmt.Sprintf("--weight %d", weight), start, err)
+	return err
+}
+
+// setDNSWeightOnly only applies Terraform, as the record is the only thing that changes
+func (client *Client) setDNSWeightOnly(weight int) error {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if config.StandbyOf != "" {
+		return errors.New("cannot weight the record of a standby as the domain stays with the primary until it is promoted")
+	}
+	if config.HostedZoneID == "" {
+		return errors.New("the deployment has no Route53 record. Deploy it with --domain first")
+	}
+
+	if err = client.warnAboutWeighting(config); err != nil {
+		return err
+	}
+	config.DNSWeighted = true
+	config.DNSWeight = weight
+
+	if _, err = client.applyTerraform(config); err != nil {
+		return err
+	}
+	if err = client.configClient.Update(config); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nDNS WEIGHT of %s for %s is now %d\n\n", config.Domain, config.Project, weight)
+	return err
+}
+
+// setDNSWeight makes the deployment's Route53 record a weighted record when a weight is given.
+// Once weighted it stays weighted, so that self-updates don't fight the other deployment for the domain
+func (client *Client) setDNSWeight(conf *config.Config) error {
+	if !client.deployArgs.DNSWeightIsSet {
+		return nil
+	}
+
+	if conf.HostedZoneID == "" {
+		return errors.New("--dns-weight requires a domain in a Route53 hosted zone. Pass --domain")
+	}
+
+	if err := client.warnAboutWeighting(conf); err != nil {
+		return err
+	}
+	conf.DNSWeighted = true
+	conf.DNSWeight = client.deployArgs.DNSWeight
+	return nil
+}
+
+// warnAboutWeighting warns that an existing simple record is replaced by the weighted record
+func (client *Client) warnAboutWeighting(conf *config.Config) error {
+	if conf.DNSWeighted || conf.DirectorPublicIP == "" {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(client.stderr, "\nWARNING: replacing the record for %s with a weighted record. Route53 can't change the routing policy of a record, so the domain won't resolve until the new record is created\n\n", conf.Domain)
+	return err
+}
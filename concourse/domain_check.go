@@ -0,0 +1,62 @@
+package concourse
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+)
+
+// domainCheckInterval is how often the domain is resolved while waiting for its record to propagate,
+// and domainCheckTimeout is how long to wait. Route53 records usually propagate within a minute
+var (
+	domainCheckInterval = 10 * time.Second
+	domainCheckTimeout  = 5 * time.Minute
+)
+
+// lookupHost resolves a domain to its addresses, and is replaced in tests
+var lookupHost = net.LookupHost
+
+// waitForDomain waits for the deployment's domain to resolve to the web node's public IP before a certificate
+// is issued for it. A hosted zone that the domain isn't delegated to, or a record that hasn't propagated,
+// fails the deploy with an explanation rather than leaving logins to fail against the wrong address
+func (client *Client) waitForDomain(config *config.Config, metadata *terraform.Metadata) error {
+	// Without a hosted zone the user manages the record, a private deployment's record only resolves
+	// from within its VPC, and a weighted record may resolve to another deployment sharing the domain
+	if config.HostedZoneID == "" || config.Private || config.DNSWeighted {
+		return nil
+	}
+
+	ip := metadata.ATCPublicIP.Value
+	if _, err := fmt.Fprintf(client.stdout, "\nCHECKING %s RESOLVES TO %s\n", config.Domain, ip); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(domainCheckTimeout)
+	for {
+		addresses, err := lookupHost(config.Domain)
+		if err == nil && containsString(addresses, ip) {
+			return nil
+		}
+		if time.Now().Add(domainCheckInterval).After(deadline) {
+			found := "did not resolve"
+			if err == nil {
+				found = fmt.Sprintf("resolves to %s", strings.Join(addresses, ", "))
+			}
+			return fmt.Errorf("%s %s after %s, rather than to the web node's IP %s. Check that the domain's NS records delegate it to the Route53 hosted zone %s", config.Domain, found, domainCheckTimeout, ip, config.HostedZoneID)
+		}
+		time.Sleep(domainCheckInterval)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+)
+
+// Drain quiesces the Concourse before maintenance: it pauses the active pipelines and lands every
+// worker, waiting up to timeout for their running builds to finish. Resume reverses it
+func (client *Client) Drain(timeout time.Duration) error {
+	start := time.Now()
+	err := client.drain(timeout)
+	client.recordEvent("drain", fmt.Sprintf("--timeout %s", timeout), start, err)
+	return err
+}
+
+func (client *Client) drain(timeout time.Duration) error {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if config.StandbyOf != "" {
+		return errors.New("cannot drain a standby as it has no Concourse deployment until it is promoted")
+	}
+	if config.Drained {
+		return errors.New("the deployment is already drained. Run resume first")
+	}
+
+	flyClient, err := client.buildFlyClient(config)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+
+	pipelines, err := flyClient.ActivePipelines()
+	if err != nil {
+		return err
+	}
+	if err = flyClient.PausePipelines(pipelines); err != nil {
+		return err
+	}
+
+	// Recorded before landing the workers, so that resume can still be run if landing times out
+	config.Drained = true
+	config.DrainedPipelines = pipelines
+	if err = client.configClient.Update(config); err != nil {
+		return err
+	}
+
+	if err = flyClient.LandWorkers(timeout); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nDRAINED. Paused %d pipelines and landed all workers. Run `concourse-up resume %s` when maintenance is finished\n\n", len(pipelines), config.Project)
+	return err
+}
+
+// Resume reverses a drain: it restarts the landed workers, so that they register with the ATC
+// again, and unpauses the pipelines the drain paused
+func (client *Client) Resume() error {
+	start := time.Now()
+	err := client.resume()
+	client.recordEvent("resume", "", start, err)
+	return err
+}
+
+func (client *Client) resume() error {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if !config.Drained {
+		return errors.New("the deployment is not drained")
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), config, client.stdout, client.stderr)
+	if err != nil {
+		return err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return err
+	}
+
+	boshClient, err := client.buildBoshClient(config, metadata)
+	if err != nil {
+		return err
+	}
+	defer boshClient.Cleanup()
+
+	// A landed worker stays landed until its worker process restarts
+	if err = boshClient.Restart("worker"); err != nil {
+		return err
+	}
+
+	flyClient, err := client.buildFlyClient(config)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+
+	if err = flyClient.UnpausePipelines(config.DrainedPipelines); err != nil {
+		return err
+	}
+
+	unpaused := len(config.DrainedPipelines)
+	config.Drained = false
+	config.DrainedPipelines = nil
+	if err = client.configClient.Update(config); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nRESUMED. Unpaused %d pipelines\n\n", unpaused)
+	return err
+}
+
+func (client *Client) buildFlyClient(config *config.Config) (fly.IClient, error) {
+	return client.buildFlyClientWithPassword(config, config.ConcoursePassword)
+}
+
+// buildFlyClientWithPassword is for deploys, where the running Concourse may still have the previous admin password
+func (client *Client) buildFlyClientWithPassword(config *config.Config, password string) (fly.IClient, error) {
+	return client.flyClientFactory(fly.Credentials{
+		Target:   config.Deployment,
+		API:      config.ConcourseURL(),
+		Username: config.ConcourseUsername,
+		Password: password,
+		Proxy:    deploymentProxy(config),
+	},
+		client.stdout,
+		client.stderr,
+	)
+}
@@ -0,0 +1,78 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+)
+
+// RunErrand runs the named BOSH errand in the Concourse deployment
+func (client *Client) RunErrand(name string) error {
+	start := time.Now()
+	err := client.runErrand(name)
+	client.recordEvent("run-errand", fmt.Sprintf("--errand %s", name), start, err)
+	return err
+}
+
+func (client *Client) runErrand(name string) error {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if config.StandbyOf != "" {
+		return errors.New("cannot run errands on a standby as it has no Concourse deployment until it is promoted")
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), config, client.stdout, client.stderr)
+	if err != nil {
+		return err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return err
+	}
+
+	return client.runErrands(config, metadata, []string{name})
+}
+
+// runErrands runs the errands in order, stopping at the first that fails. Every name is checked
+// against the deployment first, so a typo is caught before any errand runs
+func (client *Client) runErrands(config *config.Config, metadata *terraform.Metadata, errands []string) error {
+	if len(errands) == 0 {
+		return nil
+	}
+
+	boshClient, err := client.buildBoshClient(config, metadata)
+	if err != nil {
+		return err
+	}
+	defer boshClient.Cleanup()
+
+	deployed, err := boshClient.Errands()
+	if err != nil {
+		return err
+	}
+	for _, errand := range errands {
+		if !containsString(deployed, errand) {
+			return fmt.Errorf("errand %s isn't in the Concourse deployment, whose errands are: %s", errand, strings.Join(deployed, ", "))
+		}
+	}
+
+	for _, errand := range errands {
+		if _, err = fmt.Fprintf(client.stdout, "\nRUNNING ERRAND %s\n\n", errand); err != nil {
+			return err
+		}
+		if err = boshClient.RunErrand(errand); err != nil {
+			return fmt.Errorf("errand %s failed: %s", errand, err)
+		}
+	}
+
+	return nil
+}
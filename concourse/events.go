@@ -0,0 +1,122 @@
+package concourse
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+)
+
+// EventsFilename is the config bucket asset that the audit trail of operations is kept in
+const EventsFilename = "events.json"
+
+// Event records an operation performed on a deployment
+type Event struct {
+	Time     time.Time `json:"time"`
+	Operator string    `json:"operator"`
+	Command  string    `json:"command"`
+	Args     string    `json:"args"`
+	Result   string    `json:"result"`
+	Duration string    `json:"duration"`
+}
+
+// FetchEvents returns the operations performed on the deployment, oldest first
+func (client *Client) FetchEvents() ([]Event, error) {
+	hasEvents, err := client.configClient.HasAsset(EventsFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasEvents {
+		return []Event{}, nil
+	}
+
+	eventsBytes, err := client.configClient.LoadAsset(EventsFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	if err = json.Unmarshal(eventsBytes, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// recordEvent appends an event for the operation to the deployment's audit trail.
+// Failing to record an event is only warned about, so it never masks the operation's own result
+func (client *Client) recordEvent(command, args string, start time.Time, opErr error) {
+	if err := client.appendEvent(command, args, start, opErr); err != nil {
+		fmt.Fprintf(client.stderr, "WARNING: could not record %s in the audit trail: %s\n", command, err)
+	}
+}
+
+func (client *Client) appendEvent(command, args string, start time.Time, opErr error) error {
+	operator, err := client.iaasClient.CallerIdentity()
+	if err != nil {
+		return err
+	}
+
+	result := "success"
+	if opErr != nil {
+		result = fmt.Sprintf("failed: %s", opErr)
+	}
+
+	events, err := client.FetchEvents()
+	if err != nil {
+		return err
+	}
+
+	events = append(events, Event{
+		Time:     start.UTC(),
+		Operator: operator,
+		Command:  command,
+		Args:     args,
+		Result:   result,
+		Duration: time.Since(start).Round(time.Second).String(),
+	})
+
+	eventsBytes, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return client.configClient.StoreAsset(EventsFilename, eventsBytes)
+}
+
+func deployCommand(args *config.DeployArgs) string {
+	switch {
+	case args.Promote:
+		return "promote"
+	case args.SelfUpdate:
+		return "deploy --self-update"
+	default:
+		return "deploy"
+	}
+}
+
+// deployArgsSummary describes the deploy flags that change what gets deployed
+func deployArgsSummary(args *config.DeployArgs) string {
+	summary := []string{
+		fmt.Sprintf("--region %s", args.AWSRegion),
+		fmt.Sprintf("--workers %d", args.WorkerCount),
+		fmt.Sprintf("--worker-size %s", args.WorkerSize),
+		fmt.Sprintf("--web-size %s", args.WebSize),
+	}
+	if args.DBSizeIsSet {
+		summary = append(summary, fmt.Sprintf("--db-size %s", args.DBSize))
+	}
+	if args.DBEngineVersionIsSet {
+		summary = append(summary, fmt.Sprintf("--db-engine-version %s", args.DBEngineVersion))
+	}
+	if args.Domain != "" {
+		summary = append(summary, fmt.Sprintf("--domain %s", args.Domain))
+	}
+	if args.StandbyOf != "" {
+		summary = append(summary, fmt.Sprintf("--standby-of %s", args.StandbyOf))
+	}
+	return strings.Join(summary, " ")
+}
@@ -0,0 +1,183 @@
+package concourse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"gopkg.in/yaml.v2"
+)
+
+// Creds are the credentials of the deployment's Concourse, Grafana, CredHub and BOSH director,
+// gathered into one document for handing off to other tools
+type Creds struct {
+	Concourse LoginCreds   `json:"concourse" yaml:"concourse"`
+	Grafana   LoginCreds   `json:"grafana" yaml:"grafana"`
+	Credhub   CredhubCreds `json:"credhub" yaml:"credhub"`
+	BOSH      BOSHCreds    `json:"bosh" yaml:"bosh"`
+}
+
+// LoginCreds are the credentials to log in to one of the deployment's services
+type LoginCreds struct {
+	URL      string `json:"url" yaml:"url"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	CACert   string `json:"ca_cert,omitempty" yaml:"ca_cert,omitempty"`
+}
+
+// CredhubCreds are CredHub's user credentials, and the UAA client the credhub CLI can log in as instead
+type CredhubCreds struct {
+	LoginCreds   `yaml:",inline"`
+	Client       string `json:"client" yaml:"client"`
+	ClientSecret string `json:"client_secret" yaml:"client_secret"`
+}
+
+// BOSHCreds are the director's credentials and the SSH gateway to reach its VMs through. Vars is
+// the director's vars store, which holds the credentials BOSH generated for its own components
+type BOSHCreds struct {
+	LoginCreds        `yaml:",inline"`
+	GatewayHost       string                 `json:"gateway_host" yaml:"gateway_host"`
+	GatewayUser       string                 `json:"gateway_user" yaml:"gateway_user"`
+	GatewayPrivateKey string                 `json:"gateway_private_key" yaml:"gateway_private_key"`
+	Vars              map[string]interface{} `json:"vars" yaml:"vars"`
+}
+
+// ExportCreds gathers every credential of the deployment, including the director's vars store
+func (client *Client) ExportCreds() (*Creds, error) {
+	start := time.Now()
+	creds, err := client.exportCreds()
+	client.recordEvent("export-creds", "", start, err)
+	return creds, err
+}
+
+func (client *Client) exportCreds() (*Creds, error) {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.StandbyOf != "" {
+		return nil, fmt.Errorf("%s is a standby of %s, and has no credentials of its own until it is promoted", conf.Deployment, conf.StandbyOf)
+	}
+
+	boshCredsBytes, err := loadDirectorCreds(client.configClient)
+	if err != nil {
+		return nil, err
+	}
+	if boshCredsBytes == nil {
+		return nil, errors.New("the director's credentials have not been stored yet. Deploy first")
+	}
+
+	vars := map[string]interface{}{}
+	if err = yaml.Unmarshal(boshCredsBytes, &vars); err != nil {
+		return nil, fmt.Errorf("could not parse the director's credentials: %s", err)
+	}
+	for name, value := range vars {
+		vars[name] = stringKeys(value)
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), conf, client.stdout, client.stderr)
+	if err != nil {
+		return nil, err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return nil, err
+	}
+	directorIP := metadata.DirectorPublicIP.Value
+
+	return &Creds{
+		Concourse: LoginCreds{
+			URL:      conf.ConcourseURL(),
+			Username: conf.ConcourseUsername,
+			Password: conf.ConcoursePassword,
+		},
+		Grafana: LoginCreds{
+			URL:      conf.MetricsURL(),
+			Username: conf.GrafanaUsername,
+			Password: conf.GrafanaPassword,
+		},
+		Credhub: CredhubCreds{
+			LoginCreds: LoginCreds{
+				URL:      conf.CredhubURL,
+				Username: conf.CredhubUsername,
+				Password: conf.CredhubPassword,
+				CACert:   conf.CredhubCACert,
+			},
+			Client:       "credhub_cli",
+			ClientSecret: conf.CredhubPassword,
+		},
+		BOSH: BOSHCreds{
+			LoginCreds: LoginCreds{
+				URL:      fmt.Sprintf("https://%s:25555", directorIP),
+				Username: conf.DirectorUsername,
+				Password: conf.DirectorPassword,
+				CACert:   conf.DirectorCACert,
+			},
+			GatewayHost:       directorIP,
+			GatewayUser:       "vcap",
+			GatewayPrivateKey: conf.PrivateKey,
+			Vars:              vars,
+		},
+	}, nil
+}
+
+// Encode returns the credentials as a YAML or JSON document
+func (creds *Creds) Encode(format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		return yaml.Marshal(creds)
+	case "json":
+		return json.MarshalIndent(creds, "", "  ")
+	default:
+		return nil, fmt.Errorf("unknown format: `%s`", format)
+	}
+}
+
+// EncryptCreds writes an exported credentials document to w, symmetrically encrypted with passphrase
+// as an ASCII-armored OpenPGP message. Unlike a bundle, other tools can decrypt it, eg with gpg --decrypt
+func EncryptCreds(w io.Writer, passphrase string, document []byte) error {
+	armored, err := armor.Encode(w, "PGP MESSAGE", nil)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := openpgp.SymmetricallyEncrypt(armored, []byte(passphrase), nil, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = plaintext.Write(document); err != nil {
+		return err
+	}
+	if err = plaintext.Close(); err != nil {
+		return err
+	}
+
+	return armored.Close()
+}
+
+// stringKeys converts the maps YAML decodes nested values to into maps keyed by string, which
+// can be encoded as JSON
+func stringKeys(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for k, v := range value {
+			converted[fmt.Sprint(k)] = stringKeys(v)
+		}
+		return converted
+	case []interface{}:
+		for i, v := range value {
+			value[i] = stringKeys(v)
+		}
+		return value
+	default:
+		return value
+	}
+}
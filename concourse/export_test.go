@@ -0,0 +1,53 @@
+package concourse
+
+import (
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/credhub"
+)
+
+// SetPipelineRetryBackoff lets tests retry setting the default pipeline without waiting
+func SetPipelineRetryBackoff(backoff time.Duration) {
+	pipelineRetryBackoff = backoff
+}
+
+// SetNow lets tests choose the current time
+func SetNow(newNow func() time.Time) {
+	now = newNow
+}
+
+// SetReleasesURL lets tests list releases from a local server
+func SetReleasesURL(url string) {
+	releasesURL = url
+}
+
+// SetPingExternalDB lets tests check external databases without connecting to them
+func SetPingExternalDB(ping func(address, name, username, password, caCert string) error) {
+	pingExternalDB = ping
+}
+
+// SetLookupHost lets tests resolve domains without DNS
+func SetLookupHost(lookup func(host string) ([]string, error)) {
+	lookupHost = lookup
+}
+
+// SetQueryDNSServer lets tests check DNS servers without querying them
+func SetQueryDNSServer(query func(server, name string) error) {
+	queryDNSServer = query
+}
+
+// SetDomainCheckTimeout lets tests give up waiting for a domain to resolve without waiting
+func SetDomainCheckTimeout(interval, timeout time.Duration) {
+	domainCheckInterval = interval
+	domainCheckTimeout = timeout
+}
+
+// SetCredhubClientFactory lets tests seed a fake CredHub
+func (client *Client) SetCredhubClientFactory(factory credhub.ClientFactory) {
+	client.credhubClientFactory = factory
+}
+
+// SetCredhubRetryBackoff lets tests retry seeding CredHub without waiting
+func SetCredhubRetryBackoff(backoff time.Duration) {
+	credhubRetryBackoff = backoff
+}
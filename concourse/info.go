@@ -2,6 +2,7 @@ package concourse
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/EngineerBetter/concourse-up/bosh"
 	"github.com/EngineerBetter/concourse-up/config"
 	"github.com/EngineerBetter/concourse-up/terraform"
+	"github.com/EngineerBetter/concourse-up/util"
 	"github.com/fatih/color"
 )
 
@@ -18,6 +20,19 @@ type Info struct {
 	Terraform *terraform.Metadata `json:"terraform"`
 	Config    *config.Config      `json:"config"`
 	Instances []bosh.Instance     `json:"instances"`
+	// Versions is nil for a standby, which has no director to ask
+	Versions *Versions `json:"versions,omitempty"`
+}
+
+// Versions are the versions the BOSH director reports it and the Concourse deployment are running
+type Versions struct {
+	Concourse string `json:"concourse"`
+	BOSH      string `json:"bosh"`
+	Stemcell  string `json:"stemcell"`
+}
+
+func (versions *Versions) String() string {
+	return fmt.Sprintf("concourse: %s\nbosh: %s\nstemcell: %s\n", versions.Concourse, versions.BOSH, versions.Stemcell)
 }
 
 // FetchInfo fetches and builds the info
@@ -27,6 +42,10 @@ func (client *Client) FetchInfo() (*Info, error) {
 		return nil, err
 	}
 
+	if err = client.warnIfDirectorCertExpiring(config); err != nil {
+		return nil, err
+	}
+
 	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), config, client.stdout, client.stderr)
 	if err != nil {
 		return nil, err
@@ -38,6 +57,14 @@ func (client *Client) FetchInfo() (*Info, error) {
 		return nil, err
 	}
 
+	// Standbys have no director to ask for instances until they're promoted
+	if config.StandbyOf != "" {
+		return &Info{
+			Terraform: metadata,
+			Config:    config,
+		}, nil
+	}
+
 	boshClient, err := client.buildBoshClient(config, metadata)
 	if err != nil {
 		return nil, err
@@ -49,31 +76,62 @@ func (client *Client) FetchInfo() (*Info, error) {
 		return nil, err
 	}
 
+	running, err := boshClient.RunningVersions()
+	if err != nil {
+		return nil, fmt.Errorf("could not ask the BOSH director for the running versions: %s", err)
+	}
+
 	return &Info{
 		Terraform: metadata,
 		Config:    config,
 		Instances: instances,
+		Versions: &Versions{
+			Concourse: running["concourse"],
+			BOSH:      running["bosh"],
+			Stemcell:  running["concourse-stemcell"],
+		},
 	}, nil
 }
 
-const infoTemplate = `Deployment:
+const infoTemplate = `{{with .Config.Description}}{{.}}
+
+{{end}}Deployment:
 	IAAS:   aws
 	Region: {{.Config.Region}}
-
+{{- if not .Config.LastDeployedAt.IsZero}}
+	Last deployed: {{.Config.LastDeployedAt.Format "2006-01-02 15:04:05 MST"}} by {{.Config.LastDeployedBy}}
+{{- end}}
+{{with .Config.Notes}}
+Notes:
+	{{. | replace "\n" "\n\t"}}
+{{end}}{{with .Config.PrometheusRemoteWriteURL}}
+Metrics:
+	Sent to Prometheus: {{.}}
+{{end}}{{with .Config.SyslogAddress}}
+Logs:
+	Forwarded to: {{.}} ({{$.Config.SyslogTransport}}{{if $.Config.SyslogTLS}} over TLS{{end}})
+{{end}}
 Workers:
 	Count:              {{.Config.ConcourseWorkerCount}}
 	Size:               {{.Config.ConcourseWorkerSize}}
-	Outbound Public IP: {{.Terraform.NatGatewayIP.Value}}
+	Disk size:          {{.Config.WorkerDiskSize}} GB
+	Outbound Public IP: {{or .Terraform.NatGatewayIP.Value "the existing private subnet's NAT"}}
 
 Instances:
 {{range .Instances}}
 	{{.Name}} {{.IP | replace "\n" ","}} {{.State}}
 {{end}}
+{{- with .Versions}}
+Versions:
+	Concourse: {{.Concourse}}
+	BOSH:      {{.BOSH}}
+	Stemcell:  {{.Stemcell}}
+{{end}}
 
 Concourse credentials:
 	username: {{.Config.ConcourseUsername}}
 	password: {{.Config.ConcoursePassword}}
-	URL:      https://{{.Config.Domain}}
+	URL:      {{.Config.ConcourseURL}}
 
 Credhub credentials:
 	username: {{.Config.CredhubUsername}}
@@ -83,9 +141,9 @@ Credhub credentials:
 		{{ .Config.CredhubCACert | replace "\n" "\n\t\t"}}
 
 Grafana credentials:
-	username: {{.Config.ConcourseUsername}}
-	password: {{.Config.ConcoursePassword}}
-	URL:      https://{{.Config.Domain}}:3000
+	username: {{.Config.GrafanaUsername}}
+	password: {{.Config.GrafanaPassword}}
+	URL:      {{.Config.MetricsURL}}
 
 Bosh credentials:
 	username: {{.Config.DirectorUsername}}
@@ -113,7 +171,7 @@ func (info *Info) String() string {
 }
 
 func writeTempFile(data string) (name string, err error) {
-	f, err := ioutil.TempFile("", "")
+	f, err := ioutil.TempFile(util.WorkDir, "")
 	if err != nil {
 		return "", err
 	}
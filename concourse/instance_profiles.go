@@ -0,0 +1,113 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+)
+
+// instanceProfilePermission is a set of actions an instance profile must be allowed to perform on resources
+type instanceProfilePermission struct {
+	actions   []string
+	resources []string
+}
+
+// directorActions are the EC2 actions the BOSH AWS CPI uses to manage VMs and disks
+var directorActions = []string{
+	"ec2:AssociateAddress",
+	"ec2:AttachVolume",
+	"ec2:CreateSnapshot",
+	"ec2:CreateTags",
+	"ec2:CreateVolume",
+	"ec2:DeleteSnapshot",
+	"ec2:DeleteVolume",
+	"ec2:DeregisterImage",
+	"ec2:DescribeAddresses",
+	"ec2:DescribeAvailabilityZones",
+	"ec2:DescribeImages",
+	"ec2:DescribeInstances",
+	"ec2:DescribeRegions",
+	"ec2:DescribeSecurityGroups",
+	"ec2:DescribeSnapshots",
+	"ec2:DescribeSubnets",
+	"ec2:DescribeVolumes",
+	"ec2:DetachVolume",
+	"ec2:RebootInstances",
+	"ec2:RegisterImage",
+	"ec2:RunInstances",
+	"ec2:TerminateInstances",
+}
+
+func blobstorePermissions(bucket string, actions ...string) instanceProfilePermission {
+	return instanceProfilePermission{
+		actions:   actions,
+		resources: []string{fmt.Sprintf("arn:aws:s3:::%s/*", bucket)},
+	}
+}
+
+// setInstanceProfiles switches the deployment to any pre-existing instance profiles given, which Terraform
+// needs to know before it's applied. Deployments keep their profiles unless new ones are given, so
+// that self-updates don't switch back to Terraform-managed IAM users
+func (client *Client) setInstanceProfiles(conf *config.Config) error {
+	if client.deployArgs.DirectorInstanceProfile == "" {
+		return nil
+	}
+
+	if conf.DirectorPublicIP != "" && conf.DirectorInstanceProfile == "" {
+		return errors.New("found an existing deployment using IAM users created by concourse-up. Refusing to switch it to pre-existing instance profiles")
+	}
+
+	conf.DirectorInstanceProfile = client.deployArgs.DirectorInstanceProfile
+	conf.WebInstanceProfile = client.deployArgs.WebInstanceProfile
+	conf.WorkerInstanceProfile = client.deployArgs.WorkerInstanceProfile
+	return nil
+}
+
+// checkInstanceProfiles checks the pre-existing instance profiles given have the permissions the
+// deployment needs. It runs once Terraform has created the blobstore bucket, before BOSH is deployed
+func (client *Client) checkInstanceProfiles(metadata *terraform.Metadata) error {
+	if client.deployArgs.DirectorInstanceProfile == "" {
+		return nil
+	}
+
+	// The director passes the web and worker roles to the VMs it creates
+	var passedRoles []string
+	for _, arn := range []string{client.deployArgs.WebInstanceProfile, client.deployArgs.WorkerInstanceProfile} {
+		role, err := client.iaasClient.InstanceProfileRole(arn)
+		if err != nil {
+			return err
+		}
+		passedRoles = append(passedRoles, role)
+	}
+
+	bucket := metadata.BlobstoreBucket.Value
+	profiles := []struct {
+		arn         string
+		permissions []instanceProfilePermission
+	}{
+		{client.deployArgs.DirectorInstanceProfile, []instanceProfilePermission{
+			{directorActions, []string{"*"}},
+			{[]string{"iam:PassRole"}, passedRoles},
+			{[]string{"s3:ListBucket"}, []string{fmt.Sprintf("arn:aws:s3:::%s", bucket)}},
+			blobstorePermissions(bucket, "s3:GetObject", "s3:PutObject", "s3:DeleteObject"),
+		}},
+		{client.deployArgs.WebInstanceProfile, []instanceProfilePermission{
+			blobstorePermissions(bucket, "s3:GetObject", "s3:PutObject"),
+		}},
+		{client.deployArgs.WorkerInstanceProfile, []instanceProfilePermission{
+			blobstorePermissions(bucket, "s3:GetObject", "s3:PutObject"),
+		}},
+	}
+
+	for _, profile := range profiles {
+		for _, permission := range profile.permissions {
+			if err := client.iaasClient.CheckInstanceProfile(profile.arn, permission.actions, permission.resources); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+)
+
+// now returns the current time, and is replaced in tests
+var now = time.Now
+
+// setMaintenanceWindow sets the window RDS applies updates in, and refuses to deploy outside
+// it when it's enforced. Dry runs don't change anything, so they aren't refused
+func (client *Client) setMaintenanceWindow(conf *config.Config) error {
+	if client.deployArgs.MaintenanceWindowIsSet {
+		conf.MaintenanceWindow = ""
+		conf.RDSBackupWindow = ""
+		if client.deployArgs.MaintenanceWindow != "" {
+			window, err := config.ParseMaintenanceWindow(client.deployArgs.MaintenanceWindow)
+			if err != nil {
+				return err
+			}
+			conf.MaintenanceWindow = window.String()
+			conf.RDSBackupWindow = window.BackupWindow()
+		}
+	}
+	if client.deployArgs.EnforceMaintenanceWindowIsSet {
+		conf.EnforceMaintenanceWindow = client.deployArgs.EnforceMaintenanceWindow
+	}
+
+	if !conf.EnforceMaintenanceWindow || client.deployArgs.DryRun {
+		return nil
+	}
+	if conf.MaintenanceWindow == "" {
+		return errors.New("--enforce-maintenance-window requires a --maintenance-window")
+	}
+
+	window, err := config.ParseMaintenanceWindow(conf.MaintenanceWindow)
+	if err != nil {
+		return err
+	}
+	current := now().UTC()
+	if !window.Contains(current) {
+		return fmt.Errorf("it is %s UTC, which is outside the maintenance window %s. Refusing to deploy as the maintenance window is enforced. Use --enforce-maintenance-window=false to deploy anyway",
+			current.Format("Mon 15:04"), conf.MaintenanceWindow)
+	}
+
+	return nil
+}
+
+// deferSelfUpdate reports whether a self-update falls outside an enforced maintenance window. It's then
+// skipped rather than refused, so that the self-update job stays green until its next run inside the window
+func (client *Client) deferSelfUpdate(conf *config.Config) (bool, error) {
+	if !conf.EnforceMaintenanceWindow || conf.MaintenanceWindow == "" {
+		return false, nil
+	}
+
+	window, err := config.ParseMaintenanceWindow(conf.MaintenanceWindow)
+	if err != nil {
+		return false, err
+	}
+	current := now().UTC()
+	if window.Contains(current) {
+		return false, nil
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nSELF-UPDATE DEFERRED. It is %s UTC, which is outside the maintenance window %s. Nothing was changed\n",
+		current.Format("Mon 15:04"), conf.MaintenanceWindow)
+	return true, err
+}
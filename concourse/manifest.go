@@ -0,0 +1,83 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GenerateManifest renders the Concourse manifest a deploy with the deploy args would give BOSH, without
+// changing anything. The flags are applied to the config as they are on a dry run, and the infrastructure
+// and certificates are as the last deploy left them
+func (client *Client) GenerateManifest() ([]byte, error) {
+	start := time.Now()
+	manifest, err := client.generateManifest()
+	client.recordEvent("generate-manifest", "", start, err)
+	return manifest, err
+}
+
+func (client *Client) generateManifest() ([]byte, error) {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.StandbyOf != "" {
+		return nil, fmt.Errorf("%s is a standby of %s, and has no Concourse of its own until it is promoted", conf.Deployment, conf.StandbyOf)
+	}
+
+	boshCredsBytes, err := loadDirectorCreds(client.configClient)
+	if err != nil {
+		return nil, err
+	}
+	if boshCredsBytes == nil {
+		return nil, errors.New("the director's credentials have not been stored yet. Deploy first")
+	}
+
+	// Nothing is written back, as on a dry run
+	deployArgs := *client.deployArgs
+	deployArgs.DryRun = true
+	client.deployArgs = &deployArgs
+
+	conf, err = client.checkPreTerraformConfigRequirements(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), conf, client.stdout, client.stderr)
+	if err != nil {
+		return nil, err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	if deployArgs.Domain == "" {
+		if conf.Domain, err = conf.ATCAddress(metadata.ATCPublicIP.Value); err != nil {
+			return nil, err
+		}
+	}
+	if err = client.loadBrandingAssets(conf); err != nil {
+		return nil, err
+	}
+	if deployArgs.BrandingWordmark != "" {
+		conf.BrandingWordmark = deployArgs.BrandingWordmark
+	}
+	if deployArgs.BrandingCSS != "" {
+		conf.BrandingCSS = deployArgs.BrandingCSS
+	}
+	if err = client.applyDeployArgs(conf, metadata); err != nil {
+		return nil, err
+	}
+
+	boshClient, err := client.buildBoshClient(conf, metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer boshClient.Cleanup()
+
+	return boshClient.GenerateManifest(boshCredsBytes, deployArgs.ShowSecrets)
+}
@@ -0,0 +1,67 @@
+package concourse
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+)
+
+// dnsServerTimeout is how long each DNS server has to answer before it's taken to be unreachable
+var dnsServerTimeout = 5 * time.Second
+
+// queryDNSServer looks a name up with the DNS server at the given address, and is replaced in tests
+var queryDNSServer = func(server, name string) error {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, net.JoinHostPort(server, "53"))
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsServerTimeout)
+	defer cancel()
+	_, err := resolver.LookupHost(ctx, name)
+	return err
+}
+
+// setNameServers keeps the DNS and NTP servers unless new ones are given, so that self-updates don't
+// go back to the defaults. DNS servers given on this run are checked before anything is deployed with them
+func (client *Client) setNameServers(conf *config.Config) error {
+	if client.deployArgs.NTPServersIsSet {
+		conf.NTPServers = client.deployArgs.NTPServers
+	}
+	if !client.deployArgs.DNSServersIsSet {
+		return nil
+	}
+	conf.DNSServers = client.deployArgs.DNSServers
+
+	return client.checkDNSServers(conf)
+}
+
+// checkDNSServers warns unless at least one of the deployment's DNS servers answers, as the VMs would come up
+// without name resolution. This machine may not be able to reach servers the VMs can, so it doesn't fail the
+// deploy. The VMs need to resolve S3 for the blobstore, so that's what's looked up, but a server that answers
+// that the name doesn't exist is still reachable
+func (client *Client) checkDNSServers(conf *config.Config) error {
+	if len(conf.DNSServers) == 0 {
+		return nil
+	}
+
+	name := fmt.Sprintf("s3.%s.amazonaws.com", conf.Region)
+	failures := []string{}
+	for _, server := range conf.DNSServers {
+		err := queryDNSServer(server, name)
+		if dnsErr, ok := err.(*net.DNSError); err == nil || ok && dnsErr.IsNotFound {
+			return nil
+		}
+		failures = append(failures, fmt.Sprintf("%s (%s)", server, err))
+	}
+
+	_, err := fmt.Fprintf(client.stderr, "\nWARNING: none of the DNS servers answered from here. Unless the VMs can reach them, they will come up without name resolution: %s\n\n", strings.Join(failures, ", "))
+	return err
+}
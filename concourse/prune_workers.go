@@ -0,0 +1,36 @@
+package concourse
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PruneWorkers removes the registrations of workers in any of the given states
+func (client *Client) PruneWorkers(states []string) error {
+	start := time.Now()
+	err := client.pruneWorkers(states)
+	client.recordEvent("prune-workers", fmt.Sprintf("--state %s", strings.Join(states, ",")), start, err)
+	return err
+}
+
+func (client *Client) pruneWorkers(states []string) error {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	flyClient, err := client.buildFlyClient(config)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+
+	pruned, err := flyClient.PruneWorkers(states)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.stdout.Write([]byte(fmt.Sprintf("\nPRUNED %d WORKERS\n\n", len(pruned))))
+	return err
+}
@@ -0,0 +1,120 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/terraform"
+)
+
+// RenewCerts replaces the Concourse and BOSH director certificates that are due to expire,
+// and redeploys only what uses them. Renewing just the Concourse certificate leaves the director alone.
+// A Concourse certificate that was provided with --tls-cert can only be replaced by tlsCert and tlsKey
+func (client *Client) RenewCerts(tlsCert, tlsKey string) error {
+	start := time.Now()
+	err := client.renewCerts(tlsCert, tlsKey)
+	client.recordEvent("renew-certs", "", start, err)
+	return err
+}
+
+func (client *Client) renewCerts(tlsCert, tlsKey string) error {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if config.StandbyOf != "" {
+		return errors.New("cannot renew the certificates of a standby. Promote it first")
+	}
+
+	renewConcourse := tlsCert != "" || timeTillExpiry(config.ConcourseCert) < certRenewalPeriod
+	renewDirector := timeTillExpiry(config.DirectorCert) < certRenewalPeriod ||
+		timeTillExpiry(config.DirectorCACert) < certRenewalPeriod
+	if !renewConcourse && !renewDirector {
+		_, err = fmt.Fprintf(client.stdout, "\nNo certificates expire in the next %d days, so none were renewed\n\n", int(certRenewalPeriod.Hours()/24))
+		return err
+	}
+
+	// Generating a certificate would replace one the user provided with a self-signed one
+	if renewConcourse && tlsCert == "" && config.ConcourseUserProvidedCert && !usesACME(config) {
+		return fmt.Errorf("the Concourse certificate for %s was provided with --tls-cert, so can't be renewed by generating another. Pass a new certificate with --tls-cert and --tls-key", config.Domain)
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), config, client.stdout, client.stderr)
+	if err != nil {
+		return err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return err
+	}
+
+	if renewDirector {
+		// ensureDirectorCerts only generates missing certificates
+		config.DirectorCACert = ""
+		config, err = client.ensureDirectorCerts(config, metadata)
+		if err != nil {
+			return err
+		}
+	}
+	if renewConcourse {
+		if _, err = fmt.Fprintf(client.stdout, "\nRENEWING CONCOURSE CERTIFICATE (%s)\n\n", config.Domain); err != nil {
+			return err
+		}
+		if tlsCert != "" {
+			config.ConcourseCert = tlsCert
+			config.ConcourseKey = tlsKey
+			config.ConcourseUserProvidedCert = true
+		} else {
+			config, err = client.generateConcourseCerts(config)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err = client.configClient.Update(config); err != nil {
+		return err
+	}
+
+	// The director has to be recreated to use its new certificate, and the Concourse
+	// deployment is converged through it afterwards
+	if renewDirector {
+		if err = client.deployBosh(config, metadata, false, false); err != nil {
+			return err
+		}
+		if err = client.configClient.Update(config); err != nil {
+			return err
+		}
+	} else if err = client.deployConcourseOnly(config, metadata); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nRENEWED. Concourse certificate expires in %d days, director certificate in %d days\n\n",
+		int(timeTillExpiry(config.ConcourseCert).Hours()/24), int(timeTillExpiry(config.DirectorCert).Hours()/24))
+	return err
+}
+
+// deployConcourseOnly redeploys the Concourse deployment on the existing director
+func (client *Client) deployConcourseOnly(config *config.Config, metadata *terraform.Metadata) error {
+	boshClient, err := client.buildBoshClient(config, metadata)
+	if err != nil {
+		return err
+	}
+	defer boshClient.Cleanup()
+
+	boshCredsBytes, err := loadDirectorCreds(client.configClient)
+	if err != nil {
+		return err
+	}
+
+	boshCredsBytes, err = boshClient.DeployConcourse(boshCredsBytes)
+	if err1 := client.configClient.StoreAsset(bosh.CredsFilename, boshCredsBytes); err == nil {
+		err = err1
+	}
+	return err
+}
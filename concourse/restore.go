@@ -0,0 +1,102 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+)
+
+// Restore recreates the deployment's RDS instance from a snapshot, such as one taken by deploy
+// --db-backup, then redeploys on it with the existing director state and creds
+func (client *Client) Restore(snapshotID string) error {
+	start := time.Now()
+	err := client.restore(snapshotID)
+	client.recordEvent("restore", fmt.Sprintf("--snapshot %s", snapshotID), start, err)
+	return err
+}
+
+func (client *Client) restore(snapshotID string) error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if conf.StandbyOf != "" {
+		return errors.New("cannot restore a standby as its database is a replica. Promote it first")
+	}
+	if conf.ExternalDBURL != "" {
+		return errors.New("cannot restore a deployment that uses an external database, as there is no RDS instance. Restore the external database instead")
+	}
+
+	// Terraform destroys the current database before creating one from the snapshot,
+	// so the snapshot is checked first
+	engineVersion, err := client.iaasClient.CheckDBSnapshot(snapshotID)
+	if err != nil {
+		return err
+	}
+	if err = client.matchSnapshotEngineVersion(conf, snapshotID, engineVersion); err != nil {
+		return err
+	}
+
+	// Terraform replaces the RDS instance whenever the snapshot it's created from changes, so the
+	// snapshot is kept for later deploys, and stored before Terraform runs in case it fails part way
+	conf.RDSSnapshotIdentifier = snapshotID
+	if err = client.configClient.Update(conf); err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintf(client.stdout, "\nRESTORING THE RDS INSTANCE FROM SNAPSHOT %s\n\n", snapshotID); err != nil {
+		return err
+	}
+
+	metadata, err := client.applyTerraform(conf)
+	if err != nil {
+		return err
+	}
+
+	conf, err = client.ensureConcourseCerts(false, conf, metadata)
+	if err != nil {
+		return err
+	}
+	conf, err = client.ensureBrandingAssets(conf)
+	if err != nil {
+		return err
+	}
+	if err = client.configClient.Update(conf); err != nil {
+		return err
+	}
+
+	// The director's state and creds are kept in the conf bucket, so the same
+	// director manages Concourse on the restored database
+	if err = client.deployBosh(conf, metadata, false, false); err != nil {
+		return err
+	}
+	if err = client.configClient.Update(conf); err != nil {
+		return err
+	}
+
+	return writeDeploySuccessMessage(conf, metadata, client.stdout)
+}
+
+// matchSnapshotEngineVersion keeps the deployment on the snapshot's Postgres version if it's newer,
+// as RDS can't downgrade the restored database. Older snapshots are upgraded by Terraform
+func (client *Client) matchSnapshotEngineVersion(conf *config.Config, snapshotID, engineVersion string) error {
+	deployedVersion := conf.RDSEngineVersion
+	if deployedVersion == "" {
+		deployedVersion = config.DefaultRDSEngineVersion
+	}
+
+	switch compareVersions(engineVersion, deployedVersion) {
+	case -1:
+		_, err := fmt.Fprintf(client.stderr, "\nWARNING: snapshot %s is of Postgres %s. The restored database will be upgraded to %s\n\n", snapshotID, engineVersion, deployedVersion)
+		return err
+	case 1:
+		conf.RDSEngineVersion = engineVersion
+		_, err := fmt.Fprintf(client.stderr, "\nWARNING: snapshot %s is of Postgres %s, which is newer than %s. The deployment will stay on %s from now on\n\n", snapshotID, engineVersion, deployedVersion, engineVersion)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/config"
+)
+
+// Rollback restores the config and the director's state and creds to the versions kept from before
+// the last deploy, then redeploys BOSH with them. Terraform's state isn't versioned, so the
+// infrastructure is left as the last deploy made it
+func (client *Client) Rollback() error {
+	start := time.Now()
+	err := client.rollback()
+	client.recordEvent("rollback", "", start, err)
+	return err
+}
+
+func (client *Client) rollback() error {
+	current, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if current.StandbyOf != "" {
+		return errors.New("cannot roll back a standby. Deploy it again with --standby-of instead")
+	}
+	if current.LastDeployedAt.IsZero() {
+		return errors.New("there is no record of when the deployment was last deployed, so there is no previous version to roll back to")
+	}
+
+	// The deploy stamped the config before writing anything, so the versions it replaced are the
+	// ones replaced since then
+	deployedAt := current.LastDeployedAt
+	configVersion, err := client.versionAt(config.ConfigFilename, deployedAt)
+	if err != nil {
+		return err
+	}
+	if configVersion == 0 {
+		return fmt.Errorf("there is no version of the config from before the deploy at %s to roll back to", deployedAt.Format(time.RFC3339))
+	}
+	conf, err := client.configClient.LoadVersion(configVersion)
+	if err != nil {
+		return err
+	}
+
+	// The director's state and creds are only restored if the deploy got as far as replacing them
+	assets := map[string][]byte{}
+	for _, filename := range []string{bosh.StateFilename, bosh.CredsFilename} {
+		n, err := client.versionAt(filename, deployedAt)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			continue
+		}
+		if assets[filename], err = client.configClient.LoadAssetVersion(filename, n); err != nil {
+			return err
+		}
+	}
+
+	if _, err = fmt.Fprintf(client.stderr, "\nWARNING: Terraform's state is not rolled back, so the infrastructure is left as the deploy at %s made it\n", deployedAt.Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), conf, client.stdout, client.stderr)
+	if err != nil {
+		return err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return err
+	}
+
+	if err = client.configClient.Update(conf); err != nil {
+		return err
+	}
+	for _, filename := range []string{bosh.StateFilename, bosh.CredsFilename} {
+		if contents, ok := assets[filename]; ok {
+			if err = client.configClient.StoreAsset(filename, contents); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = fmt.Fprintf(client.stdout, "\nROLLING BACK TO THE CONFIG FROM BEFORE THE DEPLOY AT %s\n\n", deployedAt.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if err = client.deployBosh(conf, metadata, false, false); err != nil {
+		return err
+	}
+	if err = client.configClient.Update(conf); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nROLLED BACK\n\n")
+	return err
+}
+
+// versionAt returns which previous version of an asset was current at the given time, or 0 if the
+// current version still is. Versions are named by when they were replaced, so it's the oldest one
+// replaced since then
+func (client *Client) versionAt(filename string, at time.Time) (int, error) {
+	replacedAt, err := client.configClient.Versions(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for n < len(replacedAt) && !replacedAt[n].Before(at) {
+		n++
+	}
+
+	if n == len(replacedAt) && n >= config.MaxVersions {
+		return 0, fmt.Errorf("%s has been written more than %d times since %s, so its version from then is no longer kept", filename, config.MaxVersions, at.Format(time.RFC3339))
+	}
+	return n, nil
+}
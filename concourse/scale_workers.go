@@ -0,0 +1,132 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+)
+
+// ScaleWorkers changes the number or size of the workers. Count 0 and size "" keep the current values
+func (client *Client) ScaleWorkers(count int, size string) error {
+	start := time.Now()
+	err := client.scaleWorkers(count, size)
+	client.recordEvent("scale-workers", scaleWorkersSummary(count, size), start, err)
+	return err
+}
+
+// scaleWorkers only changes the workers, so it skips Terraform and the director, keeps the
+// existing certificates, and redeploys Concourse on the existing director
+func (client *Client) scaleWorkers(count int, size string) error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if conf.StandbyOf != "" {
+		return errors.New("cannot scale the workers of a standby, as it has none. Promote it first")
+	}
+	if conf.DirectorPublicIP == "" {
+		return errors.New("found no deployment to scale. Use deploy to create one")
+	}
+
+	previousWorkerCount := conf.ConcourseWorkerCount
+	previousWorkerSize := conf.ConcourseWorkerSize
+	if count != 0 {
+		conf.ConcourseWorkerCount = count
+	}
+	if size != "" {
+		conf.ConcourseWorkerSize = size
+	}
+	if conf.ConcourseWorkerCount == previousWorkerCount && conf.ConcourseWorkerSize == previousWorkerSize {
+		_, err = fmt.Fprintf(client.stdout, "\nThe deployment already has %d %s workers, so nothing was changed\n\n", previousWorkerCount, previousWorkerSize)
+		return err
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), conf, client.stdout, client.stderr)
+	if err != nil {
+		return err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return err
+	}
+
+	flyClient, err := client.flyClientFactory(fly.Credentials{
+		Target:   conf.Deployment,
+		API:      conf.ConcourseURL(),
+		Username: conf.ConcourseUsername,
+		Password: conf.ConcoursePassword,
+		Proxy:    deploymentProxy(conf),
+	},
+		client.stdout,
+		client.stderr,
+	)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+
+	if err = client.retireSurplusWorkers(previousWorkerCount, conf, metadata, flyClient); err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintf(client.stdout, "\nSCALING TO %d %s WORKERS\n\n", conf.ConcourseWorkerCount, conf.ConcourseWorkerSize); err != nil {
+		return err
+	}
+
+	if err = client.configClient.Update(conf); err != nil {
+		return err
+	}
+	if err = client.deployConcourseOnly(conf, metadata); err != nil {
+		return err
+	}
+
+	if conf.DefaultPipelineDisabled {
+		return nil
+	}
+	// The self-update pipeline redeploys with the flags it was set with, so it needs
+	// the new count and size or the next self-update would scale the workers back
+	return flyClient.SetDefaultPipeline(selfUpdatePipelineArgs(client.deployArgs, conf), conf, false)
+}
+
+// selfUpdatePipelineArgs rebuilds the deploy flags the self-update pipeline needs from the config,
+// for commands that change a deployment without being given all of its deploy flags
+func selfUpdatePipelineArgs(deployArgs *config.DeployArgs, conf *config.Config) *config.DeployArgs {
+	pipelineArgs := *deployArgs
+	pipelineArgs.AWSRegion = conf.Region
+	pipelineArgs.WorkerCount = conf.ConcourseWorkerCount
+	pipelineArgs.WorkerSize = conf.ConcourseWorkerSize
+	pipelineArgs.WebSize = conf.ConcourseWebSize
+
+	// Deployments without a domain are reached by the web node's IP address
+	if net.ParseIP(conf.Domain) == nil {
+		pipelineArgs.Domain = conf.Domain
+	}
+
+	// Let's Encrypt certificates are renewed by the deploy, but a certificate the user
+	// provided has to be passed in again
+	acmeCert := conf.HostedZoneID != "" && !conf.ACMEDisabled
+	if conf.ConcourseUserProvidedCert && !acmeCert {
+		pipelineArgs.TLSCert = conf.ConcourseCert
+		pipelineArgs.TLSKey = conf.ConcourseKey
+	}
+
+	return &pipelineArgs
+}
+
+func scaleWorkersSummary(count int, size string) string {
+	switch {
+	case count != 0 && size != "":
+		return fmt.Sprintf("--count %d --size %s", count, size)
+	case count != 0:
+		return fmt.Sprintf("--count %d", count)
+	default:
+		return fmt.Sprintf("--size %s", size)
+	}
+}
@@ -0,0 +1,145 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/config"
+)
+
+// deployStandby creates the infrastructure for a disaster recovery standby of the
+// deployment in client.deployArgs.StandbyOf. The standby's RDS instance is a read
+// replica of the primary's, so BOSH and Concourse are only deployed on promotion
+func (client *Client) deployStandby(conf *config.Config) error {
+	if conf.StandbyOf == "" && conf.DirectorPublicIP != "" {
+		return errors.New("found an existing deployment in this region. Refusing to turn it into a standby")
+	}
+	if conf.StandbyOf != "" && conf.StandbyOf != client.deployArgs.StandbyOf {
+		return fmt.Errorf("this deployment is already a standby for the deployment in %s", conf.StandbyOf)
+	}
+
+	primaryConfig, err := client.primaryConfigClient.Load()
+	if err != nil {
+		return err
+	}
+
+	if primaryConfig.ExternalDBURL != "" {
+		return fmt.Errorf("the deployment in %s uses an external database, which can't be replicated to a standby", client.deployArgs.StandbyOf)
+	}
+
+	primaryTerraformClient, err := client.terraformClientFactory(client.primaryIAASClient.IAAS(), primaryConfig, client.stdout, client.stderr)
+	if err != nil {
+		return err
+	}
+	defer primaryTerraformClient.Cleanup()
+
+	primaryMetadata, err := primaryTerraformClient.Output()
+	if err != nil {
+		return err
+	}
+	if primaryMetadata.RDSARN.Value == "" {
+		return fmt.Errorf("the deployment in %s must be redeployed with this version of concourse-up before it can have a standby", client.deployArgs.StandbyOf)
+	}
+
+	copyPrimaryCredentials(primaryConfig, conf)
+	conf.StandbyOf = client.deployArgs.StandbyOf
+	conf.RDSReplicationSource = primaryMetadata.RDSARN.Value
+	conf.DirectorDBName = standbyDirectorDBName(client.deployArgs.AWSRegion)
+
+	// The standby director can't write to the replica, so its database is
+	// created through the primary director and replicated from there
+	primaryBoshClient, err := client.buildBoshClient(primaryConfig, primaryMetadata)
+	if err != nil {
+		return err
+	}
+	defer primaryBoshClient.Cleanup()
+
+	if err = primaryBoshClient.EnsureDatabase(conf.DirectorDBName); err != nil {
+		return err
+	}
+
+	// Credhub's encryption keys live in the director creds, and are needed to
+	// read the replicated Credhub database after promotion
+	primaryCreds, err := loadDirectorCreds(client.primaryConfigClient)
+	if err != nil {
+		return err
+	}
+	if primaryCreds != nil {
+		if err = client.configClient.StoreAsset(bosh.CredsFilename, primaryCreds); err != nil {
+			return err
+		}
+	}
+
+	conf, err = client.checkPreTerraformConfigRequirements(conf)
+	if err != nil {
+		return err
+	}
+
+	// A read replica runs the same Postgres version as its source
+	conf.RDSEngineVersion = primaryConfig.RDSEngineVersion
+
+	// The domain stays with the primary until the standby is promoted
+	conf.HostedZoneID = ""
+	conf.HostedZoneRecordPrefix = ""
+
+	if _, err = client.applyTerraform(conf); err != nil {
+		return err
+	}
+
+	if err = client.configClient.Update(conf); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nSTANDBY READY. Run `concourse-up promote --region %s %s` to fail over to it\n\n", conf.Region, conf.Deployment)
+	return err
+}
+
+// checkStandbyPromotion ensures standbys are only deployed to by `concourse-up promote`,
+// and turns the standby into a full deployment when they are
+func (client *Client) checkStandbyPromotion(conf *config.Config) error {
+	if conf.StandbyOf == "" {
+		if client.deployArgs.Promote {
+			return errors.New("this deployment is not a standby, so cannot be promoted")
+		}
+		return nil
+	}
+
+	if !client.deployArgs.Promote {
+		return fmt.Errorf("this deployment is a standby for the deployment in %s. Use `concourse-up promote` to fail over to it", conf.StandbyOf)
+	}
+
+	if _, err := fmt.Fprintf(client.stdout, "\nPROMOTING STANDBY OF THE DEPLOYMENT IN %s\n\n", conf.StandbyOf); err != nil {
+		return err
+	}
+
+	// Removing the replication source makes Terraform promote the RDS replica
+	conf.StandbyOf = ""
+	conf.RDSReplicationSource = ""
+	return nil
+}
+
+func copyPrimaryCredentials(primary, standby *config.Config) {
+	standby.ConcourseDBName = primary.ConcourseDBName
+	standby.ConcoursePassword = primary.ConcoursePassword
+	standby.ConcourseUsername = primary.ConcourseUsername
+	standby.EncryptionKey = primary.EncryptionKey
+	standby.GrafanaPassword = primary.GrafanaPassword
+	standby.GrafanaUsername = primary.GrafanaUsername
+	standby.RDSDefaultDatabaseName = primary.RDSDefaultDatabaseName
+	standby.RDSPassword = primary.RDSPassword
+	standby.RDSUsername = primary.RDSUsername
+	standby.TokenPrivateKey = primary.TokenPrivateKey
+	standby.TokenPublicKey = primary.TokenPublicKey
+	standby.TSAFingerprint = primary.TSAFingerprint
+	standby.TSAPrivateKey = primary.TSAPrivateKey
+	standby.TSAPublicKey = primary.TSAPublicKey
+	standby.WorkerFingerprint = primary.WorkerFingerprint
+	standby.WorkerPrivateKey = primary.WorkerPrivateKey
+	standby.WorkerPublicKey = primary.WorkerPublicKey
+}
+
+func standbyDirectorDBName(region string) string {
+	return "bosh_" + strings.Replace(region, "-", "_", -1)
+}
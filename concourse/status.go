@@ -0,0 +1,145 @@
+package concourse
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/fly"
+)
+
+// Status summarises the health of a deployment, as seen from outside it
+type Status struct {
+	// Description and Notes are the ones given to deploy, so whoever checks the deployment knows what it's for
+	Description string `json:"description,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+	// Profile is the --profile preset the deployment was sized from, if any
+	Profile   string `json:"profile,omitempty"`
+	Domain    string `json:"domain"`
+	URL       string `json:"url"`
+	Reachable bool   `json:"reachable"`
+	// DirectorReachable is false if the BOSH director couldn't be asked for the deployment's VMs
+	DirectorReachable bool            `json:"director_reachable"`
+	Instances         []bosh.Instance `json:"instances"`
+	Workers           int             `json:"workers"`
+	RunningWorkers    int             `json:"running_workers"`
+	// ConcourseCertExpiresInDays and DirectorCertExpiresInDays are nil if the cert is missing or can't be read
+	ConcourseCertExpiresInDays *int   `json:"concourse_cert_expires_in_days,omitempty"`
+	DirectorCertExpiresInDays  *int   `json:"director_cert_expires_in_days,omitempty"`
+	DirectorVersion            string `json:"director_version"`
+	// LastDeployedAt is nil for deployments last deployed before deploys were recorded
+	LastDeployedAt *time.Time `json:"last_deployed_at,omitempty"`
+	LastDeployedBy string     `json:"last_deployed_by,omitempty"`
+}
+
+// Healthy returns true if Concourse is reachable and all of its workers are running
+func (status *Status) Healthy() bool {
+	return status.Reachable && status.DirectorReachable && status.RunningWorkers == status.Workers
+}
+
+// Status checks whether the deployment's Concourse is reachable, and asks its BOSH director
+// for the state of its VMs and its own version
+func (client *Client) Status() (*Status, error) {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.StandbyOf != "" {
+		return nil, fmt.Errorf("%s is a standby of %s, and runs no Concourse until it is promoted", config.Deployment, config.StandbyOf)
+	}
+
+	status := &Status{
+		Description:                config.Description,
+		Notes:                      config.Notes,
+		Profile:                    config.Profile,
+		Domain:                     config.Domain,
+		URL:                        config.ConcourseURL(),
+		ConcourseCertExpiresInDays: daysTillExpiry(config.ConcourseCert),
+		DirectorCertExpiresInDays:  daysTillExpiry(config.DirectorCert),
+		LastDeployedBy:             config.LastDeployedBy,
+	}
+	if !config.LastDeployedAt.IsZero() {
+		status.LastDeployedAt = &config.LastDeployedAt
+	}
+
+	flyClient, err := client.flyClientFactory(fly.Credentials{
+		Target:   config.Deployment,
+		API:      config.ConcourseURL(),
+		Username: config.ConcourseUsername,
+		Password: config.ConcoursePassword,
+		Proxy:    deploymentProxy(config),
+	},
+		client.stdout,
+		client.stderr,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer flyClient.Cleanup()
+
+	status.Reachable, err = flyClient.CanConnect()
+	if err != nil {
+		return nil, err
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), config, client.stdout, client.stderr)
+	if err != nil {
+		return nil, err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	boshClient, err := client.buildBoshClient(config, metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer boshClient.Cleanup()
+
+	// An unreachable director is part of the status being reported, rather than a reason not to report it
+	instances, err := boshClient.Instances()
+	if err != nil {
+		fmt.Fprintf(client.stderr, "WARNING: could not list the deployment's VMs: %s\n", err)
+		return status, nil
+	}
+
+	status.DirectorReachable = true
+	status.Instances = instances
+
+	// The version is left unknown rather than failing the status, which is about the deployment's health
+	running, err := boshClient.RunningVersions()
+	if err != nil {
+		fmt.Fprintf(client.stderr, "WARNING: could not ask the BOSH director for its version: %s\n", err)
+	} else {
+		status.DirectorVersion = running["bosh"]
+	}
+
+	for _, instance := range instances {
+		if !strings.HasPrefix(instance.Name, "worker/") {
+			continue
+		}
+		status.Workers++
+		if instance.State == "running" {
+			status.RunningWorkers++
+		}
+	}
+
+	return status, nil
+}
+
+// daysTillExpiry returns the whole number of days until the cert expires, or nil if it's missing or can't be read
+func daysTillExpiry(cert string) *int {
+	remaining := timeTillExpiry(cert)
+	// timeTillExpiry returns 0 for a missing or unparseable cert
+	if remaining == 0 {
+		return nil
+	}
+	days := int(math.Floor(remaining.Hours() / 24))
+	return &days
+}
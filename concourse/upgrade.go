@@ -0,0 +1,240 @@
+package concourse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EngineerBetter/concourse-up/bosh"
+	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/fly"
+)
+
+// releasesURL is where published concourse-up releases are listed
+var releasesURL = "https://api.github.com/repos/EngineerBetter/concourse-up/releases"
+
+// criticalReleaseNotes matches release notes that mention security fixes
+var criticalReleaseNotes = regexp.MustCompile(`(?i)security|CVE-\d+`)
+
+// ComponentUpgrade compares the deployed version of a component with the version this build of concourse-up deploys
+type ComponentUpgrade struct {
+	Component        string `json:"component"`
+	Deployed         string `json:"deployed"`
+	Available        string `json:"available"`
+	UpgradeAvailable bool   `json:"upgrade_available"`
+}
+
+// Release is a published release of concourse-up
+type Release struct {
+	Version  string `json:"version"`
+	URL      string `json:"url"`
+	Critical bool   `json:"critical"`
+}
+
+// UpgradeReport lists the upgrades available for a deployment
+type UpgradeReport struct {
+	Components    []ComponentUpgrade `json:"components"`
+	NewerReleases []Release          `json:"newer_releases"`
+}
+
+// UpgradeAvailable returns true if redeploying, or upgrading concourse-up, would upgrade any component
+func (report *UpgradeReport) UpgradeAvailable() bool {
+	for _, component := range report.Components {
+		if component.UpgradeAvailable {
+			return true
+		}
+	}
+	return len(report.NewerReleases) > 0
+}
+
+// CheckUpgrade compares the versions last deployed with the versions this build of
+// concourse-up deploys, and lists newer releases of concourse-up
+func (client *Client) CheckUpgrade() (*UpgradeReport, error) {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UpgradeReport{
+		Components: compareComponents(config, config.DeployedVersions),
+	}
+
+	// The published releases are only extra information, so failing to fetch them isn't fatal
+	report.NewerReleases, err = fetchNewerReleases(fly.ConcourseUpVersion)
+	if err != nil {
+		fmt.Fprintf(client.stderr, "WARNING: could not check for newer releases of concourse-up: %s\n", err)
+	}
+
+	return report, nil
+}
+
+// CheckRunningVersions asks the BOSH director and the ATC which versions they are running, and compares
+// them with the versions a deploy with this build of concourse-up would leave running. Components that
+// can't be asked, such as the director's CPI, are compared using the versions recorded at the last deploy
+func (client *Client) CheckRunningVersions() (*UpgradeReport, error) {
+	config, err := client.configClient.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.StandbyOf != "" {
+		return nil, fmt.Errorf("%s is a standby of %s, and runs no Concourse until it is promoted", config.Deployment, config.StandbyOf)
+	}
+
+	terraformClient, err := client.terraformClientFactory(client.iaasClient.IAAS(), config, client.stdout, client.stderr)
+	if err != nil {
+		return nil, err
+	}
+	defer terraformClient.Cleanup()
+
+	metadata, err := terraformClient.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	boshClient, err := client.buildBoshClient(config, metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer boshClient.Cleanup()
+
+	running, err := boshClient.RunningVersions()
+	if err != nil {
+		return nil, fmt.Errorf("could not ask the BOSH director for the running versions: %s", err)
+	}
+
+	flyClient, err := client.flyClientFactory(fly.Credentials{
+		Target:   config.Deployment,
+		API:      config.ConcourseURL(),
+		Username: config.ConcourseUsername,
+		Password: config.ConcoursePassword,
+		Proxy:    deploymentProxy(config),
+	},
+		client.stdout,
+		client.stderr,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer flyClient.Cleanup()
+
+	// The ATC reports the Concourse it is actually running, which differs from the deployed
+	// release while a deploy is in progress
+	atcVersion, err := flyClient.ATCVersion()
+	if err != nil {
+		return nil, fmt.Errorf("could not ask Concourse for its version: %s", err)
+	}
+	running["concourse"] = atcVersion
+
+	for component, version := range config.DeployedVersions {
+		if _, ok := running[component]; !ok {
+			running[component] = version
+		}
+	}
+
+	return &UpgradeReport{
+		Components: compareComponents(config, running),
+	}, nil
+}
+
+// compareComponents compares the given versions of each component with the versions this build of
+// concourse-up deploys. Components missing from deployed are treated as needing an upgrade
+func compareComponents(config *config.Config, deployed map[string]string) []ComponentUpgrade {
+	components := []ComponentUpgrade{}
+	for component, available := range deployedVersions(config) {
+		components = append(components, ComponentUpgrade{
+			Component:        component,
+			Deployed:         deployed[component],
+			Available:        available,
+			UpgradeAvailable: deployed[component] == "" || compareVersions(deployed[component], available) < 0,
+		})
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Component < components[j].Component
+	})
+	return components
+}
+
+// deployedVersions returns the versions of everything a deploy with this build of concourse-up deploys,
+// with the Concourse stemcell the deployment is pinned to
+func deployedVersions(config *config.Config) map[string]string {
+	versions := bosh.ComponentVersions()
+	versions["concourse-stemcell"] = bosh.StemcellVersion(config)
+	versions["concourse-up"] = fly.ConcourseUpVersion
+	return versions
+}
+
+func fetchNewerReleases(current string) ([]Release, error) {
+	resp, err := http.Get(releasesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", releasesURL, resp.Status)
+	}
+
+	var published []struct {
+		TagName    string `json:"tag_name"`
+		HTMLURL    string `json:"html_url"`
+		Body       string `json:"body"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&published); err != nil {
+		return nil, err
+	}
+
+	releases := []Release{}
+	for _, release := range published {
+		if release.Draft || release.Prerelease || compareVersions(release.TagName, current) <= 0 {
+			continue
+		}
+		releases = append(releases, Release{
+			Version:  strings.TrimPrefix(release.TagName, "v"),
+			URL:      release.HTMLURL,
+			Critical: criticalReleaseNotes.MatchString(release.Body),
+		})
+	}
+
+	return releases, nil
+}
+
+// compareVersions returns -1, 0 or 1 if version a is older than, the same as or newer than b.
+// Numeric parts are compared as numbers, so 3.10.0 is newer than 3.9.2
+func compareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNumber, aErr := strconv.Atoi(aPart)
+		bNumber, bErr := strconv.Atoi(bPart)
+		switch {
+		case aErr == nil && bErr == nil && aNumber != bNumber:
+			if aNumber < bNumber {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && aPart != bPart:
+			if aPart < bPart {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// AdoptDNSArgs are arguments passed to the adopt-dns command
+type AdoptDNSArgs struct {
+	AWSRegion string
+	IAAS      string
+	Domain    string
+}
+
+// Validate validates that flag interdependencies
+func (args AdoptDNSArgs) Validate() error {
+	if args.Domain == "" {
+		return errors.New("--domain is required")
+	}
+	if net.ParseIP(args.Domain) != nil {
+		return fmt.Errorf("--domain must be a domain name, not the IP address %s", args.Domain)
+	}
+
+	return nil
+}
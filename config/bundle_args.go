@@ -0,0 +1,30 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MinBundlePassphraseLength is the shortest passphrase a bundle may be encrypted with
+const MinBundlePassphraseLength = 12
+
+// BundleArgs are arguments passed to the export-bundle and import-bundle commands
+type BundleArgs struct {
+	AWSRegion  string
+	IAAS       string
+	File       string
+	Passphrase string
+}
+
+// Validate validates that flag interdependencies
+func (args BundleArgs) Validate() error {
+	if args.File == "" {
+		return errors.New("--file is required")
+	}
+
+	if len(args.Passphrase) < MinBundlePassphraseLength {
+		return fmt.Errorf("the bundle passphrase must be at least %d characters. Set it with BUNDLE_PASSPHRASE or --passphrase-file", MinBundlePassphraseLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package config
+
+// CheckUpgradeArgs are arguments passed to the check-upgrade command
+type CheckUpgradeArgs struct {
+	AWSRegion string
+	JSON      bool
+	IAAS      string
+	// Running is true if the versions should be asked of the running deployment rather than read from its config
+	Running bool
+}
@@ -5,13 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/EngineerBetter/concourse-up/iaas"
 )
 
 const terraformStateFileName = "terraform.tfstate"
-const configFilePath = "config.json"
+
+// ConfigFilename is the name of the config file in the config bucket
+const ConfigFilename = "config.json"
 
 // IClient is an interface for the config file client
 type IClient interface {
@@ -23,40 +28,81 @@ type IClient interface {
 	HasAsset(filename string) (bool, error)
 	LoadAsset(filename string) ([]byte, error)
 	DeleteAsset(filename string) error
+	Import(config *Config, assets map[string][]byte) error
+	Versions(filename string) ([]time.Time, error)
+	LoadAssetVersion(filename string, n int) ([]byte, error)
+	LoadVersion(n int) (*Config, error)
 }
 
 // Client is a client for loading the config file  from S3
 type Client struct {
 	iaas    iaas.IClient
 	project string
+	bucket  string
+	// backup reads the replica of the config bucket when the config bucket's region can't be reached.
+	// It's built for the backup region in the config once that's been read, and nil until then
+	backup iaas.IClient
+	// readReplica is true once anything has been read from the replica. The replica may be behind the
+	// config bucket, so nothing read from it is written back
+	readReplica bool
 }
 
-// New instantiates a new client
-func New(iaas iaas.IClient, project string) *Client {
+// newBackupClient builds the client for the region the replica is in, and is replaced in tests
+var newBackupClient = iaas.New
+
+// replicaLocatorRegions are asked where the replica is when the config that says so can't be read.
+// S3 says where any bucket is from any region, so there's a second in case the first is the one down
+var replicaLocatorRegions = []string{"us-east-1", "us-west-2"}
+
+// New instantiates a new client. bucket overrides the default config bucket name when it isn't empty
+func New(iaas iaas.IClient, project, bucket string) *Client {
 	return &Client{
-		iaas,
-		project,
+		iaas:    iaas,
+		project: project,
+		bucket:  bucket,
 	}
 }
 
-// StoreAsset stores an associated configuration file
+// NewWithBackup instantiates a new client which falls back to reading the replica of the config
+// bucket using backup, a client for the backup region, without having to find where the replica is
+func NewWithBackup(iaas, backup iaas.IClient, project, bucket string) *Client {
+	client := New(iaas, project, bucket)
+	client.backup = backup
+	return client
+}
+
+// ReplicaBucketName returns the name of the bucket in the backup region that the config bucket is replicated to
+func ReplicaBucketName(configBucket string) string {
+	return configBucket + "-replica"
+}
+
+// StoreAsset stores an associated configuration file, keeping the contents it replaces as a version
 func (client *Client) StoreAsset(filename string, contents []byte) error {
-	return client.iaas.WriteFile(client.configBucket(),
-		filename,
-		contents,
-	)
+	return client.writeFile(filename, contents)
 }
 
 // LoadAsset loads an associated configuration file
 func (client *Client) LoadAsset(filename string) ([]byte, error) {
-	return client.iaas.LoadFile(
+	contents, err := client.iaas.LoadFile(
 		client.configBucket(),
 		filename,
 	)
+	if iaas.IsUnreachable(err) {
+		if backup := client.backupClient(); backup != nil {
+			if replicaContents, replicaErr := backup.LoadFile(client.replicaBucket(), filename); replicaErr == nil {
+				client.readReplica = true
+				return replicaContents, nil
+			}
+		}
+	}
+	return contents, err
 }
 
 // DeleteAsset deletes an associated configuration file
 func (client *Client) DeleteAsset(filename string) error {
+	if err := client.checkWritable(); err != nil {
+		return err
+	}
 	return client.iaas.DeleteFile(
 		client.configBucket(),
 		filename,
@@ -65,38 +111,60 @@ func (client *Client) DeleteAsset(filename string) error {
 
 // HasAsset returns true if an associated configuration file exists
 func (client *Client) HasAsset(filename string) (bool, error) {
-	return client.iaas.HasFile(
+	exists, err := client.iaas.HasFile(
 		client.configBucket(),
 		filename,
 	)
+	if iaas.IsUnreachable(err) {
+		if backup := client.backupClient(); backup != nil {
+			if replicaExists, replicaErr := backup.HasFile(client.replicaBucket(), filename); replicaErr == nil {
+				client.readReplica = true
+				return replicaExists, nil
+			}
+		}
+	}
+	return exists, err
 }
 
-// Update stores the conconcourse up config file to S3
+// Update stores the conconcourse up config file to S3, keeping the contents it replaces as a version
 func (client *Client) Update(config *Config) error {
 	bytes, err := json.Marshal(config)
 	if err != nil {
 		return err
 	}
 
-	return client.iaas.WriteFile(client.configBucket(), configFilePath, bytes)
+	return client.writeFile(ConfigFilename, bytes)
 }
 
 // DeleteAll deletes the entire configuration bucket
 func (client *Client) DeleteAll(config *Config) error {
+	if err := client.checkWritable(); err != nil {
+		return err
+	}
 	return client.iaas.DeleteVersionedBucket(config.ConfigBucket)
 }
 
 // Load loads an existing config file from S3
 func (client *Client) Load() (*Config, error) {
-	configBytes, err := client.iaas.LoadFile(
-		client.configBucket(),
-		configFilePath,
-	)
+	configBytes, err := client.LoadAsset(ConfigFilename)
 	if err != nil {
 		return nil, err
 	}
 
-	conf := Config{}
+	conf, err := Parse(configBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	client.setBackupRegion(conf.BackupRegion)
+	return conf, nil
+}
+
+// Parse reads a config file. Settings that are missing because the file was written by an older
+// concourse-up take the defaults a new config would have
+func Parse(configBytes []byte) (*Config, error) {
+	// VMs deployed before termination protection existed are protected from their next deploy, like new ones
+	conf := Config{TerminationProtection: true}
 	if err := json.Unmarshal(configBytes, &conf); err != nil {
 		return nil, err
 	}
@@ -155,15 +223,7 @@ func (client *Client) LoadOrCreate(deployArgs *DeployArgs) (*Config, bool, error
 	if err != nil {
 		return nil, false, err
 	}
-	err = client.iaas.EnsureBucketExists(client.configBucket())
-	if err != nil {
-		return nil, false, err
-	}
-	configBytes, createdNewFile, err := client.iaas.EnsureFileExists(
-		client.configBucket(),
-		configFilePath,
-		defaultConfigBytes,
-	)
+	configBytes, createdNewFile, err := client.ensureConfigFile(defaultConfigBytes)
 	if err != nil {
 		return nil, false, err
 	}
@@ -171,6 +231,7 @@ func (client *Client) LoadOrCreate(deployArgs *DeployArgs) (*Config, bool, error
 	if err != nil {
 		return nil, false, err
 	}
+	client.setBackupRegion(config.BackupRegion)
 	allow, err := parseCIDRBlocks(deployArgs.AllowIPs)
 	if err != nil {
 		return nil, false, err
@@ -182,10 +243,177 @@ func (client *Client) LoadOrCreate(deployArgs *DeployArgs) (*Config, bool, error
 	return config, createdNewFile, nil
 }
 
+// Import stores a config exported from another bucket as the config of a new deployment, along with the
+// assets kept alongside it. The config is written last, so that an import that fails part way through
+// doesn't leave a config bucket that looks like a complete deployment
+func (client *Client) Import(config *Config, assets map[string][]byte) error {
+	if config.Project != client.project {
+		return fmt.Errorf("the config is for the deployment %s, not %s", config.Project, client.project)
+	}
+
+	if err := client.ensureConfigBucket(); err != nil {
+		return err
+	}
+
+	exists, err := client.iaas.HasFile(client.configBucket(), ConfigFilename)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("found an existing deployment in the config bucket %s. Refusing to overwrite it", client.configBucket())
+	}
+
+	filenames := make([]string, 0, len(assets))
+	for filename := range assets {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		if err = client.StoreAsset(filename, assets[filename]); err != nil {
+			return err
+		}
+	}
+
+	config.ConfigBucket = client.configBucket()
+	return client.Update(config)
+}
+
+// ensureConfigFile loads the config file, creating it from defaultConfigBytes if it doesn't exist. If the
+// config bucket can't be reached, the replica is read instead, so that a deployment whose region is down
+// can still be managed
+func (client *Client) ensureConfigFile(defaultConfigBytes []byte) ([]byte, bool, error) {
+	err := client.ensureConfigBucket()
+	if err == nil {
+		var configBytes []byte
+		var createdNewFile bool
+		configBytes, createdNewFile, err = client.iaas.EnsureFileExists(
+			client.configBucket(),
+			ConfigFilename,
+			defaultConfigBytes,
+		)
+		if err == nil {
+			return configBytes, createdNewFile, nil
+		}
+	}
+
+	if !iaas.IsUnreachable(err) {
+		return nil, false, err
+	}
+	if backup := client.backupClient(); backup != nil {
+		if configBytes, replicaErr := backup.LoadFile(client.replicaBucket(), ConfigFilename); replicaErr == nil {
+			client.readReplica = true
+			return configBytes, false, nil
+		}
+	}
+	return nil, false, err
+}
+
+func (client *Client) replicaBucket() string {
+	return ReplicaBucketName(client.configBucket())
+}
+
+// setBackupRegion builds the client for the replica from the backup region in the config, so that
+// later reads by the same command can fall back to it
+func (client *Client) setBackupRegion(region string) {
+	if client.backup != nil || region == "" {
+		return
+	}
+	if backup, err := newBackupClient(client.iaas.IAAS(), region); err == nil {
+		client.backup = backup
+	}
+}
+
+// backupClient returns the client for the replica's region, or nil if there's no replica. When the
+// config that names the region hasn't been read, S3 is asked where the replica is instead
+func (client *Client) backupClient() iaas.IClient {
+	if client.backup != nil {
+		return client.backup
+	}
+	for _, region := range replicaLocatorRegions {
+		if region == client.iaas.Region() {
+			continue
+		}
+		locator, err := newBackupClient(client.iaas.IAAS(), region)
+		if err != nil {
+			return nil
+		}
+		replicaRegion, err := locator.BucketRegion(client.replicaBucket())
+		if err != nil {
+			continue
+		}
+		client.setBackupRegion(replicaRegion)
+		return client.backup
+	}
+	return nil
+}
+
+// checkWritable refuses to write to the config bucket once the replica has been read, as what was
+// read may be behind what's in the config bucket, and writing it back would replace the newer state
+func (client *Client) checkWritable() error {
+	if client.readReplica {
+		return fmt.Errorf("the config was read from the replica %s as the config bucket %s couldn't be reached. The replica may be behind the config bucket, so nothing is written until the config bucket can be reached again", client.replicaBucket(), client.configBucket())
+	}
+	return nil
+}
+
+func (client *Client) ensureConfigBucket() error {
+	err := client.iaas.EnsureBucketExists(client.configBucket())
+	if err == iaas.ErrBucketNotOwned {
+		return fmt.Errorf("the config bucket %s already exists and belongs to another AWS account. S3 bucket names are shared by all AWS accounts, so use --config-bucket-name to choose a different name", client.configBucket())
+	}
+	return err
+}
+
 func (client *Client) deployment() string {
-	return fmt.Sprintf("concourse-up-%s", client.project)
+	return deploymentName(client.project)
+}
+
+func deploymentName(project string) string {
+	return fmt.Sprintf("concourse-up-%s", project)
+}
+
+// regionPattern matches AWS region names, so that a config bucket of another project
+// whose name starts with this project's name isn't mistaken for this project's
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-gov)?-[a-z]+-\d$`)
+
+// FindRegion returns the region of an existing deployment from the region of its config
+// bucket, for commands which are run without --region. bucket is the config bucket name when
+// it's been overridden
+func FindRegion(iaasClient iaas.IClient, project, bucket string) (string, error) {
+	if bucket != "" {
+		return iaasClient.BucketRegion(bucket)
+	}
+
+	buckets, err := iaasClient.ListBuckets()
+	if err != nil {
+		return "", err
+	}
+
+	prefix := deploymentName(project) + "-"
+	var regions []string
+	for _, name := range buckets {
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, "-config") {
+			continue
+		}
+		region := strings.TrimSuffix(strings.TrimPrefix(name, prefix), "-config")
+		if regionPattern.MatchString(region) {
+			regions = append(regions, region)
+		}
+	}
+
+	switch len(regions) {
+	case 0:
+		return "", fmt.Errorf("could not find a deployment called %s in any region. Pass --region to say which region it's in", project)
+	case 1:
+		return regions[0], nil
+	default:
+		return "", fmt.Errorf("found deployments called %s in more than one region: %s. Pass --region to choose one", project, strings.Join(regions, ", "))
+	}
 }
 
 func (client *Client) configBucket() string {
+	if client.bucket != "" {
+		return client.bucket
+	}
 	return fmt.Sprintf("%s-%s-config", client.deployment(), client.iaas.Region())
 }
@@ -1,8 +1,15 @@
 package config_test
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	. "github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/iaas"
 	"github.com/EngineerBetter/concourse-up/testsupport"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
@@ -26,7 +33,7 @@ var _ = Describe("Client", func() {
 				return defaultContents, true, nil
 			},
 		}
-		client = New(iaasClient, "test")
+		client = New(iaasClient, "test", "")
 
 		deployArgs = &DeployArgs{
 			IAAS:        "AWS",
@@ -178,11 +185,393 @@ var _ = Describe("Client", func() {
 					Expect(conf.Region).To(Equal("eu-west-1"))
 				})
 
+				It("Sets the default value for the ATCTLSMinVersion", func() {
+					Expect(conf.ATCTLSMinVersion).To(Equal("1.2"))
+				})
+
+				It("Sets the default value for the TSAPort", func() {
+					Expect(conf.TSAPort).To(Equal(2222))
+				})
+
 				It("Sets the default value for the TFStatePath", func() {
 					Expect(conf.TFStatePath).To(Equal("terraform.tfstate"))
 				})
 			})
 		})
+
+		Context("When a config bucket name is given", func() {
+			It("Uses that bucket instead of the default", func() {
+				var bucketName string
+				iaasClient.FakeEnsureBucketExists = func(name string) error {
+					bucketName = name
+					return nil
+				}
+				client = New(iaasClient, "test", "my-config-bucket")
+
+				conf, _, err := client.LoadOrCreate(deployArgs)
+				Expect(err).To(Succeed())
+				Expect(bucketName).To(Equal("my-config-bucket"))
+				Expect(conf.ConfigBucket).To(Equal("my-config-bucket"))
+			})
+		})
+
+		Context("When the existing config was written before termination protection existed", func() {
+			It("Protects the VMs", func() {
+				iaasClient.FakeEnsureFileExists = func(bucket, path string, defaultContents []byte) ([]byte, bool, error) {
+					return []byte(`{"project": "test", "region": "eu-west-1"}`), false, nil
+				}
+
+				conf, createdANewFile, err := client.LoadOrCreate(deployArgs)
+				Expect(err).To(Succeed())
+				Expect(createdANewFile).To(BeFalse())
+				Expect(conf.TerminationProtection).To(BeTrue())
+			})
+		})
+
+		Context("When the config bucket belongs to another AWS account", func() {
+			It("Returns an error suggesting a different bucket name", func() {
+				iaasClient.FakeEnsureBucketExists = func(name string) error {
+					return iaas.ErrBucketNotOwned
+				}
+
+				_, _, err := client.LoadOrCreate(deployArgs)
+				Expect(err).To(MatchError("the config bucket concourse-up-test-eu-west-1-config already exists and belongs to another AWS account. S3 bucket names are shared by all AWS accounts, so use --config-bucket-name to choose a different name"))
+			})
+		})
+	})
+
+	Describe("Falling back to the replica", func() {
+		var backupClient *testsupport.FakeAWSClient
+		var replicaBucket string
+		var unreachable error
+		var backupRegions []string
+
+		BeforeEach(func() {
+			unreachable = awserr.New("RequestError", "send request failed", errors.New("dial tcp: i/o timeout"))
+			iaasClient.FakeEnsureBucketExists = func(name string) error {
+				return unreachable
+			}
+			iaasClient.FakeLoadFile = func(bucket, path string) ([]byte, error) {
+				return nil, unreachable
+			}
+			backupClient = &testsupport.FakeAWSClient{
+				FakeLoadFile: func(bucket, path string) ([]byte, error) {
+					replicaBucket = bucket
+					return []byte(`{"deployment": "concourse-up-test", "backup_region": "us-east-1"}`), nil
+				},
+				FakeBucketRegion: func(name string) (string, error) {
+					return "", errors.New("NoSuchBucket")
+				},
+			}
+			backupRegions = []string{}
+			replicaBucket = ""
+			SetNewBackupClient(func(iaasName, region string) (iaas.IClient, error) {
+				backupRegions = append(backupRegions, region)
+				return backupClient, nil
+			})
+			client = NewWithBackup(iaasClient, backupClient, "test", "")
+		})
+
+		AfterEach(func() {
+			SetNewBackupClient(iaas.New)
+		})
+
+		It("Loads the config from the replica when the config bucket can't be reached", func() {
+			conf, createdANewFile, err := client.LoadOrCreate(deployArgs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(createdANewFile).To(BeFalse())
+			Expect(conf.BackupRegion).To(Equal("us-east-1"))
+			Expect(replicaBucket).To(Equal("concourse-up-test-eu-west-1-config-replica"))
+
+			conf, err = client.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.Deployment).To(Equal("concourse-up-test"))
+		})
+
+		It("Doesn't write anything once the replica has been read", func() {
+			iaasClient.FakeWriteFile = func(bucket, path string, contents []byte) error {
+				Fail("wrote " + path)
+				return nil
+			}
+
+			conf, err := client.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.Update(conf)).To(MatchError("the config was read from the replica concourse-up-test-eu-west-1-config-replica as the config bucket concourse-up-test-eu-west-1-config couldn't be reached. The replica may be behind the config bucket, so nothing is written until the config bucket can be reached again"))
+			Expect(client.StoreAsset("director-state.json", []byte("{}"))).To(HaveOccurred())
+		})
+
+		It("Only falls back when the config bucket's region can't be reached", func() {
+			denied := awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), 403, "")
+			iaasClient.FakeLoadFile = func(bucket, path string) ([]byte, error) {
+				return nil, denied
+			}
+
+			_, err := client.Load()
+			Expect(err).To(Equal(denied))
+			Expect(replicaBucket).To(BeEmpty())
+		})
+
+		It("Returns the config bucket's error if the replica can't be read either", func() {
+			backupClient.FakeLoadFile = func(bucket, path string) ([]byte, error) {
+				return nil, errors.New("no such bucket")
+			}
+
+			_, _, err := client.LoadOrCreate(deployArgs)
+			Expect(err).To(Equal(unreachable))
+		})
+
+		It("Finds the replica without being given the backup region", func() {
+			backupClient.FakeBucketRegion = func(name string) (string, error) {
+				Expect(name).To(Equal("concourse-up-test-eu-west-1-config-replica"))
+				return "us-east-1", nil
+			}
+			client = New(iaasClient, "test", "")
+
+			conf, err := client.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.Deployment).To(Equal("concourse-up-test"))
+			Expect(backupRegions).To(Equal([]string{"us-east-1", "us-east-1"}))
+		})
+
+		It("Falls back to the backup region in the config once it's been read", func() {
+			configLoaded := false
+			iaasClient.FakeLoadFile = func(bucket, path string) ([]byte, error) {
+				if !configLoaded {
+					configLoaded = true
+					return []byte(`{"deployment": "concourse-up-test", "backup_region": "us-west-2"}`), nil
+				}
+				return nil, unreachable
+			}
+			client = New(iaasClient, "test", "")
+
+			_, err := client.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(backupRegions).To(Equal([]string{"us-west-2"}))
+
+			_, err = client.LoadAsset("director-creds.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(replicaBucket).To(Equal("concourse-up-test-eu-west-1-config-replica"))
+		})
+
+		It("Doesn't fall back when there's no replica", func() {
+			client = New(iaasClient, "test", "")
+
+			_, err := client.Load()
+			Expect(err).To(Equal(unreachable))
+		})
+	})
+
+	Describe("Import", func() {
+		var writtenFiles map[string][]byte
+		var writeOrder []string
+
+		BeforeEach(func() {
+			writtenFiles = map[string][]byte{}
+			writeOrder = nil
+			iaasClient.FakeHasFile = func(bucket, path string) (bool, error) {
+				_, ok := writtenFiles[path]
+				return ok, nil
+			}
+			iaasClient.FakeLoadFile = func(bucket, path string) ([]byte, error) {
+				return writtenFiles[path], nil
+			}
+			iaasClient.FakeWriteFile = func(bucket, path string, contents []byte) error {
+				writtenFiles[path] = contents
+				writeOrder = append(writeOrder, path)
+				return nil
+			}
+		})
+
+		It("Stores the config with the new bucket", func() {
+			conf := &Config{Project: "test", ConfigBucket: "old-bucket"}
+			Expect(client.Import(conf, nil)).To(Succeed())
+
+			Expect(conf.ConfigBucket).To(Equal("concourse-up-test-eu-west-1-config"))
+			Expect(writtenFiles).To(HaveKey("config.json"))
+		})
+
+		It("Stores the assets before the config", func() {
+			err := client.Import(&Config{Project: "test"}, map[string][]byte{
+				"terraform.tfstate":   []byte("terraform state"),
+				"director-state.json": []byte("director state"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(writtenFiles).To(HaveKeyWithValue("terraform.tfstate", []byte("terraform state")))
+			Expect(writtenFiles).To(HaveKeyWithValue("director-state.json", []byte("director state")))
+			Expect(writeOrder[len(writeOrder)-1]).To(Equal("config.json"))
+		})
+
+		It("Refuses to overwrite an existing deployment", func() {
+			writtenFiles["config.json"] = []byte("{}")
+
+			err := client.Import(&Config{Project: "test"}, map[string][]byte{"terraform.tfstate": []byte("terraform state")})
+			Expect(err).To(MatchError("found an existing deployment in the config bucket concourse-up-test-eu-west-1-config. Refusing to overwrite it"))
+			Expect(writtenFiles).ToNot(HaveKey("terraform.tfstate"))
+		})
+
+		It("Refuses to import another deployment's config", func() {
+			err := client.Import(&Config{Project: "other"}, nil)
+			Expect(err).To(MatchError("the config is for the deployment other, not test"))
+		})
+	})
+
+	Describe("Versions", func() {
+		var files map[string][]byte
+		var clock time.Time
+
+		BeforeEach(func() {
+			files = map[string][]byte{}
+			iaasClient.FakeHasFile = func(bucket, path string) (bool, error) {
+				_, ok := files[path]
+				return ok, nil
+			}
+			iaasClient.FakeLoadFile = func(bucket, path string) ([]byte, error) {
+				return files[path], nil
+			}
+			iaasClient.FakeWriteFile = func(bucket, path string, contents []byte) error {
+				files[path] = contents
+				return nil
+			}
+			iaasClient.FakeDeleteFile = func(bucket, path string) error {
+				delete(files, path)
+				return nil
+			}
+			iaasClient.FakeListFiles = func(bucket, prefix string) ([]string, error) {
+				paths := []string{}
+				for path := range files {
+					if strings.HasPrefix(path, prefix) {
+						paths = append(paths, path)
+					}
+				}
+				return paths, nil
+			}
+
+			clock = time.Date(2018, 10, 15, 12, 0, 0, 0, time.UTC)
+			SetNow(func() time.Time {
+				clock = clock.Add(time.Minute)
+				return clock
+			})
+		})
+
+		AfterEach(func() {
+			SetNow(time.Now)
+		})
+
+		It("Keeps the contents each write replaces, newest first", func() {
+			Expect(client.StoreAsset("director-state.json", []byte("first"))).To(Succeed())
+			Expect(client.StoreAsset("director-state.json", []byte("second"))).To(Succeed())
+			Expect(client.StoreAsset("director-state.json", []byte("third"))).To(Succeed())
+
+			Expect(client.LoadAsset("director-state.json")).To(Equal([]byte("third")))
+			Expect(client.LoadAssetVersion("director-state.json", 1)).To(Equal([]byte("second")))
+			Expect(client.LoadAssetVersion("director-state.json", 2)).To(Equal([]byte("first")))
+			_, err := client.LoadAssetVersion("director-state.json", 3)
+			Expect(err).To(Equal(ErrNoVersion))
+
+			Expect(client.Versions("director-state.json")).To(Equal([]time.Time{
+				time.Date(2018, 10, 15, 12, 2, 0, 0, time.UTC),
+				time.Date(2018, 10, 15, 12, 1, 0, 0, time.UTC),
+			}))
+		})
+
+		It("Doesn't keep a version when the contents don't change", func() {
+			Expect(client.StoreAsset("director-state.json", []byte("same"))).To(Succeed())
+			Expect(client.StoreAsset("director-state.json", []byte("same"))).To(Succeed())
+
+			Expect(client.Versions("director-state.json")).To(BeEmpty())
+		})
+
+		It("Keeps only the newest versions", func() {
+			for i := 0; i <= MaxVersions+2; i++ {
+				Expect(client.StoreAsset("director-creds.yml", []byte(fmt.Sprintf("creds %d", i)))).To(Succeed())
+			}
+
+			Expect(client.Versions("director-creds.yml")).To(HaveLen(MaxVersions))
+			Expect(client.LoadAssetVersion("director-creds.yml", MaxVersions)).To(Equal([]byte("creds 2")))
+		})
+
+		It("Loads previous versions of the config", func() {
+			Expect(client.Update(&Config{Project: "test", ConcourseWorkerCount: 1})).To(Succeed())
+			Expect(client.Update(&Config{Project: "test", ConcourseWorkerCount: 2})).To(Succeed())
+
+			conf, err := client.LoadVersion(1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.ConcourseWorkerCount).To(Equal(1))
+		})
+
+		It("Protects the VMs of configs written before termination protection existed", func() {
+			files[ConfigFilename] = []byte(`{"project": "test"}`)
+
+			conf, err := client.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.TerminationProtection).To(BeTrue())
+
+			files[ConfigFilename] = []byte(`{"project": "test", "termination_protection": false}`)
+
+			conf, err = client.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.TerminationProtection).To(BeFalse())
+		})
+
+		It("Protects the VMs of old config versions without the setting", func() {
+			Expect(client.StoreAsset(ConfigFilename, []byte(`{"project": "test"}`))).To(Succeed())
+			Expect(client.StoreAsset(ConfigFilename, []byte(`{"project": "test", "termination_protection": false}`))).To(Succeed())
+
+			conf, err := client.LoadVersion(1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.TerminationProtection).To(BeTrue())
+		})
+	})
+
+	Describe("FindRegion", func() {
+		var buckets []string
+
+		BeforeEach(func() {
+			buckets = []string{
+				"unrelated-bucket",
+				"concourse-up-test-us-east-1-config",
+				"concourse-up-test-eu-eu-west-2-config",
+				"concourse-up-other-eu-west-1-config",
+			}
+			iaasClient.FakeListBuckets = func() ([]string, error) {
+				return buckets, nil
+			}
+			iaasClient.FakeBucketRegion = func(name string) (string, error) {
+				Expect(name).To(Equal("my-bucket"))
+				return "ap-southeast-2", nil
+			}
+		})
+
+		It("Finds the region of the deployment's config bucket", func() {
+			region, err := FindRegion(iaasClient, "test", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(region).To(Equal("us-east-1"))
+		})
+
+		It("Doesn't mistake another deployment whose name starts the same for this one", func() {
+			region, err := FindRegion(iaasClient, "test-eu", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(region).To(Equal("eu-west-2"))
+		})
+
+		It("Looks up the region of a custom config bucket", func() {
+			region, err := FindRegion(iaasClient, "test", "my-bucket")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(region).To(Equal("ap-southeast-2"))
+		})
+
+		It("Returns an error if there's no such deployment", func() {
+			_, err := FindRegion(iaasClient, "missing", "")
+			Expect(err).To(MatchError("could not find a deployment called missing in any region. Pass --region to say which region it's in"))
+		})
+
+		It("Returns an error if the deployment is in more than one region", func() {
+			buckets = append(buckets, "concourse-up-test-eu-west-1-config")
+			_, err := FindRegion(iaasClient, "test", "")
+			Expect(err).To(MatchError("found deployments called test in more than one region: us-east-1, eu-west-1. Pass --region to choose one"))
+		})
 	})
 })
 
@@ -1,15 +1,26 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/EngineerBetter/concourse-up/db"
 	"github.com/EngineerBetter/concourse-up/util"
 )
 
 // Config represents a concourse-up configuration file
 type Config struct {
+	ACMEDisabled              bool   `json:"acme_disabled"`
+	ATCDBConnectTimeout       string `json:"atc_db_connect_timeout"`
+	ATCDBMaxIdleConnections   int    `json:"atc_db_max_idle_connections"`
+	ATCDBMaxOpenConnections   int    `json:"atc_db_max_open_connections"`
+	ATCTLSMinVersion          string `json:"atc_tls_min_version"`
 	AvailabilityZone          string `json:"availability_zone"`
+	BackupRegion              string `json:"backup_region"`
+	BrandingCSSAsset          string `json:"branding_css_asset"`
+	BrandingWordmarkAsset     string `json:"branding_wordmark_asset"`
 	CredhubURL                string `json:"credhub_url"`
 	CredhubUsername           string `json:"credhub_username"`
 	CredhubPassword           string `json:"credhub_password"`
@@ -25,9 +36,12 @@ type Config struct {
 	ConcourseWorkerCount      int    `json:"concourse_worker_count"`
 	ConcourseWorkerSize       string `json:"concourse_worker_size"`
 	ConfigBucket              string `json:"config_bucket"`
+	ContainerNetworkMTU       int    `json:"container_network_mtu"`
+	ContainerNetworkPool      string `json:"container_network_pool"`
 	Deployment                string `json:"deployment"`
 	DirectorCACert            string `json:"director_ca_cert"`
 	DirectorCert              string `json:"director_cert"`
+	DirectorDBName            string `json:"director_db_name"`
 	DirectorHMUserPassword    string `json:"director_hm_user_password"`
 	DirectorKey               string `json:"director_key"`
 	DirectorMbusPassword      string `json:"director_mbus_password"`
@@ -35,35 +49,331 @@ type Config struct {
 	DirectorPassword          string `json:"director_password"`
 	DirectorPublicIP          string `json:"director_public_ip"`
 	DirectorRegistryPassword  string `json:"director_registry_password"`
+	DirectorSize              string `json:"director_size"`
 	DirectorUsername          string `json:"director_username"`
 	Domain                    string `json:"domain"`
+	EnableGlobalResources     bool   `json:"enable_global_resources"`
 	EncryptionKey             string `json:"encryption_key"`
 	GrafanaPassword           string `json:"grafana_password"`
+	GrafanaPath               string `json:"grafana_path"`
 	GrafanaUsername           string `json:"grafana_username"`
 	HostedZoneID              string `json:"hosted_zone_id"`
 	HostedZoneRecordPrefix    string `json:"hosted_zone_record_prefix"`
+	IsolatedWorkers           bool   `json:"isolated_workers"`
+	EnableIPv6                bool   `json:"enable_ipv6"`
+	PublicIPv6                bool   `json:"public_ipv6"`
+	TerminationProtection     bool   `json:"termination_protection"`
 	InfluxDBPassword          string `json:"influxdb_password"`
 	InfluxDBUsername          string `json:"influxdb_username"`
+	InstanceTenancy           string `json:"instance_tenancy"`
+	KMSKeyID                  string `json:"kms_key_id"`
 	MultiAZRDS                bool   `json:"multi_az_rds"`
+	Private                   bool   `json:"private"`
+	Profile                   string `json:"profile"`
 	PrivateKey                string `json:"private_key"`
 	Project                   string `json:"project"`
 	PublicKey                 string `json:"public_key"`
+	RDSApplyImmediately       bool   `json:"rds_apply_immediately"`
+	RDSAvailabilityZone       string `json:"rds_availability_zone"`
 	RDSDefaultDatabaseName    string `json:"rds_default_database_name"`
+	RDSEngineVersion          string `json:"rds_engine_version"`
 	RDSInstanceClass          string `json:"rds_instance_class"`
 	RDSPassword               string `json:"rds_password"`
+	RDSReplicationSource      string `json:"rds_replication_source"`
+	RDSSnapshotIdentifier     string `json:"rds_snapshot_identifier"`
 	RDSUsername               string `json:"rds_username"`
 	Region                    string `json:"region"`
+	SecretCacheTTL            string `json:"secret_cache_ttl"`
 	SourceAccessIP            string `json:"source_access_ip"`
+	StandbyOf                 string `json:"standby_of"`
+	TFLockTable               string `json:"tf_lock_table"`
 	TFStatePath               string `json:"tf_state_path"`
 	TokenPrivateKey           string `json:"token_private_key"`
 	TokenPublicKey            string `json:"token_public_key"`
 	TSAFingerprint            string `json:"tsa_fingerprint"`
+	TSAPort                   int    `json:"tsa_port"`
 	TSAPrivateKey             string `json:"tsa_private_key"`
 	TSAPublicKey              string `json:"tsa_public_key"`
 	WorkerFingerprint         string `json:"worker_fingerprint"`
 	WorkerPrivateKey          string `json:"worker_private_key"`
 	WorkerPublicKey           string `json:"worker_public_key"`
+	WorkerEphemeral           bool   `json:"worker_ephemeral"`
+	WorkerRegistryCACerts     string `json:"worker_registry_ca_certs"`
 	AllowIPs                  string `json:"allow_ips"`
+
+	// AdditionalAllowedCIDRs are IPv4 ranges, such as an office or VPN, allowed to reach the director
+	// alongside the address of the machine that last deployed
+	AdditionalAllowedCIDRs []string `json:"additional_allowed_cidrs"`
+
+	// WorkerTags are the Concourse tags given to every worker
+	WorkerTags []string `json:"worker_tags"`
+
+	// WorkerSpotDisabled is true if workers always run on demand. Spot workers are the default,
+	// so only turning them off is kept. WorkerSpotMaxPrice replaces the default spot bids when set
+	WorkerSpotDisabled bool   `json:"worker_spot_disabled"`
+	WorkerSpotMaxPrice string `json:"worker_spot_max_price"`
+
+	// WorkerAMIID is the customer-managed AMI workers are booted from, which must be built
+	// from a BOSH stemcell. Empty uses the stock stemcell
+	WorkerAMIID string `json:"worker_ami_id"`
+
+	// StemcellVersion is the version of the stemcell the Concourse VMs are pinned to. Empty uses
+	// the version this build of concourse-up deploys
+	StemcellVersion string `json:"stemcell_version"`
+
+	// ConcourseWorkerDiskSize is the size of each worker's ephemeral disk in GB. Configs from before it
+	// could be chosen have none, and use the size that was always used
+	ConcourseWorkerDiskSize int `json:"concourse_worker_disk_size"`
+
+	// ConcourseExternalPort, CredhubExternalPort and GrafanaExternalPort are the ports the web node serves
+	// Concourse, CredHub and Grafana on. Zero uses the default port
+	ConcourseExternalPort int `json:"concourse_external_port"`
+	CredhubExternalPort   int `json:"credhub_external_port"`
+	GrafanaExternalPort   int `json:"grafana_external_port"`
+
+	// UpdateDrainTimeout is the number of minutes a self-update waits for running builds to finish
+	// before updating the deployment. Zero doesn't wait
+	UpdateDrainTimeout int `json:"update_drain_timeout"`
+
+	// DNSWeighted makes the deployment's Route53 record a weighted record with the weight DNSWeight,
+	// so that it can share the domain with another deployment for a blue/green cutover
+	DNSWeighted bool `json:"dns_weighted"`
+	DNSWeight   int  `json:"dns_weight"`
+
+	// PostDeployErrands are the BOSH errands run, in order, after each manual deploy
+	PostDeployErrands []string `json:"post_deploy_errands"`
+
+	// DefaultPipelineDisabled is true if the self-update pipeline isn't set, for deployments
+	// whose pipelines are all managed outside concourse-up
+	DefaultPipelineDisabled bool `json:"default_pipeline_disabled"`
+
+	// ExtraPipelines are the pipelines set, along with the teams they're in, after each manual deploy
+	ExtraPipelines []PipelineSpec `json:"extra_pipelines"`
+
+	// Drained is true between a drain and the resume which reverses it. DrainedPipelines
+	// are the pipelines the drain paused, so that resume only unpauses those
+	Drained          bool     `json:"drained"`
+	DrainedPipelines []string `json:"drained_pipelines"`
+
+	// BoshUpdateSerial, BoshCanaryWatchTime and BoshUpdateWatchTime tune how BOSH rolls out
+	// the Concourse deployment. Empty watch times use the default
+	BoshUpdateSerial    bool   `json:"bosh_update_serial"`
+	BoshCanaryWatchTime string `json:"bosh_canary_watch_time"`
+	BoshUpdateWatchTime string `json:"bosh_update_watch_time"`
+
+	// SyslogAddress is the host:port of the external collector logs are forwarded to. Empty doesn't forward logs
+	SyslogAddress   string `json:"syslog_address"`
+	SyslogTransport string `json:"syslog_transport"`
+	SyslogTLS       bool   `json:"syslog_tls"`
+	SyslogCACert    string `json:"syslog_ca_cert"`
+
+	// PrometheusRemoteWriteURL is the remote write endpoint of the external Prometheus the ATC's metrics are
+	// sent to. Empty sends them only to the bundled Grafana. The username and password are for basic auth
+	PrometheusRemoteWriteURL string `json:"prometheus_remote_write_url"`
+	PrometheusUsername       string `json:"prometheus_username"`
+	PrometheusPassword       string `json:"prometheus_password"`
+
+	// Description and Notes tell whoever looks after the deployment what it's for
+	Description string `json:"description"`
+	Notes       string `json:"notes"`
+
+	// DeployedVersions are the versions of the components last deployed, keyed by component name
+	DeployedVersions map[string]string `json:"deployed_versions"`
+
+	// LastDeployedAt and LastDeployedBy record when the deployment was last deployed, and by whom
+	LastDeployedAt time.Time `json:"last_deployed_at"`
+	LastDeployedBy string    `json:"last_deployed_by"`
+
+	// ATCExperiments are the ATC properties of the enabled experiments
+	ATCExperiments []string `json:"atc_experiments"`
+
+	// ATCGCHijackGracePeriod and ATCInterceptIdleTimeout control how the ATC reaps hijacked
+	// containers, as durations. Empty leaves the Concourse defaults in place
+	ATCGCHijackGracePeriod  string `json:"atc_gc_hijack_grace_period"`
+	ATCInterceptIdleTimeout string `json:"atc_intercept_idle_timeout"`
+
+	// ATCResourceCheckingInterval and ATCResourceCheckTimeout are the defaults for how often resources
+	// are checked and how long a check can run, as durations. Empty leaves the Concourse defaults in place
+	ATCResourceCheckingInterval string `json:"atc_resource_checking_interval"`
+	ATCResourceCheckTimeout     string `json:"atc_resource_check_timeout"`
+
+	// ATCDefaultGetTimeout and ATCDefaultPutTimeout are how long get and put steps can run when
+	// their pipelines don't set a timeout, as durations. Empty leaves steps without a timeout
+	ATCDefaultGetTimeout string `json:"atc_default_get_timeout"`
+	ATCDefaultPutTimeout string `json:"atc_default_put_timeout"`
+
+	// ATCContainerPlacementStrategy is how the ATC chooses a worker for each container. Empty
+	// leaves the Concourse default of volume-locality in place. ATCP2PVolumeStreaming streams
+	// volumes directly between workers instead of through the ATC
+	ATCContainerPlacementStrategy string `json:"atc_container_placement_strategy"`
+	ATCP2PVolumeStreaming         bool   `json:"atc_p2p_volume_streaming"`
+
+	// MaxActiveTasksPerWorker is how many tasks the limit-active-tasks strategy places on each worker, and
+	// MaxActiveContainersPerWorker is how many containers Garden runs on each worker. Zero leaves them unlimited
+	MaxActiveTasksPerWorker      int `json:"max_active_tasks_per_worker"`
+	MaxActiveContainersPerWorker int `json:"max_active_containers_per_worker"`
+
+	// ResurrectorDisabled is true if the director leaves VMs whose agent stops responding alone. The
+	// resurrector is on by default, so that deployments from before it could be turned off keep it
+	ResurrectorDisabled bool `json:"resurrector_disabled"`
+
+	// ATCTLSCipherSuites are the cipher suites the ATC accepts. Empty leaves the Concourse default in place
+	ATCTLSCipherSuites []string `json:"atc_tls_cipher_suites"`
+
+	// ConcourseLogLevel is the minimum level the ATC logs at. Empty leaves the Concourse default of info in place
+	ConcourseLogLevel string `json:"concourse_log_level"`
+
+	// DirectorInstanceProfile, WebInstanceProfile and WorkerInstanceProfile are set when
+	// the deployment uses pre-existing instance profiles instead of Terraform-managed IAM users
+	DirectorInstanceProfile string `json:"director_instance_profile"`
+	WebInstanceProfile      string `json:"web_instance_profile"`
+	WorkerInstanceProfile   string `json:"worker_instance_profile"`
+
+	// BrandingCSS and BrandingWordmark hold the contents of the branding assets
+	// while deploying. They are stored separately in the config bucket
+	BrandingCSS      string `json:"-"`
+	BrandingWordmark string `json:"-"`
+
+	// Tags are the AWS tags given to the VMs, the RDS instance, the S3 buckets and the other
+	// Terraform-managed resources, for cost allocation
+	Tags map[string]string `json:"tags"`
+
+	// TerraformVars are the values a --tf-vars-file gave Terraform variables that concourse-up
+	// doesn't set itself
+	TerraformVars map[string]interface{} `json:"terraform_vars"`
+
+	// ATCPublicIPv6 is the web node's IPv6 address, which the AAAA record points at. AWS has no IPv6
+	// elastic IPs, so it's looked up after each deploy, as it changes when BOSH recreates the web node
+	ATCPublicIPv6 string `json:"atc_public_ipv6"`
+
+	// MaintenanceWindow is the weekly window RDS applies updates in, and RDSBackupWindow is the daily
+	// window RDS takes backups in, which is derived from it. Empty lets AWS choose both
+	MaintenanceWindow string `json:"maintenance_window"`
+	RDSBackupWindow   string `json:"rds_backup_window"`
+	// EnforceMaintenanceWindow is true if deploys are refused outside the maintenance window
+	EnforceMaintenanceWindow bool `json:"enforce_maintenance_window"`
+
+	// VPCCIDR is the address range of the VPC, which can only be chosen on the first deploy
+	VPCCIDR string `json:"vpc_cidr"`
+	// ExistingNetwork is the existing VPC and subnets the deployment was put in, which can only be chosen
+	// on the first deploy. It's nil when Terraform creates the VPC and subnets
+	ExistingNetwork *ExistingNetwork `json:"existing_network,omitempty"`
+	// PeeringConnections are the VPCs the deployment's VPC is peered with
+	PeeringConnections []PeeringSpec `json:"peering_connections"`
+
+	// HTTPProxy, HTTPSProxy and NoProxy are the proxy the BOSH director and fly connect through
+	HTTPProxy  string `json:"http_proxy"`
+	HTTPSProxy string `json:"https_proxy"`
+	NoProxy    string `json:"no_proxy"`
+
+	// DNSServers and NTPServers replace the VPC's DNS server and the public NTP pool for the director and
+	// every VM it creates. They're empty to use the defaults
+	DNSServers []string `json:"dns_servers"`
+	NTPServers []string `json:"ntp_servers"`
+
+	// ExternalDBURL is the Postgres server used instead of an RDS instance, which can only be chosen on the
+	// first deploy. Its credentials are kept in RDSUsername and RDSPassword, and ExternalDBCACert is the
+	// PEM encoded CA certificate its certificate is verified with
+	ExternalDBURL    string `json:"external_db_url"`
+	ExternalDBCACert string `json:"external_db_ca_cert"`
+}
+
+// Proxy returns the proxy the deployment's BOSH director and fly connect through
+func (c *Config) Proxy() util.Proxy {
+	return util.Proxy{
+		HTTPProxy:  c.HTTPProxy,
+		HTTPSProxy: c.HTTPSProxy,
+		NoProxy:    c.NoProxy,
+	}
+}
+
+// ExternalDB returns the Postgres server the deployment uses instead of an RDS instance, or nil if it uses RDS
+func (c *Config) ExternalDB() (*ExternalDB, error) {
+	if c.ExternalDBURL == "" {
+		return nil, nil
+	}
+	return ParseExternalDBURL(c.ExternalDBURL)
+}
+
+// DBCACert returns the CA certificate the certificate of the deployment's database is verified with
+func (c *Config) DBCACert() string {
+	if c.ExternalDBURL != "" {
+		return c.ExternalDBCACert
+	}
+	return db.RDSRootCert
+}
+
+// Network returns the layout of the deployment's VPC. Configs from before the range
+// could be chosen have no VPCCIDR, and use the range that was always used
+func (c *Config) Network() (*Network, error) {
+	if c.ExistingNetwork != nil {
+		return ParseExistingNetwork(c.VPCCIDR, c.ExistingNetwork.PublicSubnetCIDR, c.ExistingNetwork.PrivateSubnetCIDR)
+	}
+	if c.VPCCIDR == "" {
+		return ParseVPCCIDR(DefaultVPCCIDR)
+	}
+	return ParseVPCCIDR(c.VPCCIDR)
+}
+
+// TerraformVPCID returns the deployment's VPC as Terraform refers to it: the ID of an existing VPC, or the VPC Terraform creates
+func (c *Config) TerraformVPCID() string {
+	if c.ExistingNetwork != nil {
+		return c.ExistingNetwork.VPCID
+	}
+	return "${aws_vpc.default.id}"
+}
+
+// TerraformPublicSubnetID returns the director and web node's subnet as Terraform refers to it
+func (c *Config) TerraformPublicSubnetID() string {
+	if c.ExistingNetwork != nil {
+		return c.ExistingNetwork.PublicSubnetID
+	}
+	return "${aws_subnet.public.id}"
+}
+
+// TerraformPrivateSubnetID returns the workers' subnet as Terraform refers to it
+func (c *Config) TerraformPrivateSubnetID() string {
+	if c.ExistingNetwork != nil {
+		return c.ExistingNetwork.PrivateSubnetID
+	}
+	return "${aws_subnet.private.id}"
+}
+
+// ATCAddress returns the IP address the web node is reached at: its elastic IP, or for a private
+// deployment, which has no public address, its static IP within the VPC
+func (c *Config) ATCAddress(publicIP string) (string, error) {
+	if c.Private {
+		network, err := c.Network()
+		if err != nil {
+			return "", err
+		}
+		return network.WebIP(), nil
+	}
+	if publicIP == "" {
+		return "", errors.New("terraform did not output a public IP for the web node")
+	}
+	return publicIP, nil
+}
+
+// ConfigReplicaBucket returns the bucket in the backup region that the config bucket is replicated to
+func (c *Config) ConfigReplicaBucket() string {
+	return ReplicaBucketName(c.ConfigBucket)
+}
+
+// DirectorInstanceType returns the EC2 instance type of the BOSH director
+func (c *Config) DirectorInstanceType() string {
+	if c.DirectorSize == "" {
+		return DirectorInstanceTypes[DefaultDirectorSize]
+	}
+	return DirectorInstanceTypes[c.DirectorSize]
+}
+
+// WorkerDiskSize returns the size of each worker's ephemeral disk in GB
+func (c *Config) WorkerDiskSize() int {
+	if c.ConcourseWorkerDiskSize == 0 {
+		return DefaultWorkerDiskSize
+	}
+	return c.ConcourseWorkerDiskSize
 }
 
 func generateDefaultConfig(iaas, project, deployment, configBucket, region string) (*Config, error) {
@@ -91,6 +401,7 @@ func generateDefaultConfig(iaas, project, deployment, configBucket, region strin
 	concoursePassword := util.GeneratePassword()
 
 	conf := Config{
+		ATCTLSMinVersion:         DefaultTLSMinVersion,
 		AvailabilityZone:         fmt.Sprintf("%sa", region),
 		ConcourseDBName:          "concourse_atc",
 		ConcoursePassword:        concoursePassword,
@@ -98,6 +409,7 @@ func generateDefaultConfig(iaas, project, deployment, configBucket, region strin
 		ConcourseWorkerCount:     1,
 		ConcourseWebSize:         "small",
 		ConcourseWorkerSize:      "xlarge",
+		ConcourseWorkerDiskSize:  DefaultWorkerDiskSize,
 		ConfigBucket:             configBucket,
 		Deployment:               deployment,
 		DirectorHMUserPassword:   util.GeneratePassword(),
@@ -116,15 +428,19 @@ func generateDefaultConfig(iaas, project, deployment, configBucket, region strin
 		Project:                  project,
 		PublicKey:                strings.TrimSpace(string(publicKey)),
 		RDSDefaultDatabaseName:   "bosh",
+		RDSEngineVersion:         DefaultRDSEngineVersion,
 		RDSPassword:              util.GeneratePassword(),
 		RDSUsername:              "admin" + util.GeneratePassword(),
 		Region:                   region,
 		TFStatePath:              terraformStateFileName,
+		VPCCIDR:                  DefaultVPCCIDR,
 		TokenPrivateKey:          strings.TrimSpace(string(tokenPrivateKey)),
 		TokenPublicKey:           strings.TrimSpace(string(tokenPublicKey)),
 		TSAFingerprint:           strings.TrimSpace(tsaFingerprint),
+		TSAPort:                  DefaultTSAPort,
 		TSAPrivateKey:            strings.TrimSpace(string(tsaPrivateKey)),
 		TSAPublicKey:             strings.TrimSpace(string(tsaPublicKey)),
+		TerminationProtection:    true,
 		WorkerFingerprint:        strings.TrimSpace(workerFingerprint),
 		WorkerPrivateKey:         strings.TrimSpace(string(workerPrivateKey)),
 		WorkerPublicKey:          strings.TrimSpace(string(workerPublicKey)),
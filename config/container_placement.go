@@ -0,0 +1,20 @@
+package config
+
+import "sort"
+
+// ContainerPlacementStrategies maps the ATC's container placement strategies to the first Concourse release with them
+var ContainerPlacementStrategies = map[string]string{
+	"volume-locality":         "3.0.0",
+	"random":                  "3.0.0",
+	"fewest-build-containers": "4.2.0",
+	"limit-active-tasks":      "6.0.0",
+}
+
+func containerPlacementStrategyNames() []string {
+	names := []string{}
+	for name := range ContainerPlacementStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
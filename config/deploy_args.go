@@ -1,8 +1,20 @@
 package config
 
 import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/util"
 )
 
 // DeployArgs are arguments passed to the deploy command
@@ -14,20 +26,465 @@ type DeployArgs struct {
 	TLSKey      string
 	WorkerCount int
 	WorkerSize  string
-	WebSize     string
-	SelfUpdate  bool
-	DBSize      string
+	// DNSWeight is the weight of the deployment's Route53 record, which makes it a weighted record
+	DNSWeight int
+	// DNSWeightIsSet is true if the user has specified a DNS weight
+	DNSWeightIsSet bool
+	// ACMEEnabled is true if the certificate for the domain should come from Let's Encrypt rather than be self-signed
+	ACMEEnabled bool
+	// ACMEEnabledIsSet is true if the user has specified whether to use Let's Encrypt
+	ACMEEnabledIsSet bool
+	// WorkerDrainTimeout is the number of minutes to wait for running builds to finish
+	// on workers that are removed when scaling down. Zero disables worker retirement
+	WorkerDrainTimeout int
+	// DrainTimeout is the number of minutes a self-update waits for running builds to finish before updating
+	// the deployment. Zero doesn't wait
+	DrainTimeout int
+	// DrainTimeoutIsSet is true if the user has specified the drain timeout
+	DrainTimeoutIsSet bool
+	// DetachTimeout is the number of minutes a self-update waits for the BOSH deploy it starts to finish
+	DetachTimeout int
+	// WaitForDetach is true if a self-update should wait for its BOSH deploy rather than exit once it starts
+	WaitForDetach bool
+	// RecreateWorkers is true if the workers' VMs should be replaced with fresh ones after the deploy
+	RecreateWorkers bool
+	// ForceDirectorRebuild is true if the director should be deleted and bootstrapped again from scratch,
+	// with new certificates
+	ForceDirectorRebuild bool
+	// ReleaseBundle is the path of a tarball of the stemcells and releases to deploy from, rather than downloading them
+	ReleaseBundle string
+	// WaitForWorkers is the number of workers that must be running before a deploy succeeds. Zero doesn't wait
+	WaitForWorkers int
+	// WaitForWorkersTimeout is the number of minutes to wait for WaitForWorkers workers to be running
+	WaitForWorkersTimeout int
+	// PostDeployErrands are the BOSH errands to run, in order, after a deploy
+	PostDeployErrands []string
+	// PostDeployErrandsIsSet is true if the user has specified errands, which may be empty to stop running them
+	PostDeployErrandsIsSet bool
+	// NoDefaultPipeline is true if the self-update pipeline shouldn't be set
+	NoDefaultPipeline bool
+	// NoDefaultPipelineIsSet is true if the user has specified whether the self-update pipeline is set
+	NoDefaultPipelineIsSet bool
+	// ExtraPipelines are the pipelines to set, creating their teams, after a deploy
+	ExtraPipelines []PipelineSpec
+	// ExtraPipelinesIsSet is true if the user has specified pipelines, which may be empty to stop setting them
+	ExtraPipelinesIsSet bool
+	// IsolatedWorkers is true if workers should be deployed into their own subnet and security group
+	IsolatedWorkers bool
+	// IsolatedWorkersIsSet is true if the user has specified whether workers are isolated
+	IsolatedWorkersIsSet bool
+	// Private is true if Concourse should only be reachable from within the VPC, with no public IP
+	Private bool
+	// PrivateIsSet is true if the user has specified whether the deployment is private
+	PrivateIsSet bool
+	// EnableIPv6 is true if the VPC should have IPv6 addresses, with egress-only internet access from the private subnets
+	EnableIPv6 bool
+	// EnableIPv6IsSet is true if the user has specified whether IPv6 is enabled
+	EnableIPv6IsSet bool
+	// PublicIPv6 is true if the web node should be reachable over IPv6 too, with an AAAA record for the domain
+	PublicIPv6 bool
+	// PublicIPv6IsSet is true if the user has specified whether the web node is reachable over IPv6
+	PublicIPv6IsSet bool
+	WebSize         string
+	// DirectorSize is the size of the BOSH director
+	DirectorSize string
+	// DirectorSizeIsSet is true if the user has specified the director's size
+	DirectorSizeIsSet bool
+	SelfUpdate        bool
+	DBSize            string
 	// DBSizeIsSet is true if the user has manually specified the db-size (ie, it's not the default)
 	DBSizeIsSet bool
-	AllowIPs    string
+	// DryRun is true if Terraform's plan should be printed without applying it or deploying BOSH
+	DryRun bool
+	// GenerateManifestOnly is true if the Concourse manifest should be rendered without changing anything
+	GenerateManifestOnly bool
+	// ManifestFile is the path the generated manifest is written to, rather than stdout
+	ManifestFile string
+	// ShowSecrets is true if the generated manifest should include secrets, rather than redacting them
+	ShowSecrets bool
+	// JSONOutput is true if the deploy success message should be written as JSON, for scripts to read
+	JSONOutput bool
+	// BackupDB is true if the RDS instance should be snapshotted before BOSH deploys
+	BackupDB bool
+	// DBEngineVersion is the Postgres version of the RDS instance
+	DBEngineVersion string
+	// DBEngineVersionIsSet is true if the user has specified a Postgres version
+	DBEngineVersionIsSet bool
+	// Profile is the preset of sizes the deployment was deployed with
+	Profile string
+	// ProfileIsSet is true if the user has specified a profile
+	ProfileIsSet bool
+	// MultiAZRDS is true if the RDS instance should have a standby in another availability zone
+	MultiAZRDS bool
+	// MultiAZRDSIsSet is true if a profile has chosen whether the RDS instance is multi-AZ
+	MultiAZRDSIsSet bool
+	// DBApplyImmediately is true if RDS modifications should not wait for the maintenance window
+	DBApplyImmediately bool
+	// DBApplyImmediatelyIsSet is true if the user has specified whether RDS modifications wait
+	DBApplyImmediatelyIsSet bool
+	// DBAvailabilityZone is the availability zone the RDS primary is created in. Empty lets AWS choose
+	DBAvailabilityZone string
+	// DBAvailabilityZoneIsSet is true if the user has specified an availability zone for the RDS primary
+	DBAvailabilityZoneIsSet bool
+	// ExternalDBURL is the Postgres server to use instead of an RDS instance, which can only be chosen on the first deploy
+	ExternalDBURL string
+	// ExternalDBUsername and ExternalDBPassword are the credentials of a user on the external Postgres server
+	// that can create databases
+	ExternalDBUsername string
+	ExternalDBPassword string
+	// ExternalDBCACert is the PEM encoded CA certificate to verify the external Postgres server's certificate with
+	ExternalDBCACert string
+	// DBMaxOpenConnections and DBMaxIdleConnections size the ATC's connection pool.
+	// Zero leaves the Concourse default in place
+	DBMaxOpenConnections int
+	DBMaxIdleConnections int
+	// DBMaxOpenConnectionsIsSet and DBMaxIdleConnectionsIsSet are true if the user has sized the pool,
+	// which may be zero to go back to the ATC's defaults
+	DBMaxOpenConnectionsIsSet bool
+	DBMaxIdleConnectionsIsSet bool
+	// DBConnectTimeout is how long the ATC keeps trying to connect to the database. Zero uses the Concourse default
+	DBConnectTimeout time.Duration
+	// DBConnectTimeoutIsSet is true if the user has specified a database connect timeout
+	DBConnectTimeoutIsSet bool
+	// Resurrector is true if the director should recreate VMs whose agent stops responding
+	Resurrector bool
+	// ResurrectorIsSet is true if the user has specified whether the resurrector is enabled
+	ResurrectorIsSet bool
+	AllowIPs         string
+	BrandingWordmark string
+	BrandingCSS      string
+	// TerminationProtection is true if the director and web VMs should be
+	// protected from termination outside of concourse-up
+	TerminationProtection bool
+	// TerminationProtectionIsSet is true if the user has specified whether the VMs are protected
+	TerminationProtectionIsSet bool
+	// SecretCache is true if the ATC should cache credential manager lookups for SecretCacheTTL
+	SecretCache    bool
+	SecretCacheTTL time.Duration
+	// SecretCacheIsSet and SecretCacheTTLIsSet are true if the user has specified whether secrets are cached, and for how long
+	SecretCacheIsSet    bool
+	SecretCacheTTLIsSet bool
+	// StandbyOf is the region of the primary deployment when deploying a disaster recovery standby
+	StandbyOf string
+	// BackupRegion is the region the config bucket is replicated to, and read from when it can't be reached
+	BackupRegion string
+	// BackupRegionIsSet is true if the user has specified a backup region, which may be empty to stop replicating
+	BackupRegionIsSet bool
+	// KMSKeyID is the KMS key that the config bucket's objects are encrypted with
+	KMSKeyID string
+	// KMSKeyIDIsSet is true if the user has specified a KMS key, which may be empty to go back to S3's own keys
+	KMSKeyIDIsSet bool
+	// Promote is true if a standby deployment should take over from its primary
+	Promote bool
+	TSAPort int
+	// TSAPortIsSet is true if the user has manually specified the tsa-port (ie, it's not the default)
+	TSAPortIsSet bool
+	// ContainerNetworkPool and ContainerNetworkMTU configure the workers' container networking.
+	// Empty and zero leave the Garden defaults in place
+	ContainerNetworkPool string
+	ContainerNetworkMTU  int
+	// ContainerNetworkPoolIsSet and ContainerNetworkMTUIsSet are true if the user has specified them,
+	// which may be empty or zero to go back to the defaults
+	ContainerNetworkPoolIsSet bool
+	ContainerNetworkMTUIsSet  bool
+	// EnableGlobalResources is true if the ATC should share resource checks between pipelines
+	EnableGlobalResources bool
+	// Confirm is true if the user has agreed to changes that affect running pipelines
+	Confirm bool
+	// ConcoursePassword replaces the generated password of the Concourse admin user when it isn't empty
+	ConcoursePassword string
+	// MetricsUsername is the Grafana admin's username. Empty goes back to the Concourse admin's
+	MetricsUsername string
+	// MetricsUsernameIsSet is true if the user has specified a Grafana username
+	MetricsUsernameIsSet bool
+	// MetricsPassword is the Grafana admin's password. Empty goes back to the Concourse admin's
+	MetricsPassword string
+	// MetricsPasswordIsSet is true if the user has specified a Grafana password
+	MetricsPasswordIsSet bool
+	// PipelineRetries is the number of times to retry setting the default pipeline on a fresh deploy
+	PipelineRetries int
+	// CredhubSeedFile is the path of a YAML file of secrets to set in CredHub after BOSH deploys, keyed by team
+	CredhubSeedFile string
+	// WorkerTags are the Concourse tags given to every worker
+	WorkerTags []string
+	// WorkerTagsIsSet is true if the user has specified worker tags, which may be empty to remove them
+	WorkerTagsIsSet bool
+	// Tags are the AWS tags given to the deployment's resources
+	Tags map[string]string
+	// TagsIsSet is true if the user has specified AWS tags, which may be empty to remove them
+	TagsIsSet bool
+	// TerraformVars are the Terraform variables read from --tf-vars-file
+	TerraformVars map[string]interface{}
+	// TerraformVarsIsSet is true if the user has specified a Terraform variables file, which may be empty
+	// to go back to the defaults
+	TerraformVarsIsSet bool
+	// MaintenanceWindow is the weekly window, in UTC, that RDS applies updates in, eg sun:03:00-sun:04:00
+	MaintenanceWindow string
+	// MaintenanceWindowIsSet is true if the user has specified a maintenance window, which may be empty to let AWS choose it
+	MaintenanceWindowIsSet bool
+	// EnforceMaintenanceWindow is true if deploys should be refused outside the maintenance window
+	EnforceMaintenanceWindow bool
+	// EnforceMaintenanceWindowIsSet is true if the user has specified whether the maintenance window is enforced
+	EnforceMaintenanceWindowIsSet bool
+	// VPCCIDR is the address range of the VPC, which can only be chosen on the first deploy
+	VPCCIDR string
+	// VPCCIDRIsSet is true if the user has specified the VPC's address range
+	VPCCIDRIsSet bool
+	// ExistingPublicSubnetIDs and ExistingPrivateSubnetIDs are subnets of an existing VPC to deploy into
+	// instead of creating a VPC, which can only be chosen on the first deploy
+	ExistingPublicSubnetIDs  []string
+	ExistingPrivateSubnetIDs []string
+	// PeeringConnections are the VPCs to peer the deployment's VPC with
+	PeeringConnections []PeeringSpec
+	// AdditionalAllowedCIDRs are IPv4 ranges allowed to reach the director as well as the deploying machine
+	AdditionalAllowedCIDRs []string
+	// AdditionalAllowedCIDRsIsSet is true if the user has specified allowed ranges, which may be empty to remove them
+	AdditionalAllowedCIDRsIsSet bool
+	// PeeringConnectionsIsSet is true if the user has specified peered VPCs, which may be empty to remove them
+	PeeringConnectionsIsSet bool
+	// Proxy is the HTTP(S) proxy given with the global proxy flags
+	Proxy util.Proxy
+	// ProxyIsSet is true if the user has specified any of the proxy flags, which may be empty to stop using a proxy
+	ProxyIsSet bool
+	// S3Endpoint is the S3-compatible object store given with the global --s3-endpoint flag, if the config
+	// bucket isn't in S3
+	S3Endpoint string
+	// DNSServers are the addresses of the DNS servers the director and VMs use instead of the VPC's
+	DNSServers []string
+	// DNSServersIsSet is true if the user has specified DNS servers, which may be empty to go back to the VPC's
+	DNSServersIsSet bool
+	// NTPServers are the NTP servers the director and VMs keep time with instead of the public pool
+	NTPServers []string
+	// NTPServersIsSet is true if the user has specified NTP servers, which may be empty to go back to the public pool
+	NTPServersIsSet bool
+	// WorkerAMIID is the customer-managed AMI to boot workers from. Empty uses the stock stemcell
+	WorkerAMIID string
+	// WorkerAMIIDIsSet is true if the user has specified a worker AMI, which may be empty to go back to the stock stemcell
+	WorkerAMIIDIsSet bool
+	// StemcellVersion is the version of the stemcell to pin the Concourse VMs to
+	StemcellVersion string
+	// StemcellVersionIsSet is true if the user has specified a stemcell version, which may be empty to unpin it
+	StemcellVersionIsSet bool
+	// WorkerDiskSize is the size of each worker's ephemeral disk in GB
+	WorkerDiskSize int
+	// WorkerDiskSizeIsSet is true if the user has specified the workers' disk size
+	WorkerDiskSizeIsSet bool
+	// WorkerEphemeral is true if Concourse should remove workers' registrations as soon as they go away
+	WorkerEphemeral bool
+	// WorkerEphemeralIsSet is true if the user has specified whether workers are ephemeral
+	WorkerEphemeralIsSet bool
+	// WorkerSpot is true if workers of the sizes that support it should run on spot instances
+	WorkerSpot bool
+	// WorkerSpotIsSet is true if the user has specified whether workers run on spot instances
+	WorkerSpotIsSet bool
+	// WorkerSpotMaxPrice is the most to pay per hour, in US dollars, for each spot worker. Empty uses the defaults for each size
+	WorkerSpotMaxPrice string
+	// WorkerSpotMaxPriceIsSet is true if the user has specified a maximum spot price, which may be empty to go back to the defaults
+	WorkerSpotMaxPriceIsSet bool
+	// DirectorInstanceProfile, WebInstanceProfile and WorkerInstanceProfile are the ARNs of
+	// pre-existing instance profiles to use instead of having Terraform create IAM users
+	DirectorInstanceProfile string
+	WebInstanceProfile      string
+	WorkerInstanceProfile   string
+	// TLSMinVersion and TLSCipherSuites restrict the TLS connections the ATC accepts
+	TLSMinVersion string
+	// TLSMinVersionIsSet is true if the user has specified the concourse-tls-min-version
+	TLSMinVersionIsSet bool
+	TLSCipherSuites    []string
+	// TLSCipherSuitesIsSet is true if the user has specified cipher suites, which may be empty to remove them
+	TLSCipherSuitesIsSet bool
+	// Tenancy is the EC2 tenancy of the deployment's instances, which can only be chosen on the first deploy
+	Tenancy string
+	// TenancyIsSet is true if the user has manually specified the tenancy (ie, it's not the default)
+	TenancyIsSet bool
+	// ExternalPort, CredhubPort and GrafanaPort are the ports the web node serves Concourse, CredHub and Grafana on
+	ExternalPort int
+	CredhubPort  int
+	GrafanaPort  int
+	// ExternalPortIsSet, CredhubPortIsSet and GrafanaPortIsSet are true if the user has specified the ports
+	ExternalPortIsSet bool
+	CredhubPortIsSet  bool
+	GrafanaPortIsSet  bool
+	// GrafanaPath is the path on the Concourse domain to serve Grafana under instead of port 3000
+	GrafanaPath string
+	// GrafanaPathIsSet is true if the user has specified a Grafana path, which may be empty to move it back to port 3000
+	GrafanaPathIsSet bool
+	// WorkerRegistryCACerts are PEM encoded CA certificates the workers trust when pulling images from registries
+	WorkerRegistryCACerts string
+	// WorkerRegistryCACertsIsSet is true if the user has specified registry CA certificates, which may be empty to remove them
+	WorkerRegistryCACertsIsSet bool
+	// BoshUpdateSerial is true if BOSH should wait for other deployments on the director to finish updating first
+	BoshUpdateSerial bool
+	// BoshUpdateSerialIsSet is true if the user has specified whether updates are serial
+	BoshUpdateSerialIsSet bool
+	// BoshCanaryWatchTime is how long BOSH waits for canary instances to become healthy,
+	// in milliseconds or as a range of milliseconds such as 1000-300000
+	BoshCanaryWatchTime string
+	// BoshCanaryWatchTimeIsSet is true if the user has specified a canary watch time, which may be empty to use the default
+	BoshCanaryWatchTimeIsSet bool
+	// BoshUpdateWatchTime is how long BOSH waits for the remaining instances to become healthy, in the same format
+	BoshUpdateWatchTime string
+	// BoshUpdateWatchTimeIsSet is true if the user has specified an update watch time, which may be empty to use the default
+	BoshUpdateWatchTimeIsSet bool
+	// HijackGracePeriod is how long the ATC keeps a hijacked container after its session ends. Zero uses the Concourse default
+	HijackGracePeriod time.Duration
+	// HijackGracePeriodIsSet is true if the user has specified a hijack grace period
+	HijackGracePeriodIsSet bool
+	// InterceptIdleTimeout is how long a hijack session can be idle before the ATC ends it. Zero uses the Concourse default
+	InterceptIdleTimeout time.Duration
+	// InterceptIdleTimeoutIsSet is true if the user has specified an intercept idle timeout
+	InterceptIdleTimeoutIsSet bool
+	// ResourceCheckingInterval is how often resources are checked when their pipelines don't say. Zero uses the Concourse default
+	ResourceCheckingInterval time.Duration
+	// ResourceCheckingIntervalIsSet is true if the user has specified a resource checking interval
+	ResourceCheckingIntervalIsSet bool
+	// ResourceCheckTimeout is how long a resource check can run when its pipeline doesn't say. Zero uses the Concourse default
+	ResourceCheckTimeout time.Duration
+	// ResourceCheckTimeoutIsSet is true if the user has specified a resource check timeout
+	ResourceCheckTimeoutIsSet bool
+	// DefaultGetTimeout is how long a get step can run when its pipeline doesn't say. Zero means no timeout
+	DefaultGetTimeout time.Duration
+	// DefaultGetTimeoutIsSet is true if the user has specified a default get timeout
+	DefaultGetTimeoutIsSet bool
+	// DefaultPutTimeout is how long a put step can run when its pipeline doesn't say. Zero means no timeout
+	DefaultPutTimeout time.Duration
+	// DefaultPutTimeoutIsSet is true if the user has specified a default put timeout
+	DefaultPutTimeoutIsSet bool
+	// ContainerPlacementStrategy is how the ATC chooses a worker for each container. Empty uses the Concourse default
+	ContainerPlacementStrategy string
+	// ContainerPlacementStrategyIsSet is true if the user has specified a container placement strategy
+	ContainerPlacementStrategyIsSet bool
+	// MaxActiveTasksPerWorker is how many tasks the ATC places on each worker. Zero is unlimited
+	MaxActiveTasksPerWorker int
+	// MaxActiveTasksPerWorkerIsSet is true if the user has specified a task limit, which may be zero to remove it
+	MaxActiveTasksPerWorkerIsSet bool
+	// MaxActiveContainersPerWorker is how many containers each worker runs. Zero is unlimited
+	MaxActiveContainersPerWorker int
+	// MaxActiveContainersPerWorkerIsSet is true if the user has specified a container limit, which may be zero to remove it
+	MaxActiveContainersPerWorkerIsSet bool
+	// P2PVolumeStreaming is true if volumes should be streamed directly between workers
+	P2PVolumeStreaming bool
+	// P2PVolumeStreamingIsSet is true if the user has specified whether to stream volumes directly between workers
+	P2PVolumeStreamingIsSet bool
+	// ATCLogLevel is the minimum level the ATC logs at. Empty uses the Concourse default
+	ATCLogLevel string
+	// ATCLogLevelIsSet is true if the user has specified a log level
+	ATCLogLevelIsSet bool
+	// SyslogAddress is the host:port of an external syslog collector to forward logs to
+	SyslogAddress string
+	// SyslogAddressIsSet is true if the user has specified a syslog address, which may be empty to stop forwarding logs
+	SyslogAddressIsSet bool
+	// SyslogTransport is the protocol logs are forwarded with. Can be tcp, udp or relp
+	SyslogTransport string
+	// SyslogTLS is true if logs should be forwarded over TLS
+	SyslogTLS bool
+	// SyslogCACert is a PEM encoded CA certificate to verify the collector's certificate with
+	SyslogCACert string
+	// PrometheusURL is the remote write endpoint of an external Prometheus to send the ATC's metrics to
+	PrometheusURL string
+	// PrometheusURLIsSet is true if the user has specified a Prometheus URL, which may be empty to stop sending metrics
+	PrometheusURLIsSet bool
+	// PrometheusUsername and PrometheusPassword are the basic auth credentials of the remote write endpoint
+	PrometheusUsername string
+	PrometheusPassword string
+	// Description is a one line summary of what the deployment is for
+	Description string
+	// DescriptionIsSet is true if the user has specified a description, which may be empty to remove it
+	DescriptionIsSet bool
+	// Notes are freeform notes about the deployment, such as its owner or anything unusual about it
+	Notes string
+	// NotesIsSet is true if the user has specified notes, which may be empty to remove them
+	NotesIsSet bool
+	// Operator is who is running the deploy. Empty records the caller's AWS identity
+	Operator string
+	// Experiments are the ATC properties of the experiments to enable
+	Experiments []string
 }
 
+// MinContainerNetworkMTU and MaxContainerNetworkMTU bound the container network MTU.
+// 9001 is the largest MTU EC2 instances support
+const (
+	MinContainerNetworkMTU = 1280
+	MaxContainerNetworkMTU = 9001
+)
+
+// DefaultWorkerDiskSize is the size in GB of each worker's ephemeral disk unless --worker-disk-size is given.
+// MinWorkerDiskSize and MaxWorkerDiskSize bound it, leaving room under the largest gp2 volume AWS allows
+const (
+	DefaultWorkerDiskSize = 200
+	MinWorkerDiskSize     = 50
+	MaxWorkerDiskSize     = 16000
+)
+
+// SyslogTransports are the protocols logs can be forwarded to a syslog collector with
+var SyslogTransports = []string{"tcp", "udp", "relp"}
+
+// TLSVersions are the permitted minimum TLS versions for the ATC
+var TLSVersions = []string{"1.0", "1.1", "1.2", "1.3"}
+
+// DefaultTLSMinVersion is the minimum TLS version of the ATC for new deployments
+const DefaultTLSMinVersion = "1.2"
+
+// DefaultTSAPort is the port workers register with the TSA on unless --tsa-port is given
+const DefaultTSAPort = 2222
+
+// DefaultRDSEngineVersion is the Postgres version of the RDS instance unless --db-engine-version is given
+const DefaultRDSEngineVersion = "9.6.6"
+
+// dbEngineVersionPattern matches the RDS Postgres engine versions, eg 9.6.6 or 10.4
+var dbEngineVersionPattern = regexp.MustCompile(`^\d+(\.\d+)+$`)
+
+// stemcellVersionPattern matches stemcell versions, eg 3468 or 3468.22
+var stemcellVersionPattern = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// spotPricePattern matches a price in US dollars, eg 0.25
+var spotPricePattern = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// webPorts are the ports already in use on the web node, which the TSA can't listen on
+var webPorts = []int{22, 80, 443, 3000, 5555, 6868, 8080, 8086, 8443, 8844}
+
+// grafanaPathPattern matches the paths Grafana can be served under
+var grafanaPathPattern = regexp.MustCompile(`^/[a-z0-9-]+$`)
+
+// watchTimePattern matches a BOSH watch time, which is either a number of milliseconds or a range of them
+var watchTimePattern = regexp.MustCompile(`^(\d+)(?:-(\d+))?$`)
+
+// kmsKeyPattern matches a KMS key ID or alias, or the ARN of either, eg alias/concourse-up
+var kmsKeyPattern = regexp.MustCompile(`^((arn:aws[a-z-]*:kms:[a-z0-9-]+:\d{12}:key/)?[0-9a-f]{8}(-[0-9a-f]{4}){3}-[0-9a-f]{12}|(arn:aws[a-z-]*:kms:[a-z0-9-]+:\d{12}:)?alias/[a-zA-Z0-9/_-]+)$`)
+
+// ATCLogLevels are the levels the ATC can log at, from most to least verbose
+var ATCLogLevels = []string{"debug", "info", "error"}
+
+func isATCLogLevel(level string) bool {
+	for _, l := range ATCLogLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// atcPaths are the top level paths the ATC serves, which Grafana can't be served under
+var atcPaths = []string{"/api", "/auth", "/builds", "/login", "/logout", "/oauth", "/pipelines", "/public", "/sky", "/teams"}
+
+// MinSecretCacheTTL and MaxSecretCacheTTL bound how long the ATC may cache secrets for
+const (
+	MinSecretCacheTTL = 10 * time.Second
+	MaxSecretCacheTTL = time.Hour
+)
+
 // WorkerSizes are the permitted concourse worker sizes
 var WorkerSizes = []string{"medium", "large", "xlarge", "2xlarge", "4xlarge", "10xlarge", "16xlarge"}
 
 // WebSizes are the permitted concourse web sizes
 var WebSizes = []string{"small", "medium", "large", "xlarge", "2xlarge"}
 
+// RDSAvailabilityZones returns the availability zones of the rds_a and rds_b subnets
+// in the terraform template, which the RDS primary can be placed in
+func RDSAvailabilityZones(region string) []string {
+	return []string{region + "a", region + "b"}
+}
+
 // DBSizes maps SML sizes to RDS instance classes
 var DBSizes = map[string]string{
 	"small":   "db.t2.small",
@@ -38,12 +495,70 @@ var DBSizes = map[string]string{
 	"4xlarge": "db.m4.4xlarge",
 }
 
+// RDSMaxConnections maps RDS instance classes to the default max_connections
+// of their parameter group, which AWS derives from the instance's memory
+var RDSMaxConnections = map[string]int{
+	"db.t2.small":   225,
+	"db.t2.medium":  450,
+	"db.m4.large":   901,
+	"db.m4.xlarge":  1802,
+	"db.m4.2xlarge": 3604,
+	"db.m4.4xlarge": 5000,
+}
+
+// RDSReservedConnections is the number of RDS connections kept back for BOSH, UAA and Credhub
+const RDSReservedConnections = 64
+
 // Validate validates that flag interdependencies
 func (args DeployArgs) Validate() error {
 	if err := args.validateCertFields(); err != nil {
 		return err
 	}
 
+	if err := ValidateDNSWeight("dns-weight", args.DNSWeight); err != nil {
+		return err
+	}
+
+	if args.DryRun && (args.SelfUpdate || args.StandbyOf != "") {
+		return errors.New("--dry-run cannot be used with --self-update or --standby-of")
+	}
+
+	if args.GenerateManifestOnly && (args.DryRun || args.SelfUpdate || args.StandbyOf != "") {
+		return errors.New("--generate-manifest-only cannot be used with --dry-run, --self-update or --standby-of")
+	}
+
+	if !args.GenerateManifestOnly && (args.ManifestFile != "" || args.ShowSecrets) {
+		return errors.New("--manifest-file and --show-secrets can only be used with --generate-manifest-only")
+	}
+
+	if args.NoDefaultPipeline && args.SelfUpdate {
+		return errors.New("--no-pipeline cannot be used with --self-update, which is run by the pipeline it stops setting")
+	}
+
+	if args.WaitForDetach && !args.SelfUpdate {
+		return errors.New("--detach-timeout can only be used with --self-update")
+	}
+
+	if args.WaitForDetach && args.DetachTimeout < 1 {
+		return errors.New("--detach-timeout must be at least 1 minute")
+	}
+
+	if args.RecreateWorkers && args.SelfUpdate {
+		return errors.New("--recreate-workers cannot be used with --self-update, which doesn't wait for the deploy to finish")
+	}
+
+	if args.ForceDirectorRebuild && (args.DryRun || args.SelfUpdate || args.StandbyOf != "" || args.GenerateManifestOnly) {
+		return errors.New("--force-redeploy-director cannot be used with --dry-run, --self-update, --standby-of or --generate-manifest-only")
+	}
+
+	if args.ReleaseBundle != "" && args.SelfUpdate {
+		return errors.New("--release-bundle cannot be used with --self-update, which runs in the pipeline without the bundle")
+	}
+
+	if args.CredhubSeedFile != "" && (args.SelfUpdate || args.StandbyOf != "") {
+		return errors.New("--credhub-seed-file cannot be used with --self-update or --standby-of")
+	}
+
 	if err := args.validateWorkerFields(); err != nil {
 		return err
 	}
@@ -52,10 +567,149 @@ func (args DeployArgs) Validate() error {
 		return err
 	}
 
+	if err := args.validateDirectorFields(); err != nil {
+		return err
+	}
+
 	if err := args.validateDBFields(); err != nil {
 		return err
 	}
 
+	if err := args.validateBrandingFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateSecretCacheFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateStandbyFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateBackupFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateKMSFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateTSAFields(); err != nil {
+		return err
+	}
+
+	if err := args.validatePortFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateNetworkFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateProxyFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateNameServerFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateInstanceProfileFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateTLSFields(); err != nil {
+		return err
+	}
+
+	if !contains(Tenancies, args.Tenancy) {
+		return fmt.Errorf("unknown tenancy: `%s`. Valid tenancies are: %v", args.Tenancy, Tenancies)
+	}
+
+	if err := args.validateGrafanaFields(); err != nil {
+		return err
+	}
+
+	if err := args.validateWorkerRegistryCACerts(); err != nil {
+		return err
+	}
+
+	if args.DBConnectTimeout < 0 {
+		return errors.New("--db-connect-timeout cannot be negative")
+	}
+
+	if args.HijackGracePeriod < 0 {
+		return errors.New("--concourse-gc-hijack-grace-period cannot be negative")
+	}
+
+	if args.InterceptIdleTimeout < 0 {
+		return errors.New("--concourse-intercept-idle-timeout cannot be negative")
+	}
+
+	if args.ResourceCheckingInterval < 0 {
+		return errors.New("--concourse-resource-checking-interval cannot be negative")
+	}
+
+	if args.ResourceCheckTimeout < 0 {
+		return errors.New("--concourse-resource-check-timeout cannot be negative")
+	}
+
+	if args.DefaultGetTimeout < 0 {
+		return errors.New("--concourse-default-get-timeout cannot be negative")
+	}
+
+	if args.DefaultPutTimeout < 0 {
+		return errors.New("--concourse-default-put-timeout cannot be negative")
+	}
+
+	if _, ok := ContainerPlacementStrategies[args.ContainerPlacementStrategy]; args.ContainerPlacementStrategy != "" && !ok {
+		return fmt.Errorf("unknown container placement strategy: `%s`. Valid strategies are: %v", args.ContainerPlacementStrategy, containerPlacementStrategyNames())
+	}
+
+	if args.MaxActiveTasksPerWorker < 0 {
+		return errors.New("--max-active-tasks-per-worker cannot be negative")
+	}
+
+	if args.MaxActiveContainersPerWorker < 0 {
+		return errors.New("--max-active-containers-per-worker cannot be negative")
+	}
+
+	if args.ATCLogLevel != "" && !isATCLogLevel(args.ATCLogLevel) {
+		return fmt.Errorf("unknown ATC log level: `%s`. Valid levels are: %v", args.ATCLogLevel, ATCLogLevels)
+	}
+
+	if err := args.validateSyslogFields(); err != nil {
+		return err
+	}
+
+	if err := args.validatePrometheusFields(); err != nil {
+		return err
+	}
+
+	if err := validateWatchTime("--bosh-canary-watch-time", args.BoshCanaryWatchTime); err != nil {
+		return err
+	}
+
+	if err := validateWatchTime("--bosh-update-watch-time", args.BoshUpdateWatchTime); err != nil {
+		return err
+	}
+
+	if strings.ContainsAny(args.Description, "\r\n") {
+		return errors.New("--description must be a single line. Use --notes for anything longer")
+	}
+
+	return nil
+}
+
+// MaxDNSWeight is the largest weight Route53 allows on a weighted record
+const MaxDNSWeight = 255
+
+// ValidateDNSWeight returns an error if weight, given to flag, isn't a valid Route53 record weight
+func ValidateDNSWeight(flag string, weight int) error {
+	if weight < 0 || weight > MaxDNSWeight {
+		return fmt.Errorf("--%s must be between 0 and %d", flag, MaxDNSWeight)
+	}
 	return nil
 }
 
@@ -78,6 +732,50 @@ func (args DeployArgs) validateWorkerFields() error {
 		return errors.New("minimum of workers is 1")
 	}
 
+	if args.WorkerDrainTimeout < 0 {
+		return errors.New("--worker-drain-timeout cannot be negative")
+	}
+
+	if args.DrainTimeout < 0 {
+		return errors.New("--drain-timeout cannot be negative")
+	}
+
+	if args.PipelineRetries < 0 {
+		return errors.New("--pipeline-retries cannot be negative")
+	}
+
+	if err := args.validateWaitForWorkers(); err != nil {
+		return err
+	}
+
+	for _, tag := range args.WorkerTags {
+		if strings.ContainsAny(tag, " \t\n,") {
+			return fmt.Errorf("worker tag `%s` cannot contain whitespace or commas", tag)
+		}
+	}
+
+	if args.WorkerSpotMaxPrice != "" {
+		price, _ := strconv.ParseFloat(args.WorkerSpotMaxPrice, 64)
+		if !spotPricePattern.MatchString(args.WorkerSpotMaxPrice) || price <= 0 {
+			return fmt.Errorf("--spot-max-price must be a price per hour in US dollars, eg 0.25, not `%s`", args.WorkerSpotMaxPrice)
+		}
+		if args.WorkerSpotIsSet && !args.WorkerSpot {
+			return errors.New("--spot-max-price cannot be used with --spot-workers=false")
+		}
+	}
+
+	if args.WorkerDiskSizeIsSet && (args.WorkerDiskSize < MinWorkerDiskSize || args.WorkerDiskSize > MaxWorkerDiskSize) {
+		return fmt.Errorf("--worker-disk-size must be between %d and %d GB", MinWorkerDiskSize, MaxWorkerDiskSize)
+	}
+
+	if args.StemcellVersion != "" && !stemcellVersionPattern.MatchString(args.StemcellVersion) {
+		return fmt.Errorf("--stemcell-version must be a stemcell version, eg 3468.22, not `%s`", args.StemcellVersion)
+	}
+
+	if args.WorkerAMIID != "" && !strings.HasPrefix(args.WorkerAMIID, "ami-") {
+		return fmt.Errorf("--worker-ami-id must be an AMI ID, eg ami-0123456789abcdef0, not `%s`", args.WorkerAMIID)
+	}
+
 	for _, size := range WorkerSizes {
 		if size == args.WorkerSize {
 			return nil
@@ -86,6 +784,30 @@ func (args DeployArgs) validateWorkerFields() error {
 	return fmt.Errorf("unknown worker size: `%s`. Valid sizes are: %v", args.WorkerSize, WorkerSizes)
 }
 
+func (args DeployArgs) validateWaitForWorkers() error {
+	if args.WaitForWorkers < 0 {
+		return errors.New("--wait-for-workers cannot be negative")
+	}
+	if args.WaitForWorkers == 0 {
+		return nil
+	}
+
+	if args.WaitForWorkers > args.WorkerCount {
+		return fmt.Errorf("--wait-for-workers cannot be more than the %d workers being deployed", args.WorkerCount)
+	}
+	if args.WaitForWorkersTimeout < 1 {
+		return errors.New("--wait-for-workers-timeout must be at least 1 minute")
+	}
+	if args.SelfUpdate {
+		return errors.New("--wait-for-workers cannot be used with --self-update")
+	}
+	if args.StandbyOf != "" {
+		return errors.New("--wait-for-workers cannot be used with --standby-of, as standbys have no workers")
+	}
+
+	return nil
+}
+
 func (args DeployArgs) validateWebFields() error {
 	for _, size := range WebSizes {
 		if size == args.WebSize {
@@ -95,10 +817,462 @@ func (args DeployArgs) validateWebFields() error {
 	return fmt.Errorf("unknown web node size: `%s`. Valid sizes are: %v", args.WebSize, WebSizes)
 }
 
+func (args DeployArgs) validateDirectorFields() error {
+	if !args.DirectorSizeIsSet {
+		return nil
+	}
+	for _, size := range DirectorSizes {
+		if size == args.DirectorSize {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown director size: `%s`. Valid sizes are: %v", args.DirectorSize, DirectorSizes)
+}
+
 func (args DeployArgs) validateDBFields() error {
 	if _, ok := DBSizes[args.DBSize]; !ok {
 		return fmt.Errorf("unknown DB size: `%s`. Valid sizes are: %v", args.DBSize, DBSizes)
 	}
 
+	if args.DBEngineVersionIsSet && !dbEngineVersionPattern.MatchString(args.DBEngineVersion) {
+		return fmt.Errorf("--db-engine-version must be a Postgres version, eg %s", DefaultRDSEngineVersion)
+	}
+
+	if args.MaintenanceWindowIsSet && args.MaintenanceWindow != "" {
+		if _, err := ParseMaintenanceWindow(args.MaintenanceWindow); err != nil {
+			return err
+		}
+	}
+
+	if err := args.validateExternalDBFields(); err != nil {
+		return err
+	}
+
+	if args.DBMaxOpenConnections < 0 || args.DBMaxIdleConnections < 0 {
+		return errors.New("--db-max-open-connections and --db-max-idle-connections cannot be negative")
+	}
+
+	if args.DBMaxOpenConnections != 0 && args.DBMaxIdleConnections > args.DBMaxOpenConnections {
+		return errors.New("--db-max-idle-connections cannot be greater than --db-max-open-connections")
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateExternalDBFields() error {
+	if args.ExternalDBURL == "" {
+		if args.ExternalDBUsername != "" || args.ExternalDBPassword != "" || args.ExternalDBCACert != "" {
+			return errors.New("--external-db-username, --external-db-password and --external-db-ca-cert require --external-db-url to also be provided")
+		}
+		return nil
+	}
+
+	if args.StandbyOf != "" {
+		return errors.New("--external-db-url cannot be used with --standby-of, as a standby's database is a replica of its primary's RDS instance")
+	}
+
+	if _, err := ParseExternalDBURL(args.ExternalDBURL); err != nil {
+		return err
+	}
+
+	// A profile leaves the RDS settings alone with an external database, so these were given directly
+	if args.DBSizeIsSet || args.MultiAZRDSIsSet {
+		return errors.New("--db-size cannot be used with --external-db-url, as the server is sized outside concourse-up")
+	}
+
+	if args.ExternalDBUsername == "" || args.ExternalDBPassword == "" || args.ExternalDBCACert == "" {
+		return errors.New("--external-db-url requires --external-db-username, --external-db-password and --external-db-ca-cert to also be provided")
+	}
+
+	return validateCertificates("--external-db-ca-cert", args.ExternalDBCACert)
+}
+
+func (args DeployArgs) validateBrandingFields() error {
+	if args.BrandingWordmark != "" && !strings.Contains(args.BrandingWordmark, "<svg") {
+		return errors.New("--branding-wordmark must be the contents of an SVG image")
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateSecretCacheFields() error {
+	if !args.SecretCache && !args.SecretCacheTTLIsSet {
+		return nil
+	}
+
+	if args.SecretCacheTTL < MinSecretCacheTTL || args.SecretCacheTTL > MaxSecretCacheTTL {
+		return fmt.Errorf("--concourse-secret-cache-ttl must be between %s and %s", MinSecretCacheTTL, MaxSecretCacheTTL)
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateStandbyFields() error {
+	if args.StandbyOf == "" {
+		return nil
+	}
+
+	if args.StandbyOf == args.AWSRegion {
+		return errors.New("--standby-of must be a different region to --region")
+	}
+
+	if args.Promote {
+		return errors.New("--standby-of cannot be used when promoting a standby")
+	}
+
+	if args.SelfUpdate {
+		return errors.New("--standby-of cannot be used with --self-update")
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateKMSFields() error {
+	if args.KMSKeyID == "" {
+		return nil
+	}
+
+	if !kmsKeyPattern.MatchString(args.KMSKeyID) {
+		return fmt.Errorf("`%s` is not a KMS key ID, alias or ARN, eg alias/concourse-up", args.KMSKeyID)
+	}
+
+	if arn := strings.Split(args.KMSKeyID, ":"); len(arn) > 3 && arn[3] != args.AWSRegion {
+		return fmt.Errorf("--kms-key must be a key in %s, as S3 can only use keys in the config bucket's region", args.AWSRegion)
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateBackupFields() error {
+	if args.BackupRegion == "" {
+		return nil
+	}
+
+	if !regionPattern.MatchString(args.BackupRegion) {
+		return fmt.Errorf("--backup-region must be an AWS region, eg us-east-1, not `%s`", args.BackupRegion)
+	}
+
+	if args.BackupRegion == args.AWSRegion {
+		return errors.New("--backup-region must be a different region to --region")
+	}
+
+	if args.S3Endpoint != "" {
+		return errors.New("--backup-region cannot be used with --s3-endpoint, as replicating the config bucket to another region is an S3 feature")
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateTSAFields() error {
+	if args.TSAPort < 1 || args.TSAPort > 65535 {
+		return errors.New("--tsa-port must be between 1 and 65535")
+	}
+
+	for _, port := range webPorts {
+		if port == args.TSAPort {
+			return fmt.Errorf("--tsa-port cannot be %d as it is already in use on the web node", port)
+		}
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validatePortFields() error {
+	if !args.ExternalPortIsSet && !args.CredhubPortIsSet && !args.GrafanaPortIsSet {
+		return nil
+	}
+
+	// HAProxy serves Concourse and Grafana together on 443 when Grafana has a path
+	if args.GrafanaPath != "" && (args.ExternalPortIsSet || args.GrafanaPortIsSet) {
+		return errors.New("--external-port and --grafana-port cannot be used with --grafana-path")
+	}
+
+	// Ports that aren't given are checked at their defaults
+	port := func(port int, isSet bool, fallback int) int {
+		if isSet {
+			return port
+		}
+		return fallback
+	}
+	return checkWebPorts([]webPort{
+		{"--external-port", port(args.ExternalPort, args.ExternalPortIsSet, DefaultConcoursePort)},
+		{"--credhub-port", port(args.CredhubPort, args.CredhubPortIsSet, DefaultCredhubPort)},
+		{"--grafana-port", port(args.GrafanaPort, args.GrafanaPortIsSet, DefaultGrafanaPort)},
+		{"--tsa-port", args.TSAPort},
+	})
+}
+
+func (args DeployArgs) validateNetworkFields() error {
+	vpcCIDR := DefaultVPCCIDR
+	if args.VPCCIDR != "" {
+		vpcCIDR = args.VPCCIDR
+	}
+	network, err := ParseVPCCIDR(vpcCIDR)
+	if err != nil {
+		return err
+	}
+
+	if args.ContainerNetworkPool != "" {
+		if err := CheckContainerNetworkPool(args.ContainerNetworkPool, network); err != nil {
+			return err
+		}
+	}
+
+	if err := args.validateExistingSubnetFields(); err != nil {
+		return err
+	}
+
+	if args.ContainerNetworkMTU != 0 && (args.ContainerNetworkMTU < MinContainerNetworkMTU || args.ContainerNetworkMTU > MaxContainerNetworkMTU) {
+		return fmt.Errorf("--worker-container-network-mtu must be between %d and %d", MinContainerNetworkMTU, MaxContainerNetworkMTU)
+	}
+
+	if args.Private {
+		if args.Domain == "" {
+			return errors.New("--private requires a --domain in a private hosted zone, as Concourse has no public IP to be reached at")
+		}
+		// Let's Encrypt can't see the DNS-01 challenge records of a private hosted zone
+		if args.ACMEEnabledIsSet && args.ACMEEnabled {
+			return errors.New("--acme cannot be used with --private")
+		}
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateExistingSubnetFields() error {
+	if (len(args.ExistingPublicSubnetIDs) == 0) != (len(args.ExistingPrivateSubnetIDs) == 0) {
+		return errors.New("--public-subnet-id and --private-subnet-id must be given together")
+	}
+
+	for _, id := range append(args.ExistingPublicSubnetIDs, args.ExistingPrivateSubnetIDs...) {
+		if !strings.HasPrefix(id, "subnet-") {
+			return fmt.Errorf("`%s` is not a subnet ID, eg subnet-0a1b2c3d", id)
+		}
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateProxyFields() error {
+	proxies := []struct{ flag, value string }{
+		{"--http-proxy", args.Proxy.HTTPProxy},
+		{"--https-proxy", args.Proxy.HTTPSProxy},
+	}
+	for _, proxy := range proxies {
+		if proxy.value == "" {
+			continue
+		}
+		u, err := url.Parse(proxy.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s `%s` must be a URL, eg http://proxy.example.com:3128", proxy.flag, proxy.value)
+		}
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateNameServerFields() error {
+	// BOSH writes the DNS servers to each VM's resolv.conf, so they must be addresses rather than names
+	for _, server := range args.DNSServers {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("DNS server `%s` must be an IP address", server)
+		}
+	}
+
+	for _, server := range args.NTPServers {
+		if strings.ContainsAny(server, " \t\n,") {
+			return fmt.Errorf("NTP server `%s` cannot contain whitespace or commas", server)
+		}
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateInstanceProfileFields() error {
+	profiles := []string{args.DirectorInstanceProfile, args.WebInstanceProfile, args.WorkerInstanceProfile}
+	if args.DirectorInstanceProfile == "" && args.WebInstanceProfile == "" && args.WorkerInstanceProfile == "" {
+		return nil
+	}
+
+	for _, profile := range profiles {
+		if profile == "" {
+			return errors.New("--director-instance-profile, --web-instance-profile and --worker-instance-profile must all be provided together")
+		}
+		if !strings.HasPrefix(profile, "arn:") || !strings.Contains(profile, ":instance-profile/") {
+			return fmt.Errorf("`%s` is not an instance profile ARN", profile)
+		}
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateTLSFields() error {
+	if args.TLSMinVersionIsSet && !contains(TLSVersions, args.TLSMinVersion) {
+		return fmt.Errorf("unknown TLS version: `%s`. Valid versions are: %v", args.TLSMinVersion, TLSVersions)
+	}
+
+	// TLS 1.3 cipher suites aren't configurable
+	if args.TLSMinVersion == "1.3" && len(args.TLSCipherSuites) > 0 {
+		return errors.New("--concourse-tls-cipher-suite cannot be used with a --concourse-tls-min-version of 1.3")
+	}
+
+	for _, suite := range args.TLSCipherSuites {
+		if !contains(TLSCipherSuites(), suite) {
+			return fmt.Errorf("unknown or insecure TLS cipher suite: `%s`. Valid cipher suites are: %v", suite, TLSCipherSuites())
+		}
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateGrafanaFields() error {
+	if args.GrafanaPath == "" {
+		return nil
+	}
+
+	if !grafanaPathPattern.MatchString(args.GrafanaPath) {
+		return fmt.Errorf("--grafana-path must be a single path segment of lowercase letters, digits and dashes, eg /grafana, not `%s`", args.GrafanaPath)
+	}
+
+	if contains(atcPaths, args.GrafanaPath) {
+		return fmt.Errorf("--grafana-path cannot be `%s` as Concourse already serves that path", args.GrafanaPath)
+	}
+
+	// HAProxy fronts the ATC when Grafana is served under a path, and it doesn't share Go's cipher suite names
+	if len(args.TLSCipherSuites) > 0 {
+		return errors.New("--concourse-tls-cipher-suite cannot be used with --grafana-path")
+	}
+
+	if args.TLSMinVersion == "1.3" {
+		return errors.New("--grafana-path cannot be used with a --concourse-tls-min-version of 1.3")
+	}
+
+	return nil
+}
+
+func (args DeployArgs) validateWorkerRegistryCACerts() error {
+	return validateCertificates("--worker-registry-ca-cert", args.WorkerRegistryCACerts)
+}
+
+func (args DeployArgs) validateSyslogFields() error {
+	if args.SyslogAddress == "" {
+		if args.SyslogTLS || args.SyslogCACert != "" {
+			return errors.New("--syslog-tls and --syslog-ca-cert require --syslog-address to also be provided")
+		}
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(args.SyslogAddress)
+	if err != nil || host == "" {
+		return fmt.Errorf("--syslog-address must be a host and port, eg logs.example.com:514, not `%s`", args.SyslogAddress)
+	}
+	if portNumber, err := strconv.Atoi(port); err != nil || portNumber < 1 || portNumber > 65535 {
+		return fmt.Errorf("--syslog-address port must be between 1 and 65535, not `%s`", port)
+	}
+
+	if !contains(SyslogTransports, args.SyslogTransport) {
+		return fmt.Errorf("unknown syslog transport: `%s`. Valid transports are: %v", args.SyslogTransport, SyslogTransports)
+	}
+	if args.SyslogTLS && args.SyslogTransport != "tcp" {
+		return errors.New("--syslog-tls can only be used with the tcp transport")
+	}
+	if args.SyslogCACert != "" && !args.SyslogTLS {
+		return errors.New("--syslog-ca-cert requires --syslog-tls to also be provided")
+	}
+
+	return validateCertificates("--syslog-ca-cert", args.SyslogCACert)
+}
+
+func (args DeployArgs) validatePrometheusFields() error {
+	if args.PrometheusURL == "" {
+		if args.PrometheusUsername != "" || args.PrometheusPassword != "" {
+			return errors.New("--prometheus-username and --prometheus-password require --prometheus-url to also be provided")
+		}
+		return nil
+	}
+
+	u, err := url.Parse(args.PrometheusURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("--prometheus-url must be an http or https URL, eg https://prometheus.example.com/api/v1/write, not `%s`", args.PrometheusURL)
+	}
+	if u.User != nil {
+		return errors.New("--prometheus-url cannot contain credentials. Use --prometheus-username and --prometheus-password")
+	}
+	if (args.PrometheusUsername == "") != (args.PrometheusPassword == "") {
+		return errors.New("--prometheus-username and --prometheus-password must be provided together")
+	}
+
+	return nil
+}
+
+// validateCertificates checks that certs, given with flag, is empty or a series of PEM encoded certificates
+func validateCertificates(flag, certs string) error {
+	rest := []byte(certs)
+	for len(bytes.TrimSpace(rest)) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return fmt.Errorf("%s must be one or more PEM encoded certificates", flag)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("%s contains an invalid certificate: %s", flag, err)
+		}
+	}
+
+	return nil
+}
+
+// TLSCipherSuites are the permitted cipher suites for the ATC. Go's insecure cipher suites are excluded
+func TLSCipherSuites() []string {
+	var suites []string
+	for _, suite := range tls.CipherSuites() {
+		for _, version := range suite.SupportedVersions {
+			if version == tls.VersionTLS12 {
+				suites = append(suites, suite.Name)
+				break
+			}
+		}
+	}
+	return suites
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validateWatchTime(flag, watchTime string) error {
+	if watchTime == "" {
+		return nil
+	}
+
+	matches := watchTimePattern.FindStringSubmatch(watchTime)
+	if matches == nil {
+		return fmt.Errorf("%s must be a number of milliseconds or a range such as 1000-300000, not `%s`", flag, watchTime)
+	}
+	if matches[2] != "" {
+		min, _ := strconv.Atoi(matches[1])
+		max, _ := strconv.Atoi(matches[2])
+		if min > max {
+			return fmt.Errorf("%s range `%s` must not end before it starts", flag, watchTime)
+		}
+	}
+
+	return nil
+}
+
+// CheckContainerNetworkPool fails if pool isn't a CIDR range or overlaps the VPC
+func CheckContainerNetworkPool(pool string, network *Network) error {
+	_, poolNet, err := net.ParseCIDR(pool)
+	if err != nil {
+		return fmt.Errorf("--worker-container-network-pool must be a CIDR range: %s", err)
+	}
+	if network.Overlaps(poolNet) {
+		return fmt.Errorf("--worker-container-network-pool cannot overlap the VPC's %s range", network.VPCCIDR())
+	}
 	return nil
 }
@@ -4,4 +4,6 @@ package config
 type DestroyArgs struct {
 	AWSRegion string
 	IAAS      string
+	// DryRun is true if what would be destroyed should be listed without deleting anything
+	DryRun bool
 }
@@ -0,0 +1,26 @@
+package config
+
+import "errors"
+
+// DrainArgs are arguments passed to the drain command
+type DrainArgs struct {
+	AWSRegion string
+	IAAS      string
+	// Timeout is how many minutes to wait for running builds to finish
+	Timeout int
+}
+
+// Validate validates that flag interdependencies
+func (args DrainArgs) Validate() error {
+	if args.Timeout <= 0 {
+		return errors.New("--timeout must be a positive number of minutes")
+	}
+
+	return nil
+}
+
+// ResumeArgs are arguments passed to the resume command
+type ResumeArgs struct {
+	AWSRegion string
+	IAAS      string
+}
@@ -0,0 +1,8 @@
+package config
+
+// EventsArgs are arguments passed to the events command
+type EventsArgs struct {
+	AWSRegion string
+	JSON      bool
+	IAAS      string
+}
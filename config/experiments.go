@@ -0,0 +1,60 @@
+package config
+
+// Experiment is an opt-in ATC feature, which is only available from a given version of Concourse
+type Experiment struct {
+	// Flag is the deploy flag that enables the experiment
+	Flag string
+	// Property is the ATC job property that enables the experiment
+	Property string
+	// MinConcourseVersion is the first Concourse release with the experiment
+	MinConcourseVersion string
+	// MaxConcourseVersion, if set, is the first Concourse release without the experiment,
+	// which is when it became the default and its property was removed
+	MaxConcourseVersion string
+	Usage               string
+}
+
+// Experiments are the ATC experiments that can be enabled on deploy
+var Experiments = []Experiment{
+	{
+		Flag:                "concourse-enable-across-step",
+		Property:            "enable_across_step",
+		MinConcourseVersion: "6.5.0",
+		Usage:               "(optional) Enable the experimental across step, which runs a step for each combination of a set of values",
+	},
+	{
+		Flag:                "concourse-enable-redact-secrets",
+		Property:            "enable_redact_secrets",
+		MinConcourseVersion: "6.4.0",
+		Usage:               "(optional) Redact credential manager secrets from build logs",
+	},
+	{
+		Flag:                "concourse-enable-pipeline-instances",
+		Property:            "enable_pipeline_instances",
+		MinConcourseVersion: "7.0.0",
+		Usage:               "(optional) Enable the experimental instanced pipelines, which group pipelines made from the same config",
+	},
+	{
+		Flag:                "concourse-enable-build-rerun",
+		Property:            "enable_rerun_when_worker_disappears",
+		MinConcourseVersion: "6.4.0",
+		Usage:               "(optional) Rerun builds whose worker disappeared while they were running, instead of erroring them",
+	},
+	{
+		Flag:                "concourse-enable-lidar",
+		Property:            "enable_lidar",
+		MinConcourseVersion: "5.7.0",
+		MaxConcourseVersion: "6.0.0",
+		Usage:               "(optional) Check resources with the experimental lidar checker, which queues checks so they can be run on demand. Lidar is always on from Concourse 6.0.0",
+	},
+}
+
+// FindExperiment returns the experiment enabled by the given ATC property
+func FindExperiment(property string) (Experiment, bool) {
+	for _, experiment := range Experiments {
+		if experiment.Property == property {
+			return experiment, true
+		}
+	}
+	return Experiment{}, false
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CredsFormats are the formats the export-creds command can write credentials in
+var CredsFormats = []string{"yaml", "json"}
+
+// ExportCredsArgs are arguments passed to the export-creds command
+type ExportCredsArgs struct {
+	AWSRegion  string
+	IAAS       string
+	Format     string
+	File       string
+	Passphrase string
+}
+
+// Validate validates that flag interdependencies
+func (args ExportCredsArgs) Validate() error {
+	if args.Format != CredsFormats[0] && args.Format != CredsFormats[1] {
+		return fmt.Errorf("unknown format: `%s`. Valid formats are: %v", args.Format, CredsFormats)
+	}
+
+	// Credentials are only written to disk encrypted
+	if args.File != "" && len(args.Passphrase) < MinBundlePassphraseLength {
+		return fmt.Errorf("the passphrase must be at least %d characters to write credentials to --file. Set it with EXPORT_CREDS_PASSPHRASE or --passphrase-file", MinBundlePassphraseLength)
+	}
+
+	if args.File == "" && args.Passphrase != "" {
+		return errors.New("--passphrase can only be used with --file")
+	}
+
+	return nil
+}
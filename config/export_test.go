@@ -1,3 +1,21 @@
 package config
 
+import (
+	"time"
+
+	"github.com/EngineerBetter/concourse-up/iaas"
+)
+
 var ParseCIDRBlocks = parseCIDRBlocks
+
+func (args DeployArgs) ValidatePortFields() error {
+	return args.validatePortFields()
+}
+
+func SetNow(f func() time.Time) {
+	now = f
+}
+
+func SetNewBackupClient(f func(iaas, region string) (iaas.IClient, error)) {
+	newBackupClient = f
+}
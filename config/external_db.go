@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultExternalDBPort and DefaultExternalDBName are used when the external database's URL doesn't give them
+const (
+	DefaultExternalDBPort = "5432"
+	DefaultExternalDBName = "bosh"
+)
+
+// ExternalDB is a Postgres server outside the deployment, which is used instead of an RDS instance
+type ExternalDB struct {
+	Host string
+	Port string
+	// Name is the database the BOSH director keeps its state in, which must already exist. The databases of
+	// Concourse, UAA and Credhub are created alongside it
+	Name string
+}
+
+// ParseExternalDBURL parses the URL of an external Postgres server, eg postgres://db.example.com:5432/bosh.
+// The port defaults to 5432 and the database to bosh. Credentials are given separately, so that
+// the URL can be stored and shown without them
+func ParseExternalDBURL(rawURL string) (*ExternalDB, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") || u.Hostname() == "" {
+		return nil, fmt.Errorf("external database URL `%s` must be a Postgres URL, eg postgres://db.example.com:5432/bosh", rawURL)
+	}
+	if u.User != nil {
+		return nil, errors.New("external database URL cannot contain credentials. Use --external-db-username and --external-db-password")
+	}
+	if u.RawQuery != "" {
+		return nil, fmt.Errorf("external database URL `%s` cannot have parameters, as concourse-up always connects over TLS", rawURL)
+	}
+
+	db := &ExternalDB{
+		Host: u.Hostname(),
+		Port: u.Port(),
+		Name: strings.TrimPrefix(u.Path, "/"),
+	}
+	if db.Port == "" {
+		db.Port = DefaultExternalDBPort
+	}
+	if port, err := strconv.Atoi(db.Port); err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("external database URL `%s` has an invalid port. Ports must be between 1 and 65535", rawURL)
+	}
+	if db.Name == "" {
+		db.Name = DefaultExternalDBName
+	}
+	if strings.Contains(db.Name, "/") {
+		return nil, fmt.Errorf("external database URL `%s` must name a single database", rawURL)
+	}
+
+	return db, nil
+}
+
+// Address returns the host and port of the external database
+func (db *ExternalDB) Address() string {
+	return net.JoinHostPort(db.Host, db.Port)
+}
@@ -6,4 +6,6 @@ type InfoArgs struct {
 	JSON      bool
 	IAAS      string
 	Env       bool
+	// Versions is true if only the versions the deployment is running should be output
+	Versions bool
 }
@@ -0,0 +1,62 @@
+package config
+
+// Tenancies are the permitted EC2 instance tenancies
+var Tenancies = []string{"default", "dedicated"}
+
+// DefaultDirectorSize is the size of the BOSH director unless another is chosen
+const DefaultDirectorSize = "small"
+
+// DirectorSizes are the permitted BOSH director sizes
+var DirectorSizes = []string{"small", "medium", "large", "xlarge"}
+
+// DirectorInstanceTypes maps director sizes to EC2 instance types
+var DirectorInstanceTypes = map[string]string{
+	"small":  "t2.small",
+	"medium": "t2.medium",
+	"large":  "t2.large",
+	"xlarge": "m4.xlarge",
+}
+
+// CompilationInstanceType is the EC2 instance type BOSH compiles packages on
+const CompilationInstanceType = "m4.large"
+
+// WebInstanceTypes maps web sizes to EC2 instance types
+var WebInstanceTypes = map[string]string{
+	"small":   "t2.small",
+	"medium":  "t2.medium",
+	"large":   "t2.large",
+	"xlarge":  "t2.xlarge",
+	"2xlarge": "t2.2xlarge",
+}
+
+// WorkerInstanceTypes maps worker sizes to EC2 instance types
+var WorkerInstanceTypes = map[string]string{
+	"medium":   "t2.medium",
+	"large":    "m4.large",
+	"xlarge":   "m4.xlarge",
+	"2xlarge":  "m4.2xlarge",
+	"4xlarge":  "m4.4xlarge",
+	"10xlarge": "m4.10xlarge",
+	"16xlarge": "m4.16xlarge",
+}
+
+// dedicatedInstanceTypes maps the burstable instance types, which can't be run with
+// dedicated tenancy, to the smallest general purpose type with at least as much memory
+var dedicatedInstanceTypes = map[string]string{
+	"t2.small":   "m4.large",
+	"t2.medium":  "m4.large",
+	"t2.large":   "m4.large",
+	"t2.xlarge":  "m4.xlarge",
+	"t2.2xlarge": "m4.2xlarge",
+}
+
+// InstanceType returns the EC2 instance type to use in place of instanceType with the given tenancy
+func InstanceType(instanceType, tenancy string) string {
+	if tenancy != "dedicated" {
+		return instanceType
+	}
+	if dedicatedType, ok := dedicatedInstanceTypes[instanceType]; ok {
+		return dedicatedType
+	}
+	return instanceType
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+// weekdays are the days RDS maintenance windows are given in, indexed by time.Weekday
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// maintenanceWindowPattern matches an RDS maintenance window, eg sun:03:00-sun:04:00
+var maintenanceWindowPattern = regexp.MustCompile(`^([a-z]{3}):(\d\d):(\d\d)-([a-z]{3}):(\d\d):(\d\d)$`)
+
+// MaintenanceWindow is a weekly window in UTC. It may wrap around from Saturday to Sunday
+type MaintenanceWindow struct {
+	// start and end are minutes since the start of Sunday
+	start, end int
+}
+
+// ParseMaintenanceWindow parses a window in the RDS format ddd:hh:mm-ddd:hh:mm, eg sun:03:00-sun:04:00.
+// Like RDS, it requires the window to be at least 30 minutes long
+func ParseMaintenanceWindow(window string) (*MaintenanceWindow, error) {
+	parts := maintenanceWindowPattern.FindStringSubmatch(strings.ToLower(window))
+	if parts == nil {
+		return nil, fmt.Errorf("maintenance window `%s` must be in the form ddd:hh:mm-ddd:hh:mm, eg sun:03:00-sun:04:00", window)
+	}
+
+	start, err := minuteOfWeek(parts[1], parts[2], parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window `%s` is invalid: %s", window, err)
+	}
+	end, err := minuteOfWeek(parts[4], parts[5], parts[6])
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window `%s` is invalid: %s", window, err)
+	}
+
+	parsed := &MaintenanceWindow{start: start, end: end}
+	if parsed.length() < 30 {
+		return nil, fmt.Errorf("maintenance window `%s` must be at least 30 minutes long", window)
+	}
+
+	return parsed, nil
+}
+
+func minuteOfWeek(day, hour, minute string) (int, error) {
+	dayIndex := -1
+	for i, weekday := range weekdays {
+		if weekday == day {
+			dayIndex = i
+		}
+	}
+	if dayIndex == -1 {
+		return 0, fmt.Errorf("unknown day `%s`. Valid days are: %v", day, weekdays)
+	}
+
+	hours, _ := strconv.Atoi(hour)
+	minutes, _ := strconv.Atoi(minute)
+	if hours > 23 || minutes > 59 {
+		return 0, fmt.Errorf("%s:%s is not a time of day", hour, minute)
+	}
+
+	return dayIndex*minutesPerDay + hours*60 + minutes, nil
+}
+
+func (w *MaintenanceWindow) length() int {
+	return (w.end - w.start + minutesPerWeek) % minutesPerWeek
+}
+
+// Contains returns true if t falls within the window
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	minute := int(t.Weekday())*minutesPerDay + t.Hour()*60 + t.Minute()
+	return (minute-w.start+minutesPerWeek)%minutesPerWeek < w.length()
+}
+
+// String returns the window in the lowercase form RDS reports it in
+func (w *MaintenanceWindow) String() string {
+	return fmt.Sprintf("%s-%s", formatMinuteOfWeek(w.start), formatMinuteOfWeek(w.end))
+}
+
+// BackupWindow returns the daily RDS backup window, which is the half hour before the
+// maintenance window starts as RDS doesn't allow the two to overlap
+func (w *MaintenanceWindow) BackupWindow() string {
+	start := (w.start - 30 + minutesPerDay) % minutesPerDay
+	end := w.start % minutesPerDay
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", start/60, start%60, end/60, end%60)
+}
+
+func formatMinuteOfWeek(minute int) string {
+	day := minute / minutesPerDay
+	minute = minute % minutesPerDay
+	return fmt.Sprintf("%s:%02d:%02d", weekdays[day], minute/60, minute%60)
+}
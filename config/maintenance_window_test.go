@@ -0,0 +1,62 @@
+package config_test
+
+import (
+	"time"
+
+	. "github.com/EngineerBetter/concourse-up/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseMaintenanceWindow", func() {
+	It("Parses a window in the RDS format", func() {
+		window, err := ParseMaintenanceWindow("Sun:03:00-Sun:04:00")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(window.String()).To(Equal("sun:03:00-sun:04:00"))
+		Expect(window.BackupWindow()).To(Equal("02:30-03:00"))
+	})
+
+	It("Knows whether a time is inside the window", func() {
+		window, err := ParseMaintenanceWindow("sun:03:00-sun:04:00")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(window.Contains(time.Date(2018, 6, 17, 3, 30, 0, 0, time.UTC))).To(BeTrue())
+		Expect(window.Contains(time.Date(2018, 6, 17, 4, 0, 0, 0, time.UTC))).To(BeFalse())
+		Expect(window.Contains(time.Date(2018, 6, 18, 3, 30, 0, 0, time.UTC))).To(BeFalse())
+	})
+
+	It("Handles windows that wrap around the end of the week", func() {
+		window, err := ParseMaintenanceWindow("sat:23:00-sun:00:15")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(window.Contains(time.Date(2018, 6, 16, 23, 30, 0, 0, time.UTC))).To(BeTrue())
+		Expect(window.Contains(time.Date(2018, 6, 17, 0, 10, 0, 0, time.UTC))).To(BeTrue())
+		Expect(window.Contains(time.Date(2018, 6, 17, 0, 15, 0, 0, time.UTC))).To(BeFalse())
+		Expect(window.BackupWindow()).To(Equal("22:30-23:00"))
+	})
+
+	It("Compares times in UTC", func() {
+		window, err := ParseMaintenanceWindow("sun:03:00-sun:04:00")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(window.Contains(time.Date(2018, 6, 17, 5, 30, 0, 0, time.FixedZone("CEST", 2*60*60)))).To(BeTrue())
+	})
+
+	It("Rejects windows in the wrong format", func() {
+		_, err := ParseMaintenanceWindow("sunday 3am")
+		Expect(err).To(MatchError("maintenance window `sunday 3am` must be in the form ddd:hh:mm-ddd:hh:mm, eg sun:03:00-sun:04:00"))
+	})
+
+	It("Rejects unknown days and times", func() {
+		_, err := ParseMaintenanceWindow("sun:03:00-fun:04:00")
+		Expect(err).To(MatchError(ContainSubstring("unknown day `fun`")))
+
+		_, err = ParseMaintenanceWindow("sun:25:00-mon:04:00")
+		Expect(err).To(MatchError("maintenance window `sun:25:00-mon:04:00` is invalid: 25:00 is not a time of day"))
+	})
+
+	It("Rejects windows shorter than 30 minutes", func() {
+		_, err := ParseMaintenanceWindow("sun:03:00-sun:03:20")
+		Expect(err).To(MatchError("maintenance window `sun:03:00-sun:03:20` must be at least 30 minutes long"))
+	})
+})
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// DefaultVPCCIDR is the address range of the VPC unless --vpc-cidr is given
+const DefaultVPCCIDR = "10.0.0.0/16"
+
+// MinVPCPrefix and MaxVPCPrefix bound the size of the VPC. AWS doesn't allow VPCs bigger
+// than a /16, and concourse-up needs at least a /24 to fit its subnets
+const (
+	MinVPCPrefix = 16
+	MaxVPCPrefix = 24
+)
+
+// Subnets are given an index within the VPC, leaving 3 unused for the layout they've always had
+const (
+	publicSubnetIndex  = 0
+	privateSubnetIndex = 1
+	workersSubnetIndex = 2
+	rdsASubnetIndex    = 4
+	rdsBSubnetIndex    = 5
+)
+
+// Network lays out the subnets and static IPs of a deployment within its VPC's address range.
+// The VPC is split into /24 subnets, or into 8 equal subnets when it's smaller than a /21,
+// so that the default 10.0.0.0/16 has the subnets 10.0.0.0/24, 10.0.1.0/24 and so on
+type Network struct {
+	vpc          *net.IPNet
+	subnetPrefix int
+
+	// existing maps the indexes of subnets that weren't created by concourse-up to their ranges
+	existing map[int]*net.IPNet
+}
+
+// ExistingNetwork is an existing VPC's subnets that a deployment was put in, given with --public-subnet-id
+// and --private-subnet-id, instead of a VPC and subnets created by Terraform
+type ExistingNetwork struct {
+	VPCID             string `json:"vpc_id"`
+	PublicSubnetID    string `json:"public_subnet_id"`
+	PublicSubnetCIDR  string `json:"public_subnet_cidr"`
+	PrivateSubnetID   string `json:"private_subnet_id"`
+	PrivateSubnetCIDR string `json:"private_subnet_cidr"`
+	// DBSubnetIDs are the private subnets the RDS instance's subnet group spans, which must
+	// be in at least two availability zones, and DBAvailabilityZones are their zones
+	DBSubnetIDs         []string `json:"db_subnet_ids"`
+	DBAvailabilityZones []string `json:"db_availability_zones"`
+}
+
+// Subnet is a subnet of the VPC, with the addresses BOSH needs to know about
+type Subnet struct {
+	CIDR    string
+	Gateway string
+	// Reserved are the addresses at the start of the subnet that BOSH mustn't give to VMs.
+	// AWS reserves the first four, and the fifth has always been kept back too
+	Reserved string
+}
+
+// ParseAllowedCIDRs parses the IPv4 ranges given with --allow-cidr, returning each as its network address, eg
+// 203.0.113.0/24 for 203.0.113.7/24, as AWS stores them. Empty ranges are skipped, so that an empty
+// --allow-cidr can be passed to remove the existing ranges
+func ParseAllowedCIDRs(cidrs []string) ([]string, error) {
+	allowed := []string{}
+	for _, cidr := range cidrs {
+		if cidr == "" {
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil || ipNet.IP.To4() == nil {
+			return nil, fmt.Errorf("--allow-cidr must be an IPv4 CIDR range, eg 203.0.113.0/24, not `%s`", cidr)
+		}
+		allowed = append(allowed, ipNet.String())
+	}
+	return allowed, nil
+}
+
+// ParseVPCCIDR parses the address range of the VPC, which must be an IPv4 network of between a /16 and a /24
+func ParseVPCCIDR(cidr string) (*Network, error) {
+	ip, vpc, err := net.ParseCIDR(cidr)
+	if err != nil || ip.To4() == nil {
+		return nil, fmt.Errorf("VPC CIDR `%s` must be an IPv4 range, eg %s", cidr, DefaultVPCCIDR)
+	}
+	if !ip.Equal(vpc.IP) {
+		return nil, fmt.Errorf("VPC CIDR `%s` must be the start of its range, eg %s", cidr, vpc)
+	}
+
+	prefix, _ := vpc.Mask.Size()
+	if prefix < MinVPCPrefix || prefix > MaxVPCPrefix {
+		return nil, fmt.Errorf("VPC CIDR `%s` must be between a /%d and a /%d", cidr, MinVPCPrefix, MaxVPCPrefix)
+	}
+
+	subnetPrefix := prefix + 3
+	if subnetPrefix < 24 {
+		subnetPrefix = 24
+	}
+
+	return &Network{vpc: vpc, subnetPrefix: subnetPrefix}, nil
+}
+
+// ParseExistingNetwork returns the layout of a deployment in an existing VPC's subnets. The VPC may be
+// any size AWS allows, but the public subnet must have room for the static IPs of the director and web node
+func ParseExistingNetwork(vpcCIDR, publicCIDR, privateCIDR string) (*Network, error) {
+	_, vpc, err := net.ParseCIDR(vpcCIDR)
+	if err != nil || vpc.IP.To4() == nil {
+		return nil, fmt.Errorf("VPC CIDR `%s` must be an IPv4 range", vpcCIDR)
+	}
+
+	network := &Network{vpc: vpc, existing: map[int]*net.IPNet{}}
+	subnets := []struct {
+		index int
+		cidr  string
+	}{
+		{publicSubnetIndex, publicCIDR},
+		{privateSubnetIndex, privateCIDR},
+	}
+	for _, subnet := range subnets {
+		_, ipNet, err := net.ParseCIDR(subnet.cidr)
+		if err != nil || ipNet.IP.To4() == nil || !vpc.Contains(ipNet.IP) {
+			return nil, fmt.Errorf("subnet CIDR `%s` must be an IPv4 range within the VPC's %s", subnet.cidr, vpc)
+		}
+		network.existing[subnet.index] = ipNet
+	}
+
+	// The web node's static IP is the eighth address of the public subnet
+	if prefix, _ := network.existing[publicSubnetIndex].Mask.Size(); prefix > 28 {
+		return nil, fmt.Errorf("public subnet `%s` must be at least a /28 to fit the director and web node", publicCIDR)
+	}
+
+	return network, nil
+}
+
+// Overlaps returns true if the range cidr shares any addresses with the VPC
+func (n *Network) Overlaps(cidr *net.IPNet) bool {
+	return n.vpc.Contains(cidr.IP) || cidr.Contains(n.vpc.IP)
+}
+
+// VPCCIDR returns the address range of the VPC
+func (n *Network) VPCCIDR() string {
+	return n.vpc.String()
+}
+
+// DNS returns the address of the VPC's DNS server, which AWS puts at the base of the VPC plus two
+func (n *Network) DNS() string {
+	return n.address(n.base() + 2)
+}
+
+// PublicSubnet returns the subnet of the director and web node
+func (n *Network) PublicSubnet() Subnet {
+	return n.subnet(publicSubnetIndex)
+}
+
+// PrivateSubnet returns the subnet of the workers, unless they're isolated, and of compilation VMs
+func (n *Network) PrivateSubnet() Subnet {
+	return n.subnet(privateSubnetIndex)
+}
+
+// WorkersSubnet returns the subnet of isolated workers
+func (n *Network) WorkersSubnet() Subnet {
+	return n.subnet(workersSubnetIndex)
+}
+
+// RDSSubnetA returns the RDS instance's subnet in the region's first availability zone
+func (n *Network) RDSSubnetA() Subnet {
+	return n.subnet(rdsASubnetIndex)
+}
+
+// RDSSubnetB returns the RDS instance's subnet in the region's second availability zone
+func (n *Network) RDSSubnetB() Subnet {
+	return n.subnet(rdsBSubnetIndex)
+}
+
+// DirectorIP returns the static internal IP of the BOSH director
+func (n *Network) DirectorIP() string {
+	return n.address(n.subnetBase(publicSubnetIndex) + 6)
+}
+
+// WebIP returns the static internal IP of the Concourse web node
+func (n *Network) WebIP() string {
+	return n.address(n.subnetBase(publicSubnetIndex) + 7)
+}
+
+func (n *Network) subnet(index int) Subnet {
+	base := n.subnetBase(index)
+	return Subnet{
+		CIDR:     fmt.Sprintf("%s/%d", n.address(base), n.prefix(index)),
+		Gateway:  n.address(base + 1),
+		Reserved: fmt.Sprintf("%s-%s", n.address(base+1), n.address(base+5)),
+	}
+}
+
+func (n *Network) base() uint32 {
+	return binary.BigEndian.Uint32(n.vpc.IP.To4())
+}
+
+func (n *Network) subnetBase(index int) uint32 {
+	if existing, ok := n.existing[index]; ok {
+		return binary.BigEndian.Uint32(existing.IP.To4())
+	}
+	return n.base() + uint32(index)<<uint(32-n.subnetPrefix)
+}
+
+func (n *Network) prefix(index int) int {
+	if existing, ok := n.existing[index]; ok {
+		prefix, _ := existing.Mask.Size()
+		return prefix
+	}
+	return n.subnetPrefix
+}
+
+func (n *Network) address(address uint32) string {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, address)
+	return ip.String()
+}
@@ -0,0 +1,111 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/concourse-up/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseVPCCIDR", func() {
+	It("Lays out the default range as it always has been", func() {
+		network, err := ParseVPCCIDR(DefaultVPCCIDR)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(network.VPCCIDR()).To(Equal("10.0.0.0/16"))
+		Expect(network.DNS()).To(Equal("10.0.0.2"))
+		Expect(network.PublicSubnet()).To(Equal(Subnet{CIDR: "10.0.0.0/24", Gateway: "10.0.0.1", Reserved: "10.0.0.1-10.0.0.5"}))
+		Expect(network.PrivateSubnet().CIDR).To(Equal("10.0.1.0/24"))
+		Expect(network.WorkersSubnet().CIDR).To(Equal("10.0.2.0/24"))
+		Expect(network.RDSSubnetA().CIDR).To(Equal("10.0.4.0/24"))
+		Expect(network.RDSSubnetB().CIDR).To(Equal("10.0.5.0/24"))
+		Expect(network.DirectorIP()).To(Equal("10.0.0.6"))
+		Expect(network.WebIP()).To(Equal("10.0.0.7"))
+	})
+
+	It("Lays out other ranges the same way", func() {
+		network, err := ParseVPCCIDR("172.16.32.0/20")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(network.DNS()).To(Equal("172.16.32.2"))
+		Expect(network.PublicSubnet().CIDR).To(Equal("172.16.32.0/24"))
+		Expect(network.RDSSubnetB().CIDR).To(Equal("172.16.37.0/24"))
+		Expect(network.DirectorIP()).To(Equal("172.16.32.6"))
+	})
+
+	It("Splits small ranges into 8 subnets", func() {
+		network, err := ParseVPCCIDR("192.168.10.0/24")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(network.PublicSubnet()).To(Equal(Subnet{CIDR: "192.168.10.0/27", Gateway: "192.168.10.1", Reserved: "192.168.10.1-192.168.10.5"}))
+		Expect(network.PrivateSubnet().CIDR).To(Equal("192.168.10.32/27"))
+		Expect(network.RDSSubnetB().CIDR).To(Equal("192.168.10.160/27"))
+		Expect(network.WebIP()).To(Equal("192.168.10.7"))
+	})
+
+	It("Rejects ranges that aren't between a /16 and a /24", func() {
+		_, err := ParseVPCCIDR("10.0.0.0/8")
+		Expect(err).To(MatchError("VPC CIDR `10.0.0.0/8` must be between a /16 and a /24"))
+
+		_, err = ParseVPCCIDR("10.0.0.0/25")
+		Expect(err).To(MatchError("VPC CIDR `10.0.0.0/25` must be between a /16 and a /24"))
+	})
+
+	It("Rejects addresses that aren't the start of their range", func() {
+		_, err := ParseVPCCIDR("10.0.0.1/16")
+		Expect(err).To(MatchError("VPC CIDR `10.0.0.1/16` must be the start of its range, eg 10.0.0.0/16"))
+	})
+
+	It("Rejects things that aren't IPv4 ranges", func() {
+		_, err := ParseVPCCIDR("fd00::/56")
+		Expect(err).To(MatchError(ContainSubstring("must be an IPv4 range")))
+
+		_, err = ParseVPCCIDR("not a range")
+		Expect(err).To(MatchError(ContainSubstring("must be an IPv4 range")))
+	})
+})
+
+var _ = Describe("ParseExistingNetwork", func() {
+	It("Uses the existing subnets, and puts the static IPs in the public one", func() {
+		network, err := ParseExistingNetwork("172.31.0.0/16", "172.31.32.0/20", "172.31.128.0/20")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(network.VPCCIDR()).To(Equal("172.31.0.0/16"))
+		Expect(network.DNS()).To(Equal("172.31.0.2"))
+		Expect(network.PublicSubnet()).To(Equal(Subnet{CIDR: "172.31.32.0/20", Gateway: "172.31.32.1", Reserved: "172.31.32.1-172.31.32.5"}))
+		Expect(network.PrivateSubnet().CIDR).To(Equal("172.31.128.0/20"))
+		Expect(network.DirectorIP()).To(Equal("172.31.32.6"))
+		Expect(network.WebIP()).To(Equal("172.31.32.7"))
+	})
+
+	It("Rejects subnets outside the VPC", func() {
+		_, err := ParseExistingNetwork("172.31.0.0/16", "10.0.0.0/24", "172.31.128.0/20")
+		Expect(err).To(MatchError("subnet CIDR `10.0.0.0/24` must be an IPv4 range within the VPC's 172.31.0.0/16"))
+	})
+
+	It("Rejects public subnets too small for the director and web node", func() {
+		_, err := ParseExistingNetwork("10.0.0.0/16", "10.0.0.0/29", "10.0.1.0/24")
+		Expect(err).To(MatchError("public subnet `10.0.0.0/29` must be at least a /28 to fit the director and web node"))
+	})
+})
+
+var _ = Describe("ParseAllowedCIDRs", func() {
+	It("Returns each range as its network address", func() {
+		cidrs, err := ParseAllowedCIDRs([]string{"203.0.113.7/24", "198.51.100.10/32"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cidrs).To(Equal([]string{"203.0.113.0/24", "198.51.100.10/32"}))
+	})
+
+	It("Skips empty ranges, so ranges can be removed", func() {
+		cidrs, err := ParseAllowedCIDRs([]string{""})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cidrs).To(BeEmpty())
+	})
+
+	It("Rejects addresses without a prefix, and IPv6 ranges", func() {
+		_, err := ParseAllowedCIDRs([]string{"203.0.113.7"})
+		Expect(err).To(MatchError("--allow-cidr must be an IPv4 CIDR range, eg 203.0.113.0/24, not `203.0.113.7`"))
+
+		_, err = ParseAllowedCIDRs([]string{"2001:db8::/32"})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// PeeringRouteTables are the route tables routes to a peered VPC can be added to. private
+// is used by the workers and compilation VMs, and public by the director and web node
+var PeeringRouteTables = []string{"private", "public", "all"}
+
+// PeeringSpec is a VPC in the same account and region that the deployment's VPC is peered with
+type PeeringSpec struct {
+	PeerVPCID string `json:"peer_vpc_id"`
+	PeerCIDR  string `json:"peer_cidr"`
+	// RouteTables are the deployment's route tables, private and/or public, that route PeerCIDR over the peering connection
+	RouteTables []string `json:"route_tables"`
+}
+
+// RoutesFrom returns true if the named route table routes to the peered VPC
+func (p PeeringSpec) RoutesFrom(routeTable string) bool {
+	for _, table := range p.RouteTables {
+		if table == routeTable {
+			return true
+		}
+	}
+	return false
+}
+
+// vpcIDPattern matches an AWS VPC ID, eg vpc-0123456789abcdef0
+var vpcIDPattern = regexp.MustCompile(`^vpc-[0-9a-f]+$`)
+
+// ParsePeeringSpecs parses peered VPCs given as vpc-id=cidr or vpc-id=cidr:route-table, where route-table
+// is private, public or all and defaults to private. Empty specs are skipped, so that an empty --peer-vpc
+// can be passed to remove the peering connections
+func ParsePeeringSpecs(specs []string) ([]PeeringSpec, error) {
+	peers := []PeeringSpec{}
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		if spec == "" {
+			continue
+		}
+
+		parts := strings.SplitN(spec, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("peer VPC `%s` must be in the form vpc-id=cidr or vpc-id=cidr:route-table", spec)
+		}
+		vpcID := parts[0]
+		if !vpcIDPattern.MatchString(vpcID) {
+			return nil, fmt.Errorf("peer VPC `%s` is invalid: `%s` is not a VPC ID, eg vpc-0123456789abcdef0", spec, vpcID)
+		}
+		if seen[vpcID] {
+			return nil, fmt.Errorf("peer VPC %s is given more than once", vpcID)
+		}
+		seen[vpcID] = true
+
+		routes := strings.SplitN(parts[1], ":", 2)
+		ip, cidr, err := net.ParseCIDR(routes[0])
+		if err != nil || ip.To4() == nil {
+			return nil, fmt.Errorf("peer VPC `%s` is invalid: `%s` is not an IPv4 CIDR range", spec, routes[0])
+		}
+
+		routeTable := "private"
+		if len(routes) == 2 {
+			routeTable = routes[1]
+		}
+
+		peer := PeeringSpec{PeerVPCID: vpcID, PeerCIDR: cidr.String()}
+		switch routeTable {
+		case "private", "public":
+			peer.RouteTables = []string{routeTable}
+		case "all":
+			peer.RouteTables = []string{"private", "public"}
+		default:
+			return nil, fmt.Errorf("peer VPC `%s` is invalid: unknown route table `%s`. Valid route tables are: %v", spec, routeTable, PeeringRouteTables)
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
+// CheckPeeringCIDRs fails if a peered VPC's range overlaps the deployment's VPC or another peered VPC,
+// as AWS can't route between overlapping ranges
+func CheckPeeringCIDRs(peers []PeeringSpec, network *Network) error {
+	for i, peer := range peers {
+		_, cidr, err := net.ParseCIDR(peer.PeerCIDR)
+		if err != nil {
+			return err
+		}
+		if network.Overlaps(cidr) {
+			return fmt.Errorf("peer VPC %s's range %s overlaps this deployment's VPC range %s. Choose another range for a new deployment with --vpc-cidr", peer.PeerVPCID, peer.PeerCIDR, network.VPCCIDR())
+		}
+
+		for _, other := range peers[:i] {
+			_, otherCIDR, err := net.ParseCIDR(other.PeerCIDR)
+			if err != nil {
+				return err
+			}
+			if cidr.Contains(otherCIDR.IP) || otherCIDR.Contains(cidr.IP) {
+				return fmt.Errorf("peer VPCs %s and %s have overlapping ranges %s and %s", other.PeerVPCID, peer.PeerVPCID, other.PeerCIDR, peer.PeerCIDR)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/concourse-up/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParsePeeringSpecs", func() {
+	It("Parses peered VPCs, routing from the private route table by default", func() {
+		peers, err := ParsePeeringSpecs([]string{"vpc-0abc=10.1.0.0/16", "vpc-0def=10.2.0.0/16:public", "vpc-0123=10.3.0.0/24:all"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(peers).To(Equal([]PeeringSpec{
+			{PeerVPCID: "vpc-0abc", PeerCIDR: "10.1.0.0/16", RouteTables: []string{"private"}},
+			{PeerVPCID: "vpc-0def", PeerCIDR: "10.2.0.0/16", RouteTables: []string{"public"}},
+			{PeerVPCID: "vpc-0123", PeerCIDR: "10.3.0.0/24", RouteTables: []string{"private", "public"}},
+		}))
+		Expect(peers[2].RoutesFrom("public")).To(BeTrue())
+		Expect(peers[0].RoutesFrom("public")).To(BeFalse())
+	})
+
+	It("Skips empty specs, so peering connections can be removed", func() {
+		peers, err := ParsePeeringSpecs([]string{""})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(peers).To(BeEmpty())
+	})
+
+	It("Rejects specs in the wrong form", func() {
+		_, err := ParsePeeringSpecs([]string{"vpc-0abc"})
+		Expect(err).To(MatchError("peer VPC `vpc-0abc` must be in the form vpc-id=cidr or vpc-id=cidr:route-table"))
+
+		_, err = ParsePeeringSpecs([]string{"my-vpc=10.1.0.0/16"})
+		Expect(err).To(MatchError(ContainSubstring("`my-vpc` is not a VPC ID")))
+
+		_, err = ParsePeeringSpecs([]string{"vpc-0abc=10.1.0.0"})
+		Expect(err).To(MatchError(ContainSubstring("`10.1.0.0` is not an IPv4 CIDR range")))
+
+		_, err = ParsePeeringSpecs([]string{"vpc-0abc=10.1.0.0/16:rds"})
+		Expect(err).To(MatchError(ContainSubstring("unknown route table `rds`")))
+	})
+
+	It("Rejects VPCs given more than once", func() {
+		_, err := ParsePeeringSpecs([]string{"vpc-0abc=10.1.0.0/16", "vpc-0abc=10.2.0.0/16"})
+		Expect(err).To(MatchError("peer VPC vpc-0abc is given more than once"))
+	})
+})
+
+var _ = Describe("CheckPeeringCIDRs", func() {
+	var network *Network
+
+	BeforeEach(func() {
+		var err error
+		network, err = ParseVPCCIDR(DefaultVPCCIDR)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Allows ranges outside the VPC", func() {
+		err := CheckPeeringCIDRs([]PeeringSpec{{PeerVPCID: "vpc-0abc", PeerCIDR: "10.1.0.0/16"}}, network)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Rejects ranges that overlap the VPC", func() {
+		err := CheckPeeringCIDRs([]PeeringSpec{{PeerVPCID: "vpc-0abc", PeerCIDR: "10.0.128.0/20"}}, network)
+		Expect(err).To(MatchError(ContainSubstring("peer VPC vpc-0abc's range 10.0.128.0/20 overlaps this deployment's VPC range 10.0.0.0/16")))
+	})
+
+	It("Rejects peers whose ranges overlap each other", func() {
+		err := CheckPeeringCIDRs([]PeeringSpec{
+			{PeerVPCID: "vpc-0abc", PeerCIDR: "10.1.0.0/16"},
+			{PeerVPCID: "vpc-0def", PeerCIDR: "10.1.4.0/24"},
+		}, network)
+		Expect(err).To(MatchError("peer VPCs vpc-0abc and vpc-0def have overlapping ranges 10.1.0.0/16 and 10.1.4.0/24"))
+	})
+})
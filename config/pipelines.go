@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// PipelineSpec is a pipeline to set in a Concourse team after each manual deploy
+type PipelineSpec struct {
+	Team string `json:"team"`
+	Name string `json:"name"`
+	// ConfigPath and VarsPath are local paths to the pipeline's YAML and, optionally, a file of vars for it.
+	// They aren't stored, as later deploys may be run from elsewhere
+	ConfigPath string `json:"-"`
+	VarsPath   string `json:"-"`
+	// Config and Vars are the contents of those files, which are stored so that later deploys can set the pipeline
+	Config string `json:"config"`
+	Vars   string `json:"vars,omitempty"`
+}
+
+// pipelineNamePattern matches the team and pipeline names concourse-up accepts
+var pipelineNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-.]+$`)
+
+// ParsePipelineSpecs parses pipelines given as team/name=pipeline.yml or team/name=pipeline.yml:vars.yml.
+// Empty specs are skipped, so that an empty --pipeline can be passed to stop setting pipelines
+func ParsePipelineSpecs(specs []string) ([]PipelineSpec, error) {
+	pipelines := []PipelineSpec{}
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		if spec == "" {
+			continue
+		}
+
+		parts := strings.SplitN(spec, "=", 2)
+		names := strings.SplitN(parts[0], "/", 2)
+		if len(parts) != 2 || len(names) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("pipeline `%s` must be in the form team/name=pipeline.yml or team/name=pipeline.yml:vars.yml", spec)
+		}
+		team, name := names[0], names[1]
+		if !pipelineNamePattern.MatchString(team) || !pipelineNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("pipeline `%s` is invalid: team and pipeline names can only contain letters, numbers and _ - .", spec)
+		}
+		if name == "concourse-up-self-update" && team == "main" {
+			return nil, fmt.Errorf("pipeline `%s` is invalid: main/concourse-up-self-update is reserved for concourse-up", spec)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("pipeline %s is given more than once", parts[0])
+		}
+		seen[parts[0]] = true
+
+		paths := strings.SplitN(parts[1], ":", 2)
+		pipeline := PipelineSpec{Team: team, Name: name, ConfigPath: paths[0]}
+		if len(paths) == 2 {
+			pipeline.VarsPath = paths[1]
+		}
+		if pipeline.ConfigPath == "" || (len(paths) == 2 && pipeline.VarsPath == "") {
+			return nil, fmt.Errorf("pipeline `%s` must be in the form team/name=pipeline.yml or team/name=pipeline.yml:vars.yml", spec)
+		}
+
+		pipelines = append(pipelines, pipeline)
+	}
+
+	return pipelines, nil
+}
+
+// ReadPipelineFiles reads the contents of each pipeline's files into it, failing if any can't be read
+func ReadPipelineFiles(pipelines []PipelineSpec) error {
+	for i := range pipelines {
+		pipeline := &pipelines[i]
+		contents, err := ioutil.ReadFile(pipeline.ConfigPath)
+		if err != nil {
+			return fmt.Errorf("could not read the files of pipeline %s/%s: %s", pipeline.Team, pipeline.Name, err)
+		}
+		pipeline.Config = string(contents)
+
+		if pipeline.VarsPath == "" {
+			continue
+		}
+		if contents, err = ioutil.ReadFile(pipeline.VarsPath); err != nil {
+			return fmt.Errorf("could not read the files of pipeline %s/%s: %s", pipeline.Team, pipeline.Name, err)
+		}
+		pipeline.Vars = string(contents)
+	}
+	return nil
+}
+
+// PipelineTeams returns the distinct teams of the given pipelines, in the order they're first given
+func PipelineTeams(pipelines []PipelineSpec) []string {
+	teams := []string{}
+	seen := map[string]bool{}
+	for _, pipeline := range pipelines {
+		if !seen[pipeline.Team] {
+			seen[pipeline.Team] = true
+			teams = append(teams, pipeline.Team)
+		}
+	}
+	return teams
+}
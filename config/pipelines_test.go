@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/EngineerBetter/concourse-up/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParsePipelineSpecs", func() {
+	It("Parses pipelines with and without a vars file", func() {
+		pipelines, err := ParsePipelineSpecs([]string{"main/website=ci/website.yml", "platform/infra=ci/infra.yml:ci/vars.yml"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pipelines).To(Equal([]PipelineSpec{
+			{Team: "main", Name: "website", ConfigPath: "ci/website.yml"},
+			{Team: "platform", Name: "infra", ConfigPath: "ci/infra.yml", VarsPath: "ci/vars.yml"},
+		}))
+	})
+
+	It("Skips empty specs, so pipelines can be removed", func() {
+		pipelines, err := ParsePipelineSpecs([]string{""})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pipelines).To(BeEmpty())
+	})
+
+	It("Rejects specs in the wrong form", func() {
+		for _, spec := range []string{"website=ci/website.yml", "main/website", "main/website=", "main/website=ci/website.yml:"} {
+			_, err := ParsePipelineSpecs([]string{spec})
+			Expect(err).To(MatchError(ContainSubstring("must be in the form team/name=pipeline.yml")), spec)
+		}
+	})
+
+	It("Rejects invalid names", func() {
+		_, err := ParsePipelineSpecs([]string{"main/my website=ci/website.yml"})
+		Expect(err).To(MatchError(ContainSubstring("team and pipeline names can only contain")))
+	})
+
+	It("Rejects the self-update pipeline", func() {
+		_, err := ParsePipelineSpecs([]string{"main/concourse-up-self-update=ci/website.yml"})
+		Expect(err).To(MatchError(ContainSubstring("reserved for concourse-up")))
+	})
+
+	It("Rejects pipelines given more than once", func() {
+		_, err := ParsePipelineSpecs([]string{"main/website=ci/website.yml", "main/website=ci/other.yml"})
+		Expect(err).To(MatchError("pipeline main/website is given more than once"))
+	})
+})
+
+var _ = Describe("PipelineTeams", func() {
+	It("Returns each team once, in order", func() {
+		teams := PipelineTeams([]PipelineSpec{{Team: "platform"}, {Team: "main"}, {Team: "platform"}})
+		Expect(teams).To(Equal([]string{"platform", "main"}))
+	})
+})
+
+var _ = Describe("ReadPipelineFiles", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "pipelines")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "infra.yml"), []byte("jobs: []"), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "vars.yml"), []byte("env: prod"), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Reads the contents of the files, which are stored instead of their paths", func() {
+		pipelines := []PipelineSpec{{Team: "platform", Name: "infra", ConfigPath: filepath.Join(dir, "infra.yml"), VarsPath: filepath.Join(dir, "vars.yml")}}
+		Expect(ReadPipelineFiles(pipelines)).To(Succeed())
+		Expect(pipelines[0].Config).To(Equal("jobs: []"))
+		Expect(pipelines[0].Vars).To(Equal("env: prod"))
+
+		stored, err := json.Marshal(pipelines[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(stored)).To(Equal(`{"team":"platform","name":"infra","config":"jobs: []","vars":"env: prod"}`))
+	})
+
+	It("Fails if a file can't be read", func() {
+		pipelines := []PipelineSpec{{Team: "main", Name: "website", ConfigPath: filepath.Join(dir, "website.yml")}}
+		Expect(ReadPipelineFiles(pipelines)).To(MatchError(ContainSubstring("could not read the files of pipeline main/website")))
+	})
+})
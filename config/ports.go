@@ -0,0 +1,81 @@
+package config
+
+import "fmt"
+
+// DefaultConcoursePort, DefaultCredhubPort and DefaultGrafanaPort are the ports the web node serves
+// Concourse, CredHub and Grafana on unless others are chosen
+const (
+	DefaultConcoursePort = 443
+	DefaultCredhubPort   = 8844
+	DefaultGrafanaPort   = 3000
+)
+
+// fixedWebPorts are the ports of the web node's other services, which Concourse, CredHub and Grafana can't be moved to
+var fixedWebPorts = []int{22, 80, 5555, 6868, 8080, 8086, 8443, 9090, 9391}
+
+// ConcoursePort returns the port the ATC serves HTTPS on. Configs from before it could be
+// chosen have none, and use the port that was always used
+func (c *Config) ConcoursePort() int {
+	if c.ConcourseExternalPort == 0 {
+		return DefaultConcoursePort
+	}
+	return c.ConcourseExternalPort
+}
+
+// CredhubPort returns the port CredHub serves its API on
+func (c *Config) CredhubPort() int {
+	if c.CredhubExternalPort == 0 {
+		return DefaultCredhubPort
+	}
+	return c.CredhubExternalPort
+}
+
+// GrafanaPort returns the port Grafana serves on, which is only reachable directly when it isn't served under a path
+func (c *Config) GrafanaPort() int {
+	if c.GrafanaExternalPort == 0 {
+		return DefaultGrafanaPort
+	}
+	return c.GrafanaExternalPort
+}
+
+// ConcourseURL returns the URL Concourse is reached at, which only has a port when it isn't 443
+func (c *Config) ConcourseURL() string {
+	if c.ConcoursePort() == DefaultConcoursePort {
+		return fmt.Sprintf("https://%s", c.Domain)
+	}
+	return fmt.Sprintf("https://%s:%d", c.Domain, c.ConcoursePort())
+}
+
+// MetricsURL returns the URL Grafana is reached at, either under its path on the Concourse domain or on its own port
+func (c *Config) MetricsURL() string {
+	if c.GrafanaPath != "" {
+		return c.ConcourseURL() + c.GrafanaPath
+	}
+	return fmt.Sprintf("https://%s:%d", c.Domain, c.GrafanaPort())
+}
+
+// webPort is a port a service on the web node is served on, and the flag that chooses it
+type webPort struct {
+	flag string
+	port int
+}
+
+// checkWebPorts returns an error if any of the ports is invalid or clashes with another, or with the web node's other services
+func checkWebPorts(ports []webPort) error {
+	for i, p := range ports {
+		if p.port < 1 || p.port > 65535 {
+			return fmt.Errorf("%s must be between 1 and 65535", p.flag)
+		}
+		for _, fixed := range fixedWebPorts {
+			if p.port == fixed {
+				return fmt.Errorf("%s cannot be %d as it is already in use on the web node", p.flag, fixed)
+			}
+		}
+		for _, other := range ports[:i] {
+			if p.port == other.port {
+				return fmt.Errorf("%s and %s cannot both be %d", other.flag, p.flag, p.port)
+			}
+		}
+	}
+	return nil
+}
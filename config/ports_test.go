@@ -0,0 +1,60 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/concourse-up/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ports", func() {
+	It("Serves everything on the ports it always has by default", func() {
+		conf := &Config{Domain: "ci.example.com"}
+
+		Expect(conf.ConcourseURL()).To(Equal("https://ci.example.com"))
+		Expect(conf.MetricsURL()).To(Equal("https://ci.example.com:3000"))
+		Expect(conf.CredhubPort()).To(Equal(8844))
+	})
+
+	It("Puts chosen ports in the URLs", func() {
+		conf := &Config{Domain: "ci.example.com", ConcourseExternalPort: 4443, GrafanaExternalPort: 4000}
+
+		Expect(conf.ConcourseURL()).To(Equal("https://ci.example.com:4443"))
+		Expect(conf.MetricsURL()).To(Equal("https://ci.example.com:4000"))
+	})
+
+	It("Serves Grafana under its path on the Concourse URL", func() {
+		conf := &Config{Domain: "ci.example.com", GrafanaPath: "/grafana", GrafanaExternalPort: 4000}
+
+		Expect(conf.MetricsURL()).To(Equal("https://ci.example.com/grafana"))
+	})
+
+	Describe("validation", func() {
+		var args DeployArgs
+
+		BeforeEach(func() {
+			args = DeployArgs{TSAPort: DefaultTSAPort}
+		})
+
+		It("Rejects ports in use by the web node's other services", func() {
+			args.ExternalPort = 8443
+			args.ExternalPortIsSet = true
+			Expect(args.ValidatePortFields()).To(MatchError("--external-port cannot be 8443 as it is already in use on the web node"))
+		})
+
+		It("Rejects ports that clash with each other, including the defaults", func() {
+			args.CredhubPort = 443
+			args.CredhubPortIsSet = true
+			Expect(args.ValidatePortFields()).To(MatchError("--external-port and --credhub-port cannot both be 443"))
+
+			args.CredhubPort = 2222
+			Expect(args.ValidatePortFields()).To(MatchError("--credhub-port and --tsa-port cannot both be 2222"))
+		})
+
+		It("Rejects moving Concourse or Grafana when Grafana is served under a path", func() {
+			args.GrafanaPath = "/grafana"
+			args.ExternalPort = 4443
+			args.ExternalPortIsSet = true
+			Expect(args.ValidatePortFields()).To(MatchError("--external-port and --grafana-port cannot be used with --grafana-path"))
+		})
+	})
+})
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Profile is a preset combination of sizes for a deployment
+type Profile struct {
+	WorkerCount int
+	WorkerSize  string
+	WebSize     string
+	DBSize      string
+	MultiAZRDS  bool
+}
+
+// Profiles are the presets --profile can choose from
+var Profiles = map[string]Profile{
+	"small": {
+		WorkerCount: 1,
+		WorkerSize:  "large",
+		WebSize:     "small",
+		DBSize:      "small",
+	},
+	"medium": {
+		WorkerCount: 2,
+		WorkerSize:  "xlarge",
+		WebSize:     "medium",
+		DBSize:      "medium",
+	},
+	"large": {
+		WorkerCount: 4,
+		WorkerSize:  "2xlarge",
+		WebSize:     "large",
+		DBSize:      "large",
+	},
+	"production": {
+		WorkerCount: 4,
+		WorkerSize:  "2xlarge",
+		WebSize:     "large",
+		DBSize:      "large",
+		MultiAZRDS:  true,
+	},
+}
+
+// ProfileNames returns the names of the profiles in alphabetical order
+func ProfileNames() []string {
+	names := []string{}
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile sets the sizes in args from the profile in args.Profile. Sizes whose
+// flags were given, according to isSet, are kept so that they override the profile
+func (args *DeployArgs) ApplyProfile(isSet func(flag string) bool) error {
+	profile, ok := Profiles[args.Profile]
+	if !ok {
+		return fmt.Errorf("unknown profile: `%s`. Valid profiles are: %v", args.Profile, ProfileNames())
+	}
+
+	if !isSet("workers") {
+		args.WorkerCount = profile.WorkerCount
+	}
+	if !isSet("worker-size") {
+		args.WorkerSize = profile.WorkerSize
+	}
+	if !isSet("web-size") {
+		args.WebSize = profile.WebSize
+	}
+	// An external database is sized outside concourse-up, so the profile's RDS settings don't apply to it
+	if args.ExternalDBURL != "" {
+		return nil
+	}
+	if !isSet("db-size") {
+		args.DBSize = profile.DBSize
+		args.DBSizeIsSet = true
+	}
+	args.MultiAZRDS = profile.MultiAZRDS
+	args.MultiAZRDSIsSet = true
+
+	return nil
+}
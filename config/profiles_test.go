@@ -0,0 +1,67 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/concourse-up/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ApplyProfile", func() {
+	var args *DeployArgs
+	var setFlags map[string]bool
+
+	isSet := func(flag string) bool {
+		return setFlags[flag]
+	}
+
+	BeforeEach(func() {
+		args = &DeployArgs{
+			WorkerCount: 1,
+			WorkerSize:  "xlarge",
+			WebSize:     "small",
+			DBSize:      "small",
+		}
+		setFlags = map[string]bool{}
+	})
+
+	It("Sets the sizes from the profile", func() {
+		args.Profile = "production"
+
+		Expect(args.ApplyProfile(isSet)).To(Succeed())
+		Expect(args.WorkerCount).To(Equal(4))
+		Expect(args.WorkerSize).To(Equal("2xlarge"))
+		Expect(args.WebSize).To(Equal("large"))
+		Expect(args.DBSize).To(Equal("large"))
+		Expect(args.DBSizeIsSet).To(BeTrue())
+		Expect(args.MultiAZRDS).To(BeTrue())
+		Expect(args.MultiAZRDSIsSet).To(BeTrue())
+	})
+
+	It("Keeps the sizes given by flags", func() {
+		args.Profile = "medium"
+		args.WorkerCount = 6
+		setFlags["workers"] = true
+
+		Expect(args.ApplyProfile(isSet)).To(Succeed())
+		Expect(args.WorkerCount).To(Equal(6))
+		Expect(args.WorkerSize).To(Equal("xlarge"))
+		Expect(args.WebSize).To(Equal("medium"))
+	})
+
+	It("Leaves the RDS settings alone with an external database", func() {
+		args.Profile = "production"
+		args.ExternalDBURL = "postgres://db.example.com/bosh"
+
+		Expect(args.ApplyProfile(isSet)).To(Succeed())
+		Expect(args.WorkerCount).To(Equal(4))
+		Expect(args.DBSize).To(Equal("small"))
+		Expect(args.DBSizeIsSet).To(BeFalse())
+		Expect(args.MultiAZRDSIsSet).To(BeFalse())
+	})
+
+	It("Rejects unknown profiles", func() {
+		args.Profile = "huge"
+
+		Expect(args.ApplyProfile(isSet)).To(MatchError("unknown profile: `huge`. Valid profiles are: [large medium production small]"))
+	})
+})
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PruneWorkersArgs are arguments passed to the prune-workers command
+type PruneWorkersArgs struct {
+	AWSRegion string
+	IAAS      string
+	// States is a comma separated list of the worker states to prune
+	States string
+}
+
+// PrunableWorkerStates are the worker states which fly is able to prune
+var PrunableWorkerStates = []string{"stalled", "landing", "landed", "retiring"}
+
+// StateList returns the worker states to prune
+func (args PruneWorkersArgs) StateList() []string {
+	states := []string{}
+	for _, state := range strings.Split(args.States, ",") {
+		if state = strings.TrimSpace(state); state != "" {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+// Validate validates that flag interdependencies
+func (args PruneWorkersArgs) Validate() error {
+	states := args.StateList()
+	if len(states) == 0 {
+		return fmt.Errorf("--state must be one or more of: %v", PrunableWorkerStates)
+	}
+
+	for _, state := range states {
+		if !isPrunableWorkerState(state) {
+			return fmt.Errorf("unknown worker state: `%s`. Valid states are: %v", state, PrunableWorkerStates)
+		}
+	}
+
+	return nil
+}
+
+func isPrunableWorkerState(state string) bool {
+	for _, prunable := range PrunableWorkerStates {
+		if prunable == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package config
+
+import "errors"
+
+// RenewCertsArgs are arguments passed to the renew-certs command
+type RenewCertsArgs struct {
+	AWSRegion string
+	IAAS      string
+	// TLSCert and TLSKey replace a Concourse certificate that was provided with deploy --tls-cert
+	TLSCert string
+	TLSKey  string
+}
+
+// Validate validates that flag interdependencies
+func (args RenewCertsArgs) Validate() error {
+	if args.TLSKey != "" && args.TLSCert == "" {
+		return errors.New("--tls-key requires --tls-cert to also be provided")
+	}
+	if args.TLSCert != "" && args.TLSKey == "" {
+		return errors.New("--tls-cert requires --tls-key to also be provided")
+	}
+
+	return nil
+}
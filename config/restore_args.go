@@ -0,0 +1,20 @@
+package config
+
+import "errors"
+
+// RestoreArgs are arguments passed to the restore command
+type RestoreArgs struct {
+	AWSRegion string
+	IAAS      string
+	// SnapshotID is the RDS snapshot to restore the database from
+	SnapshotID string
+}
+
+// Validate validates that flag interdependencies
+func (args RestoreArgs) Validate() error {
+	if args.SnapshotID == "" {
+		return errors.New("--snapshot is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,7 @@
+package config
+
+// RollbackArgs are arguments passed to the rollback command
+type RollbackArgs struct {
+	AWSRegion string
+	IAAS      string
+}
@@ -0,0 +1,19 @@
+package config
+
+import "errors"
+
+// RunErrandArgs are arguments passed to the run-errand command
+type RunErrandArgs struct {
+	AWSRegion string
+	IAAS      string
+	Errand    string
+}
+
+// Validate validates that flag interdependencies
+func (args RunErrandArgs) Validate() error {
+	if args.Errand == "" {
+		return errors.New("--errand is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ScaleWorkersArgs are arguments passed to the scale-workers command
+type ScaleWorkersArgs struct {
+	AWSRegion string
+	IAAS      string
+	// WorkerCount is the number of workers to scale to
+	WorkerCount int
+	// WorkerCountIsSet is true if the user has specified a number of workers
+	WorkerCountIsSet bool
+	// WorkerSize is the size to give the workers. Empty keeps their current size
+	WorkerSize string
+	// WorkerDrainTimeout is the number of minutes to wait for running builds to finish
+	// on workers that are removed. Zero removes them immediately
+	WorkerDrainTimeout int
+}
+
+// Validate validates that flag interdependencies
+func (args ScaleWorkersArgs) Validate() error {
+	if !args.WorkerCountIsSet && args.WorkerSize == "" {
+		return errors.New("--count or --size is required")
+	}
+
+	if args.WorkerCountIsSet && args.WorkerCount < 1 {
+		return errors.New("minimum of workers is 1")
+	}
+
+	if args.WorkerDrainTimeout < 0 {
+		return errors.New("--drain-timeout cannot be negative")
+	}
+
+	if args.WorkerSize == "" {
+		return nil
+	}
+	for _, size := range WorkerSizes {
+		if size == args.WorkerSize {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown worker size: `%s`. Valid sizes are: %v", args.WorkerSize, WorkerSizes)
+}
@@ -0,0 +1,21 @@
+package config
+
+import "errors"
+
+// SetDNSWeightArgs are arguments passed to the set-dns-weight command
+type SetDNSWeightArgs struct {
+	AWSRegion string
+	IAAS      string
+	Weight    int
+	// WeightIsSet is true if the user has specified a weight
+	WeightIsSet bool
+}
+
+// Validate validates that flag interdependencies
+func (args SetDNSWeightArgs) Validate() error {
+	if !args.WeightIsSet {
+		return errors.New("--weight is required")
+	}
+
+	return ValidateDNSWeight("weight", args.Weight)
+}
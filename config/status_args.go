@@ -0,0 +1,8 @@
+package config
+
+// StatusArgs are arguments passed to the status command
+type StatusArgs struct {
+	AWSRegion string
+	JSON      bool
+	IAAS      string
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reservedTags are the tags concourse-up gives its resources itself
+var reservedTags = []string{"Name", "concourse-up-project", "concourse-up-component"}
+
+// tagPattern matches the characters AWS allows in tag keys and values
+var tagPattern = regexp.MustCompile(`^[\pL\pZ\pN_.:/=+\-@]*$`)
+
+// ParseTags parses key=value pairs into AWS tags. Empty pairs are skipped, so
+// that an empty --tag can be passed to remove the existing tags
+func ParseTags(pairs []string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("tag `%s` must be in the form key=value", pair)
+		}
+		key, value := parts[0], parts[1]
+
+		if len(key) > 128 || len(value) > 256 {
+			return nil, fmt.Errorf("tag `%s` is too long. Keys can be up to 128 characters and values up to 256", pair)
+		}
+		if !tagPattern.MatchString(key) || !tagPattern.MatchString(value) {
+			return nil, fmt.Errorf("tag `%s` can only contain letters, numbers, spaces and _ . : / = + - @", pair)
+		}
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			return nil, fmt.Errorf("tag key `%s` cannot start with aws:, which is reserved for AWS", key)
+		}
+		for _, reserved := range reservedTags {
+			if key == reserved {
+				return nil, fmt.Errorf("tag key `%s` is reserved for concourse-up", key)
+			}
+		}
+
+		tags[key] = value
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,55 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/concourse-up/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseTags", func() {
+	It("Parses key=value pairs", func() {
+		tags, err := ParseTags([]string{"cost-centre=platform", "team=ci ops", "repo=github.com/team/ci"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tags).To(Equal(map[string]string{
+			"cost-centre": "platform",
+			"team":        "ci ops",
+			"repo":        "github.com/team/ci",
+		}))
+	})
+
+	It("Skips empty pairs, so tags can be removed", func() {
+		tags, err := ParseTags([]string{""})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tags).To(BeEmpty())
+	})
+
+	It("Allows empty values", func() {
+		tags, err := ParseTags([]string{"reviewed="})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tags).To(HaveKeyWithValue("reviewed", ""))
+	})
+
+	It("Rejects pairs without a key", func() {
+		_, err := ParseTags([]string{"platform"})
+		Expect(err).To(MatchError("tag `platform` must be in the form key=value"))
+
+		_, err = ParseTags([]string{"=platform"})
+		Expect(err).To(MatchError("tag `=platform` must be in the form key=value"))
+	})
+
+	It("Rejects characters AWS doesn't allow", func() {
+		_, err := ParseTags([]string{`team="ci"`})
+		Expect(err).To(MatchError(ContainSubstring("can only contain letters, numbers, spaces")))
+
+		_, err = ParseTags([]string{"team=${var.project}"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Rejects keys reserved by AWS or concourse-up", func() {
+		_, err := ParseTags([]string{"aws:createdBy=me"})
+		Expect(err).To(MatchError("tag key `aws:createdBy` cannot start with aws:, which is reserved for AWS"))
+
+		_, err = ParseTags([]string{"concourse-up-project=other"})
+		Expect(err).To(MatchError("tag key `concourse-up-project` is reserved for concourse-up"))
+	})
+})
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxVersions is how many previous versions of each asset are kept in the config bucket
+const MaxVersions = 10
+
+// ErrNoVersion is returned when an asset has fewer previous versions than were asked for
+var ErrNoVersion = errors.New("no such version")
+
+// versionsDir is the prefix of the config bucket that previous versions of the assets are kept under
+const versionsDir = "versions/"
+
+// versionTimeFormat names each version by the time it was replaced. It's fixed width so that
+// versions sort by name in the order they were replaced
+const versionTimeFormat = "20060102T150405.000000000Z"
+
+// now returns the time a version is replaced, and is replaced in tests
+var now = time.Now
+
+// writeFile writes an asset to the config bucket, first keeping its current contents as a version
+// if they're about to change. Only the newest MaxVersions versions are kept
+func (client *Client) writeFile(filename string, contents []byte) error {
+	if err := client.checkWritable(); err != nil {
+		return err
+	}
+	if err := client.keepVersion(filename, contents); err != nil {
+		return err
+	}
+
+	return client.iaas.WriteFile(client.configBucket(), filename, contents)
+}
+
+func (client *Client) keepVersion(filename string, contents []byte) error {
+	exists, err := client.iaas.HasFile(client.configBucket(), filename)
+	if err != nil || !exists {
+		return err
+	}
+
+	current, err := client.iaas.LoadFile(client.configBucket(), filename)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(current, contents) {
+		return nil
+	}
+
+	versionPath := versionsDir + filename + "/" + now().UTC().Format(versionTimeFormat)
+	if err = client.iaas.WriteFile(client.configBucket(), versionPath, current); err != nil {
+		return err
+	}
+
+	paths, err := client.versionPaths(filename)
+	if err != nil {
+		return err
+	}
+	for i := MaxVersions; i < len(paths); i++ {
+		if err = client.iaas.DeleteFile(client.configBucket(), paths[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// versionPaths returns the paths of the asset's versions, newest first
+func (client *Client) versionPaths(filename string) ([]string, error) {
+	paths, err := client.iaas.ListFiles(client.configBucket(), versionsDir+filename+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// Versions returns the times that each kept version of an asset was replaced, newest first
+func (client *Client) Versions(filename string) ([]time.Time, error) {
+	paths, err := client.versionPaths(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	times := []time.Time{}
+	for _, path := range paths {
+		replacedAt, err := time.Parse(versionTimeFormat, strings.TrimPrefix(path, versionsDir+filename+"/"))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the time of version %s: %s", path, err)
+		}
+		times = append(times, replacedAt)
+	}
+
+	return times, nil
+}
+
+// LoadAssetVersion loads the nth previous version of an asset, where 1 is the version it last replaced
+func (client *Client) LoadAssetVersion(filename string, n int) ([]byte, error) {
+	paths, err := client.versionPaths(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 1 || n > len(paths) {
+		return nil, ErrNoVersion
+	}
+
+	return client.iaas.LoadFile(client.configBucket(), paths[n-1])
+}
+
+// LoadVersion loads the nth previous version of the config file, where 1 is the version it last replaced
+func (client *Client) LoadVersion(n int) (*Config, error) {
+	configBytes, err := client.LoadAssetVersion(ConfigFilename, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(configBytes)
+}
@@ -0,0 +1,153 @@
+package credhub
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// IClient represents an interface for a client
+type IClient interface {
+	SetSecrets(secrets []Secret) error
+}
+
+// ClientFactory creates a new IClient
+type ClientFactory func(creds Credentials) (IClient, error)
+
+// Client sets credentials in a deployment's CredHub through its API
+type Client struct {
+	creds      Credentials
+	httpClient *http.Client
+}
+
+// Credentials represents credentials needed to connect to CredHub
+type Credentials struct {
+	// URL is CredHub's API, eg https://10.0.0.1:8844/
+	URL string
+	// Client and ClientSecret are the UAA client that logs in to CredHub
+	Client       string
+	ClientSecret string
+	// CACert signs the certificates of CredHub and of the UAA it logs in with
+	CACert string
+}
+
+// requestTimeout bounds each request to CredHub and UAA
+const requestTimeout = 30 * time.Second
+
+// New returns a new CredHub client
+func New(creds Credentials) (IClient, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(creds.CACert)) {
+		return nil, errors.New("could not parse CredHub's CA certificate")
+	}
+
+	return &Client{
+		creds: creds,
+		httpClient: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		},
+	}, nil
+}
+
+// SetSecrets logs in to CredHub and sets each secret, overwriting any existing value
+func (client *Client) SetSecrets(secrets []Secret) error {
+	token, err := client.login()
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range secrets {
+		body, err := json.Marshal(map[string]interface{}{
+			"name":      secret.Path(),
+			"type":      "value",
+			"value":     secret.Value,
+			"overwrite": true,
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest(http.MethodPut, client.apiURL("api/v1/data"), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		if err = client.do(req, nil); err != nil {
+			return fmt.Errorf("could not set %s in CredHub: %s", secret.Path(), err)
+		}
+	}
+
+	return nil
+}
+
+// login gets a token for the client from the UAA that CredHub trusts
+func (client *Client) login() (string, error) {
+	req, err := http.NewRequest(http.MethodGet, client.apiURL("info"), nil)
+	if err != nil {
+		return "", err
+	}
+	var info struct {
+		AuthServer struct {
+			URL string `json:"url"`
+		} `json:"auth-server"`
+	}
+	if err = client.do(req, &info); err != nil {
+		return "", fmt.Errorf("could not reach CredHub at %s: %s", client.creds.URL, err)
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err = http.NewRequest(http.MethodPost, strings.TrimSuffix(info.AuthServer.URL, "/")+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(client.creds.Client, client.creds.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err = client.do(req, &token); err != nil {
+		return "", fmt.Errorf("could not log in to CredHub as %s: %s", client.creds.Client, err)
+	}
+
+	return token.AccessToken, nil
+}
+
+func (client *Client) apiURL(path string) string {
+	return strings.TrimSuffix(client.creds.URL, "/") + "/" + path
+}
+
+// do sends the request and decodes the JSON response into result, unless it's nil
+func (client *Client) do(req *http.Request, result interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
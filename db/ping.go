@@ -0,0 +1,54 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"time"
+
+	// Registers the postgres driver
+	_ "github.com/lib/pq"
+)
+
+// PingTimeout is how long Ping waits to connect before giving up
+const PingTimeout = 10 * time.Second
+
+// Ping connects to the Postgres database name at address, eg db.example.com:5432, over TLS, checking the
+// server's certificate is for its host and is signed by caCert
+func Ping(address, name, username, password, caCert string) error {
+	caFile, err := ioutil.TempFile("", "concourse-up-db-ca")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(caFile.Name())
+
+	if _, err = caFile.WriteString(caCert); err != nil {
+		caFile.Close()
+		return err
+	}
+	if err = caFile.Close(); err != nil {
+		return err
+	}
+
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(username, password),
+		Host:   address,
+		Path:   name,
+		RawQuery: url.Values{
+			"sslmode":         {"verify-full"},
+			"sslrootcert":     {caFile.Name()},
+			"connect_timeout": {fmt.Sprintf("%d", int(PingTimeout.Seconds()))},
+		}.Encode(),
+	}
+
+	conn, err := sql.Open("postgres", u.String())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Ping()
+}
@@ -34,6 +34,8 @@ type Credentials struct {
 	Password string
 	Host     string
 	CACert   string
+	// Proxy is the proxy the bosh CLI connects to the director through
+	Proxy util.Proxy
 }
 
 // Client represents a low-level wrapper for bosh director
@@ -81,6 +83,21 @@ func (client *Client) Cleanup() error {
 	return client.tempDir.Cleanup()
 }
 
+// BinaryVersion downloads the bosh CLI concourse-up uses and returns the version it reports
+func BinaryVersion() (string, error) {
+	client, err := NewClient(Credentials{})
+	if err != nil {
+		return "", err
+	}
+	defer client.Cleanup()
+
+	if err := client.ensureBinaryDownloaded(); err != nil {
+		return "", err
+	}
+
+	return util.BinaryVersion(client.tempDir.Path("bosh-cli"), "--version")
+}
+
 func (client *Client) ensureBinaryDownloaded() error {
 	if client.hasDownloadedBinary {
 		return nil
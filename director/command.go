@@ -6,6 +6,8 @@ import (
 	"io"
 	"os/exec"
 	"strings"
+
+	"github.com/EngineerBetter/concourse-up/util"
 )
 
 var defaultBoshArgs = []string{"--non-interactive", "--tty", "--no-color"}
@@ -40,6 +42,7 @@ func (client *Client) RunCommand(stdout, stderr io.Writer, args ...string) error
 	args = append(defaultBoshArgs, args...)
 
 	cmd := exec.Command(client.tempDir.Path("bosh-cli"), args...)
+	cmd.Env = client.creds.Proxy.Env(util.CommandEnv())
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 	return cmd.Run()
@@ -53,6 +56,7 @@ func (client *Client) runDetachingCommand(stdout, stderr io.Writer, args ...stri
 	args = append(defaultBoshArgs, args...)
 
 	cmd := exec.Command(client.tempDir.Path("bosh-cli"), args...)
+	cmd.Env = client.creds.Proxy.Env(util.CommandEnv())
 	cmd.Stderr = stderr
 
 	cmdReader, err := cmd.StdoutPipe()
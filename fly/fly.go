@@ -2,6 +2,8 @@ package fly
 
 import (
 	"bytes"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,6 +16,7 @@ import (
 	"time"
 
 	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/iaas"
 	"github.com/EngineerBetter/concourse-up/util"
 )
 
@@ -33,6 +36,18 @@ var ConcourseUpVersion = "COMPILE_TIME_VARIABLE_fly_concourse_up_version"
 type IClient interface {
 	CanConnect() (bool, error)
 	SetDefaultPipeline(deployArgs *config.DeployArgs, config *config.Config, allowFlyVersionDiscrepancy bool) error
+	SetPipelines(pipelines []config.PipelineSpec) error
+	DestroyDefaultPipeline() error
+	RetireWorkers(names []string, timeout time.Duration) error
+	PruneWorkers(states []string) ([]string, error)
+	WaitForWorkers(count int, timeout time.Duration) error
+	ActivePipelines() ([]string, error)
+	PausePipelines(names []string) error
+	UnpausePipelines(names []string) error
+	LandWorkers(timeout time.Duration) error
+	WaitForBuilds(timeout time.Duration) ([]Build, error)
+	ATCVersion() (string, error)
+	URL() string
 	Cleanup() error
 }
 
@@ -51,6 +66,8 @@ type Credentials struct {
 	Username string
 	Password string
 	CACert   string
+	// Proxy is the proxy fly connects to Concourse through
+	Proxy util.Proxy
 }
 
 // New returns a new fly client
@@ -60,41 +77,65 @@ func New(creds Credentials, stdout, stderr io.Writer) (IClient, error) {
 		return nil, err
 	}
 
+	if err := downloadBinary(tempDir); err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		tempDir,
+		creds,
+		stdout,
+		stderr,
+	}, nil
+}
+
+// BinaryVersion downloads the fly binary concourse-up uses and returns the version it reports
+func BinaryVersion() (string, error) {
+	tempDir, err := util.NewTempDir()
+	if err != nil {
+		return "", err
+	}
+	defer tempDir.Cleanup()
+
+	if err := downloadBinary(tempDir); err != nil {
+		return "", err
+	}
+
+	return util.BinaryVersion(tempDir.Path("fly"), "--version")
+}
+
+func downloadBinary(tempDir *util.TempDir) error {
 	fileHandler, err := os.Create(tempDir.Path("fly"))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer fileHandler.Close()
 
 	url, err := getFlyURL()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	resp, err := http.Get(url)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if _, err := io.Copy(fileHandler, resp.Body); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := fileHandler.Sync(); err != nil {
-		return nil, err
+		return err
 	}
 
-	if err := os.Chmod(fileHandler.Name(), 0700); err != nil {
-		return nil, err
-	}
+	return os.Chmod(fileHandler.Name(), 0700)
+}
 
-	return &Client{
-		tempDir,
-		creds,
-		stdout,
-		stderr,
-	}, nil
+// URL returns the URL of the Concourse the client targets
+func (client *Client) URL() string {
+	return client.creds.API
 }
 
 // CanConnect returns true if it can connect to the concourse
@@ -114,6 +155,7 @@ func (client *Client) CanConnect() (bool, error) {
 	)
 
 	stderr := bytes.NewBuffer(nil)
+	cmd.Env = client.creds.Proxy.Env(util.CommandEnv())
 	cmd.Stdout = client.stdout
 	cmd.Stderr = stderr
 
@@ -152,7 +194,7 @@ func (client *Client) SetDefaultPipeline(deployArgs *config.DeployArgs, config *
 	}
 
 	pipelinePath := client.tempDir.Path("default-pipeline.yml")
-	pipelineName := "concourse-up-self-update"
+	pipelineName := SelfUpdatePipeline
 
 	if err := client.writePipelineConfig(pipelinePath, deployArgs, config); err != nil {
 		return err
@@ -173,6 +215,89 @@ func (client *Client) SetDefaultPipeline(deployArgs *config.DeployArgs, config *
 	return client.run("unpause-pipeline", "--pipeline", pipelineName)
 }
 
+// DestroyDefaultPipeline destroys the self-update pipeline, if there is one, along with its build history
+func (client *Client) DestroyDefaultPipeline() error {
+	if err := client.login(); err != nil {
+		return err
+	}
+
+	return client.run("destroy-pipeline", "--pipeline", SelfUpdatePipeline, "--non-interactive")
+}
+
+// SetPipelines creates the teams of the given pipelines, other than main, and sets and unpauses
+// each pipeline in its team. Teams are given the admin user's basic auth, like the main team
+func (client *Client) SetPipelines(pipelines []config.PipelineSpec) error {
+	if err := client.login(); err != nil {
+		return err
+	}
+
+	for _, team := range config.PipelineTeams(pipelines) {
+		if team == "main" {
+			continue
+		}
+		if _, err := client.stdout.Write([]byte(fmt.Sprintf("Creating team %s\n", team))); err != nil {
+			return err
+		}
+		err := client.run(
+			"set-team",
+			"--team-name", team,
+			"--basic-auth-username", client.creds.Username,
+			"--basic-auth-password", client.creds.Password,
+			"--non-interactive",
+		)
+		if err != nil {
+			return err
+		}
+		if err = client.loginTeam(team); err != nil {
+			return err
+		}
+	}
+
+	for _, pipeline := range pipelines {
+		target := client.teamTarget(pipeline.Team)
+		configPath := client.tempDir.Path(fmt.Sprintf("pipeline-%s-%s.yml", pipeline.Team, pipeline.Name))
+		if err := ioutil.WriteFile(configPath, []byte(pipeline.Config), 0600); err != nil {
+			return err
+		}
+		args := []string{"--target", target, "set-pipeline", "--pipeline", pipeline.Name, "--config", configPath, "--non-interactive"}
+		if pipeline.Vars != "" {
+			varsPath := client.tempDir.Path(fmt.Sprintf("pipeline-%s-%s-vars.yml", pipeline.Team, pipeline.Name))
+			if err := ioutil.WriteFile(varsPath, []byte(pipeline.Vars), 0600); err != nil {
+				return err
+			}
+			args = append(args, "--load-vars-from", varsPath)
+		}
+		if err := client.runWithTarget(args...); err != nil {
+			return err
+		}
+		if err := client.runWithTarget("--target", target, "unpause-pipeline", "--pipeline", pipeline.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// teamTarget returns the fly target for the given team. The main team uses the client's own target
+func (client *Client) teamTarget(team string) string {
+	if team == "main" {
+		return client.creds.Target
+	}
+	return fmt.Sprintf("%s-%s", client.creds.Target, team)
+}
+
+func (client *Client) loginTeam(team string) error {
+	return client.runWithTarget(
+		"--target", client.teamTarget(team),
+		"login",
+		"--insecure",
+		"--concourse-url", client.creds.API,
+		"--team-name", team,
+		"--username", client.creds.Username,
+		"--password", client.creds.Password,
+	)
+}
+
 func (client *Client) writePipelineConfig(pipelinePath string, deployArgs *config.DeployArgs, config *config.Config) error {
 	fileHandler, err := os.Create(pipelinePath)
 	if err != nil {
@@ -229,13 +354,398 @@ func (client *Client) login() error {
 	return fmt.Errorf("failed to log in to %s after %d seconds", client.creds.API, attempts*secondsBetweenAttempts)
 }
 
+// RetireWorkers lands the named workers so that no new builds are scheduled on them,
+// waits for their in-flight builds to finish and then prunes them from the ATC
+func (client *Client) RetireWorkers(names []string, timeout time.Duration) error {
+	if err := client.login(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := client.stdout.Write([]byte(fmt.Sprintf("Retiring worker %s\n", name))); err != nil {
+			return err
+		}
+		if err := client.run("land-worker", "--worker", name); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, name := range names {
+		if err := client.waitUntilLanded(name, deadline, timeout); err != nil {
+			return err
+		}
+
+		if err := client.run("prune-worker", "--worker", name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PruneWorkers prunes the registrations of all workers in one of the given states
+// and returns the names of the workers it pruned
+func (client *Client) PruneWorkers(states []string) ([]string, error) {
+	if err := client.login(); err != nil {
+		return nil, err
+	}
+
+	workers, err := client.workers()
+	if err != nil {
+		return nil, err
+	}
+
+	statesToPrune := map[string]bool{}
+	for _, state := range states {
+		statesToPrune[state] = true
+	}
+
+	pruned := []string{}
+	for _, worker := range workers {
+		if !statesToPrune[worker.State] {
+			continue
+		}
+		if _, err := client.stdout.Write([]byte(fmt.Sprintf("Pruning %s worker %s\n", worker.State, worker.Name))); err != nil {
+			return nil, err
+		}
+		if err := client.run("prune-worker", "--worker", worker.Name); err != nil {
+			return nil, err
+		}
+		pruned = append(pruned, worker.Name)
+	}
+
+	return pruned, nil
+}
+
+// WaitForWorkers waits until at least count workers are registered and running
+func (client *Client) WaitForWorkers(count int, timeout time.Duration) error {
+	if err := client.login(); err != nil {
+		return err
+	}
+
+	if _, err := client.stdout.Write([]byte(fmt.Sprintf("Waiting for %d workers to be running\n", count))); err != nil {
+		return err
+	}
+
+	secondsBetweenAttempts := 10
+	deadline := time.Now().Add(timeout)
+	for {
+		workers, err := client.workers()
+		if err != nil {
+			return err
+		}
+
+		running := 0
+		for _, worker := range workers {
+			if worker.State == "running" {
+				running++
+			}
+		}
+		if running >= count {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("only %d of %d workers were running after %s", running, count, timeout)
+		}
+
+		time.Sleep(time.Second * time.Duration(secondsBetweenAttempts))
+	}
+}
+
+// ATCVersion asks the ATC which version of Concourse it is running
+func (client *Client) ATCVersion() (string, error) {
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			// fly logs in with --insecure too, as the deployment may still be using its self-signed cert
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	resp, err := httpClient.Get(strings.TrimSuffix(client.creds.API, "/") + "/api/v1/info")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", client.creds.API, resp.Status)
+	}
+
+	var info struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+
+	return info.Version, nil
+}
+
+// ActivePipelines returns the names of the pipelines that aren't paused
+func (client *Client) ActivePipelines() ([]string, error) {
+	if err := client.login(); err != nil {
+		return nil, err
+	}
+
+	stdoutBuffer := bytes.NewBuffer(nil)
+	cmd := exec.Command(client.tempDir.Path("fly"), "--target", client.creds.Target, "pipelines", "--json")
+	cmd.Env = client.creds.Proxy.Env(util.CommandEnv())
+	cmd.Stdout = stdoutBuffer
+	cmd.Stderr = client.stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var pipelines []struct {
+		Name   string `json:"name"`
+		Paused bool   `json:"paused"`
+	}
+	if err := json.NewDecoder(stdoutBuffer).Decode(&pipelines); err != nil {
+		return nil, err
+	}
+
+	active := []string{}
+	for _, pipeline := range pipelines {
+		if !pipeline.Paused {
+			active = append(active, pipeline.Name)
+		}
+	}
+
+	return active, nil
+}
+
+// PausePipelines pauses the named pipelines, so that no new builds are scheduled in them
+func (client *Client) PausePipelines(names []string) error {
+	if err := client.login(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := client.run("pause-pipeline", "--pipeline", name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnpausePipelines unpauses the named pipelines
+func (client *Client) UnpausePipelines(names []string) error {
+	if err := client.login(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := client.run("unpause-pipeline", "--pipeline", name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LandWorkers lands every running worker, so that no new builds are scheduled on them,
+// and waits for their in-flight builds to finish. Unlike RetireWorkers their registrations are kept
+func (client *Client) LandWorkers(timeout time.Duration) error {
+	if err := client.login(); err != nil {
+		return err
+	}
+
+	workers, err := client.workers()
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for _, worker := range workers {
+		if worker.State != "running" {
+			continue
+		}
+		if _, err = client.stdout.Write([]byte(fmt.Sprintf("Landing worker %s\n", worker.Name))); err != nil {
+			return err
+		}
+		if err = client.run("land-worker", "--worker", worker.Name); err != nil {
+			return err
+		}
+		names = append(names, worker.Name)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, name := range names {
+		if err = client.waitUntilLanded(name, deadline, timeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type worker struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+func (client *Client) workers() ([]worker, error) {
+	stdoutBuffer := bytes.NewBuffer(nil)
+	cmd := exec.Command(client.tempDir.Path("fly"), "--target", client.creds.Target, "workers", "--json")
+	cmd.Env = client.creds.Proxy.Env(util.CommandEnv())
+	cmd.Stdout = stdoutBuffer
+	cmd.Stderr = client.stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var workers []worker
+	if err := json.NewDecoder(stdoutBuffer).Decode(&workers); err != nil {
+		return nil, err
+	}
+
+	return workers, nil
+}
+
+func (client *Client) hasLanded(name string) (bool, error) {
+	workers, err := client.workers()
+	if err != nil {
+		return false, err
+	}
+
+	for _, worker := range workers {
+		if worker.Name == name {
+			return worker.State == "landed", nil
+		}
+	}
+
+	// A worker which is no longer registered has nothing left to drain
+	return true, nil
+}
+
+func (client *Client) waitUntilLanded(name string, deadline time.Time, timeout time.Duration) error {
+	secondsBetweenAttempts := 10
+	for {
+		landed, err := client.hasLanded(name)
+		if err != nil {
+			return err
+		}
+		if landed {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("worker %s did not finish its running builds within %s", name, timeout)
+		}
+
+		time.Sleep(time.Second * time.Duration(secondsBetweenAttempts))
+	}
+}
+
+// SelfUpdatePipeline is the pipeline that keeps the deployment up to date with concourse-up's releases
+const SelfUpdatePipeline = "concourse-up-self-update"
+
+// Build is a build that was running on the deployment
+type Build struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Status       string `json:"status"`
+	TeamName     string `json:"team_name"`
+	PipelineName string `json:"pipeline_name"`
+	JobName      string `json:"job_name"`
+}
+
+func (build Build) String() string {
+	if build.JobName == "" {
+		return fmt.Sprintf("one-off build %d (team %s)", build.ID, build.TeamName)
+	}
+	return fmt.Sprintf("%s/%s #%s (team %s)", build.PipelineName, build.JobName, build.Name, build.TeamName)
+}
+
+// runningBuildsCount is how many of the latest builds are checked for ones that are still running
+const runningBuildsCount = "1000"
+
+// WaitForBuilds waits up to timeout for the builds running now to finish, and returns those still running
+// when it elapses. Builds of the self-update pipeline are ignored, as one of them may be what's waiting
+func (client *Client) WaitForBuilds(timeout time.Duration) ([]Build, error) {
+	if err := client.login(); err != nil {
+		return nil, err
+	}
+
+	running, err := client.runningBuilds()
+	if err != nil {
+		return nil, err
+	}
+	if len(running) == 0 {
+		return nil, nil
+	}
+
+	if _, err = fmt.Fprintf(client.stdout, "Waiting up to %s for %d running builds to finish\n", timeout, len(running)); err != nil {
+		return nil, err
+	}
+
+	secondsBetweenAttempts := 10
+	deadline := time.Now().Add(timeout)
+	for {
+		stillRunning, err := client.runningBuilds()
+		if err != nil {
+			return nil, err
+		}
+
+		// Builds started since the wait began are left alone, so that busy pipelines can't hold it up
+		remaining := []Build{}
+		for _, build := range running {
+			for _, current := range stillRunning {
+				if current.ID == build.ID {
+					remaining = append(remaining, current)
+					break
+				}
+			}
+		}
+		running = remaining
+
+		if len(running) == 0 || time.Now().After(deadline) {
+			return running, nil
+		}
+
+		time.Sleep(time.Second * time.Duration(secondsBetweenAttempts))
+	}
+}
+
+func (client *Client) runningBuilds() ([]Build, error) {
+	stdoutBuffer := bytes.NewBuffer(nil)
+	cmd := exec.Command(client.tempDir.Path("fly"), "--target", client.creds.Target, "builds", "--all-teams", "--count", runningBuildsCount, "--json")
+	cmd.Env = client.creds.Proxy.Env(util.CommandEnv())
+	cmd.Stdout = stdoutBuffer
+	cmd.Stderr = client.stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var builds []Build
+	if err := json.NewDecoder(stdoutBuffer).Decode(&builds); err != nil {
+		return nil, err
+	}
+
+	running := []Build{}
+	for _, build := range builds {
+		if (build.Status == "started" || build.Status == "pending") && build.PipelineName != SelfUpdatePipeline {
+			running = append(running, build)
+		}
+	}
+	return running, nil
+}
+
 func (client *Client) sync() error {
 	return client.run("sync")
 }
 
 func (client *Client) run(args ...string) error {
-	args = append([]string{"--target", client.creds.Target}, args...)
+	return client.runWithTarget(append([]string{"--target", client.creds.Target}, args...)...)
+}
+
+// runWithTarget runs fly with args that give their own --target
+func (client *Client) runWithTarget(args ...string) error {
 	cmd := exec.Command(client.tempDir.Path("fly"), args...)
+	cmd.Env = client.creds.Proxy.Env(util.CommandEnv())
 	cmd.Stdout = client.stdout
 	cmd.Stderr = client.stderr
 	return cmd.Run()
@@ -276,6 +786,9 @@ func (client *Client) buildDefaultPipelineParams(deployArgs *config.DeployArgs,
 		FlagWorkerSize:     deployArgs.WorkerSize,
 		FlagWorkers:        deployArgs.WorkerCount,
 		ConcourseUpVersion: ConcourseUpVersion,
+		ConfigBucketName:   config.ConfigBucket,
+		Proxy:              config.Proxy(),
+		S3Endpoint:         iaas.GlobalS3Endpoint,
 	}, nil
 }
 
@@ -292,6 +805,12 @@ type defaultPipelineParams struct {
 	FlagWorkerSize     string
 	FlagWorkers        int
 	ConcourseUpVersion string
+	// ConfigBucketName is passed on so that self-updates find a config bucket given --config-bucket-name
+	ConfigBucketName string
+	// Proxy is passed on so that self-updates from inside the VPC connect through the same proxy
+	Proxy util.Proxy
+	// S3Endpoint is passed on so that self-updates find the config bucket in the same object store
+	S3Endpoint iaas.S3Endpoint
 }
 
 // Indent is a helper function to indent the field a given number of spaces
@@ -334,6 +853,26 @@ jobs:
       AWS_ACCESS_KEY_ID: "<% .AWSAccessKeyID %>"
       AWS_SECRET_ACCESS_KEY: "<% .AWSSecretAccessKey %>"
       SELF_UPDATE: true
+<%if .ConfigBucketName %>
+      CONFIG_BUCKET_NAME: "<% .ConfigBucketName %>"
+<%end%>
+<%if .Proxy.HTTPProxy %>
+      CONCOURSE_UP_HTTP_PROXY: "<% .Proxy.HTTPProxy %>"
+<%end%>
+<%if .Proxy.HTTPSProxy %>
+      CONCOURSE_UP_HTTPS_PROXY: "<% .Proxy.HTTPSProxy %>"
+<%end%>
+<%if .Proxy.NoProxy %>
+      CONCOURSE_UP_NO_PROXY: "<% .Proxy.NoProxy %>"
+<%end%>
+<%if .S3Endpoint.IsSet %>
+      S3_ENDPOINT: "<% .S3Endpoint.URL %>"
+      S3_FORCE_PATH_STYLE: <% .S3Endpoint.ForcePathStyle %>
+<%end%>
+<%if .S3Endpoint.AccessKeyID %>
+      S3_ACCESS_KEY_ID: "<% .S3Endpoint.AccessKeyID %>"
+      S3_SECRET_ACCESS_KEY: "<% .S3Endpoint.SecretAccessKey %>"
+<%end%>
     config:
       platform: linux
       image_resource:
@@ -375,6 +914,26 @@ jobs:
       AWS_ACCESS_KEY_ID: "<% .AWSAccessKeyID %>"
       AWS_SECRET_ACCESS_KEY: "<% .AWSSecretAccessKey %>"
       SELF_UPDATE: true
+<%if .ConfigBucketName %>
+      CONFIG_BUCKET_NAME: "<% .ConfigBucketName %>"
+<%end%>
+<%if .Proxy.HTTPProxy %>
+      CONCOURSE_UP_HTTP_PROXY: "<% .Proxy.HTTPProxy %>"
+<%end%>
+<%if .Proxy.HTTPSProxy %>
+      CONCOURSE_UP_HTTPS_PROXY: "<% .Proxy.HTTPSProxy %>"
+<%end%>
+<%if .Proxy.NoProxy %>
+      CONCOURSE_UP_NO_PROXY: "<% .Proxy.NoProxy %>"
+<%end%>
+<%if .S3Endpoint.IsSet %>
+      S3_ENDPOINT: "<% .S3Endpoint.URL %>"
+      S3_FORCE_PATH_STYLE: <% .S3Endpoint.ForcePathStyle %>
+<%end%>
+<%if .S3Endpoint.AccessKeyID %>
+      S3_ACCESS_KEY_ID: "<% .S3Endpoint.AccessKeyID %>"
+      S3_SECRET_ACCESS_KEY: "<% .S3Endpoint.SecretAccessKey %>"
+<%end%>
     config:
       platform: linux
       image_resource:
@@ -9,7 +9,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
 // AWSClient is the concrete implementation of IClient on AWS
@@ -39,6 +41,153 @@ func (client *AWSClient) IAAS() string {
 	return "AWS"
 }
 
+// CallerIdentity returns the ARN of the AWS user or role the client is acting as
+func (client *AWSClient) CallerIdentity() (string, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return "", err
+	}
+
+	stsClient := sts.New(sess, &aws.Config{Region: &client.region})
+
+	output, err := stsClient.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.Arn), nil
+}
+
+// InstanceProfileRole returns the ARN of the role of the instance profile with the given ARN
+func (client *AWSClient) InstanceProfileRole(arn string) (string, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return "", err
+	}
+
+	return instanceProfileRole(iam.New(sess, &aws.Config{Region: &client.region}), arn)
+}
+
+func instanceProfileRole(iamClient *iam.IAM, arn string) (string, error) {
+	profileName := arn[strings.LastIndex(arn, "/")+1:]
+	profile, err := iamClient.GetInstanceProfile(&iam.GetInstanceProfileInput{
+		InstanceProfileName: &profileName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not find instance profile %s: %s", arn, err)
+	}
+	if aws.StringValue(profile.InstanceProfile.Arn) != arn {
+		return "", fmt.Errorf("could not find instance profile %s", arn)
+	}
+	if len(profile.InstanceProfile.Roles) == 0 {
+		return "", fmt.Errorf("instance profile %s has no role", arn)
+	}
+
+	return aws.StringValue(profile.InstanceProfile.Roles[0].Arn), nil
+}
+
+// CheckInstanceProfile returns an error if the instance profile with the given ARN
+// doesn't exist, or if its role isn't allowed to perform all of the actions on the resources
+func (client *AWSClient) CheckInstanceProfile(arn string, actions, resources []string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	iamClient := iam.New(sess, &aws.Config{Region: &client.region})
+
+	roleARN, err := instanceProfileRole(iamClient, arn)
+	if err != nil {
+		return err
+	}
+
+	var denied []string
+	err = iamClient.SimulatePrincipalPolicyPages(&iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: &roleARN,
+		ActionNames:     aws.StringSlice(actions),
+		ResourceArns:    aws.StringSlice(resources),
+	}, func(page *iam.SimulatePolicyResponse, lastPage bool) bool {
+		for _, result := range page.EvaluationResults {
+			if aws.StringValue(result.EvalDecision) != iam.PolicyEvaluationDecisionTypeAllowed {
+				denied = append(denied, aws.StringValue(result.EvalActionName))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(denied) > 0 {
+		return fmt.Errorf("instance profile %s is missing permissions for: %s", arn, strings.Join(denied, ", "))
+	}
+
+	return nil
+}
+
+// SupportsDedicatedTenancy returns true if instances of the given type can be run with
+// dedicated tenancy in the availability zone. EC2 has no direct way to ask this, but only
+// such instances have dedicated reserved instance offerings
+func (client *AWSClient) SupportsDedicatedTenancy(instanceType, availabilityZone string) (bool, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return false, err
+	}
+
+	ec2Client := ec2.New(sess, &aws.Config{Region: &client.region})
+
+	output, err := ec2Client.DescribeReservedInstancesOfferings(&ec2.DescribeReservedInstancesOfferingsInput{
+		AvailabilityZone:   &availabilityZone,
+		IncludeMarketplace: aws.Bool(false),
+		InstanceTenancy:    aws.String(ec2.TenancyDedicated),
+		InstanceType:       &instanceType,
+		MaxResults:         aws.Int64(5),
+		ProductDescription: aws.String(ec2.RIProductDescriptionLinuxUnix),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return len(output.ReservedInstancesOfferings) > 0, nil
+}
+
+// CheckStemcellImage returns an error if the AMI doesn't exist in the region, or wasn't built from
+// a BOSH stemcell for the operating system. The AWS CPI describes each image it creates from a
+// stemcell with the stemcell's name, such as bosh-aws-xen-hvm-ubuntu-trusty-go_agent 3586.25
+func (client *AWSClient) CheckStemcellImage(amiID, operatingSystem string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	ec2Client := ec2.New(sess, &aws.Config{Region: &client.region})
+
+	output, err := ec2Client.DescribeImages(&ec2.DescribeImagesInput{
+		ImageIds: []*string{&amiID},
+	})
+	if err != nil {
+		return err
+	}
+	if len(output.Images) == 0 {
+		return fmt.Errorf("AMI %s was not found in %s", amiID, client.region)
+	}
+
+	image := output.Images[0]
+	if aws.StringValue(image.State) != ec2.ImageStateAvailable {
+		return fmt.Errorf("AMI %s is %s, not available", amiID, aws.StringValue(image.State))
+	}
+	if aws.StringValue(image.VirtualizationType) != ec2.VirtualizationTypeHvm || aws.StringValue(image.Architecture) != ec2.ArchitectureValuesX8664 {
+		return fmt.Errorf("AMI %s must be an x86_64 HVM image", amiID)
+	}
+
+	stemcellName := fmt.Sprintf("-%s-go_agent", operatingSystem)
+	if !strings.Contains(aws.StringValue(image.Description), stemcellName) && !strings.Contains(aws.StringValue(image.Name), stemcellName) {
+		return fmt.Errorf("AMI %s is not a BOSH stemcell image for %s. Its name or description must contain the name of the stemcell it was built from, eg bosh-aws-xen-hvm%s", amiID, operatingSystem, stemcellName)
+	}
+
+	return nil
+}
+
 // DeleteVMsInVPC deletes all the VMs in the given VPC
 func (client *AWSClient) DeleteVMsInVPC(vpcID string) error {
 	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
@@ -81,6 +230,253 @@ func (client *AWSClient) DeleteVMsInVPC(vpcID string) error {
 	return err
 }
 
+// DeleteVMsInSecurityGroups deletes the VMs in any of the given security groups. It's used instead of
+// DeleteVMsInVPC when the deployment shares a VPC, whose other VMs mustn't be touched
+func (client *AWSClient) DeleteVMsInSecurityGroups(groupIDs []string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	ec2Client := ec2.New(sess, &aws.Config{Region: &client.region})
+
+	resp, err := ec2Client.DescribeInstances(&ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   aws.String("instance.group-id"),
+				Values: aws.StringSlice(groupIDs),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	instancesToTerminate := []*string{}
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			fmt.Printf("Terminating instance %s\n", *instance.InstanceId)
+			instancesToTerminate = append(instancesToTerminate, instance.InstanceId)
+		}
+	}
+
+	if len(instancesToTerminate) == 0 {
+		return nil
+	}
+
+	_, err = ec2Client.TerminateInstances(&ec2.TerminateInstancesInput{
+		InstanceIds: instancesToTerminate,
+	})
+	return err
+}
+
+// DeleteVMWithPrivateIP deletes the VM with the given private IP in the VPC, and waits for it to be
+// terminated so that the IP can be given to a new VM
+func (client *AWSClient) DeleteVMWithPrivateIP(vpcID, ip string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	ec2Client := ec2.New(sess, &aws.Config{Region: &client.region})
+
+	resp, err := ec2Client.DescribeInstances(&ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   aws.String("vpc-id"),
+				Values: aws.StringSlice([]string{vpcID}),
+			},
+			&ec2.Filter{
+				Name:   aws.String("private-ip-address"),
+				Values: aws.StringSlice([]string{ip}),
+			},
+			&ec2.Filter{
+				Name:   aws.String("instance-state-name"),
+				Values: aws.StringSlice([]string{"pending", "running", "stopping", "stopped", "shutting-down"}),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	instancesToTerminate := []*string{}
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			fmt.Printf("Terminating instance %s\n", *instance.InstanceId)
+			instancesToTerminate = append(instancesToTerminate, instance.InstanceId)
+		}
+	}
+
+	if len(instancesToTerminate) == 0 {
+		return nil
+	}
+
+	if _, err = ec2Client.TerminateInstances(&ec2.TerminateInstancesInput{
+		InstanceIds: instancesToTerminate,
+	}); err != nil {
+		return err
+	}
+
+	return ec2Client.WaitUntilInstanceTerminated(&ec2.DescribeInstancesInput{
+		InstanceIds: instancesToTerminate,
+	})
+}
+
+// InstanceIPv6Address returns the IPv6 address of the running VM with the given private IP in the VPC, or
+// an empty string if it doesn't have one
+func (client *AWSClient) InstanceIPv6Address(vpcID, privateIP string) (string, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return "", err
+	}
+
+	ec2Client := ec2.New(sess, &aws.Config{Region: &client.region})
+
+	resp, err := ec2Client.DescribeInstances(&ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   aws.String("vpc-id"),
+				Values: aws.StringSlice([]string{vpcID}),
+			},
+			&ec2.Filter{
+				Name:   aws.String("private-ip-address"),
+				Values: aws.StringSlice([]string{privateIP}),
+			},
+			&ec2.Filter{
+				Name:   aws.String("instance-state-name"),
+				Values: aws.StringSlice([]string{"running"}),
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			for _, networkInterface := range instance.NetworkInterfaces {
+				if aws.StringValue(networkInterface.PrivateIpAddress) != privateIP {
+					continue
+				}
+				for _, address := range networkInterface.Ipv6Addresses {
+					return aws.StringValue(address.Ipv6Address), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// Subnet is an existing subnet that a deployment can be put in
+type Subnet struct {
+	ID               string
+	VPCID            string
+	CIDR             string
+	AvailabilityZone string
+}
+
+// DescribeSubnets returns the subnets with the given IDs, in the same order
+func (client *AWSClient) DescribeSubnets(ids []string) ([]Subnet, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return nil, err
+	}
+
+	ec2Client := ec2.New(sess, &aws.Config{Region: &client.region})
+
+	output, err := ec2Client.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		SubnetIds: aws.StringSlice(ids),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]Subnet{}
+	for _, subnet := range output.Subnets {
+		found[aws.StringValue(subnet.SubnetId)] = Subnet{
+			ID:               aws.StringValue(subnet.SubnetId),
+			VPCID:            aws.StringValue(subnet.VpcId),
+			CIDR:             aws.StringValue(subnet.CidrBlock),
+			AvailabilityZone: aws.StringValue(subnet.AvailabilityZone),
+		}
+	}
+
+	subnets := []Subnet{}
+	for _, id := range ids {
+		subnet, ok := found[id]
+		if !ok {
+			return nil, fmt.Errorf("subnet %s was not found in %s", id, client.region)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// VPCCIDR returns the primary IPv4 address range of the VPC
+func (client *AWSClient) VPCCIDR(vpcID string) (string, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return "", err
+	}
+
+	ec2Client := ec2.New(sess, &aws.Config{Region: &client.region})
+
+	output, err := ec2Client.DescribeVpcs(&ec2.DescribeVpcsInput{
+		VpcIds: []*string{&vpcID},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(output.Vpcs) == 0 {
+		return "", fmt.Errorf("VPC %s was not found in %s", vpcID, client.region)
+	}
+
+	return aws.StringValue(output.Vpcs[0].CidrBlock), nil
+}
+
+// SetTerminationProtection enables or disables termination protection on the VMs
+// in the given VPC that have one of the given public IPs
+func (client *AWSClient) SetTerminationProtection(vpcID string, publicIPs []string, enabled bool) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	ec2Client := ec2.New(sess, &aws.Config{Region: &client.region})
+
+	resp, err := ec2Client.DescribeInstances(&ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{&vpcID},
+			},
+			&ec2.Filter{
+				Name:   aws.String("ip-address"),
+				Values: aws.StringSlice(publicIPs),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			_, err = ec2Client.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+				InstanceId:            instance.InstanceId,
+				DisableApiTermination: &ec2.AttributeBooleanValue{Value: aws.Bool(enabled)},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // FindLongestMatchingHostedZone finds the longest hosted zone that matches the given subdomain
 func (client *AWSClient) FindLongestMatchingHostedZone(subdomain string) (string, string, error) {
 	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
@@ -1,17 +1,43 @@
 package iaas
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
 
 // IClient represents actions taken against AWS
 type IClient interface {
+	BucketRegion(name string) (string, error)
+	CallerIdentity() (string, error)
+	CheckInstanceProfile(arn string, actions, resources []string) error
+	CheckDBSnapshot(snapshotID string) (string, error)
+	CheckStemcellImage(amiID, operatingSystem string) error
+	CreateDBSnapshot(dbARN, snapshotID string) error
 	DeleteFile(bucket, path string) error
+	DeleteLockTable(name string) error
 	DeleteVersionedBucket(name string) error
 	DeleteVMsInVPC(vpcID string) error
+	DeleteVMsInSecurityGroups(groupIDs []string) error
+	DeleteVMWithPrivateIP(vpcID, ip string) error
+	DescribeSubnets(ids []string) ([]Subnet, error)
 	EnsureBucketExists(name string) error
 	EnsureFileExists(bucket, path string, defaultContents []byte) ([]byte, bool, error)
+	EnsureLockTable(name string) error
 	FindLongestMatchingHostedZone(subdomain string) (string, string, error)
+	InstanceIPv6Address(vpcID, privateIP string) (string, error)
+	InstanceProfileRole(arn string) (string, error)
 	HasFile(bucket, path string) (bool, error)
+	ListBuckets() ([]string, error)
+	ListFiles(bucket, prefix string) ([]string, error)
 	LoadFile(bucket, path string) ([]byte, error)
+	SetBucketEncryption(name, kmsKeyID string) error
+	SetBucketReplication(name, roleARN, destinationBucket, replicaKMSKeyARN string) error
+	SetBucketTags(name string, previous, tags map[string]string) error
+	SetTerminationProtection(vpcID string, publicIPs []string, enabled bool) error
+	SupportsDedicatedTenancy(instanceType, availabilityZone string) (bool, error)
+	VPCCIDR(vpcID string) (string, error)
 	WriteFile(bucket, path string, contents []byte) error
 	Region() string
 	IAAS() string
@@ -25,3 +51,20 @@ func New(iaas string, region string) (IClient, error) {
 
 	return nil, fmt.Errorf("IAAS not supported: %s", iaas)
 }
+
+// IsUnreachable returns true if err means that AWS couldn't be reached or failed to answer, as when
+// a region is down, rather than that it refused the request, eg as it was denied, throttled or for
+// something that doesn't exist
+func IsUnreachable(err error) bool {
+	if failure, ok := err.(awserr.RequestFailure); ok {
+		return failure.StatusCode() >= 500 && failure.Code() != "SlowDown"
+	}
+	if awsErr, ok := err.(awserr.Error); ok {
+		// The SDK gives requests that couldn't be sent the code RequestError
+		switch awsErr.Code() {
+		case "RequestError", request.ErrCodeResponseTimeout, request.ErrCodeRead:
+			return true
+		}
+	}
+	return false
+}
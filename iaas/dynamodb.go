@@ -0,0 +1,71 @@
+package iaas
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// EnsureLockTable creates the DynamoDB table Terraform locks its state with, unless it already exists.
+// Terraform's S3 backend requires the table to have a string hash key called LockID
+func (client *AWSClient) EnsureLockTable(name string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	dynamoClient := dynamodb.New(sess, &aws.Config{Region: &client.region})
+
+	_, err = dynamoClient.DescribeTable(&dynamodb.DescribeTableInput{
+		TableName: aws.String(name),
+	})
+	if err == nil {
+		return nil
+	}
+	if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != dynamodb.ErrCodeResourceNotFoundException {
+		return err
+	}
+
+	// Locks are only taken while Terraform runs, so the table needs the least capacity there is
+	_, err = dynamoClient.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(name),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{{
+			AttributeName: aws.String("LockID"),
+			AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+		}},
+		KeySchema: []*dynamodb.KeySchemaElement{{
+			AttributeName: aws.String("LockID"),
+			KeyType:       aws.String(dynamodb.KeyTypeHash),
+		}},
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(1),
+			WriteCapacityUnits: aws.Int64(1),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return dynamoClient.WaitUntilTableExists(&dynamodb.DescribeTableInput{
+		TableName: aws.String(name),
+	})
+}
+
+// DeleteLockTable deletes the DynamoDB table Terraform locks its state with, if it exists
+func (client *AWSClient) DeleteLockTable(name string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	dynamoClient := dynamodb.New(sess, &aws.Config{Region: &client.region})
+
+	_, err = dynamoClient.DeleteTable(&dynamodb.DeleteTableInput{
+		TableName: aws.String(name),
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+		return nil
+	}
+	return err
+}
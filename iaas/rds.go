@@ -0,0 +1,68 @@
+package iaas
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+// CreateDBSnapshot snapshots the RDS instance with the given ARN and waits for the snapshot to be available
+func (client *AWSClient) CreateDBSnapshot(dbARN, snapshotID string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	rdsClient := rds.New(sess, &aws.Config{Region: &client.region})
+
+	_, err = rdsClient.CreateDBSnapshot(&rds.CreateDBSnapshotInput{
+		DBInstanceIdentifier: aws.String(dbInstanceIdentifier(dbARN)),
+		DBSnapshotIdentifier: aws.String(snapshotID),
+	})
+	if err != nil {
+		return err
+	}
+
+	return rdsClient.WaitUntilDBSnapshotAvailable(&rds.DescribeDBSnapshotsInput{
+		DBSnapshotIdentifier: aws.String(snapshotID),
+	})
+}
+
+// CheckDBSnapshot checks the RDS snapshot exists and is available to restore from, and returns its engine version
+func (client *AWSClient) CheckDBSnapshot(snapshotID string) (string, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return "", err
+	}
+
+	rdsClient := rds.New(sess, &aws.Config{Region: &client.region})
+
+	output, err := rdsClient.DescribeDBSnapshots(&rds.DescribeDBSnapshotsInput{
+		DBSnapshotIdentifier: aws.String(snapshotID),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(output.DBSnapshots) == 0 {
+		return "", fmt.Errorf("RDS snapshot %s was not found in %s", snapshotID, client.region)
+	}
+
+	snapshot := output.DBSnapshots[0]
+	if aws.StringValue(snapshot.Status) != "available" {
+		return "", fmt.Errorf("RDS snapshot %s is %s, not available", snapshotID, aws.StringValue(snapshot.Status))
+	}
+	if aws.StringValue(snapshot.Engine) != "postgres" {
+		return "", fmt.Errorf("RDS snapshot %s is of a %s database, not postgres", snapshotID, aws.StringValue(snapshot.Engine))
+	}
+
+	return aws.StringValue(snapshot.EngineVersion), nil
+}
+
+// dbInstanceIdentifier returns the identifier of the RDS instance from its ARN,
+// which is of the form arn:aws:rds:<region>:<account>:db:<identifier>
+func dbInstanceIdentifier(dbARN string) string {
+	return dbARN[strings.LastIndex(dbARN, ":")+1:]
+}
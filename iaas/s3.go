@@ -2,7 +2,10 @@ package iaas
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
+	"net/url"
+	"sort"
 
 	"time"
 
@@ -30,8 +33,25 @@ const (
 
 	// Returned when calling HEAD on non-existant bucket or object
 	awsErrCodeNotFound = "NotFound"
+
+	// Returned when calling HEAD on a bucket the caller has no access to
+	awsErrCodeForbidden = "Forbidden"
+
+	// ErrCodeBucketAlreadyExists for service response error code
+	// "BucketAlreadyExists".
+	//
+	// The requested bucket name is not available. The bucket namespace is shared
+	// by all users of the system.
+	awsErrCodeBucketAlreadyExists = "BucketAlreadyExists"
+
+	// ErrCodeBucketAlreadyOwnedByYou for service response error code
+	// "BucketAlreadyOwnedByYou".
+	awsErrCodeBucketAlreadyOwnedByYou = "BucketAlreadyOwnedByYou"
 )
 
+// ErrBucketNotOwned is returned when a bucket exists but belongs to another AWS account
+var ErrBucketNotOwned = errors.New("bucket is owned by another AWS account")
+
 // DeleteVersionedBucket deletes and empties a versioned bucket
 func (client *AWSClient) DeleteVersionedBucket(name string) error {
 	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
@@ -39,7 +59,7 @@ func (client *AWSClient) DeleteVersionedBucket(name string) error {
 		return err
 	}
 
-	s3Client := s3.New(sess, &aws.Config{Region: &client.region})
+	s3Client := client.s3Client(sess)
 
 	// Delete all objects
 	objects := []*s3.Object{}
@@ -76,7 +96,7 @@ func (client *AWSClient) EnsureBucketExists(name string) error {
 		return err
 	}
 
-	s3Client := s3.New(sess, &aws.Config{Region: &client.region})
+	s3Client := client.s3Client(sess)
 
 	_, err = s3Client.HeadBucket(&s3.HeadBucketInput{Bucket: &name})
 	if err == nil {
@@ -84,6 +104,9 @@ func (client *AWSClient) EnsureBucketExists(name string) error {
 	}
 
 	awsErrCode := err.(awserr.Error).Code()
+	if awsErrCode == awsErrCodeForbidden {
+		return ErrBucketNotOwned
+	}
 	if awsErrCode != awsErrCodeNotFound && awsErrCode != awsErrCodeNoSuchBucket {
 		return err
 	}
@@ -100,6 +123,17 @@ func (client *AWSClient) EnsureBucketExists(name string) error {
 	}
 
 	_, err = s3Client.CreateBucket(bucketInput)
+	if err == nil {
+		return nil
+	}
+
+	// Another deploy may have created the bucket since it was checked for
+	switch err.(awserr.Error).Code() {
+	case awsErrCodeBucketAlreadyOwnedByYou:
+		return nil
+	case awsErrCodeBucketAlreadyExists:
+		return ErrBucketNotOwned
+	}
 	return err
 }
 
@@ -109,7 +143,7 @@ func (client *AWSClient) WriteFile(bucket, path string, contents []byte) error {
 	if err != nil {
 		return err
 	}
-	s3Client := s3.New(sess, &aws.Config{Region: &client.region})
+	s3Client := client.s3Client(sess)
 
 	_, err = s3Client.PutObject(&s3.PutObjectInput{
 		Bucket: &bucket,
@@ -125,7 +159,7 @@ func (client *AWSClient) HasFile(bucket, path string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	s3Client := s3.New(sess, &aws.Config{Region: &client.region})
+	s3Client := client.s3Client(sess)
 
 	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: &bucket, Key: &path})
 	if err != nil {
@@ -147,7 +181,7 @@ func (client *AWSClient) EnsureFileExists(bucket, path string, defaultContents [
 		return nil, false, err
 	}
 
-	s3Client := s3.New(sess, &aws.Config{Region: &client.region})
+	s3Client := client.s3Client(sess)
 
 	output, err := s3Client.GetObject(&s3.GetObjectInput{Bucket: &bucket, Key: &path})
 	if err == nil {
@@ -186,7 +220,7 @@ func (client *AWSClient) LoadFile(bucket, path string) ([]byte, error) {
 		return nil, err
 	}
 
-	s3Client := s3.New(sess, &aws.Config{Region: &client.region})
+	s3Client := client.s3Client(sess)
 
 	output, err := s3Client.GetObject(&s3.GetObjectInput{Bucket: &bucket, Key: &path})
 	if err != nil {
@@ -196,6 +230,31 @@ func (client *AWSClient) LoadFile(bucket, path string) ([]byte, error) {
 	return ioutil.ReadAll(output.Body)
 }
 
+// ListFiles lists the paths of the objects in the bucket that start with prefix
+func (client *AWSClient) ListFiles(bucket, prefix string) ([]string, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return nil, err
+	}
+
+	s3Client := client.s3Client(sess)
+
+	paths := []string{}
+	err = s3Client.ListObjectsPages(&s3.ListObjectsInput{Bucket: &bucket, Prefix: &prefix},
+		func(output *s3.ListObjectsOutput, _ bool) bool {
+			for _, object := range output.Contents {
+				paths = append(paths, aws.StringValue(object.Key))
+			}
+
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
 // DeleteFile deletes a file from S3
 func (client *AWSClient) DeleteFile(bucket, path string) error {
 	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
@@ -203,7 +262,7 @@ func (client *AWSClient) DeleteFile(bucket, path string) error {
 		return err
 	}
 
-	s3Client := s3.New(sess, &aws.Config{Region: &client.region})
+	s3Client := client.s3Client(sess)
 	_, err = s3Client.DeleteObject(&s3.DeleteObjectInput{
 		Bucket: &bucket,
 		Key:    &path,
@@ -211,3 +270,216 @@ func (client *AWSClient) DeleteFile(bucket, path string) error {
 
 	return err
 }
+
+// SetBucketTags sets tags on a bucket and removes the keys of previous that tags no longer has. Other tags,
+// eg ones added outside concourse-up, are left as they were, as S3 only lets the whole tag set be replaced
+func (client *AWSClient) SetBucketTags(name string, previous, tags map[string]string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	s3Client := client.s3Client(sess)
+	merged := map[string]string{}
+	existing, err := s3Client.GetBucketTagging(&s3.GetBucketTaggingInput{
+		Bucket: &name,
+	})
+	if err != nil {
+		// S3 reports a bucket without tags as an error
+		if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != "NoSuchTagSet" {
+			return err
+		}
+	} else {
+		for _, tag := range existing.TagSet {
+			merged[*tag.Key] = *tag.Value
+		}
+	}
+
+	for key := range previous {
+		delete(merged, key)
+	}
+	for key, value := range tags {
+		merged[key] = value
+	}
+
+	if len(merged) == 0 {
+		_, err = s3Client.DeleteBucketTagging(&s3.DeleteBucketTaggingInput{
+			Bucket: &name,
+		})
+		return err
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tagSet := []*s3.Tag{}
+	for _, key := range keys {
+		tagSet = append(tagSet, &s3.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(merged[key]),
+		})
+	}
+	_, err = s3Client.PutBucketTagging(&s3.PutBucketTaggingInput{
+		Bucket:  &name,
+		Tagging: &s3.Tagging{TagSet: tagSet},
+	})
+
+	return err
+}
+
+// SetBucketReplication replicates every object written to the named bucket to destinationBucket, which must be
+// versioned, using the IAM role roleARN. Versioning is turned on for the named bucket, as S3 requires it for
+// replication. An empty roleARN stops replication, leaving the copies already made in destinationBucket.
+// S3 skips KMS-encrypted objects unless replicaKMSKeyARN is given, a key in destinationBucket's region that
+// the copies are encrypted with
+func (client *AWSClient) SetBucketReplication(name, roleARN, destinationBucket, replicaKMSKeyARN string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	s3Client := client.s3Client(sess)
+	if roleARN == "" {
+		_, err = s3Client.DeleteBucketReplication(&s3.DeleteBucketReplicationInput{
+			Bucket: &name,
+		})
+		return err
+	}
+
+	_, err = s3Client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: &name,
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	rule := &s3.ReplicationRule{
+		ID:     aws.String("concourse-up-backup"),
+		Prefix: aws.String(""),
+		Status: aws.String(s3.ReplicationRuleStatusEnabled),
+		Destination: &s3.Destination{
+			Bucket: aws.String("arn:aws:s3:::" + destinationBucket),
+		},
+	}
+	if replicaKMSKeyARN != "" {
+		rule.SourceSelectionCriteria = &s3.SourceSelectionCriteria{
+			SseKmsEncryptedObjects: &s3.SseKmsEncryptedObjects{
+				Status: aws.String(s3.SseKmsEncryptedObjectsStatusEnabled),
+			},
+		}
+		rule.Destination.EncryptionConfiguration = &s3.EncryptionConfiguration{
+			ReplicaKmsKeyID: &replicaKMSKeyARN,
+		}
+	}
+
+	_, err = s3Client.PutBucketReplication(&s3.PutBucketReplicationInput{
+		Bucket: &name,
+		ReplicationConfiguration: &s3.ReplicationConfiguration{
+			Role:  &roleARN,
+			Rules: []*s3.ReplicationRule{rule},
+		},
+	})
+	return err
+}
+
+// SetBucketEncryption encrypts the objects written to the named bucket with the KMS key kmsKeyID, which may
+// be a key ID, alias or ARN, and re-encrypts the objects already in it. An empty kmsKeyID goes back to
+// encrypting them with S3's own keys. Reads are decrypted by S3, so callers can't tell the difference
+func (client *AWSClient) SetBucketEncryption(name, kmsKeyID string) error {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return err
+	}
+
+	s3Client := client.s3Client(sess)
+
+	encryption := &s3.ServerSideEncryptionByDefault{
+		SSEAlgorithm: aws.String(s3.ServerSideEncryptionAes256),
+	}
+	if kmsKeyID != "" {
+		encryption = &s3.ServerSideEncryptionByDefault{
+			SSEAlgorithm:   aws.String(s3.ServerSideEncryptionAwsKms),
+			KMSMasterKeyID: &kmsKeyID,
+		}
+	}
+	_, err = s3Client.PutBucketEncryption(&s3.PutBucketEncryptionInput{
+		Bucket: &name,
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{
+				{ApplyServerSideEncryptionByDefault: encryption},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// The default only applies to new objects, so copy each existing object over itself to re-encrypt it
+	objects := []*s3.Object{}
+	err = s3Client.ListObjectsPages(&s3.ListObjectsInput{Bucket: &name},
+		func(output *s3.ListObjectsOutput, _ bool) bool {
+			objects = append(objects, output.Contents...)
+
+			return true
+		})
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		_, err = s3Client.CopyObject(&s3.CopyObjectInput{
+			Bucket:               &name,
+			Key:                  object.Key,
+			CopySource:           aws.String(name + "/" + url.PathEscape(aws.StringValue(object.Key))),
+			ServerSideEncryption: encryption.SSEAlgorithm,
+			SSEKMSKeyId:          encryption.KMSMasterKeyID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListBuckets lists the names of the account's buckets in every region
+func (client *AWSClient) ListBuckets() ([]string, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return nil, err
+	}
+
+	s3Client := client.s3Client(sess)
+	output, err := s3Client.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, bucket := range output.Buckets {
+		names = append(names, aws.StringValue(bucket.Name))
+	}
+	return names, nil
+}
+
+// BucketRegion returns the region the named bucket is in
+func (client *AWSClient) BucketRegion(name string) (string, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithCredentialsChainVerboseErrors(true))
+	if err != nil {
+		return "", err
+	}
+
+	s3Client := client.s3Client(sess)
+	output, err := s3Client.GetBucketLocation(&s3.GetBucketLocationInput{Bucket: &name})
+	if err != nil {
+		return "", err
+	}
+
+	return s3.NormalizeBucketLocation(aws.StringValue(output.LocationConstraint)), nil
+}
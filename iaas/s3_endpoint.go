@@ -0,0 +1,41 @@
+package iaas
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3Endpoint is an S3-compatible object store, eg MinIO, that config buckets are kept in instead of S3
+type S3Endpoint struct {
+	URL string
+	// ForcePathStyle addresses buckets as paths on the endpoint rather than as its subdomains
+	ForcePathStyle bool
+	// AccessKeyID and SecretAccessKey are the object store's credentials. When they're empty the AWS
+	// credentials are used
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// GlobalS3Endpoint is the object store given with the global --s3-endpoint flags. Its URL is empty when
+// config buckets are kept in S3
+var GlobalS3Endpoint S3Endpoint
+
+// IsSet returns true if config buckets are kept outside S3
+func (e S3Endpoint) IsSet() bool {
+	return e.URL != ""
+}
+
+// s3Client returns a client for the config buckets, which talks to the S3-compatible object store when one is given
+func (client *AWSClient) s3Client(sess *session.Session) *s3.S3 {
+	conf := &aws.Config{Region: &client.region}
+	if GlobalS3Endpoint.IsSet() {
+		conf.Endpoint = aws.String(GlobalS3Endpoint.URL)
+		conf.S3ForcePathStyle = aws.Bool(GlobalS3Endpoint.ForcePathStyle)
+		if GlobalS3Endpoint.AccessKeyID != "" {
+			conf.Credentials = credentials.NewStaticCredentials(GlobalS3Endpoint.AccessKeyID, GlobalS3Endpoint.SecretAccessKey, "")
+		}
+	}
+	return s3.New(sess, conf)
+}
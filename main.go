@@ -27,6 +27,7 @@ func main() {
 	app.Version = ConcourseUpVersion
 	app.Commands = commands.Commands
 	app.Flags = commands.GlobalFlags
+	app.Before = commands.ApplyGlobalFlags
 	cli.AppHelpTemplate = fmt.Sprintf(`%s
 
 See 'concourse-up help <command>' to read about a specific command.
@@ -84,8 +84,24 @@ var _ = Describe("concourse-up", func() {
 		Expect(err).ToNot(HaveOccurred(), "Error running CLI: "+cliPath)
 		Eventually(session).Should(Exit(0))
 		Expect(session.Out).To(Say("Concourse-Up - A CLI tool to deploy Concourse CI"))
-		Expect(session.Out).To(Say("deploy, d   Deploys or updates a Concourse"))
-		Expect(session.Out).To(Say("destroy, x  Destroys a Concourse"))
+		Expect(session.Out).To(Say(`deploy, d\s+Deploys or updates a Concourse`))
+		Expect(session.Out).To(Say(`destroy, x\s+Destroys a Concourse`))
+		Expect(session.Out).To(Say(`prune-workers\s+Removes stale worker registrations from a Concourse`))
+		Expect(session.Out).To(Say(`promote\s+Promotes a disaster recovery standby to a full Concourse, taking over from its primary`))
+		Expect(session.Out).To(Say(`events\s+Lists the operations that have been performed on a deployment`))
+		Expect(session.Out).To(Say(`export-bundle\s+Exports a deployment's config and state to an encrypted bundle`))
+		Expect(session.Out).To(Say(`import-bundle\s+Imports a deployment's config and state from a bundle into a new config bucket`))
+		Expect(session.Out).To(Say(`check-upgrade\s+Reports whether newer versions are available than those deployed`))
+		Expect(session.Out).To(Say(`status\s+Reports whether a Concourse is reachable and its workers are running`))
+		Expect(session.Out).To(Say(`adopt-dns\s+Moves a deployment reached by its IP address onto a domain, redeploying only the web node`))
+		Expect(session.Out).To(Say(`run-errand\s+Runs a BOSH errand in a Concourse deployment`))
+		Expect(session.Out).To(Say(`drain\s+Pauses pipelines and lands workers before maintenance on a Concourse`))
+		Expect(session.Out).To(Say(`resume\s+Restarts the workers and unpauses the pipelines of a drained Concourse`))
+		Expect(session.Out).To(Say(`renew-certs\s+Renews the Concourse and BOSH director certificates that are due to expire`))
+		Expect(session.Out).To(Say(`restore\s+Recreates a deployment's database from an RDS snapshot and redeploys Concourse on it`))
+		Expect(session.Out).To(Say(`scale-workers\s+Changes the number or size of a Concourse's workers without redeploying the rest of it`))
+		Expect(session.Out).To(Say(`set-dns-weight\s+Changes the weight of a deployment's Route53 record, for a blue/green cutover`))
+		Expect(session.Out).To(Say(`self-check\s+Checks this concourse-up binary is intact, and can download and run the CLIs it uses`))
 	})
 
 	Context("When a compile-time variable is missing", func() {
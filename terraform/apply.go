@@ -3,6 +3,8 @@ package terraform
 import (
 	"io"
 	"os/exec"
+
+	"github.com/EngineerBetter/concourse-up/util"
 )
 
 // Apply takes a terraform config and applies it
@@ -17,8 +19,15 @@ func (client *Client) Apply(dryrun bool) error {
 	}, client.stdout)
 }
 
-// Destroy destroys the given terraform config
-func (client *Client) Destroy() error {
+// Destroy destroys the given terraform config, or only plans destroying it in a dry run
+func (client *Client) Destroy(dryrun bool) error {
+	if dryrun {
+		return client.terraform([]string{
+			"plan",
+			"-destroy",
+			"-input=false",
+		}, client.stdout)
+	}
 	return client.terraform([]string{
 		"destroy",
 		"-force",
@@ -28,6 +37,7 @@ func (client *Client) Destroy() error {
 func (client *Client) terraform(args []string, stdout io.Writer) error {
 	cmd := exec.Command(client.tempDir.Path("terraform"), args...)
 	cmd.Dir = client.configDir
+	cmd.Env = util.CommandEnv()
 	cmd.Stdout = stdout
 	cmd.Stderr = client.stderr
 	return cmd.Run()
@@ -3,6 +3,18 @@ terraform {
 		bucket = "<% .ConfigBucket %>"
 		key    = "<% .TFStatePath %>"
 		region = "<% .Region %>"
+<%if .S3Endpoint.IsSet %>
+		endpoint = "<% .S3Endpoint.URL %>"
+		force_path_style = <% .S3Endpoint.ForcePathStyle %>
+		skip_credentials_validation = true
+<%if .S3Endpoint.AccessKeyID %>
+		access_key = "<% .S3Endpoint.AccessKeyID %>"
+		secret_key = "<% .S3Endpoint.SecretAccessKey %>"
+<%end%>
+<%end%>
+<%if .TFLockTable %>
+		dynamodb_table = "<% .TFLockTable %>"
+<%end%>
 	}
 }
 
@@ -61,6 +73,39 @@ variable "multi_az_rds" {
   default = <%if .MultiAZRDS %>true<%else%>false<%end%>
 }
 
+variable "rds_apply_immediately" {
+  type = "string"
+  default = <%if .RDSApplyImmediately %>true<%else%>false<%end%>
+}
+
+# The variables below are left at their defaults by concourse-up, and can be set with --tf-vars-file
+variable "rds_allocated_storage" {
+  type = "string"
+  default = "10"
+}
+
+variable "rds_backup_retention_period" {
+  type = "string"
+  default = "1"
+}
+
+# Set from --tenancy, which the VMs' tenancy has to agree with
+variable "instance_tenancy" {
+  type = "string"
+  default = "<%if eq .InstanceTenancy "dedicated" %>dedicated<%else%>default<%end%>"
+}
+
+# Each rule is a map of from_port, to_port, protocol and cidr
+variable "atc_extra_ingress_rules" {
+  type = "list"
+  default = []
+}
+
+variable "internal_cidrs" {
+  type = "list"
+  default = [<%if .IsolatedWorkers %>"<% .Network.PublicSubnet.CIDR %>", "<% .Network.PrivateSubnet.CIDR %>"<%else%>"<% .Network.VPCCIDR %>"<%end%>]
+}
+
 <%if .HostedZoneID %>
 variable "hosted_zone_id" {
   type = "string"
@@ -91,9 +136,13 @@ resource "aws_s3_bucket" "blobstore" {
     Name = "${var.deployment}"
     concourse-up-project = "${var.project}"
     concourse-up-component = "bosh"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
+<%if not .DirectorInstanceProfile %>
 resource "aws_iam_user" "blobstore" {
   name = "${var.deployment}-${var.region}-blobstore"
 }
@@ -155,14 +204,23 @@ resource "aws_iam_user_policy" "bosh" {
 }
 EOF
 }
+<%end%>
 
+<%if not .ExistingNetwork %>
 resource "aws_vpc" "default" {
-  cidr_block = "10.0.0.0/16"
+  cidr_block = "<% .Network.VPCCIDR %>"
+  instance_tenancy = "${var.instance_tenancy}"
+<%if .EnableIPv6 %>
+  assign_generated_ipv6_cidr_block = true
+<%end%>
 
   tags {
     Name = "${var.deployment}"
     concourse-up-project = "${var.project}"
     concourse-up-component = "bosh"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
@@ -173,6 +231,9 @@ resource "aws_internet_gateway" "default" {
     Name = "${var.deployment}"
     concourse-up-project = "${var.project}"
     concourse-up-component = "bosh"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
@@ -182,6 +243,20 @@ resource "aws_route" "internet_access" {
   gateway_id             = "${aws_internet_gateway.default.id}"
 }
 
+<%if .EnableIPv6 %>
+resource "aws_route" "internet_access_ipv6" {
+  route_table_id              = "${aws_vpc.default.main_route_table_id}"
+  destination_ipv6_cidr_block = "::/0"
+  gateway_id                  = "${aws_internet_gateway.default.id}"
+}
+
+# IPv6 addresses are all public, so the private subnets reach the internet through an
+# egress-only gateway, which like the NAT gateway doesn't allow connections in
+resource "aws_egress_only_internet_gateway" "default" {
+  vpc_id = "${aws_vpc.default.id}"
+}
+<%end%>
+
  resource "aws_nat_gateway" "default" {
   allocation_id = "${aws_eip.nat.id}"
   subnet_id     = "${aws_subnet.public.id}"
@@ -196,37 +271,66 @@ resource "aws_route_table" "private" {
     cidr_block = "0.0.0.0/0"
     nat_gateway_id = "${aws_nat_gateway.default.id}"
   }
+<%if .EnableIPv6 %>
+  route {
+    ipv6_cidr_block        = "::/0"
+    egress_only_gateway_id = "${aws_egress_only_internet_gateway.default.id}"
+  }
+<%end%>
+<%range .PeeringConnections %><%if .RoutesFrom "private" %>
+  route {
+    cidr_block                = "<% .PeerCIDR %>"
+    vpc_peering_connection_id = "${aws_vpc_peering_connection.<% .PeerVPCID %>.id}"
+  }
+<%end%><%end%>
 
   tags {
     Name = "${var.deployment}-private"
     concourse-up-project = "${var.project}"
     concourse-up-component = "bosh"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
 resource "aws_subnet" "public" {
   vpc_id                  = "${aws_vpc.default.id}"
   availability_zone       = "${var.availability_zone}"
-  cidr_block              = "10.0.0.0/24"
+  cidr_block              = "<% .Network.PublicSubnet.CIDR %>"
+<%if .EnableIPv6 %>
+  ipv6_cidr_block                 = "${cidrsubnet(aws_vpc.default.ipv6_cidr_block, 8, 0)}"
+  assign_ipv6_address_on_creation = true
+<%end%>
   map_public_ip_on_launch = true
 
   tags {
     Name = "${var.deployment}-public"
     concourse-up-project = "${var.project}"
     concourse-up-component = "bosh"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
 resource "aws_subnet" "private" {
   vpc_id                  = "${aws_vpc.default.id}"
   availability_zone       = "${var.availability_zone}"
-  cidr_block              = "10.0.1.0/24"
+  cidr_block              = "<% .Network.PrivateSubnet.CIDR %>"
+<%if .EnableIPv6 %>
+  ipv6_cidr_block                 = "${cidrsubnet(aws_vpc.default.ipv6_cidr_block, 8, 1)}"
+  assign_ipv6_address_on_creation = true
+<%end%>
   map_public_ip_on_launch = false
 
   tags {
     Name = "${var.deployment}-private"
     concourse-up-project = "${var.project}"
     concourse-up-component = "bosh"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
@@ -234,6 +338,61 @@ resource "aws_route_table_association" "private" {
   subnet_id      = "${aws_subnet.private.id}"
   route_table_id = "${aws_route_table.private.id}"
 }
+<%end%>
+
+<%if .IsolatedWorkers %>
+resource "aws_subnet" "workers" {
+  vpc_id                  = "${aws_vpc.default.id}"
+  availability_zone       = "${var.availability_zone}"
+  cidr_block              = "<% .Network.WorkersSubnet.CIDR %>"
+<%if .EnableIPv6 %>
+  ipv6_cidr_block                 = "${cidrsubnet(aws_vpc.default.ipv6_cidr_block, 8, 2)}"
+  assign_ipv6_address_on_creation = true
+<%end%>
+  map_public_ip_on_launch = false
+
+  tags {
+    Name = "${var.deployment}-workers"
+    concourse-up-project = "${var.project}"
+    concourse-up-component = "concourse"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
+  }
+}
+
+resource "aws_route_table_association" "workers" {
+  subnet_id      = "${aws_subnet.workers.id}"
+  route_table_id = "${aws_route_table.private.id}"
+}
+<%end%>
+
+<%range $peer := .PeeringConnections %>
+# Peering within the account and region can be accepted from this side
+resource "aws_vpc_peering_connection" "<% $peer.PeerVPCID %>" {
+  vpc_id      = "<% .TerraformVPCID %>"
+  peer_vpc_id = "<% $peer.PeerVPCID %>"
+  auto_accept = true
+
+  tags {
+    Name = "${var.deployment}-<% $peer.PeerVPCID %>"
+    concourse-up-project = "${var.project}"
+    concourse-up-component = "bosh"
+<%range $key, $value := $.Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
+  }
+}
+
+<%if $peer.RoutesFrom "public" %>
+# The private route table declares its peering routes inline, as Terraform can't mix the two
+resource "aws_route" "public_<% $peer.PeerVPCID %>" {
+  route_table_id            = "${aws_vpc.default.main_route_table_id}"
+  destination_cidr_block    = "<% $peer.PeerCIDR %>"
+  vpc_peering_connection_id = "${aws_vpc_peering_connection.<% $peer.PeerVPCID %>.id}"
+}
+<%end%>
+<%end%>
 
 <%if .HostedZoneID %>
 resource "aws_route53_record" "concourse" {
@@ -241,52 +400,84 @@ resource "aws_route53_record" "concourse" {
   name    = "${var.hosted_zone_record_prefix}"
   ttl     = "60"
   type    = "A"
-  records = ["${aws_eip.atc.public_ip}"]
+  records = [<%if .Private %>"<% .Network.WebIP %>"<%else%>"${aws_eip.atc.public_ip}"<%end%>]
+  <%if .DNSWeighted %>
+  set_identifier = "<% .Deployment %>-<% .Region %>"
+
+  weighted_routing_policy {
+    weight = <% .DNSWeight %>
+  }
+  <%end%>
 }
+
+<%if and .PublicIPv6 .ATCPublicIPv6 %>
+# AWS has no IPv6 elastic IPs, so this follows the web node's own address, which is looked up after each deploy
+resource "aws_route53_record" "concourse_ipv6" {
+  zone_id = "${var.hosted_zone_id}"
+  name    = "${var.hosted_zone_record_prefix}"
+  ttl     = "60"
+  type    = "AAAA"
+  records = ["<% .ATCPublicIPv6 %>"]
+  <%if .DNSWeighted %>
+  set_identifier = "<% .Deployment %>-<% .Region %>"
+
+  weighted_routing_policy {
+    weight = <% .DNSWeight %>
+  }
+  <%end%>
+}
+<%end%>
 <%end%>
 
 resource "aws_eip" "director" {
   vpc = true
 }
 
+<%if not .Private %>
 resource "aws_eip" "atc" {
   vpc = true
 }
+<%end%>
 
+<%if not .ExistingNetwork %>
 resource "aws_eip" "nat" {
   vpc = true
 }
+<%end%>
 
 resource "aws_security_group" "director" {
   name        = "${var.deployment}-director"
   description = "Concourse UP Default BOSH security group"
-  vpc_id      = "${aws_vpc.default.id}"
+  vpc_id      = "<% .TerraformVPCID %>"
 
   tags {
     Name = "${var.deployment}-director"
     concourse-up-project = "${var.project}"
     concourse-up-component = "bosh"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 
   ingress {
     from_port   = 6868
     to_port     = 6868
     protocol    = "tcp"
-    cidr_blocks = ["${var.source_access_ip}/32", "${aws_nat_gateway.default.public_ip}/32"]
+    cidr_blocks = ["${var.source_access_ip}/32"<%if not .ExistingNetwork %>, "${aws_nat_gateway.default.public_ip}/32"<%end%><%range .AdditionalAllowedCIDRs %>, "<% . %>"<%end%>]
   }
 
   ingress {
     from_port   = 25555
     to_port     = 25555
     protocol    = "tcp"
-    cidr_blocks = ["${var.source_access_ip}/32", "${aws_nat_gateway.default.public_ip}/32"]
+    cidr_blocks = ["${var.source_access_ip}/32"<%if not .ExistingNetwork %>, "${aws_nat_gateway.default.public_ip}/32"<%end%><%range .AdditionalAllowedCIDRs %>, "<% . %>"<%end%>]
   }
 
   ingress {
     from_port   = 22
     to_port     = 22
     protocol    = "tcp"
-    cidr_blocks = ["${var.source_access_ip}/32", "${aws_nat_gateway.default.public_ip}/32"]
+    cidr_blocks = ["${var.source_access_ip}/32"<%if not .ExistingNetwork %>, "${aws_nat_gateway.default.public_ip}/32"<%end%><%range .AdditionalAllowedCIDRs %>, "<% . %>"<%end%>]
   }
 
   egress {
@@ -295,31 +486,42 @@ resource "aws_security_group" "director" {
     protocol    = "-1"
     cidr_blocks = ["0.0.0.0/0"]
   }
+<%if .EnableIPv6 %>
+  egress {
+    from_port        = 0
+    to_port          = 0
+    protocol         = "-1"
+    ipv6_cidr_blocks = ["::/0"]
+  }
+<%end%>
 }
 
 resource "aws_security_group" "vms" {
   name        = "${var.deployment}-vms"
   description = "Concourse UP VMs security group"
-  vpc_id      = "${aws_vpc.default.id}"
+  vpc_id      = "<% .TerraformVPCID %>"
 
   tags {
     Name = "${var.deployment}-vms"
     concourse-up-project = "${var.project}"
     concourse-up-component = "bosh"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 
   ingress {
     from_port   = 6868
     to_port     = 6868
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
     from_port   = 4222
     to_port     = 4222
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
 
@@ -327,63 +529,63 @@ resource "aws_security_group" "vms" {
     from_port   = 25250
     to_port     = 25250
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
     from_port   = 25555
     to_port     = 25555
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
     from_port   = 25777
     to_port     = 25777
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
     from_port   = 53
     to_port     = 53
     protocol    = "udp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
-    from_port   = 2222
-    to_port     = 2222
+    from_port   = <% .TSAPort %>
+    to_port     = <% .TSAPort %>
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
     from_port   = 5555
     to_port     = 5555
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
     from_port   = 7777
     to_port     = 7777
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
     from_port   = 7788
     to_port     = 7788
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 
   ingress {
     from_port   = 0
     to_port     = 0
     protocol    = "icmp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
   ingress {
     from_port = 22
@@ -391,6 +593,42 @@ resource "aws_security_group" "vms" {
     self      = true
     protocol  = "tcp"
   }
+<%if .IsolatedWorkers %>
+  ingress {
+    from_port       = 4222
+    to_port         = 4222
+    protocol        = "tcp"
+    security_groups = ["${aws_security_group.workers.id}"]
+  }
+
+  ingress {
+    from_port       = 25250
+    to_port         = 25250
+    protocol        = "tcp"
+    security_groups = ["${aws_security_group.workers.id}"]
+  }
+
+  ingress {
+    from_port       = 25777
+    to_port         = 25777
+    protocol        = "tcp"
+    security_groups = ["${aws_security_group.workers.id}"]
+  }
+
+  ingress {
+    from_port       = <% .TSAPort %>
+    to_port         = <% .TSAPort %>
+    protocol        = "tcp"
+    security_groups = ["${aws_security_group.workers.id}"]
+  }
+
+  ingress {
+    from_port       = 5555
+    to_port         = 5555
+    protocol        = "tcp"
+    security_groups = ["${aws_security_group.workers.id}"]
+  }
+<%end%>
 
   egress {
     from_port   = 0
@@ -398,36 +636,127 @@ resource "aws_security_group" "vms" {
     protocol    = "-1"
     cidr_blocks = ["0.0.0.0/0"]
   }
+<%if .EnableIPv6 %>
+  egress {
+    from_port        = 0
+    to_port          = 0
+    protocol         = "-1"
+    ipv6_cidr_blocks = ["::/0"]
+  }
+<%end%>
+}
+
+<%if .IsolatedWorkers %>
+resource "aws_security_group" "workers" {
+  name        = "${var.deployment}-workers"
+  description = "Concourse UP workers security group"
+  vpc_id      = "<% .TerraformVPCID %>"
+
+  tags {
+    Name = "${var.deployment}-workers"
+    concourse-up-project = "${var.project}"
+    concourse-up-component = "concourse"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
+  }
+}
+
+# The workers' rules are separate resources as the vms security group refers back to this one
+resource "aws_security_group_rule" "workers_garden" {
+  security_group_id        = "${aws_security_group.workers.id}"
+  type                     = "ingress"
+  from_port                = 7777
+  to_port                  = 7777
+  protocol                 = "tcp"
+  source_security_group_id = "${aws_security_group.vms.id}"
 }
 
+resource "aws_security_group_rule" "workers_baggageclaim" {
+  security_group_id        = "${aws_security_group.workers.id}"
+  type                     = "ingress"
+  from_port                = 7788
+  to_port                  = 7788
+  protocol                 = "tcp"
+  source_security_group_id = "${aws_security_group.vms.id}"
+}
+
+<%if .ATCP2PVolumeStreaming %>
+# Workers stream volumes to each other from baggageclaim
+resource "aws_security_group_rule" "workers_baggageclaim_p2p" {
+  security_group_id        = "${aws_security_group.workers.id}"
+  type                     = "ingress"
+  from_port                = 7788
+  to_port                  = 7788
+  protocol                 = "tcp"
+  source_security_group_id = "${aws_security_group.workers.id}"
+}
+<%end%>
+
+resource "aws_security_group_rule" "workers_ssh" {
+  security_group_id        = "${aws_security_group.workers.id}"
+  type                     = "ingress"
+  from_port                = 22
+  to_port                  = 22
+  protocol                 = "tcp"
+  source_security_group_id = "${aws_security_group.vms.id}"
+}
+
+resource "aws_security_group_rule" "workers_egress" {
+  security_group_id = "${aws_security_group.workers.id}"
+  type              = "egress"
+  from_port         = 0
+  to_port           = 0
+  protocol          = "-1"
+  cidr_blocks       = ["0.0.0.0/0"]
+}
+
+<%if .EnableIPv6 %>
+resource "aws_security_group_rule" "workers_egress_ipv6" {
+  security_group_id = "${aws_security_group.workers.id}"
+  type              = "egress"
+  from_port         = 0
+  to_port           = 0
+  protocol          = "-1"
+  ipv6_cidr_blocks  = ["::/0"]
+}
+<%end%>
+<%end%>
+
 resource "aws_security_group" "rds" {
   name        = "${var.deployment}-rds"
   description = "Concourse UP RDS security group"
-  vpc_id      = "${aws_vpc.default.id}"
+  vpc_id      = "<% .TerraformVPCID %>"
 
   tags {
     Name = "${var.deployment}-rds"
     concourse-up-project = "${var.project}"
     concourse-up-component = "rds"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 
   ingress {
     from_port   = 5432
     to_port     = 5432
     protocol    = "tcp"
-    cidr_blocks = ["10.0.0.0/16"]
+    cidr_blocks = ["${var.internal_cidrs}"]
   }
 }
 
 resource "aws_security_group" "atc" {
   name        = "${var.deployment}-atc"
   description = "Concourse UP ATC security group"
-  vpc_id      = "${aws_vpc.default.id}"
+  vpc_id      = "<% .TerraformVPCID %>"
 
   tags {
     Name = "${var.deployment}-atc"
     concourse-up-project = "${var.project}"
     concourse-up-component = "concourse"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 
   egress {
@@ -436,6 +765,14 @@ resource "aws_security_group" "atc" {
     protocol    = "-1"
     cidr_blocks = ["0.0.0.0/0"]
   }
+<%if .EnableIPv6 %>
+  egress {
+    from_port        = 0
+    to_port          = 0
+    protocol         = "-1"
+    ipv6_cidr_blocks = ["::/0"]
+  }
+<%end%>
 
   ingress {
     from_port   = 80
@@ -443,37 +780,78 @@ resource "aws_security_group" "atc" {
     protocol    = "tcp"
     security_groups = ["${aws_security_group.vms.id}", "${aws_security_group.director.id}"]
     cidr_blocks = [<% .AllowIPs %>]  
+<%if .PublicIPv6 %>
+    ipv6_cidr_blocks = ["::/0"]
+<%end%>
   }
 
   ingress {
-    from_port   = 443
-    to_port     = 443
+    from_port   = <% .ConcoursePort %>
+    to_port     = <% .ConcoursePort %>
     protocol    = "tcp"
     cidr_blocks = [<% .AllowIPs %>]
+<%if .PublicIPv6 %>
+    ipv6_cidr_blocks = ["::/0"]
+<%end%>
   }
 
+<%if not .GrafanaPath %>
   ingress {
-    from_port   = 3000
-    to_port     = 3000
+    from_port   = <% .GrafanaPort %>
+    to_port     = <% .GrafanaPort %>
     protocol    = "tcp"
     cidr_blocks = [<% .AllowIPs %>]
+<%if .PublicIPv6 %>
+    ipv6_cidr_blocks = ["::/0"]
+<%end%>
   }
+<%end%>
 
   ingress {
-    from_port   = 8844
-    to_port     = 8844
+    from_port   = <% .CredhubPort %>
+    to_port     = <% .CredhubPort %>
     protocol    = "tcp"
     cidr_blocks = [<% .AllowIPs %>]
+<%if .PublicIPv6 %>
+    ipv6_cidr_blocks = ["::/0"]
+<%end%>
   }
 
   ingress {
     from_port   = 8443
     to_port     = 8443
     protocol    = "tcp"
-    cidr_blocks = [ "${aws_eip.atc.public_ip}/32", <% .AllowIPs %>]
+    cidr_blocks = [ <%if .Private %>"<% .Network.WebIP %>/32"<%else%>"${aws_eip.atc.public_ip}/32"<%end%>, <% .AllowIPs %>]
   }
 }
 
+# The extra rules have their own group, as Terraform can't mix rule resources with the atc group's inline rules
+resource "aws_security_group" "atc_extra" {
+  name        = "${var.deployment}-atc-extra"
+  description = "Concourse UP ATC extra rules security group"
+  vpc_id      = "<% .TerraformVPCID %>"
+
+  tags {
+    Name = "${var.deployment}-atc-extra"
+    concourse-up-project = "${var.project}"
+    concourse-up-component = "concourse"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
+  }
+}
+
+resource "aws_security_group_rule" "atc_extra_ingress" {
+  count             = "${length(var.atc_extra_ingress_rules)}"
+  security_group_id = "${aws_security_group.atc_extra.id}"
+  type              = "ingress"
+  from_port         = "${lookup(var.atc_extra_ingress_rules[count.index], "from_port")}"
+  to_port           = "${lookup(var.atc_extra_ingress_rules[count.index], "to_port")}"
+  protocol          = "${lookup(var.atc_extra_ingress_rules[count.index], "protocol")}"
+  cidr_blocks       = ["${lookup(var.atc_extra_ingress_rules[count.index], "cidr")}"]
+}
+
+<%if not .ExistingNetwork %>
 resource "aws_route_table" "rds" {
   vpc_id = "${aws_vpc.default.id}"
 
@@ -481,6 +859,9 @@ resource "aws_route_table" "rds" {
     Name = "${var.deployment}-rds"
     concourse-up-project = "${var.project}"
     concourse-up-component = "concourse"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
@@ -497,65 +878,102 @@ resource "aws_route_table_association" "rds_b" {
 resource "aws_subnet" "rds_a" {
   vpc_id            = "${aws_vpc.default.id}"
   availability_zone = "${var.region}a"
-  cidr_block        = "10.0.4.0/24"
+  cidr_block        = "<% .Network.RDSSubnetA.CIDR %>"
 
   tags {
     Name = "${var.deployment}-rds-a"
     concourse-up-project = "${var.project}"
     concourse-up-component = "rds"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
 resource "aws_subnet" "rds_b" {
   vpc_id            = "${aws_vpc.default.id}"
   availability_zone = "${var.region}b"
-  cidr_block        = "10.0.5.0/24"
+  cidr_block        = "<% .Network.RDSSubnetB.CIDR %>"
 
   tags {
     Name = "${var.deployment}-rds-b"
     concourse-up-project = "${var.project}"
     concourse-up-component = "rds"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
+<%end%>
 
+<%if not .ExternalDBURL %>
 resource "aws_db_subnet_group" "default" {
   name       = "${var.deployment}"
+<%with .ExistingNetwork %>
+  subnet_ids = [<%range $i, $id := .DBSubnetIDs %><%if $i %>, <%end%>"<% $id %>"<%end%>]
+<%else%>
   subnet_ids = ["${aws_subnet.rds_a.id}", "${aws_subnet.rds_b.id}"]
+<%end%>
 
   tags {
     Name = "${var.deployment}"
     concourse-up-project = "${var.project}"
     concourse-up-component = "rds"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
 
 resource "aws_db_instance" "default" {
-  allocated_storage      = 10
-  apply_immediately      = true
+  allocated_storage      = "${var.rds_allocated_storage}"
+  backup_retention_period = "${var.rds_backup_retention_period}"
+  apply_immediately      = "${var.rds_apply_immediately}"
   port                   = 5432
   engine                 = "postgres"
   instance_class         = "${var.rds_instance_class}"
-  engine_version         = "9.6.6"
+<%if .RDSEngineVersion %>
+  engine_version         = "<% .RDSEngineVersion %>"
+<%end%>
+  allow_major_version_upgrade = true
+<%if .MaintenanceWindow %>
+  maintenance_window     = "<% .MaintenanceWindow %>"
+  backup_window          = "<% .RDSBackupWindow %>"
+<%end%>
   name                   = "${var.rds_default_database_name}"
   username               = "${var.rds_instance_username}"
   password               = "${var.rds_instance_password}"
+<%if .RDSReplicationSource %>
+  replicate_source_db    = "<% .RDSReplicationSource %>"
+<%end%>
+<%if .RDSSnapshotIdentifier %>
+  snapshot_identifier    = "<% .RDSSnapshotIdentifier %>"
+<%end%>
+<%if .RDSAvailabilityZone %>
+  availability_zone      = "<% .RDSAvailabilityZone %>"
+<%end%>
   publicly_accessible    = false
   multi_az               = "${var.multi_az_rds}"
   vpc_security_group_ids = ["${aws_security_group.rds.id}"]
   db_subnet_group_name   = "${aws_db_subnet_group.default.name}"
   skip_final_snapshot    = true
   lifecycle {
-    ignore_changes = ["allocated_storage"]
+    # The primary can move zone after a multi-AZ failover, which mustn't recreate the database
+    ignore_changes = ["allocated_storage", "availability_zone"]
   }
   tags {
     Name = "${var.deployment}"
     concourse-up-project = "${var.project}"
     concourse-up-component = "rds"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
   }
 }
+<%end%>
 
 output "vpc_id" {
-  value = "${aws_vpc.default.id}"
+  value = "<% .TerraformVPCID %>"
 }
 
 output "source_access_ip" {
@@ -570,9 +988,11 @@ output "director_public_ip" {
   value = "${aws_eip.director.public_ip}"
 }
 
+<%if not .Private %>
 output "atc_public_ip" {
   value = "${aws_eip.atc.public_ip}"
 }
+<%end%>
 
 output "director_security_group_id" {
   value = "${aws_security_group.director.id}"
@@ -586,22 +1006,29 @@ output "atc_security_group_id" {
   value = "${aws_security_group.atc.id}"
 }
 
+output "atc_extra_security_group_id" {
+  value = "${aws_security_group.atc_extra.id}"
+}
+
+<%if not .ExistingNetwork %>
 output "nat_gateway_ip" {
   value = "${aws_nat_gateway.default.public_ip}"
 }
+<%end%>
 
 output "public_subnet_id" {
-  value = "${aws_subnet.public.id}"
+  value = "<% .TerraformPublicSubnetID %>"
 }
 
 output "private_subnet_id" {
-  value = "${aws_subnet.private.id}"
+  value = "<% .TerraformPrivateSubnetID %>"
 }
 
 output "blobstore_bucket" {
   value = "${aws_s3_bucket.blobstore.id}"
 }
 
+<%if not .DirectorInstanceProfile %>
 output "blobstore_user_access_key_id" {
   value = "${aws_iam_access_key.blobstore.id}"
 }
@@ -619,11 +1046,167 @@ output "bosh_user_secret_access_key" {
   value     = "${aws_iam_access_key.bosh.secret}"
   sensitive = true
 }
+<%end%>
 
+<%with .ExternalDB %>
+output "bosh_db_port" {
+  value = "<% .Port %>"
+}
+
+output "bosh_db_address" {
+  value = "<% .Host %>"
+}
+<%else%>
 output "bosh_db_port" {
   value = "${aws_db_instance.default.port}"
 }
 
 output "bosh_db_address" {
   value = "${aws_db_instance.default.address}"
-}
\ No newline at end of file
+}
+
+output "rds_arn" {
+  value = "${aws_db_instance.default.arn}"
+}
+
+output "rds_maintenance_window" {
+  value = "${aws_db_instance.default.maintenance_window}"
+}
+<%end%>
+<%if .IsolatedWorkers %>
+output "workers_subnet_id" {
+  value = "${aws_subnet.workers.id}"
+}
+
+output "workers_security_group_id" {
+  value = "${aws_security_group.workers.id}"
+}
+<%end%>
+<%if .BackupRegion %>
+provider "aws" {
+  alias  = "backup"
+  region = "<% .BackupRegion %>"
+}
+
+resource "aws_s3_bucket" "config_replica" {
+  provider      = "aws.backup"
+  bucket        = "<% .ConfigReplicaBucket %>"
+  force_destroy = true
+
+  versioning {
+    enabled = true
+  }
+
+  tags {
+    Name = "${var.deployment}"
+    concourse-up-project = "${var.project}"
+    concourse-up-component = "config"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
+  }
+}
+
+<%if .KMSKeyID %>
+# S3 only replicates KMS-encrypted objects with a key in the replica's region to encrypt the copies with
+resource "aws_kms_key" "config_replica" {
+  provider    = "aws.backup"
+  description = "Encrypts the replica of ${var.deployment}'s config bucket"
+
+  tags {
+    Name = "${var.deployment}"
+    concourse-up-project = "${var.project}"
+    concourse-up-component = "config"
+<%range $key, $value := .Tags %>
+    <% printf "%q" $key %> = <% printf "%q" $value %>
+<%end%>
+  }
+}
+
+output "config_replica_kms_key_arn" {
+  value = "${aws_kms_key.config_replica.arn}"
+}
+<%end%>
+
+resource "aws_iam_role" "config_replication" {
+  name = "${var.deployment}-${var.region}-config-replication"
+
+  assume_role_policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Action": "sts:AssumeRole",
+      "Principal": {
+        "Service": "s3.amazonaws.com"
+      },
+      "Effect": "Allow"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy" "config_replication" {
+  name = "${var.deployment}-${var.region}-config-replication"
+  role = "${aws_iam_role.config_replication.id}"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Action": [
+        "s3:GetReplicationConfiguration",
+        "s3:ListBucket"
+      ],
+      "Effect": "Allow",
+      "Resource": [
+        "arn:aws:s3:::<% .ConfigBucket %>"
+      ]
+    },
+    {
+      "Action": [
+        "s3:GetObjectVersion",
+        "s3:GetObjectVersionAcl",
+        "s3:GetObjectVersionTagging"
+      ],
+      "Effect": "Allow",
+      "Resource": [
+        "arn:aws:s3:::<% .ConfigBucket %>/*"
+      ]
+    },
+    {
+      "Action": [
+        "s3:ReplicateObject",
+        "s3:ReplicateDelete",
+        "s3:ReplicateTags"
+      ],
+      "Effect": "Allow",
+      "Resource": "${aws_s3_bucket.config_replica.arn}/*"
+    }<%if .KMSKeyID %>,
+    {
+      "Action": "kms:Decrypt",
+      "Effect": "Allow",
+      "Resource": "*",
+      "Condition": {
+        "StringLike": {
+          "kms:ViaService": "s3.<% .Region %>.amazonaws.com",
+          "kms:EncryptionContext:aws:s3:arn": "arn:aws:s3:::<% .ConfigBucket %>/*"
+        }
+      }
+    },
+    {
+      "Action": "kms:Encrypt",
+      "Effect": "Allow",
+      "Resource": "${aws_kms_key.config_replica.arn}"
+    }<%end%>
+  ]
+}
+EOF
+}
+
+output "config_replication_role_arn" {
+  value = "${aws_iam_role.config_replication.arn}"
+}
+<%end%>
\ No newline at end of file
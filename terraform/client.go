@@ -11,6 +11,7 @@ import (
 	"runtime"
 
 	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/iaas"
 	"github.com/EngineerBetter/concourse-up/util"
 )
 
@@ -32,7 +33,7 @@ var AWSTemplate = string(MustAsset("assets/main.tf"))
 type IClient interface {
 	Output() (*Metadata, error)
 	Apply(dryrun bool) error
-	Destroy() error
+	Destroy(dryrun bool) error
 	Cleanup() error
 }
 
@@ -44,6 +45,17 @@ type Client struct {
 	stderr    io.Writer
 }
 
+// templateParams are the config the template is rendered from, and the object store Terraform's
+// state is kept in when the config bucket isn't in S3
+type templateParams struct {
+	*config.Config
+	S3Endpoint iaas.S3Endpoint
+}
+
+func newTemplateParams(config *config.Config) templateParams {
+	return templateParams{config, iaas.GlobalS3Endpoint}
+}
+
 // ClientFactory is a function that builds a client interface
 type ClientFactory func(iaas string, config *config.Config, stdout, stderr io.Writer) (IClient, error)
 
@@ -62,7 +74,7 @@ func NewClient(iaas string, config *config.Config, stdout, stderr io.Writer) (IC
 		return nil, err
 	}
 
-	terraformFile, err := util.RenderTemplate(AWSTemplate, config)
+	terraformFile, err := util.RenderTemplate(AWSTemplate, newTemplateParams(config))
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +89,10 @@ func NewClient(iaas string, config *config.Config, stdout, stderr io.Writer) (IC
 		return nil, err
 	}
 
+	if err := writeVarsFile(tempDir.Path("config/terraform.tfvars"), config.TerraformVars); err != nil {
+		return nil, err
+	}
+
 	client := &Client{
 		tempDir:   tempDir,
 		configDir: configDir,
@@ -117,6 +133,21 @@ func (client *Client) Output() (*Metadata, error) {
 	return &metadata, nil
 }
 
+// BinaryVersion downloads the terraform binary concourse-up uses and returns the version it reports
+func BinaryVersion() (string, error) {
+	tempDir, err := util.NewTempDir()
+	if err != nil {
+		return "", err
+	}
+	defer tempDir.Cleanup()
+
+	if err := setupBinary(tempDir); err != nil {
+		return "", err
+	}
+
+	return util.BinaryVersion(tempDir.Path("terraform"), "version")
+}
+
 func getTerraformURL() (string, error) {
 	os := runtime.GOOS
 	if os == "darwin" {
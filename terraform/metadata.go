@@ -11,23 +11,32 @@ type MetadataStringValue struct {
 type Metadata struct {
 	DirectorKeyPair         MetadataStringValue `json:"director_key_pair" valid:"required"`
 	DirectorPublicIP        MetadataStringValue `json:"director_public_ip" valid:"required"`
-	ATCPublicIP             MetadataStringValue `json:"atc_public_ip" valid:"required"`
+	ATCPublicIP             MetadataStringValue `json:"atc_public_ip"`
 	DirectorSecurityGroupID MetadataStringValue `json:"director_security_group_id" valid:"required"`
 	VMsSecurityGroupID      MetadataStringValue `json:"vms_security_group_id" valid:"required"`
 	ATCSecurityGroupID      MetadataStringValue `json:"atc_security_group_id" valid:"required"`
 	PublicSubnetID          MetadataStringValue `json:"public_subnet_id" valid:"required"`
 	PrivateSubnetID         MetadataStringValue `json:"private_subnet_id" valid:"required"`
 	VPCID                   MetadataStringValue `json:"vpc_id" valid:"required"`
-	NatGatewayIP            MetadataStringValue `json:"nat_gateway_ip" valid:"required"`
+	NatGatewayIP            MetadataStringValue `json:"nat_gateway_ip"`
 
 	BlobstoreBucket          MetadataStringValue `json:"blobstore_bucket" valid:"required"`
-	BlobstoreUserAccessKeyID MetadataStringValue `json:"blobstore_user_access_key_id" valid:"required"`
-	BlobstoreSecretAccessKey MetadataStringValue `json:"blobstore_user_secret_access_key" valid:"required"`
-	BoshUserAccessKeyID      MetadataStringValue `json:"bosh_user_access_key_id" valid:"required"`
-	BoshSecretAccessKey      MetadataStringValue `json:"bosh_user_secret_access_key" valid:"required"`
+	BlobstoreUserAccessKeyID MetadataStringValue `json:"blobstore_user_access_key_id"`
+	BlobstoreSecretAccessKey MetadataStringValue `json:"blobstore_user_secret_access_key"`
+	BoshUserAccessKeyID      MetadataStringValue `json:"bosh_user_access_key_id"`
+	BoshSecretAccessKey      MetadataStringValue `json:"bosh_user_secret_access_key"`
 	BoshDBPort               MetadataStringValue `json:"bosh_db_port" valid:"required"`
 	BoshDBAddress            MetadataStringValue `json:"bosh_db_address" valid:"required"`
+	RDSARN                   MetadataStringValue `json:"rds_arn"`
 	SourceAccessIP           MetadataStringValue `json:"source_access_ip"`
+	RDSMaintenanceWindow     MetadataStringValue `json:"rds_maintenance_window"`
+	WorkersSubnetID          MetadataStringValue `json:"workers_subnet_id"`
+	WorkersSecurityGroupID   MetadataStringValue `json:"workers_security_group_id"`
+	ConfigReplicationRoleARN MetadataStringValue `json:"config_replication_role_arn"`
+	// ConfigReplicaKMSKeyARN is only set when a replicated config bucket is encrypted with --kms-key
+	ConfigReplicaKMSKeyARN MetadataStringValue `json:"config_replica_kms_key_arn"`
+	// ATCExtraSecurityGroupID is only missing from metadata applied by earlier versions
+	ATCExtraSecurityGroupID MetadataStringValue `json:"atc_extra_security_group_id"`
 }
 
 // AssertValid returns an error if the struct contains any missing fields
@@ -0,0 +1,109 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OverridableVariables are the variables of the template that concourse-up leaves at their defaults,
+// which a --tf-vars-file can set
+var OverridableVariables = []string{
+	"atc_extra_ingress_rules",
+	"instance_tenancy",
+	"rds_allocated_storage",
+	"rds_backup_retention_period",
+}
+
+var variablePattern = regexp.MustCompile(`(?m)^variable "(\w+)"`)
+
+// ReservedVariables returns the variables of the template that concourse-up sets itself, which a
+// --tf-vars-file can't override
+func ReservedVariables() []string {
+	var reserved []string
+	for _, match := range variablePattern.FindAllStringSubmatch(AWSTemplate, -1) {
+		if !isOverridable(match[1]) {
+			reserved = append(reserved, match[1])
+		}
+	}
+	sort.Strings(reserved)
+	return reserved
+}
+
+func isOverridable(name string) bool {
+	for _, overridable := range OverridableVariables {
+		if name == overridable {
+			return true
+		}
+	}
+	return false
+}
+
+func isReserved(name string) bool {
+	for _, reserved := range ReservedVariables() {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadVarsFile reads a JSON object of Terraform variables. Terraform ignores values for variables the
+// template doesn't declare, so those are refused rather than silently doing nothing. concourse-up's own
+// variables take precedence, so those are dropped with a warning
+func ReadVarsFile(path string, stderr io.Writer) (map[string]interface{}, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read --tf-vars-file: %s", err)
+	}
+
+	vars := map[string]interface{}{}
+	if err = json.Unmarshal(contents, &vars); err != nil {
+		return nil, fmt.Errorf("--tf-vars-file must be a JSON object of variables, eg {\"rds_allocated_storage\": \"20\"}: %s", err)
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if isReserved(name) {
+			fmt.Fprintf(stderr, "Ignoring the Terraform variable %s, which concourse-up sets itself\n", name)
+			delete(vars, name)
+			continue
+		}
+		if !isOverridable(name) {
+			return nil, fmt.Errorf("--tf-vars-file sets `%s`, which isn't a Terraform variable. It can set %s", name, strings.Join(OverridableVariables, ", "))
+		}
+	}
+
+	return vars, nil
+}
+
+// writeVarsFile writes the variables a --tf-vars-file gave to terraform.tfvars, which Terraform loads
+// from its working directory. Configs saved before ReadVarsFile dropped concourse-up's own variables
+// may still have them, so those are left out
+func writeVarsFile(path string, vars map[string]interface{}) error {
+	overrides := map[string]interface{}{}
+	for name, value := range vars {
+		if isOverridable(name) {
+			overrides[name] = value
+		}
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	// Terraform reads JSON var files as well as HCL ones
+	contents, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, contents, 0600)
+}
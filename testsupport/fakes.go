@@ -1,23 +1,49 @@
 package testsupport
 
 import (
+	"time"
+
 	"github.com/EngineerBetter/concourse-up/bosh"
 	"github.com/EngineerBetter/concourse-up/config"
+	"github.com/EngineerBetter/concourse-up/credhub"
+	"github.com/EngineerBetter/concourse-up/fly"
+	"github.com/EngineerBetter/concourse-up/iaas"
 	"github.com/EngineerBetter/concourse-up/terraform"
 )
 
 // FakeAWSClient implements iaas.IClient for testing
 type FakeAWSClient struct {
+	FakeBucketRegion                  func(name string) (string, error)
+	FakeCallerIdentity                func() (string, error)
+	FakeCheckInstanceProfile          func(arn string, actions, resources []string) error
+	FakeCheckDBSnapshot               func(snapshotID string) (string, error)
+	FakeCheckStemcellImage            func(amiID, operatingSystem string) error
+	FakeCreateDBSnapshot              func(dbARN, snapshotID string) error
 	FakeDeleteVMsInVPC                func(vpcID string) error
+	FakeDeleteVMsInSecurityGroups     func(groupIDs []string) error
+	FakeDeleteVMWithPrivateIP         func(vpcID, ip string) error
+	FakeDescribeSubnets               func(ids []string) ([]iaas.Subnet, error)
 	FakeDeleteFile                    func(bucket, path string) error
+	FakeDeleteLockTable               func(name string) error
 	FakeDeleteVersionedBucket         func(name string) error
 	FakeEnsureBucketExists            func(name string) error
 	FakeEnsureFileExists              func(bucket, path string, defaultContents []byte) ([]byte, bool, error)
+	FakeEnsureLockTable               func(name string) error
 	FakeFindLongestMatchingHostedZone func(subdomain string) (string, string, error)
+	FakeInstanceIPv6Address           func(vpcID, privateIP string) (string, error)
+	FakeInstanceProfileRole           func(arn string) (string, error)
 	FakeHasFile                       func(bucket, path string) (bool, error)
+	FakeListBuckets                   func() ([]string, error)
+	FakeListFiles                     func(bucket, prefix string) ([]string, error)
 	FakeLoadFile                      func(bucket, path string) ([]byte, error)
 	FakeWriteFile                     func(bucket, path string, contents []byte) error
 	FakeRegion                        func() string
+	FakeSetBucketEncryption           func(name, kmsKeyID string) error
+	FakeSetBucketReplication          func(name, roleARN, destinationBucket, replicaKMSKeyARN string) error
+	FakeSetBucketTags                 func(name string, previous, tags map[string]string) error
+	FakeSetTerminationProtection      func(vpcID string, publicIPs []string, enabled bool) error
+	FakeSupportsDedicatedTenancy      func(instanceType, availabilityZone string) (bool, error)
+	FakeVPCCIDR                       func(vpcID string) (string, error)
 }
 
 // IAAS is here to implement iaas.IClient
@@ -35,6 +61,101 @@ func (client *FakeAWSClient) DeleteVMsInVPC(vpcID string) error {
 	return client.FakeDeleteVMsInVPC(vpcID)
 }
 
+// DeleteVMsInSecurityGroups delegates to FakeDeleteVMsInSecurityGroups which is dynamically set by the tests
+func (client *FakeAWSClient) DeleteVMsInSecurityGroups(groupIDs []string) error {
+	return client.FakeDeleteVMsInSecurityGroups(groupIDs)
+}
+
+// DeleteVMWithPrivateIP delegates to FakeDeleteVMWithPrivateIP which is dynamically set by the tests
+func (client *FakeAWSClient) DeleteVMWithPrivateIP(vpcID, ip string) error {
+	return client.FakeDeleteVMWithPrivateIP(vpcID, ip)
+}
+
+// DescribeSubnets delegates to FakeDescribeSubnets which is dynamically set by the tests
+func (client *FakeAWSClient) DescribeSubnets(ids []string) ([]iaas.Subnet, error) {
+	return client.FakeDescribeSubnets(ids)
+}
+
+// VPCCIDR delegates to FakeVPCCIDR which is dynamically set by the tests
+func (client *FakeAWSClient) VPCCIDR(vpcID string) (string, error) {
+	return client.FakeVPCCIDR(vpcID)
+}
+
+// BucketRegion delegates to FakeBucketRegion which is dynamically set by the tests
+func (client *FakeAWSClient) BucketRegion(name string) (string, error) {
+	return client.FakeBucketRegion(name)
+}
+
+// ListBuckets delegates to FakeListBuckets which is dynamically set by the tests
+func (client *FakeAWSClient) ListBuckets() ([]string, error) {
+	return client.FakeListBuckets()
+}
+
+// CallerIdentity delegates to FakeCallerIdentity which is dynamically set by the tests
+func (client *FakeAWSClient) CallerIdentity() (string, error) {
+	return client.FakeCallerIdentity()
+}
+
+// SupportsDedicatedTenancy delegates to FakeSupportsDedicatedTenancy which is dynamically set by the tests
+func (client *FakeAWSClient) SupportsDedicatedTenancy(instanceType, availabilityZone string) (bool, error) {
+	return client.FakeSupportsDedicatedTenancy(instanceType, availabilityZone)
+}
+
+// CheckInstanceProfile delegates to FakeCheckInstanceProfile which is dynamically set by the tests
+func (client *FakeAWSClient) CheckInstanceProfile(arn string, actions, resources []string) error {
+	return client.FakeCheckInstanceProfile(arn, actions, resources)
+}
+
+// InstanceProfileRole delegates to FakeInstanceProfileRole which is dynamically set by the tests
+func (client *FakeAWSClient) InstanceProfileRole(arn string) (string, error) {
+	return client.FakeInstanceProfileRole(arn)
+}
+
+// CheckStemcellImage delegates to FakeCheckStemcellImage which is dynamically set by the tests
+func (client *FakeAWSClient) CheckStemcellImage(amiID, operatingSystem string) error {
+	return client.FakeCheckStemcellImage(amiID, operatingSystem)
+}
+
+// CheckDBSnapshot delegates to FakeCheckDBSnapshot which is dynamically set by the tests
+func (client *FakeAWSClient) CheckDBSnapshot(snapshotID string) (string, error) {
+	return client.FakeCheckDBSnapshot(snapshotID)
+}
+
+// CreateDBSnapshot delegates to FakeCreateDBSnapshot which is dynamically set by the tests
+func (client *FakeAWSClient) CreateDBSnapshot(dbARN, snapshotID string) error {
+	return client.FakeCreateDBSnapshot(dbARN, snapshotID)
+}
+
+// EnsureLockTable delegates to FakeEnsureLockTable which is dynamically set by the tests
+func (client *FakeAWSClient) EnsureLockTable(name string) error {
+	return client.FakeEnsureLockTable(name)
+}
+
+// DeleteLockTable delegates to FakeDeleteLockTable which is dynamically set by the tests
+func (client *FakeAWSClient) DeleteLockTable(name string) error {
+	return client.FakeDeleteLockTable(name)
+}
+
+// SetBucketEncryption delegates to FakeSetBucketEncryption which is dynamically set by the tests
+func (client *FakeAWSClient) SetBucketEncryption(name, kmsKeyID string) error {
+	return client.FakeSetBucketEncryption(name, kmsKeyID)
+}
+
+// SetBucketReplication delegates to FakeSetBucketReplication which is dynamically set by the tests
+func (client *FakeAWSClient) SetBucketReplication(name, roleARN, destinationBucket, replicaKMSKeyARN string) error {
+	return client.FakeSetBucketReplication(name, roleARN, destinationBucket, replicaKMSKeyARN)
+}
+
+// SetBucketTags delegates to FakeSetBucketTags which is dynamically set by the tests
+func (client *FakeAWSClient) SetBucketTags(name string, previous, tags map[string]string) error {
+	return client.FakeSetBucketTags(name, previous, tags)
+}
+
+// SetTerminationProtection delegates to FakeSetTerminationProtection which is dynamically set by the tests
+func (client *FakeAWSClient) SetTerminationProtection(vpcID string, publicIPs []string, enabled bool) error {
+	return client.FakeSetTerminationProtection(vpcID, publicIPs, enabled)
+}
+
 // DeleteFile delegates to FakeDeleteFile which is dynamically set by the tests
 func (client *FakeAWSClient) DeleteFile(bucket, path string) error {
 	return client.FakeDeleteFile(bucket, path)
@@ -60,11 +181,21 @@ func (client *FakeAWSClient) FindLongestMatchingHostedZone(subdomain string) (st
 	return client.FakeFindLongestMatchingHostedZone(subdomain)
 }
 
+// InstanceIPv6Address delegates to FakeInstanceIPv6Address which is dynamically set by the tests
+func (client *FakeAWSClient) InstanceIPv6Address(vpcID, privateIP string) (string, error) {
+	return client.FakeInstanceIPv6Address(vpcID, privateIP)
+}
+
 // HasFile delegates to FakeHasFile which is dynamically set by the tests
 func (client *FakeAWSClient) HasFile(bucket, path string) (bool, error) {
 	return client.FakeHasFile(bucket, path)
 }
 
+// ListFiles delegates to FakeListFiles which is dynamically set by the tests
+func (client *FakeAWSClient) ListFiles(bucket, prefix string) ([]string, error) {
+	return client.FakeListFiles(bucket, prefix)
+}
+
 // LoadFile delegates to FakeLoadFile which is dynamically set by the tests
 func (client *FakeAWSClient) LoadFile(bucket, path string) ([]byte, error) {
 	return client.FakeLoadFile(bucket, path)
@@ -77,9 +208,21 @@ func (client *FakeAWSClient) WriteFile(bucket, path string, contents []byte) err
 
 // FakeFlyClient implements fly.IClient for testing
 type FakeFlyClient struct {
-	FakeSetDefaultPipeline func(deployAgs *config.DeployArgs, config *config.Config, allowFlyVersionDiscrepancy bool) error
-	FakeCleanup            func() error
-	FakeCanConnect         func() (bool, error)
+	FakeSetDefaultPipeline     func(deployAgs *config.DeployArgs, config *config.Config, allowFlyVersionDiscrepancy bool) error
+	FakeSetPipelines           func(pipelines []config.PipelineSpec) error
+	FakeDestroyDefaultPipeline func() error
+	FakeRetireWorkers          func(names []string, timeout time.Duration) error
+	FakePruneWorkers           func(states []string) ([]string, error)
+	FakeWaitForWorkers         func(count int, timeout time.Duration) error
+	FakeActivePipelines        func() ([]string, error)
+	FakePausePipelines         func(names []string) error
+	FakeUnpausePipelines       func(names []string) error
+	FakeLandWorkers            func(timeout time.Duration) error
+	FakeWaitForBuilds          func(timeout time.Duration) ([]fly.Build, error)
+	FakeCleanup                func() error
+	FakeCanConnect             func() (bool, error)
+	FakeATCVersion             func() (string, error)
+	FakeURL                    func() string
 }
 
 // SetDefaultPipeline delegates to FakeSetDefaultPipeline which is dynamically set by the tests
@@ -87,16 +230,86 @@ func (client *FakeFlyClient) SetDefaultPipeline(deployArgs *config.DeployArgs, c
 	return client.FakeSetDefaultPipeline(deployArgs, config, allowFlyVersionDiscrepancy)
 }
 
+// SetPipelines delegates to FakeSetPipelines which is dynamically set by the tests
+func (client *FakeFlyClient) SetPipelines(pipelines []config.PipelineSpec) error {
+	return client.FakeSetPipelines(pipelines)
+}
+
+// DestroyDefaultPipeline delegates to FakeDestroyDefaultPipeline which is dynamically set by the tests
+func (client *FakeFlyClient) DestroyDefaultPipeline() error {
+	return client.FakeDestroyDefaultPipeline()
+}
+
+// RetireWorkers delegates to FakeRetireWorkers which is dynamically set by the tests
+func (client *FakeFlyClient) RetireWorkers(names []string, timeout time.Duration) error {
+	return client.FakeRetireWorkers(names, timeout)
+}
+
+// PruneWorkers delegates to FakePruneWorkers which is dynamically set by the tests
+func (client *FakeFlyClient) PruneWorkers(states []string) ([]string, error) {
+	return client.FakePruneWorkers(states)
+}
+
+// WaitForWorkers delegates to FakeWaitForWorkers which is dynamically set by the tests
+func (client *FakeFlyClient) WaitForWorkers(count int, timeout time.Duration) error {
+	return client.FakeWaitForWorkers(count, timeout)
+}
+
+// ActivePipelines delegates to FakeActivePipelines which is dynamically set by the tests
+func (client *FakeFlyClient) ActivePipelines() ([]string, error) {
+	return client.FakeActivePipelines()
+}
+
+// PausePipelines delegates to FakePausePipelines which is dynamically set by the tests
+func (client *FakeFlyClient) PausePipelines(names []string) error {
+	return client.FakePausePipelines(names)
+}
+
+// UnpausePipelines delegates to FakeUnpausePipelines which is dynamically set by the tests
+func (client *FakeFlyClient) UnpausePipelines(names []string) error {
+	return client.FakeUnpausePipelines(names)
+}
+
+// LandWorkers delegates to FakeLandWorkers which is dynamically set by the tests
+func (client *FakeFlyClient) LandWorkers(timeout time.Duration) error {
+	return client.FakeLandWorkers(timeout)
+}
+
+// WaitForBuilds delegates to FakeWaitForBuilds which is dynamically set by the tests
+func (client *FakeFlyClient) WaitForBuilds(timeout time.Duration) ([]fly.Build, error) {
+	return client.FakeWaitForBuilds(timeout)
+}
+
 // Cleanup delegates to FakeCleanup which is dynamically set by the tests
 func (client *FakeFlyClient) Cleanup() error {
 	return client.FakeCleanup()
 }
 
+// ATCVersion delegates to FakeATCVersion which is dynamically set by the tests
+func (client *FakeFlyClient) ATCVersion() (string, error) {
+	return client.FakeATCVersion()
+}
+
+// URL delegates to FakeURL which is dynamically set by the tests
+func (client *FakeFlyClient) URL() string {
+	return client.FakeURL()
+}
+
 // CanConnect delegates to FakeCanConnect which is dynamically set by the tests
 func (client *FakeFlyClient) CanConnect() (bool, error) {
 	return client.FakeCanConnect()
 }
 
+// FakeCredhubClient implements credhub.IClient for testing
+type FakeCredhubClient struct {
+	FakeSetSecrets func(secrets []credhub.Secret) error
+}
+
+// SetSecrets delegates to FakeSetSecrets which is dynamically set by the tests
+func (client *FakeCredhubClient) SetSecrets(secrets []credhub.Secret) error {
+	return client.FakeSetSecrets(secrets)
+}
+
 // FakeConfigClient implements config.IClient for testing
 type FakeConfigClient struct {
 	FakeLoad         func() (*config.Config, error)
@@ -107,6 +320,11 @@ type FakeConfigClient struct {
 	FakeDeleteAsset  func(filename string) error
 	FakeDeleteAll    func(config *config.Config) error
 	FakeHasAsset     func(filename string) (bool, error)
+	FakeImport       func(config *config.Config, assets map[string][]byte) error
+	FakeVersions     func(filename string) ([]time.Time, error)
+	FakeLoadVersion  func(n int) (*config.Config, error)
+
+	FakeLoadAssetVersion func(filename string, n int) ([]byte, error)
 }
 
 // Load delegates to FakeLoad which is dynamically set by the tests
@@ -149,11 +367,31 @@ func (client *FakeConfigClient) HasAsset(filename string) (bool, error) {
 	return client.FakeHasAsset(filename)
 }
 
+// Import delegates to FakeImport which is dynamically set by the tests
+func (client *FakeConfigClient) Import(config *config.Config, assets map[string][]byte) error {
+	return client.FakeImport(config, assets)
+}
+
+// Versions delegates to FakeVersions which is dynamically set by the tests
+func (client *FakeConfigClient) Versions(filename string) ([]time.Time, error) {
+	return client.FakeVersions(filename)
+}
+
+// LoadAssetVersion delegates to FakeLoadAssetVersion which is dynamically set by the tests
+func (client *FakeConfigClient) LoadAssetVersion(filename string, n int) ([]byte, error) {
+	return client.FakeLoadAssetVersion(filename, n)
+}
+
+// LoadVersion delegates to FakeLoadVersion which is dynamically set by the tests
+func (client *FakeConfigClient) LoadVersion(n int) (*config.Config, error) {
+	return client.FakeLoadVersion(n)
+}
+
 // FakeTerraformClient implements terraform.IClient for testing
 type FakeTerraformClient struct {
 	FakeOutput  func() (*terraform.Metadata, error)
 	FakeApply   func(dryrun bool) error
-	FakeDestroy func() error
+	FakeDestroy func(dryrun bool) error
 	FakeCleanup func() error
 }
 
@@ -168,8 +406,8 @@ func (client *FakeTerraformClient) Apply(dryrun bool) error {
 }
 
 // Destroy delegates to FakeDestroy which is dynamically set by the tests
-func (client *FakeTerraformClient) Destroy() error {
-	return client.FakeDestroy()
+func (client *FakeTerraformClient) Destroy(dryrun bool) error {
+	return client.FakeDestroy(dryrun)
 }
 
 // Cleanup delegates to FakeCleanup which is dynamically set by the tests
@@ -179,10 +417,20 @@ func (client *FakeTerraformClient) Cleanup() error {
 
 // FakeBoshClient implements bosh.IClient for testing
 type FakeBoshClient struct {
-	FakeDeploy    func([]byte, []byte, bool) ([]byte, []byte, error)
-	FakeDelete    func([]byte) ([]byte, error)
-	FakeCleanup   func() error
-	FakeInstances func() ([]bosh.Instance, error)
+	FakeDeploy            func([]byte, []byte, bool) ([]byte, []byte, error)
+	FakeDeployConcourse   func([]byte) ([]byte, error)
+	FakeDelete            func([]byte) ([]byte, error)
+	FakeCleanup           func() error
+	FakeInstances         func() ([]bosh.Instance, error)
+	FakeEnsureDatabase    func(string) error
+	FakeRunErrand         func(string) error
+	FakeErrands           func() ([]string, error)
+	FakeRestart           func(string) error
+	FakeRecreate          func(string) error
+	FakeRunningVersions   func() (map[string]string, error)
+	FakeWaitForDeployTask func(time.Duration) (bosh.Task, error)
+	FakeUseReleaseBundle  func(string) error
+	FakeGenerateManifest  func([]byte, bool) ([]byte, error)
 }
 
 // Deploy delegates to FakeDeploy which is dynamically set by the tests
@@ -204,3 +452,53 @@ func (client *FakeBoshClient) Cleanup() error {
 func (client *FakeBoshClient) Instances() ([]bosh.Instance, error) {
 	return client.FakeInstances()
 }
+
+// EnsureDatabase delegates to FakeEnsureDatabase which is dynamically set by the tests
+func (client *FakeBoshClient) EnsureDatabase(dbName string) error {
+	return client.FakeEnsureDatabase(dbName)
+}
+
+// RunErrand delegates to FakeRunErrand which is dynamically set by the tests
+func (client *FakeBoshClient) RunErrand(name string) error {
+	return client.FakeRunErrand(name)
+}
+
+// Errands delegates to FakeErrands which is dynamically set by the tests
+func (client *FakeBoshClient) Errands() ([]string, error) {
+	return client.FakeErrands()
+}
+
+// DeployConcourse delegates to FakeDeployConcourse which is dynamically set by the tests
+func (client *FakeBoshClient) DeployConcourse(credsFileBytes []byte) ([]byte, error) {
+	return client.FakeDeployConcourse(credsFileBytes)
+}
+
+// Restart delegates to FakeRestart which is dynamically set by the tests
+func (client *FakeBoshClient) Restart(instanceGroup string) error {
+	return client.FakeRestart(instanceGroup)
+}
+
+// RunningVersions delegates to FakeRunningVersions which is dynamically set by the tests
+func (client *FakeBoshClient) RunningVersions() (map[string]string, error) {
+	return client.FakeRunningVersions()
+}
+
+// Recreate delegates to FakeRecreate which is dynamically set by the tests
+func (client *FakeBoshClient) Recreate(instanceGroup string) error {
+	return client.FakeRecreate(instanceGroup)
+}
+
+// WaitForDeployTask delegates to FakeWaitForDeployTask which is dynamically set by the tests
+func (client *FakeBoshClient) WaitForDeployTask(timeout time.Duration) (bosh.Task, error) {
+	return client.FakeWaitForDeployTask(timeout)
+}
+
+// UseReleaseBundle delegates to FakeUseReleaseBundle which is dynamically set by the tests
+func (client *FakeBoshClient) UseReleaseBundle(path string) error {
+	return client.FakeUseReleaseBundle(path)
+}
+
+// GenerateManifest delegates to FakeGenerateManifest which is dynamically set by the tests
+func (client *FakeBoshClient) GenerateManifest(creds []byte, showSecrets bool) ([]byte, error) {
+	return client.FakeGenerateManifest(creds, showSecrets)
+}
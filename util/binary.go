@@ -0,0 +1,20 @@
+package util
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// BinaryVersion runs the CLI at path with the args that make it print its version,
+// and returns the first line it prints
+func BinaryVersion(path string, args ...string) (string, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Env = CommandEnv()
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("could not run %s: %s", path, err)
+	}
+
+	return strings.SplitN(strings.TrimSpace(string(output)), "\n", 2)[0], nil
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AssetsChecksum returns the hex encoded SHA256 of the named assets' contents, concatenated in
+// name order. This matches `ls | xargs cat | sha256sum` in the assets directory, with LC_ALL=C
+func AssetsChecksum(names []string, asset func(string) ([]byte, error)) (string, error) {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+
+	hash := sha256.New()
+	for _, name := range sorted {
+		contents, err := asset(name)
+		if err != nil {
+			return "", err
+		}
+		hash.Write(contents)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// VerifyAssets returns an error if the assets' checksum isn't the expected one, which is
+// recorded when concourse-up is built
+func VerifyAssets(names []string, asset func(string) ([]byte, error), expected string) error {
+	if expected == "" || strings.HasPrefix(expected, "COMPILE_TIME_VARIABLE") {
+		return errors.New("no checksum was recorded when this binary was built")
+	}
+
+	checksum, err := AssetsChecksum(names, asset)
+	if err != nil {
+		return err
+	}
+	if checksum != expected {
+		return fmt.Errorf("checksum %s does not match %s, which was recorded when this binary was built", checksum, expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"os"
+	"strings"
+)
+
+// Proxy is the HTTP(S) proxy that outbound connections are made through, for networks
+// where they can't be made directly
+type Proxy struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	// NoProxy is a comma separated list of hosts, domains and CIDR ranges that bypass the proxy
+	NoProxy string
+}
+
+// GlobalProxy is the proxy given with the global --http-proxy, --https-proxy and --no-proxy flags
+var GlobalProxy Proxy
+
+// IsSet returns true if any of the proxy's settings are given
+func (p Proxy) IsSet() bool {
+	return p.HTTPProxy != "" || p.HTTPSProxy != "" || p.NoProxy != ""
+}
+
+// Vars returns the proxy's settings as environment variables. Both the upper and lower case names are
+// given, as the CLIs we download and the tools they run don't agree on which they read
+func (p Proxy) Vars() map[string]string {
+	vars := map[string]string{}
+	for name, value := range map[string]string{
+		"http_proxy":  p.HTTPProxy,
+		"https_proxy": p.HTTPSProxy,
+		"no_proxy":    p.NoProxy,
+	} {
+		if value == "" {
+			continue
+		}
+		vars[name] = value
+		vars[strings.ToUpper(name)] = value
+	}
+	return vars
+}
+
+// Env appends the proxy's environment variables to env, overriding any already in it
+func (p Proxy) Env(env []string) []string {
+	for name, value := range p.Vars() {
+		env = append(env, name+"="+value)
+	}
+	return env
+}
+
+// Setenv sets the proxy's environment variables in this process, so that the S3 and AWS API
+// clients and the binary downloads go through it. It must be called before the first request is
+// made, as Go only reads the proxy from the environment once
+func (p Proxy) Setenv() error {
+	for name, value := range p.Vars() {
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,10 @@ import (
 	"path/filepath"
 )
 
+// WorkDir is the directory that working files are written to.
+// When empty the system's temp directory is used
+var WorkDir string
+
 // TempDir represents a temporary directory to store files
 type TempDir struct {
 	path string
@@ -13,7 +17,12 @@ type TempDir struct {
 
 // NewTempDir returns a new temporary directory
 func NewTempDir() (*TempDir, error) {
-	path, err := ioutil.TempDir("", "concourse-up")
+	path, err := ioutil.TempDir(WorkDir, "concourse-up")
+	if err != nil {
+		return nil, err
+	}
+
+	path, err = filepath.Abs(path)
 	if err != nil {
 		return nil, err
 	}
@@ -23,6 +32,22 @@ func NewTempDir() (*TempDir, error) {
 	}, nil
 }
 
+// CommandEnv returns the environment to run the CLIs we download in. When a work dir
+// has been set it is used as their HOME, as the CLIs keep state there (eg. ~/.flyrc)
+func CommandEnv() []string {
+	env := os.Environ()
+	if WorkDir == "" {
+		return env
+	}
+
+	home, err := filepath.Abs(WorkDir)
+	if err != nil {
+		home = WorkDir
+	}
+
+	return append(env, "HOME="+home)
+}
+
 // Save writes the given file into the tempDir
 func (tempDir *TempDir) Save(filename string, contents []byte) (string, error) {
 	path := filepath.Join(tempDir.path, filename)
@@ -1,7 +1,11 @@
 package util_test
 
 import (
+	"errors"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/EngineerBetter/concourse-up/util"
 	. "github.com/onsi/ginkgo"
@@ -49,4 +53,99 @@ var _ = Describe("util functions", func() {
 			})
 		})
 	})
+
+	Describe("work dir", func() {
+		var workDir string
+
+		BeforeEach(func() {
+			var err error
+			workDir, err = ioutil.TempDir("", "work-dir")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			util.WorkDir = ""
+			os.RemoveAll(workDir)
+		})
+
+		Context("When a work dir is set", func() {
+			It("Creates temp dirs inside it", func() {
+				util.WorkDir = workDir
+
+				tempDir, err := util.NewTempDir()
+				Expect(err).ToNot(HaveOccurred())
+				defer tempDir.Cleanup()
+
+				Expect(tempDir.Path("file")).To(HavePrefix(workDir + string(filepath.Separator)))
+			})
+
+			It("Uses it as HOME for commands", func() {
+				util.WorkDir = workDir
+
+				Expect(util.CommandEnv()).To(ContainElement("HOME=" + workDir))
+			})
+		})
+
+		Context("When no work dir is set", func() {
+			It("Leaves HOME untouched for commands", func() {
+				Expect(util.CommandEnv()).To(Equal(os.Environ()))
+			})
+		})
+	})
+
+	Describe("proxy", func() {
+		It("Gives the proxy to commands under both names, leaving out unset settings", func() {
+			proxy := util.Proxy{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: "s3.amazonaws.com"}
+
+			env := proxy.Env([]string{"PATH=/bin"})
+			Expect(env).To(ConsistOf(
+				"PATH=/bin",
+				"https_proxy=http://proxy.example.com:3128",
+				"HTTPS_PROXY=http://proxy.example.com:3128",
+				"no_proxy=s3.amazonaws.com",
+				"NO_PROXY=s3.amazonaws.com",
+			))
+		})
+
+		It("Leaves the environment untouched when no proxy is set", func() {
+			Expect(util.Proxy{}.IsSet()).To(BeFalse())
+			Expect(util.Proxy{}.Env(os.Environ())).To(Equal(os.Environ()))
+		})
+	})
+
+	Describe("assets checksum", func() {
+		assets := map[string][]byte{
+			"assets/b.yml": []byte("cd"),
+			"assets/a.yml": []byte("ab"),
+		}
+		asset := func(name string) ([]byte, error) {
+			return assets[name], nil
+		}
+
+		It("Hashes the assets' contents in name order", func() {
+			checksum, err := util.AssetsChecksum([]string{"assets/b.yml", "assets/a.yml"}, asset)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checksum).To(Equal("88d4266fd4e6338d13b845fcf289579d209c897823b9217da3e161936f031589"))
+		})
+
+		It("Returns an error if an asset can't be read", func() {
+			_, err := util.AssetsChecksum([]string{"assets/a.yml"}, func(name string) ([]byte, error) {
+				return nil, errors.New("corrupt asset")
+			})
+			Expect(err).To(MatchError("corrupt asset"))
+		})
+
+		It("Verifies the checksum", func() {
+			err := util.VerifyAssets([]string{"assets/a.yml", "assets/b.yml"}, asset, "88d4266fd4e6338d13b845fcf289579d209c897823b9217da3e161936f031589")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = util.VerifyAssets([]string{"assets/a.yml"}, asset, "88d4266fd4e6338d13b845fcf289579d209c897823b9217da3e161936f031589")
+			Expect(err).To(MatchError(HavePrefix("checksum ")))
+		})
+
+		It("Returns an error if no checksum was recorded", func() {
+			err := util.VerifyAssets([]string{"assets/a.yml"}, asset, "COMPILE_TIME_VARIABLE_bosh_assetsSHA256")
+			Expect(err).To(MatchError("no checksum was recorded when this binary was built"))
+		})
+	})
 })